@@ -16,6 +16,11 @@ flock $lockfile -c "./bin/migrate-database -c ./settings up"
 echo "Get SFTPgo admin API key and store in NCA settings file"
 flock /mnt/news/get-sftpgo-api-key-running -c "SETTINGS_PATH=settings SFTPGO_ADMIN_LOGIN=admin SFTPGO_ADMIN_PASSWORD=password sftpgo/get_admin_api_key.sh --force"
 
+# The agents generate their host keys on first startup, so in this dev-only
+# setup we simply trust whatever they present. Never do this in production!
+echo "Scanning ONI Agent host keys"
+ssh-keyscan -p 22 oni-agent-staging oni-agent-prod > /usr/local/nca/known_hosts
+
 echo 'Executing "'$@'"'
 cd /usr/local/nca
 exec $@
@@ -6,6 +6,7 @@ toolchain go1.24.2
 
 require (
 	github.com/Nerdmaster/magicsql v0.11.0
+	github.com/fsnotify/fsnotify v1.7.0
 	github.com/go-sql-driver/mysql v1.7.1
 	github.com/google/go-cmp v0.6.0
 	github.com/gorilla/mux v1.7.0
@@ -0,0 +1,82 @@
+package marc
+
+// DiffOp describes what happened to a line between two records
+type DiffOp string
+
+// All diff operations
+const (
+	DiffSame    DiffOp = "same"
+	DiffRemoved DiffOp = "removed"
+	DiffAdded   DiffOp = "added"
+)
+
+// DiffLine is a single line of a record diff
+type DiffLine struct {
+	Op   DiffOp
+	Text string
+}
+
+// Diff compares two records' human-readable lines (see [MARC.Lines]),
+// returning every line of both, marked as unchanged, removed from before,
+// or added in after. Either record may be nil, which is treated as an empty
+// record.
+func Diff(before, after *MARC) []DiffLine {
+	var a, b []string
+	if before != nil {
+		a = before.Lines()
+	}
+	if after != nil {
+		b = after.Lines()
+	}
+
+	// Records are small (rarely more than a hundred fields), so a simple
+	// longest-common-subsequence table is plenty fast
+	var lcs = make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var lines []DiffLine
+	var i, j int
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, DiffLine{DiffSame, a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, DiffLine{DiffRemoved, a[i]})
+			i++
+		default:
+			lines = append(lines, DiffLine{DiffAdded, b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		lines = append(lines, DiffLine{DiffRemoved, a[i]})
+	}
+	for ; j < len(b); j++ {
+		lines = append(lines, DiffLine{DiffAdded, b[j]})
+	}
+
+	return lines
+}
+
+// Changed returns true if any line in the diff was added or removed
+func Changed(lines []DiffLine) bool {
+	for _, l := range lines {
+		if l.Op != DiffSame {
+			return true
+		}
+	}
+	return false
+}
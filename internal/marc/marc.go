@@ -1,5 +1,6 @@
-// Package marc has *extremely* rudimentary MARC XML processing for getting at
-// a title's name, LCCN, and language code
+// Package marc has rudimentary MARC XML processing for getting at a title's
+// name, LCCN, and language code, presenting the record in a human-readable
+// form, and validating that it has what NDNP and ONI need
 package marc
 
 import (
@@ -16,7 +17,7 @@ var marcStripLocRE = regexp.MustCompile(`[ /:,]+$`)
 
 type subfield struct {
 	Code string `xml:"code,attr"`
-	Data string `xml:",innerxml"`
+	Data string `xml:",chardata"`
 }
 
 type datafield struct {
@@ -28,35 +29,124 @@ type datafield struct {
 
 type controlfield struct {
 	Tag  string `xml:"tag,attr"`
-	Data string `xml:",innerxml"`
+	Data string `xml:",chardata"`
 }
 
 type marcXML struct {
+	Leader        string         `xml:"leader"`
 	Datafields    []datafield    `xml:"datafield"`
 	Controlfields []controlfield `xml:"controlfield"`
 }
 
+// Subfield is a single coded value within a data field
+type Subfield struct {
+	Code  string
+	Value string
+}
+
+// Field is a single MARC field. Control fields (tags 001-009) only have a
+// Value, while data fields have indicators and subfields.
+type Field struct {
+	Tag       string
+	Ind1      string
+	Ind2      string
+	Value     string
+	Subfields []Subfield
+}
+
+// IsControl returns true if this is a control field
+func (f Field) IsControl() bool {
+	return f.Subfields == nil
+}
+
+// Get returns the first subfield value with the given code
+func (f Field) Get(code string) string {
+	for _, sf := range f.Subfields {
+		if sf.Code == code {
+			return sf.Value
+		}
+	}
+	return ""
+}
+
+// String returns a human-readable line for the field in a format close to
+// what catalogers see, e.g., "245 10 $a North Douglas herald."  Blank
+// indicators are shown as underscores so they're visible.
+func (f Field) String() string {
+	if f.IsControl() {
+		return f.Tag + "    " + f.Value
+	}
+
+	var indicator = func(i string) string {
+		if strings.TrimSpace(i) == "" {
+			return "_"
+		}
+		return i
+	}
+	var parts = []string{f.Tag + " " + indicator(f.Ind1) + indicator(f.Ind2)}
+	for _, sf := range f.Subfields {
+		parts = append(parts, "$"+sf.Code+" "+sf.Value)
+	}
+	return strings.Join(parts, " ")
+}
+
 // MARC holds the raw data parsed from an XML source
 type MARC struct {
-	raw    *marcXML
-	fields map[string]string
+	raw        *marcXML
+	fields     map[string]string
+	fieldList  []Field
+	fieldsByID map[string][]Field
 }
 
 func newMARC(raw *marcXML) *MARC {
-	var m = &MARC{raw: raw, fields: make(map[string]string)}
+	var m = &MARC{raw: raw, fields: make(map[string]string), fieldsByID: make(map[string][]Field)}
 
+	// Control fields always precede data fields in a MARC record
 	for _, cf := range raw.Controlfields {
 		m.fields[cf.Tag] = cf.Data
+		m.addField(Field{Tag: cf.Tag, Value: cf.Data})
 	}
 	for _, df := range raw.Datafields {
+		var f = Field{Tag: df.Tag, Ind1: df.Ind1, Ind2: df.Ind2, Subfields: []Subfield{}}
 		for _, sf := range df.Subfields {
 			m.fields[df.Tag+"$"+sf.Code] = sf.Data
+			f.Subfields = append(f.Subfields, Subfield{Code: sf.Code, Value: sf.Data})
 		}
+		m.addField(f)
 	}
 
 	return m
 }
 
+func (m *MARC) addField(f Field) {
+	m.fieldList = append(m.fieldList, f)
+	m.fieldsByID[f.Tag] = append(m.fieldsByID[f.Tag], f)
+}
+
+// Leader returns the record's leader
+func (m *MARC) Leader() string {
+	return m.raw.Leader
+}
+
+// Fields returns every field in the record, in order
+func (m *MARC) Fields() []Field {
+	return m.fieldList
+}
+
+// FieldsByTag returns all fields with the given tag, in order
+func (m *MARC) FieldsByTag(tag string) []Field {
+	return m.fieldsByID[tag]
+}
+
+// Lines returns the leader and each field as human-readable lines
+func (m *MARC) Lines() []string {
+	var lines = []string{"LDR    " + m.raw.Leader}
+	for _, f := range m.fieldList {
+		lines = append(lines, f.String())
+	}
+	return lines
+}
+
 // Get returns the value of the field with the given tag. Control fields, such
 // as "008", have no code, and can be requested directly. Data fields have
 // subfields, and must include a tag to indicate which subfield, e.g., tag
@@ -95,14 +185,19 @@ func (m *MARC) Location() string {
 	return marcStripLocRE.ReplaceAllString(location, "")
 }
 
-// Language returns the three-character language code from field 008
+// Language returns the three-character language code from field 008, or an
+// empty string if 008 doesn't have a valid code
 func (m *MARC) Language() string {
 	var lang = []rune(m.Get("008", ""))
 	if len(lang) < 38 {
 		return ""
 	}
 
-	return string(lang[35:38])
+	var code = string(lang[35:38])
+	if !langRE.MatchString(code) {
+		return ""
+	}
+	return code
 }
 
 // parse is our low-level XML parser that gets the raw data structure set up,
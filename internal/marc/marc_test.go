@@ -3,6 +3,7 @@ package marc
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -73,3 +74,162 @@ func TestParseXML(t *testing.T) {
 		})
 	}
 }
+
+// record builds a minimal MARC XML record from an 008 value and a list of
+// pre-built datafield elements
+func record(ff string, datafields ...string) *MARC {
+	var xml = `<record><leader>00000cas a2200000 a 4500</leader>`
+	if ff != "" {
+		xml += `<controlfield tag="008">` + ff + `</controlfield>`
+	}
+	xml += strings.Join(datafields, "") + `</record>`
+	var m, err = ParseXML(strings.NewReader(xml))
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+func df(tag string, subfields ...string) string {
+	var s = `<datafield tag="` + tag + `" ind1=" " ind2=" ">`
+	for i := 0; i+1 < len(subfields); i += 2 {
+		s += `<subfield code="` + subfields[i] + `">` + subfields[i+1] + `</subfield>`
+	}
+	return s + `</datafield>`
+}
+
+func TestValidate(t *testing.T) {
+	var good008 = "240925c20239999orumr noo     0    0eng c"
+	var complete = []string{
+		df("010", "a", "sn12345678"),
+		df("245", "a", "The daily test."),
+		df("264", "a", "Eugene, Or."),
+		df("310", "a", "Daily"),
+		df("752", "a", "United States", "b", "Oregon", "d", "Eugene"),
+	}
+	var without = func(tag string) []string {
+		var list []string
+		for _, f := range complete {
+			if !strings.Contains(f, `tag="`+tag+`"`) {
+				list = append(list, f)
+			}
+		}
+		return list
+	}
+
+	var tests = map[string]struct {
+		m         *MARC
+		want      []string
+		hasErrors bool
+	}{
+		"complete":           {m: record(good008, complete...)},
+		"ONI test file":      {m: mustParse(t, "oni-2024240297-NorthDouglasHerald.xml")},
+		"no 752 in LoC file": {m: mustParse(t, "2002260445-UnitedAmerican.mrk"), want: []string{"752 (warning)"}},
+		"no LCCN":            {m: record(good008, without("010")...), want: []string{"010 (error)"}, hasErrors: true},
+		"no title":           {m: record(good008, without("245")...), want: []string{"245 (error)"}, hasErrors: true},
+		"no place":           {m: record(good008, without("264")...), want: []string{"260/264 (error)"}, hasErrors: true},
+		"no frequency":       {m: record(good008, without("310")...), want: []string{"310 (warning)"}},
+		"752 without state":  {m: record(good008, append(without("752"), df("752", "a", "United States"))...), want: []string{"752 (warning)"}},
+		"no 008":             {m: record("", complete...), want: []string{"008 (warning): fixed-length data elements are missing"}},
+		"short 008":          {m: record("240925c2023", complete...), want: []string{"008 (warning): must be 40"}},
+		"bad dates and lang": {
+			m:    record("240925c19x9    orumr noo     0    0EN  c", complete...),
+			want: []string{"008 (warning): start year", "008 (warning): end year", "008 (warning): language"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var problems = tc.m.Validate()
+			if len(problems) != len(tc.want) {
+				t.Fatalf("Expected %d problem(s), got %q", len(tc.want), problems)
+			}
+			for i, p := range problems {
+				if !strings.HasPrefix(p.String(), tc.want[i]) {
+					t.Errorf("Expected problem %d to start with %q, got %q", i, tc.want[i], p.String())
+				}
+			}
+			if HasErrors(problems) != tc.hasErrors {
+				t.Errorf("Expected HasErrors to be %v", tc.hasErrors)
+			}
+		})
+	}
+}
+
+func mustParse(t *testing.T, name string) *MARC {
+	var f = getFile(t, name)
+	defer f.Close()
+	var m, err = ParseXML(f)
+	if err != nil {
+		t.Fatalf("Unable to parse MARC from %q: %s", name, err)
+	}
+	return m
+}
+
+// TestBad008Usable makes sure a record whose only problem is unreadable
+// fixed-length data can still be used: it's a common cataloging slip, and
+// shouldn't keep a title from validating
+func TestBad008Usable(t *testing.T) {
+	var m = record("240925c19x9    orumr noo     0    0EN  c",
+		df("010", "a", "sn12345678"),
+		df("245", "a", "The daily test."),
+		df("264", "a", "Eugene, Or."),
+	)
+
+	var problems = m.Validate()
+	if HasErrors(problems) {
+		t.Fatalf("Expected no errors, got %q", problems)
+	}
+	if len(problems) == 0 {
+		t.Fatalf("Expected warnings about the 008, got none")
+	}
+	compare(t, "LCCN", "sn12345678", m.LCCN())
+	compare(t, "Title", "The daily test.", m.Title())
+	compare(t, "Language", "", m.Language())
+}
+
+func TestLines(t *testing.T) {
+	var m = record("240925c20239999orumr noo     0    0eng c",
+		`<datafield tag="245" ind1="1" ind2="0"><subfield code="a">Fish &amp; chips :</subfield><subfield code="b">a daily.</subfield></datafield>`,
+		df("310", "a", "Daily"),
+	)
+	var want = []string{
+		"LDR    00000cas a2200000 a 4500",
+		"008    240925c20239999orumr noo     0    0eng c",
+		"245 10 $a Fish & chips : $b a daily.",
+		"310 __ $a Daily",
+	}
+	var got = m.Lines()
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Expected lines:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+}
+
+func TestDiff(t *testing.T) {
+	var ff = "240925c20239999orumr noo     0    0eng c"
+	var before = record(ff, df("245", "a", "Old title."), df("310", "a", "Daily"))
+	var after = record(ff, df("245", "a", "New title."), df("310", "a", "Daily"), df("752", "b", "Oregon"))
+
+	var got []string
+	for _, l := range Diff(before, after) {
+		got = append(got, string(l.Op)+" "+l.Text)
+	}
+	var want = []string{
+		"same LDR    00000cas a2200000 a 4500",
+		"same 008    " + ff,
+		"removed 245 __ $a Old title.",
+		"added 245 __ $a New title.",
+		"same 310 __ $a Daily",
+		"added 752 __ $b Oregon",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Expected diff:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+
+	if !Changed(Diff(before, after)) || Changed(Diff(before, before)) {
+		t.Errorf("Changed didn't report correctly")
+	}
+	if len(Diff(nil, after)) != len(after.Lines()) {
+		t.Errorf("Diff against nil should add every line")
+	}
+}
@@ -0,0 +1,113 @@
+package marc
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Severity tells us whether a problem makes a record unusable
+type Severity string
+
+// All problem severities
+const (
+	// SeverityError means NCA can't use the record at all: it has no LCCN,
+	// title, or place of publication
+	SeverityError Severity = "error"
+
+	// SeverityWarning means the record is usable, but is missing data NDNP
+	// expects, or ONI displays, or has fixed-length data which can't be read
+	SeverityWarning Severity = "warning"
+)
+
+// Problem describes a single issue found when validating a record
+type Problem struct {
+	Tag      string
+	Severity Severity
+	Message  string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s (%s): %s", p.Tag, p.Severity, p.Message)
+}
+
+var (
+	yearRE = regexp.MustCompile(`^[0-9u]{4}$`)
+	langRE = regexp.MustCompile(`^[a-z]{3}$`)
+)
+
+// Validate checks the record for the fields NDNP and ONI rely on: LCCN (010),
+// title (245), place of publication (260 or 264), the fixed-length data
+// elements in 008 (dates and language), geographic place (752), and
+// frequency (310). Problems are returned in tag order. Only a missing LCCN,
+// title, or place of publication is an error; NCA can use a record with any of
+// the other problems.
+func (m *MARC) Validate() []Problem {
+	var list []Problem
+	var problem = func(tag string, sev Severity, format string, args ...any) {
+		list = append(list, Problem{Tag: tag, Severity: sev, Message: fmt.Sprintf(format, args...)})
+	}
+
+	list = append(list, m.validate008()...)
+
+	if m.LCCN() == "" {
+		problem("010", SeverityError, "LCCN ($a) is missing")
+	}
+	if strings.TrimSpace(m.Get("245", "a")) == "" {
+		problem("245", SeverityError, "title ($a) is missing")
+	}
+	if m.Location() == "" {
+		problem("260/264", SeverityError, "place of publication ($a) is missing from both 260 and 264")
+	}
+	if strings.TrimSpace(m.Get("310", "a")) == "" {
+		problem("310", SeverityWarning, "current publication frequency ($a) is missing")
+	}
+
+	var places = m.FieldsByTag("752")
+	if len(places) == 0 {
+		problem("752", SeverityWarning, "no geographic place of publication: ONI won't be able to list the title by state or city")
+	}
+	for _, f := range places {
+		if strings.TrimSpace(f.Get("b")) == "" {
+			problem("752", SeverityWarning, "%q has no state ($b)", f.String())
+		}
+	}
+
+	return list
+}
+
+// validate008 checks 008/07-10 (date 1), 008/11-14 (date 2), and 008/35-37
+// (language). ONI derives a title's start and end years and its language from
+// these positions.
+func (m *MARC) validate008() []Problem {
+	var ff = []rune(m.Get("008", ""))
+	if len(ff) == 0 {
+		return []Problem{{Tag: "008", Severity: SeverityWarning, Message: "fixed-length data elements are missing"}}
+	}
+	if len(ff) != 40 {
+		return []Problem{{Tag: "008", Severity: SeverityWarning, Message: fmt.Sprintf("must be 40 characters long, but is %d", len(ff))}}
+	}
+
+	var list []Problem
+	var date1, date2, lang = string(ff[7:11]), string(ff[11:15]), string(ff[35:38])
+	if !yearRE.MatchString(date1) {
+		list = append(list, Problem{Tag: "008", Severity: SeverityWarning, Message: fmt.Sprintf("start year (positions 07-10) %q is invalid", date1)})
+	}
+	if !yearRE.MatchString(date2) {
+		list = append(list, Problem{Tag: "008", Severity: SeverityWarning, Message: fmt.Sprintf("end year (positions 11-14) %q is invalid", date2)})
+	}
+	if !langRE.MatchString(lang) {
+		list = append(list, Problem{Tag: "008", Severity: SeverityWarning, Message: fmt.Sprintf("language (positions 35-37) %q is invalid", lang)})
+	}
+	return list
+}
+
+// HasErrors returns true if any problem in the list is an error
+func HasErrors(list []Problem) bool {
+	for _, p := range list {
+		if p.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
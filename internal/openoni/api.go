@@ -8,9 +8,9 @@ import (
 	"sort"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/tidwall/gjson"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
 	"golang.org/x/crypto/ssh"
 )
@@ -21,12 +21,18 @@ type slist []string
 // RPC manages the ssh connections to a single ONI Agent
 type RPC struct {
 	connection string
+	poolKey    string
+	cfg        *ssh.ClientConfig
 	call       func(slist, []byte) ([]byte, error)
 }
 
-// New parses the connection string into a server and port. Its format must be
+// New parses the connection string into a server and port, and prepares the
+// SSH configuration from auth. The connection string's format must be
 // <server>:<port>.
-func New(connection string) (*RPC, error) {
+//
+// Connections are pooled: all RPCs with the same connection and auth settings
+// share a single SSH connection, which is opened on first use.
+func New(connection string, auth Auth) (*RPC, error) {
 	var parts = strings.Split(connection, ":")
 	if len(parts) != 2 {
 		return nil, errors.New("connection must have the form <server>:<port>")
@@ -37,26 +43,58 @@ func New(connection string) (*RPC, error) {
 		return nil, errors.New("connection must contain a valid port number")
 	}
 
-	return &RPC{connection: connection}, nil
+	var cfg, err = auth.clientConfig(connection)
+	if err != nil {
+		return nil, fmt.Errorf("configuring ssh for %q: %w", connection, err)
+	}
+
+	var key = fmt.Sprintf("%s@%s|%s|%s", auth.User, connection, auth.KnownHostsFile, auth.KeyFile)
+	return &RPC{connection: connection, poolKey: key, cfg: cfg}, nil
 }
 
-func (r *RPC) defaultCall(params slist, payload []byte) (data []byte, err error) {
-	var cfg = &ssh.ClientConfig{
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
-		Timeout:         time.Second * 10,
-	}
+// ForEnvironment returns an RPC for the given environment's agent
+func ForEnvironment(env *config.ONIEnvironment) (*RPC, error) {
+	return New(env.AgentConnection, Auth{
+		KnownHostsFile: env.AgentKnownHosts,
+		User:           env.AgentUser,
+		KeyFile:        env.AgentKey,
+	})
+}
 
-	var client *ssh.Client
-	client, err = ssh.Dial("tcp", r.connection, cfg)
+// session opens a new session on the pooled connection. If the pooled
+// connection has gone bad, we discard it and try once more with a new one.
+func (r *RPC) session() (*ssh.Session, error) {
+	var client, err = connections.get(r.poolKey, r.connection, r.cfg)
 	if err != nil {
-		return data, fmt.Errorf("dialing %q: %w", r.connection, err)
+		return nil, err
 	}
 
 	var s *ssh.Session
 	s, err = client.NewSession()
+	if err == nil {
+		return s, nil
+	}
+
+	connections.drop(r.poolKey, client)
+	client, err = connections.get(r.poolKey, r.connection, r.cfg)
+	if err != nil {
+		return nil, err
+	}
+	s, err = client.NewSession()
+	if err != nil {
+		connections.drop(r.poolKey, client)
+		return nil, fmt.Errorf("starting ssh session %q: %w", r.connection, err)
+	}
+	return s, nil
+}
+
+func (r *RPC) defaultCall(params slist, payload []byte) (data []byte, err error) {
+	var s *ssh.Session
+	s, err = r.session()
 	if err != nil {
-		return data, fmt.Errorf("starting ssh session %q: %w", r.connection, err)
+		return data, err
 	}
+	defer s.Close()
 
 	var cmd = strings.Join(params, " ")
 
@@ -72,7 +110,7 @@ func (r *RPC) defaultCall(params slist, payload []byte) (data []byte, err error)
 
 	data, err = s.Output(cmd)
 	if err != nil {
-		err = fmt.Errorf("sending %q (no payload) to ONI Agent: %w", cmd, err)
+		err = fmt.Errorf("sending %q to ONI Agent: %w", cmd, err)
 	}
 	return data, err
 }
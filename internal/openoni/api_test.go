@@ -3,6 +3,8 @@ package openoni
 import (
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -10,20 +12,37 @@ import (
 	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
 )
 
+// emptyAuth returns an Auth with an empty known_hosts file, which is enough
+// to create an RPC that never actually connects anywhere
+func emptyAuth(t *testing.T) Auth {
+	var fname = filepath.Join(t.TempDir(), "known_hosts")
+	var err = os.WriteFile(fname, nil, 0600)
+	if err != nil {
+		t.Fatalf("Unable to write known_hosts: %s", err)
+	}
+	return Auth{KnownHostsFile: fname}
+}
+
 func TestNew(t *testing.T) {
+	var auth = emptyAuth(t)
 	var tests = map[string]struct {
 		connection string
+		auth       Auth
 		hasError   bool
 	}{
-		"valid":     {connection: "foo:2222", hasError: false},
-		"invalid":   {connection: "foo", hasError: true},
-		"bad port":  {connection: "foo:0", hasError: true},
-		"text port": {connection: "foo:bar", hasError: true},
+		"valid":            {connection: "foo:2222", auth: auth, hasError: false},
+		"invalid":          {connection: "foo", auth: auth, hasError: true},
+		"bad port":         {connection: "foo:0", auth: auth, hasError: true},
+		"text port":        {connection: "foo:bar", auth: auth, hasError: true},
+		"no known_hosts":   {connection: "foo:2222", auth: Auth{}, hasError: true},
+		"bad known_hosts":  {connection: "foo:2222", auth: Auth{KnownHostsFile: "/nonexistent"}, hasError: true},
+		"missing key file": {connection: "foo:2222", auth: Auth{KnownHostsFile: auth.KnownHostsFile, KeyFile: "/nonexistent"}, hasError: true},
+		"invalid key file": {connection: "foo:2222", auth: Auth{KnownHostsFile: auth.KnownHostsFile, KeyFile: auth.KnownHostsFile}, hasError: true},
 	}
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			var _, err = New(tc.connection)
+			var _, err = New(tc.connection, tc.auth)
 
 			if tc.hasError == true && err == nil {
 				t.Fatalf("Expected connection %q to have an error", tc.connection)
@@ -38,7 +57,7 @@ func TestNew(t *testing.T) {
 // getRPC returns an RPC, crashing if an error occurs since this is using a
 // hard-coded connection string that should always work
 func getRPC(t *testing.T, name string, expectedParams slist, expectPayload bool, jsonOut []byte) *RPC {
-	var r, err = New("foo:2222")
+	var r, err = New("foo:2222", emptyAuth(t))
 	if err != nil {
 		t.Fatalf("Unable to provision new RPC: %s", err)
 	}
@@ -82,7 +101,7 @@ func TestDo(t *testing.T) {
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			var r, _ = New("foo:2222")
+			var r, _ = New("foo:2222", emptyAuth(t))
 
 			r.call = func(_ slist, _ []byte) (data []byte, err error) {
 				if tc.hasError {
@@ -0,0 +1,195 @@
+package openoni
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/internal/logger"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// keepaliveTimeout is how long we wait for a keepalive response before
+// deciding the connection is dead
+var keepaliveTimeout = 15 * time.Second
+
+// Auth holds the settings used to verify an ONI Agent's identity and to
+// authenticate to it
+type Auth struct {
+	// KnownHostsFile is an OpenSSH-style known_hosts file which must contain
+	// the agent's host key. It is required: we never talk to an unverified
+	// agent.
+	KnownHostsFile string
+
+	// User is the username sent to the agent
+	User string
+
+	// KeyFile is the path to an unencrypted private key. If empty, no client
+	// authentication is attempted.
+	KeyFile string
+}
+
+// clientConfig builds an SSH client configuration for the given connection
+// string, reading and validating the known_hosts and key files
+func (a Auth) clientConfig(connection string) (*ssh.ClientConfig, error) {
+	if a.KnownHostsFile == "" {
+		return nil, errors.New("a known_hosts file is required")
+	}
+
+	var cb, err = knownhosts.New(a.KnownHostsFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading known_hosts file %q: %w", a.KnownHostsFile, err)
+	}
+
+	var cfg = &ssh.ClientConfig{
+		User:              a.User,
+		HostKeyCallback:   cb,
+		HostKeyAlgorithms: hostKeyAlgorithms(cb, connection),
+		Timeout:           time.Second * 10,
+	}
+
+	if a.KeyFile != "" {
+		var data []byte
+		data, err = os.ReadFile(a.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading private key: %w", err)
+		}
+
+		var signer ssh.Signer
+		signer, err = ssh.ParsePrivateKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("parsing private key %q: %w", a.KeyFile, err)
+		}
+		cfg.Auth = []ssh.AuthMethod{ssh.PublicKeys(signer)}
+	}
+
+	return cfg, nil
+}
+
+// hostKeyAlgorithms returns the key algorithms known_hosts has for the given
+// host. Without this, the server may offer a key type we don't have on file,
+// and verification fails even though a valid key is known.
+//
+// The knownhosts package doesn't expose its entries, so we ask the callback
+// to check a throwaway key: the resulting error lists the keys it wanted.
+func hostKeyAlgorithms(cb ssh.HostKeyCallback, connection string) []string {
+	var pub, _, err = ed25519.GenerateKey(nil)
+	if err != nil {
+		return nil
+	}
+	var key ssh.PublicKey
+	key, err = ssh.NewPublicKey(pub)
+	if err != nil {
+		return nil
+	}
+
+	var addr = &net.TCPAddr{IP: net.IPv4zero}
+	var keyErr *knownhosts.KeyError
+	if !errors.As(cb(connection, addr, key), &keyErr) {
+		return nil
+	}
+
+	var algos []string
+	for _, k := range keyErr.Want {
+		var t = k.Key.Type()
+		// RSA keys can be used with any of the RSA signature algorithms, and
+		// modern servers won't use plain "ssh-rsa" (SHA-1)
+		if t == ssh.KeyAlgoRSA {
+			algos = append(algos, ssh.KeyAlgoRSASHA512, ssh.KeyAlgoRSASHA256)
+		}
+		algos = append(algos, t)
+	}
+	return algos
+}
+
+// pool holds open connections to ONI Agents so that many calls, such as
+// repeated job status checks, don't each need a fresh TCP and SSH handshake
+type pool struct {
+	sync.Mutex
+	clients map[string]*ssh.Client
+
+	// interval is how often pooled connections are pinged. This keeps
+	// firewalls from dropping idle connections, and lets us notice dead ones
+	// before a caller tries to use them.
+	interval time.Duration
+}
+
+var connections = &pool{clients: make(map[string]*ssh.Client), interval: 30 * time.Second}
+
+// get returns the pooled client for key, dialing a new one if necessary.
+// Dialing happens without holding the lock so an unreachable agent can't
+// stall calls to every other agent. If another caller pooled a client for
+// the same key while we were dialing, theirs wins and ours is closed.
+func (p *pool) get(key, connection string, cfg *ssh.ClientConfig) (*ssh.Client, error) {
+	p.Lock()
+	var c = p.clients[key]
+	p.Unlock()
+	if c != nil {
+		return c, nil
+	}
+
+	var dialed, err = ssh.Dial("tcp", connection, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("dialing %q: %w", connection, err)
+	}
+
+	p.Lock()
+	defer p.Unlock()
+	c = p.clients[key]
+	if c != nil {
+		_ = dialed.Close()
+		return c, nil
+	}
+	p.clients[key] = dialed
+	go p.keepalive(key, dialed, p.interval)
+
+	return dialed, nil
+}
+
+// drop closes c and removes it from the pool if it's still the pooled client
+// for key
+func (p *pool) drop(key string, c *ssh.Client) {
+	p.Lock()
+	if p.clients[key] == c {
+		delete(p.clients, key)
+	}
+	p.Unlock()
+	_ = c.Close()
+}
+
+// keepalive pings c periodically until it's closed or stops responding, then
+// drops it from the pool so the next call reconnects
+func (p *pool) keepalive(key string, c *ssh.Client, interval time.Duration) {
+	var closed = make(chan struct{})
+	go func() {
+		_ = c.Wait()
+		close(closed)
+	}()
+
+	var ticker = time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-closed:
+			p.drop(key, c)
+			return
+
+		case <-ticker.C:
+			// A dead peer can leave SendRequest blocked indefinitely, so closing
+			// the client is how we give up on it
+			var timer = time.AfterFunc(keepaliveTimeout, func() { _ = c.Close() })
+			var _, _, err = c.SendRequest("keepalive@openssh.com", true, nil)
+			timer.Stop()
+			if err != nil {
+				logger.Warnf("ONI Agent connection %s lost: %s", c.RemoteAddr(), err)
+				p.drop(key, c)
+				return
+			}
+		}
+	}
+}
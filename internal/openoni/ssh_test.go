@@ -0,0 +1,373 @@
+package openoni
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// fakeAgent is a minimal in-process SSH server which answers exec requests
+// the way an ONI Agent would
+type fakeAgent struct {
+	sync.Mutex
+	listener   net.Listener
+	cfg        *ssh.ServerConfig
+	hostKey    ssh.Signer
+	conns      []*ssh.ServerConn
+	keepalives int
+	commands   []string
+}
+
+func newSigner(t *testing.T) (ssh.Signer, ed25519.PrivateKey) {
+	var _, priv, err = ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("Unable to generate key: %s", err)
+	}
+	var signer ssh.Signer
+	signer, err = ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatalf("Unable to create signer: %s", err)
+	}
+	return signer, priv
+}
+
+// startFakeAgent runs a server which only accepts the given client key
+func startFakeAgent(t *testing.T, clientKey ssh.PublicKey) *fakeAgent {
+	var hostKey, _ = newSigner(t)
+	var a = &fakeAgent{hostKey: hostKey}
+	a.cfg = &ssh.ServerConfig{
+		PublicKeyCallback: func(_ ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if bytes.Equal(key.Marshal(), clientKey.Marshal()) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("unknown client key")
+		},
+	}
+	a.cfg.AddHostKey(hostKey)
+
+	var err error
+	a.listener, err = net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unable to listen: %s", err)
+	}
+	t.Cleanup(func() { _ = a.listener.Close() })
+
+	go a.serve()
+	return a
+}
+
+func (a *fakeAgent) addr() string {
+	return a.listener.Addr().String()
+}
+
+func (a *fakeAgent) serve() {
+	for {
+		var nc, err = a.listener.Accept()
+		if err != nil {
+			return
+		}
+		go a.handleConn(nc)
+	}
+}
+
+func (a *fakeAgent) handleConn(nc net.Conn) {
+	var conn, chans, reqs, err = ssh.NewServerConn(nc, a.cfg)
+	if err != nil {
+		_ = nc.Close()
+		return
+	}
+	a.Lock()
+	a.conns = append(a.conns, conn)
+	a.Unlock()
+
+	go func() {
+		for req := range reqs {
+			if req.Type == "keepalive@openssh.com" {
+				a.Lock()
+				a.keepalives++
+				a.Unlock()
+			}
+			_ = req.Reply(false, nil)
+		}
+	}()
+
+	for nc := range chans {
+		if nc.ChannelType() != "session" {
+			_ = nc.Reject(ssh.UnknownChannelType, "sessions only")
+			continue
+		}
+		var ch, chReqs, err = nc.Accept()
+		if err != nil {
+			continue
+		}
+		go a.handleSession(ch, chReqs)
+	}
+}
+
+func (a *fakeAgent) handleSession(ch ssh.Channel, reqs <-chan *ssh.Request) {
+	defer ch.Close()
+	for req := range reqs {
+		if req.Type != "exec" {
+			_ = req.Reply(false, nil)
+			continue
+		}
+		_ = req.Reply(true, nil)
+
+		var payload struct{ Command string }
+		_ = ssh.Unmarshal(req.Payload, &payload)
+		a.Lock()
+		a.commands = append(a.commands, payload.Command)
+		a.Unlock()
+
+		_, _ = io.ReadAll(ch)
+		_, _ = io.WriteString(ch, `{"status": "success", "version": "fake-1.0"}`)
+		_, _ = ch.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{0}))
+		return
+	}
+}
+
+func (a *fakeAgent) connCount() int {
+	a.Lock()
+	defer a.Unlock()
+	return len(a.conns)
+}
+
+// dropConnections closes all connections from the server side
+func (a *fakeAgent) dropConnections() {
+	a.Lock()
+	defer a.Unlock()
+	for _, c := range a.conns {
+		_ = c.Close()
+	}
+}
+
+// writeAuth writes a known_hosts file for the given host key and address, and
+// a client key file, returning the Auth which uses them
+func writeAuth(t *testing.T, addr string, hostKey ssh.PublicKey, clientKey ed25519.PrivateKey) Auth {
+	var dir = t.TempDir()
+	var auth = Auth{
+		KnownHostsFile: filepath.Join(dir, "known_hosts"),
+		KeyFile:        filepath.Join(dir, "id_ed25519"),
+		User:           "nca",
+	}
+
+	var line = knownhosts.Line([]string{knownhosts.Normalize(addr)}, hostKey)
+	var err = os.WriteFile(auth.KnownHostsFile, []byte(line+"\n"), 0600)
+	if err != nil {
+		t.Fatalf("Unable to write known_hosts: %s", err)
+	}
+
+	var block *pem.Block
+	block, err = ssh.MarshalPrivateKey(clientKey, "")
+	if err != nil {
+		t.Fatalf("Unable to marshal client key: %s", err)
+	}
+	err = os.WriteFile(auth.KeyFile, pem.EncodeToMemory(block), 0600)
+	if err != nil {
+		t.Fatalf("Unable to write client key: %s", err)
+	}
+
+	return auth
+}
+
+func TestSSHPooling(t *testing.T) {
+	var clientSigner, clientKey = newSigner(t)
+	var agent = startFakeAgent(t, clientSigner.PublicKey())
+	var auth = writeAuth(t, agent.addr(), agent.hostKey.PublicKey(), clientKey)
+
+	var r, err = New(agent.addr(), auth)
+	if err != nil {
+		t.Fatalf("New: %s", err)
+	}
+
+	// Multiple calls, and multiple RPCs with the same settings, should all
+	// share one connection
+	for i := 0; i < 3; i++ {
+		var v string
+		v, err = r.GetVersion()
+		if err != nil {
+			t.Fatalf("GetVersion call %d: %s", i, err)
+		}
+		if v != "fake-1.0" {
+			t.Fatalf("GetVersion call %d: expected version %q, got %q", i, "fake-1.0", v)
+		}
+	}
+	var r2, _ = New(agent.addr(), auth)
+	_, err = r2.LoadTitle([]byte("<marc/>"))
+	if err != nil {
+		t.Fatalf("LoadTitle: %s", err)
+	}
+	if agent.connCount() != 1 {
+		t.Fatalf("Expected 1 connection, got %d", agent.connCount())
+	}
+	agent.Lock()
+	var cmd = agent.commands[3]
+	agent.Unlock()
+	if cmd != `"load-title"` {
+		t.Fatalf("Expected fourth command to be load-title, got %q", cmd)
+	}
+
+	// A connection dropped by the server should be replaced transparently
+	agent.dropConnections()
+	_, err = r.GetVersion()
+	if err != nil {
+		t.Fatalf("GetVersion after dropped connection: %s", err)
+	}
+	if agent.connCount() != 2 {
+		t.Fatalf("Expected 2 connections after reconnect, got %d", agent.connCount())
+	}
+}
+
+func TestSSHPoolDialUnlocked(t *testing.T) {
+	// A "host" which accepts TCP connections but never answers the SSH
+	// handshake, leaving anybody dialing it stuck
+	var l, err = net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unable to listen: %s", err)
+	}
+	var accepted = make(chan net.Conn, 1)
+	go func() {
+		var c, _ = l.Accept()
+		accepted <- c
+	}()
+	defer l.Close()
+
+	var clientSigner, clientKey = newSigner(t)
+	var agent = startFakeAgent(t, clientSigner.PublicKey())
+	var auth = writeAuth(t, agent.addr(), agent.hostKey.PublicKey(), clientKey)
+
+	var hung, _ = New(l.Addr().String(), auth)
+	var hungDone = make(chan struct{})
+	go func() {
+		_, _ = hung.GetVersion()
+		close(hungDone)
+	}()
+	var stuck = <-accepted
+
+	// The stuck dial must not keep other agents from being reached
+	var r, _ = New(agent.addr(), auth)
+	var done = make(chan error, 1)
+	go func() {
+		var _, err = r.GetVersion()
+		done <- err
+	}()
+	select {
+	case err = <-done:
+		if err != nil {
+			t.Fatalf("GetVersion: %s", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("GetVersion blocked behind an unresponsive agent")
+	}
+
+	_ = stuck.Close()
+	<-hungDone
+}
+
+func TestSSHPoolConcurrentDial(t *testing.T) {
+	var clientSigner, clientKey = newSigner(t)
+	var agent = startFakeAgent(t, clientSigner.PublicKey())
+	var auth = writeAuth(t, agent.addr(), agent.hostKey.PublicKey(), clientKey)
+	var r, _ = New(agent.addr(), auth)
+
+	// Racing callers may each dial, but only one client ends up pooled, and
+	// every caller gets that one
+	var wg sync.WaitGroup
+	var clients = make([]*ssh.Client, 5)
+	for i := range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			clients[i], _ = connections.get(r.poolKey, r.connection, r.cfg)
+		}()
+	}
+	wg.Wait()
+
+	for i, c := range clients {
+		if c == nil || c != clients[0] {
+			t.Fatalf("Expected every caller to get the same client, got %p for caller %d and %p for caller 0", c, i, clients[0])
+		}
+	}
+}
+
+func TestSSHKeepalive(t *testing.T) {
+	connections.Lock()
+	var oldInterval = connections.interval
+	connections.interval = 10 * time.Millisecond
+	connections.Unlock()
+	defer func() {
+		connections.Lock()
+		connections.interval = oldInterval
+		connections.Unlock()
+	}()
+
+	var clientSigner, clientKey = newSigner(t)
+	var agent = startFakeAgent(t, clientSigner.PublicKey())
+	var auth = writeAuth(t, agent.addr(), agent.hostKey.PublicKey(), clientKey)
+
+	var r, _ = New(agent.addr(), auth)
+	var _, err = r.GetVersion()
+	if err != nil {
+		t.Fatalf("GetVersion: %s", err)
+	}
+
+	var deadline = time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		agent.Lock()
+		var n = agent.keepalives
+		agent.Unlock()
+		if n >= 2 {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("Expected keepalive requests on idle connection")
+}
+
+func TestSSHVerification(t *testing.T) {
+	var clientSigner, clientKey = newSigner(t)
+	var agent = startFakeAgent(t, clientSigner.PublicKey())
+	var otherHost, _ = newSigner(t)
+	var _, otherClient = newSigner(t)
+
+	var tests = map[string]struct {
+		auth        Auth
+		errContains string
+	}{
+		"valid":            {auth: writeAuth(t, agent.addr(), agent.hostKey.PublicKey(), clientKey)},
+		"wrong host key":   {auth: writeAuth(t, agent.addr(), otherHost.PublicKey(), clientKey), errContains: "key mismatch"},
+		"unknown host":     {auth: writeAuth(t, "example.org:22", agent.hostKey.PublicKey(), clientKey), errContains: "key is unknown"},
+		"wrong client key": {auth: writeAuth(t, agent.addr(), agent.hostKey.PublicKey(), otherClient), errContains: "unable to authenticate"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var r, err = New(agent.addr(), tc.auth)
+			if err != nil {
+				t.Fatalf("New: %s", err)
+			}
+			_, err = r.GetVersion()
+			if tc.errContains == "" {
+				if err != nil {
+					t.Fatalf("Expected success, got %s", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.errContains) {
+				t.Fatalf("Expected error containing %q, got %v", tc.errContains, err)
+			}
+		})
+	}
+}
@@ -115,26 +115,80 @@ func (a *API) GetUser(user string) (u *User, err error) {
 }
 
 // UpdateUser tells SFTPGo to change the password and/or quota for a
-// publisher's SFTP user
+// publisher's SFTP user. An empty password leaves the current password alone.
 func (a *API) UpdateUser(user, pass string, quota int64) error {
 	if a.LastErr != nil {
 		return fmt.Errorf("updating user: uninitialized sftpgo.API instance: %w", a.LastErr)
 	}
 
-	// Get the raw user JSON and modify it - SFTPGo will reset *all fields* we
-	// omit in a PUT request. The simple "User" type works great for creation
-	// (since we want the default values) and retrieval (we only display a few
-	// fields in NCA). But for updates, we have to get the full user record and
-	// carefully modify it.
+	return a.modifyUser(user, func(data []byte) ([]byte, error) {
+		var data2, err = sjson.SetBytes(data, "quota_size", quota)
+		if err == nil {
+			data2, err = sjson.SetBytes(data2, "password", pass)
+		}
+		return data2, err
+	})
+}
+
+// SetEnabled enables or disables a user's ability to log in
+func (a *API) SetEnabled(user string, enabled bool) error {
+	if a.LastErr != nil {
+		return fmt.Errorf("setting user status: uninitialized sftpgo.API instance: %w", a.LastErr)
+	}
+
+	var status = 0
+	if enabled {
+		status = 1
+	}
+	return a.modifyUser(user, func(data []byte) ([]byte, error) {
+		return sjson.SetBytes(data, "status", status)
+	})
+}
+
+// ResetPassword gives the user a new random password, which is returned.
+// SFTPGo never exposes passwords, so this is the only chance to see it.
+func (a *API) ResetPassword(user string) (password string, err error) {
+	if a.LastErr != nil {
+		return "", fmt.Errorf("resetting password: uninitialized sftpgo.API instance: %w", a.LastErr)
+	}
+
+	password = a.rndPass()
+	err = a.modifyUser(user, func(data []byte) ([]byte, error) {
+		return sjson.SetBytes(data, "password", password)
+	})
+	if err != nil {
+		return "", err
+	}
+	return password, nil
+}
+
+// SetPublicKeys replaces the user's SSH public keys. An empty list removes
+// all keys, leaving only password authentication.
+func (a *API) SetPublicKeys(user string, keys []string) error {
+	if a.LastErr != nil {
+		return fmt.Errorf("setting public keys: uninitialized sftpgo.API instance: %w", a.LastErr)
+	}
+
+	if keys == nil {
+		keys = []string{}
+	}
+	return a.modifyUser(user, func(data []byte) ([]byte, error) {
+		return sjson.SetBytes(data, "public_keys", keys)
+	})
+}
+
+// modifyUser gets the raw user JSON, runs it through fn, and sends it back.
+// SFTPGo will reset *all fields* we omit in a PUT request. The simple "User"
+// type works great for creation (since we want the default values) and
+// retrieval (we only display a few fields in NCA). But for updates, we have
+// to get the full user record and carefully modify it.
+func (a *API) modifyUser(user string, fn func([]byte) ([]byte, error)) error {
 	var data, err = a.rpc("GET", path.Join("users", user), "")
 	if err != nil {
 		return fmt.Errorf("unable to request user from SFTPGo: %w", err)
 	}
 
-	data, err = sjson.SetBytes(data, "quota_size", quota)
-	if err == nil {
-		data, err = sjson.SetBytes(data, "password", pass)
-	}
+	data, err = fn(data)
 	if err != nil {
 		return fmt.Errorf("error setting user data: %w", err)
 	}
@@ -2,11 +2,14 @@ package sftpgo
 
 import (
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/tidwall/gjson"
 )
 
 type request struct {
@@ -14,6 +17,7 @@ type request struct {
 	method   string
 	headers  http.Header
 	url      string
+	body     string
 }
 
 const exampleStatus = `{"message":"Server fully operational"}`
@@ -37,11 +41,16 @@ func makeSpy() *spy {
 
 func (s *spy) do(_ *http.Client, req *http.Request) ([]byte, error) {
 	var function = strings.Replace(req.URL.Path, "/api/v2/", "", 1)
+	var body []byte
+	if req.Body != nil {
+		body, _ = io.ReadAll(req.Body)
+	}
 	s.requests = append(s.requests, request{
 		function: function,
 		method:   req.Method,
 		headers:  req.Header,
 		url:      req.URL.String(),
+		body:     string(body),
 	})
 	if s.responses[function] == nil {
 		return nil, fmt.Errorf("No response for function %q", function)
@@ -160,3 +169,64 @@ func TestUpdateUser(t *testing.T) {
 		t.Errorf("POST request should have been for the user, but it was %#v", s.requests[1])
 	}
 }
+
+func TestModifyUser(t *testing.T) {
+	const user = `{"username":"fakename","status":1,"quota_size":100,"public_keys":["ssh-ed25519 AAAA old"]}`
+
+	var tests = map[string]struct {
+		call func(a *API) error
+		want map[string]string
+	}{
+		"disable": {
+			call: func(a *API) error { return a.SetEnabled("fakename", false) },
+			want: map[string]string{"status": "0", "quota_size": "100"},
+		},
+		"enable": {
+			call: func(a *API) error { return a.SetEnabled("fakename", true) },
+			want: map[string]string{"status": "1"},
+		},
+		"reset password": {
+			call: func(a *API) error {
+				var pass, err = a.ResetPassword("fakename")
+				if err == nil && pass != "random" {
+					err = fmt.Errorf("expected password %q, got %q", "random", pass)
+				}
+				return err
+			},
+			want: map[string]string{"password": `"random"`, "status": "1"},
+		},
+		"set keys": {
+			call: func(a *API) error { return a.SetPublicKeys("fakename", []string{"ssh-ed25519 AAAA new"}) },
+			want: map[string]string{"public_keys": `["ssh-ed25519 AAAA new"]`},
+		},
+		"remove keys": {
+			call: func(a *API) error { return a.SetPublicKeys("fakename", nil) },
+			want: map[string]string{"public_keys": `[]`},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var a, s = newAPI(t)
+			a.rndPass = func() string { return "random" }
+			s.responses["users/fakename"] = []byte(user)
+
+			var err = tc.call(a)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if len(s.requests) != 2 || s.requests[1].method != "PUT" {
+				t.Fatalf("Expected a GET and a PUT, got %#v", s.requests)
+			}
+
+			// Values are compared as raw JSON
+			var body = s.requests[1].body
+			for field, val := range tc.want {
+				var got = gjson.Get(body, field).Raw
+				if got != val {
+					t.Errorf("Expected %s to be %s, got %s (body: %s)", field, val, got, body)
+				}
+			}
+		})
+	}
+}
@@ -1,11 +1,31 @@
 package sftpgo
 
+import "time"
+
 // User is a structured object for SFTPGo JSON requests / responses
 type User struct {
-	Status      int                 `json:"status,omitempty"`
-	Username    string              `json:"username,omitempty"`
-	Password    string              `json:"password,omitempty"`
-	Description string              `json:"description,omitempty"`
-	Permissions map[string][]string `json:"permissions,omitempty"`
-	QuotaSize   int64               `json:"quota_size,omitempty"`
+	Status         int                 `json:"status,omitempty"`
+	Username       string              `json:"username,omitempty"`
+	Password       string              `json:"password,omitempty"`
+	Description    string              `json:"description,omitempty"`
+	Permissions    map[string][]string `json:"permissions,omitempty"`
+	QuotaSize      int64               `json:"quota_size,omitempty"`
+	UsedQuotaSize  int64               `json:"used_quota_size,omitempty"`
+	UsedQuotaFiles int64               `json:"used_quota_files,omitempty"`
+	LastLogin      int64               `json:"last_login,omitempty"` // Milliseconds since the Unix epoch
+	PublicKeys     []string            `json:"public_keys,omitempty"`
+}
+
+// Enabled returns true if the user is allowed to log in
+func (u *User) Enabled() bool {
+	return u.Status == 1
+}
+
+// LastLoginTime returns when the user last logged in, or a zero time if the
+// user has never logged in
+func (u *User) LastLoginTime() time.Time {
+	if u.LastLogin == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(u.LastLogin)
 }
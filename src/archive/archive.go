@@ -0,0 +1,172 @@
+// Package archive moves or copies live batches to their long-term home: a mounted
+// filesystem, S3-compatible object storage, or a staging area for tape
+package archive
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"syscall"
+
+	"github.com/uoregon-libraries/gopkg/fileutil"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+)
+
+// A Target is a place live batches are archived
+type Target interface {
+	// Location describes where the named batch is (or will be) archived, for
+	// logs and batch activity
+	Location(name string) string
+
+	// Archive stores the batch directory in the target under the given name.
+	// Targets may move the directory rather than copy it, so it mustn't be
+	// used afterward. Archiving a batch again must be safe, as jobs are
+	// retried after a failure.
+	Archive(dir, name string) error
+}
+
+// A LocalTarget archives to a mounted filesystem, so the archived batch can
+// be validated in place
+type LocalTarget interface {
+	Target
+
+	// Path returns the archived batch's directory
+	Path(name string) string
+}
+
+// New returns the archive target described by the configuration
+func New(t *config.ArchiveTarget) (Target, error) {
+	switch t.Type {
+	case config.ArchiveFilesystem:
+		return Filesystem(t.Path), nil
+	case config.ArchiveTape:
+		return Tape(t.Path), nil
+	case config.ArchiveS3:
+		return NewS3(t)
+	}
+	return nil, fmt.Errorf("unknown archive target type %q", t.Type)
+}
+
+// Filesystem archives batches by moving them into a directory, which is
+// usually a network mount of the real archive. Nothing is verified here: the
+// archive is on disk, so the go-live jobs validate the archived bag in place.
+type Filesystem string
+
+// Location returns the archived batch's directory
+func (f Filesystem) Location(name string) string {
+	return f.Path(name)
+}
+
+// Path returns the archived batch's directory
+func (f Filesystem) Path(name string) string {
+	return filepath.Join(string(f), name)
+}
+
+// rename is os.Rename, replaceable so tests can simulate a cross-device move
+var rename = os.Rename
+
+// Archive renames the batch directory into the archive when both are on the
+// same device, which is instant and can't leave a partial copy. Otherwise
+// the batch is copied.
+func (f Filesystem) Archive(dir, name string) error {
+	var root, final = string(f), f.Path(name)
+	var wip = filepath.Join(root, ".wip-"+name)
+
+	// If a previous attempt finished, or got partway through a copy, the
+	// rename can't be attempted, and syncDir knows what to do
+	if !fileutil.Exists(final) && !fileutil.Exists(wip) {
+		var err = os.MkdirAll(root, 0755)
+		if err != nil {
+			return fmt.Errorf("creating archive root %q: %w", root, err)
+		}
+		err = rename(dir, final)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, syscall.EXDEV) {
+			return fmt.Errorf("moving %q to %q: %w", dir, final, err)
+		}
+	}
+
+	return syncDir(dir, root, name)
+}
+
+// syncDir copies dir into root/name by way of a work-in-progress directory,
+// so a partial copy never has the final name. The copy isn't verified here;
+// callers which need that must check it themselves. If the final directory
+// already exists, and the WIP directory doesn't, a previous attempt finished
+// and nothing more is done.
+func syncDir(dir, root, name string) error {
+	var wip = filepath.Join(root, ".wip-"+name)
+	var final = filepath.Join(root, name)
+	if fileutil.Exists(final) {
+		if fileutil.Exists(wip) {
+			return fmt.Errorf("both %q and %q exist; one must be removed manually", final, wip)
+		}
+		return nil
+	}
+
+	var err = os.MkdirAll(root, 0755)
+	if err != nil {
+		return fmt.Errorf("creating archive root %q: %w", root, err)
+	}
+	err = fileutil.SyncDirectory(dir, wip)
+	if err != nil {
+		return fmt.Errorf("copying %q to %q: %w", dir, wip, err)
+	}
+	err = os.Rename(wip, final)
+	if err != nil {
+		return fmt.Errorf("renaming %q to %q: %w", wip, final, err)
+	}
+	return nil
+}
+
+// fileEntry is a regular file within a batch
+type fileEntry struct {
+	path string // Full path on disk
+	rel  string // Path relative to the batch, always slash-separated
+	size int64
+}
+
+// batchFiles returns every regular file under dir, sorted by relative path
+func batchFiles(dir string) ([]fileEntry, error) {
+	var list []fileEntry
+	var err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return fmt.Errorf("%q is not a regular file", path)
+		}
+		var rel, _ = filepath.Rel(dir, path)
+		list = append(list, fileEntry{path: path, rel: filepath.ToSlash(rel), size: info.Size()})
+		return nil
+	})
+
+	sort.Slice(list, func(i, j int) bool { return list[i].rel < list[j].rel })
+	return list, err
+}
+
+// fileSHA256 returns the hex-encoded SHA256 sum of a file's contents
+func fileSHA256(path string) (string, error) {
+	var f, err = os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	var h = sha256.New()
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,208 @@
+package archive
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+)
+
+func writeBatch(t *testing.T) string {
+	var dir = t.TempDir()
+	var files = map[string]string{
+		"batch_foo_ver01.xml":       "<batch />",
+		"sn12345678/print/0001.jp2": "image",
+	}
+	for name, data := range files {
+		var fname = filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(fname), 0755)
+		var err = os.WriteFile(fname, []byte(data), 0644)
+		if err != nil {
+			t.Fatalf("Unable to write %q: %s", fname, err)
+		}
+	}
+	return dir
+}
+
+func TestNew(t *testing.T) {
+	var tests = map[string]struct {
+		target   *config.ArchiveTarget
+		expected string
+	}{
+		"filesystem": {target: &config.ArchiveTarget{Type: config.ArchiveFilesystem, Path: "/mnt/archive"}, expected: "/mnt/archive/foo"},
+		"tape":       {target: &config.ArchiveTarget{Type: config.ArchiveTape, Path: "/mnt/tape"}, expected: "/mnt/tape/foo"},
+		"s3":         {target: &config.ArchiveTarget{Type: config.ArchiveS3, S3Endpoint: "https://s3.example.org", S3Bucket: "b"}, expected: "s3://b/foo/"},
+		"unknown":    {target: &config.ArchiveTarget{Type: "floppy"}},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var target, err = New(tc.target)
+			if tc.expected == "" {
+				if err == nil {
+					t.Fatalf("Expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if target.Location("foo") != tc.expected {
+				t.Errorf("Expected location %q, got %q", tc.expected, target.Location("foo"))
+			}
+		})
+	}
+}
+
+func TestFilesystemArchive(t *testing.T) {
+	var src = writeBatch(t)
+	var root = filepath.Join(t.TempDir(), "archive")
+	var target = Filesystem(root)
+
+	var err = target.Archive(src, "batch_foo_ver01")
+	if err != nil {
+		t.Fatalf("Unable to archive: %s", err)
+	}
+	var data, _ = os.ReadFile(filepath.Join(target.Path("batch_foo_ver01"), "sn12345678", "print", "0001.jp2"))
+	if string(data) != "image" {
+		t.Errorf("Archived file has the wrong content: %q", data)
+	}
+	if _, err = os.Stat(src); !os.IsNotExist(err) {
+		t.Errorf("Expected the batch to be moved, but %q is still there", src)
+	}
+
+	// A retry after success is a no-op
+	err = target.Archive(src, "batch_foo_ver01")
+	if err != nil {
+		t.Fatalf("Unable to re-archive: %s", err)
+	}
+
+	// Leftovers from a partial copy alongside a final copy need a human
+	os.Mkdir(filepath.Join(root, ".wip-batch_foo_ver01"), 0755)
+	err = target.Archive(src, "batch_foo_ver01")
+	if err == nil || !strings.Contains(err.Error(), "removed manually") {
+		t.Errorf("Expected a conflict error, got %v", err)
+	}
+}
+
+func TestFilesystemArchiveCrossDevice(t *testing.T) {
+	rename = func(_, _ string) error { return &os.LinkError{Op: "rename", Err: syscall.EXDEV} }
+	defer func() { rename = os.Rename }()
+
+	var src = writeBatch(t)
+	var target = Filesystem(filepath.Join(t.TempDir(), "archive"))
+	var err = target.Archive(src, "batch_foo_ver01")
+	if err != nil {
+		t.Fatalf("Unable to archive: %s", err)
+	}
+	var data, _ = os.ReadFile(filepath.Join(target.Path("batch_foo_ver01"), "batch_foo_ver01.xml"))
+	if string(data) != "<batch />" {
+		t.Errorf("Archived file has the wrong content: %q", data)
+	}
+	if _, err = os.Stat(src); err != nil {
+		t.Errorf("Expected the batch to be copied, leaving the original: %s", err)
+	}
+}
+
+// writeBag writes a minimal bag holding the same files as writeBatch, with a
+// manifest and tag manifest. If corrupt is true, the payload manifest has the
+// wrong sum for the JP2.
+func writeBag(t *testing.T, corrupt bool) string {
+	var dir = t.TempDir()
+	var jp2Sum = "6105d6cc76af400325e94d588ce511be5bfdbb73b437dc51eca43917d7a43e3d"
+	if corrupt {
+		jp2Sum = strings.Repeat("0", 64)
+	}
+	var manifest = "" +
+		"89fc4daada26d62f930df01a9e405624b8e76ad2456d3a1289c5b267b31c3b91  data/batch_foo_ver01.xml\n" +
+		jp2Sum + "  data/sn12345678/print/0001.jp2\n"
+	var files = map[string]string{
+		"data/batch_foo_ver01.xml":       "<batch />",
+		"data/sn12345678/print/0001.jp2": "image",
+		"manifest-sha256.txt":            manifest,
+		"bagit.txt":                      "BagIt-Version: 0.97\n",
+	}
+	for name, data := range files {
+		var fname = filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(fname), 0755)
+		var err = os.WriteFile(fname, []byte(data), 0644)
+		if err != nil {
+			t.Fatalf("Unable to write %q: %s", fname, err)
+		}
+	}
+
+	var tagManifest = fmt.Sprintf("%s  bagit.txt\n%s  manifest-sha256.txt\n", sha256Hex("BagIt-Version: 0.97\n"), sha256Hex(manifest))
+	var err = os.WriteFile(filepath.Join(dir, "tagmanifest-sha256.txt"), []byte(tagManifest), 0644)
+	if err != nil {
+		t.Fatalf("Unable to write tag manifest: %s", err)
+	}
+	return dir
+}
+
+func sha256Hex(s string) string {
+	var sum = sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestTapeArchive(t *testing.T) {
+	var src = writeBag(t, false)
+	var target = Tape(t.TempDir())
+
+	var err = target.Archive(src, "batch_foo_ver01")
+	if err != nil {
+		t.Fatalf("Unable to archive: %s", err)
+	}
+
+	var data []byte
+	data, err = os.ReadFile(target.ManifestPath("batch_foo_ver01"))
+	if err != nil {
+		t.Fatalf("Unable to read manifest: %s", err)
+	}
+	var lines = strings.Split(strings.TrimSpace(string(data)), "\n")
+	var expected = []string{
+		sha256Hex("BagIt-Version: 0.97\n") + "\t20\tbatch_foo_ver01/bagit.txt",
+		"89fc4daada26d62f930df01a9e405624b8e76ad2456d3a1289c5b267b31c3b91\t9\tbatch_foo_ver01/data/batch_foo_ver01.xml",
+		"6105d6cc76af400325e94d588ce511be5bfdbb73b437dc51eca43917d7a43e3d\t5\tbatch_foo_ver01/data/sn12345678/print/0001.jp2",
+	}
+	if len(lines) != 5 {
+		t.Fatalf("Expected 5 manifest lines, got %d: %q", len(lines), lines)
+	}
+	for i, want := range expected {
+		if lines[i] != want {
+			t.Errorf("Expected manifest line %d to be %q, got %q", i, want, lines[i])
+		}
+	}
+	if _, err = os.Stat(target.ReadyPath("batch_foo_ver01")); err != nil {
+		t.Errorf("Expected a ready marker: %s", err)
+	}
+}
+
+func TestTapeArchiveBadBag(t *testing.T) {
+	var src = writeBag(t, true)
+	var target = Tape(t.TempDir())
+
+	var err = target.Archive(src, "batch_foo_ver01")
+	if err == nil {
+		t.Fatalf("Expected archiving a bag that doesn't match its manifest to fail")
+	}
+	if !strings.Contains(err.Error(), "0001.jp2") {
+		t.Errorf("Expected the error to name the bad file, got %q", err)
+	}
+	for _, fname := range []string{target.Location("batch_foo_ver01"), target.ManifestPath("batch_foo_ver01"), target.ReadyPath("batch_foo_ver01")} {
+		if _, err = os.Stat(fname); !os.IsNotExist(err) {
+			t.Errorf("Expected %q not to exist after a failed archive", fname)
+		}
+	}
+}
+
+func TestTapeIsNotLocal(t *testing.T) {
+	var target Target = Tape("/mnt/tape")
+	if _, ok := target.(LocalTarget); ok {
+		t.Errorf("Tape must not be a LocalTarget: its staged copy can't be validated after it's marked ready")
+	}
+}
@@ -0,0 +1,441 @@
+package archive
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+)
+
+// shaMetaHeader holds each object's SHA256 sum so archived files can be
+// verified without downloading them
+const shaMetaHeader = "X-Amz-Meta-Sha256"
+
+// S3 archives batches to an S3-compatible object store. Each file is stored
+// under "<prefix>/<batch name>/<path within batch>", and is verified after
+// upload by its size, ETag (MD5-based), and SHA256 sum.
+//
+// Requests are signed with AWS Signature Version 4 and use path-style URLs,
+// which every S3-compatible service we know of supports.
+type S3 struct {
+	Endpoint  *url.URL
+	Region    string
+	Bucket    string
+	Prefix    string
+	AccessKey string
+	SecretKey string
+	PartSize  int64
+	Client    *http.Client
+
+	// now is overridden in tests to get predictable signatures
+	now func() time.Time
+}
+
+// NewS3 returns an S3 target for the given configuration
+func NewS3(t *config.ArchiveTarget) (*S3, error) {
+	var u, err = url.Parse(t.S3Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid S3 endpoint %q: %w", t.S3Endpoint, err)
+	}
+
+	return &S3{
+		Endpoint:  u,
+		Region:    t.S3Region,
+		Bucket:    t.S3Bucket,
+		Prefix:    strings.Trim(t.S3Prefix, "/"),
+		AccessKey: t.S3AccessKey,
+		SecretKey: t.S3SecretKey,
+		PartSize:  int64(t.S3PartSize),
+		Client:    &http.Client{Timeout: 10 * time.Minute},
+		now:       time.Now,
+	}, nil
+}
+
+// Location returns an s3:// URL for the batch's archived objects
+func (s *S3) Location(name string) string {
+	return "s3://" + s.Bucket + "/" + s.key(name) + "/"
+}
+
+func (s *S3) key(parts ...string) string {
+	if s.Prefix != "" {
+		parts = append([]string{s.Prefix}, parts...)
+	}
+	return path.Join(parts...)
+}
+
+// Archive uploads every file in the batch. Files which are already in the
+// bucket with the right size and checksums are skipped, so retrying a failed
+// archive only sends what's missing.
+func (s *S3) Archive(dir, name string) error {
+	var files, err = batchFiles(dir)
+	if err != nil {
+		return fmt.Errorf("reading batch %q: %w", dir, err)
+	}
+
+	for _, f := range files {
+		err = s.archiveFile(f, s.key(name, f.rel))
+		if err != nil {
+			return fmt.Errorf("archiving %q: %w", f.path, err)
+		}
+	}
+	return nil
+}
+
+// fileSums holds what we expect S3 to report about an uploaded file
+type fileSums struct {
+	size   int64
+	sha256 string
+	etag   string
+	parts  int
+}
+
+// sums reads the file once, hashing it the way it will be uploaded: as one
+// object if it fits in a single part, otherwise as a multipart upload whose
+// ETag is the MD5 of the parts' MD5s plus a part count
+func (s *S3) sums(f fileEntry) (*fileSums, error) {
+	var fh, err = os.Open(f.path)
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+
+	var whole = sha256.New()
+	var partMD5s []byte
+	var partHash = md5.New()
+	var n int64
+	var sums = &fileSums{size: f.size}
+	for {
+		partHash.Reset()
+		n, err = io.CopyN(io.MultiWriter(whole, partHash), fh, s.PartSize)
+		if n > 0 || sums.parts == 0 {
+			partMD5s = append(partMD5s, partHash.Sum(nil)...)
+			sums.parts++
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sums.sha256 = hex.EncodeToString(whole.Sum(nil))
+	if sums.parts == 1 {
+		sums.etag = hex.EncodeToString(partMD5s)
+	} else {
+		var sum = md5.Sum(partMD5s)
+		sums.etag = hex.EncodeToString(sum[:]) + "-" + strconv.Itoa(sums.parts)
+	}
+	return sums, nil
+}
+
+func (s *S3) archiveFile(f fileEntry, key string) error {
+	var sums, err = s.sums(f)
+	if err != nil {
+		return err
+	}
+
+	// Skip objects a previous attempt already stored correctly
+	if s.verify(key, sums) == nil {
+		return nil
+	}
+
+	if sums.parts == 1 {
+		err = s.putObject(f, key, sums)
+	} else {
+		err = s.multipartUpload(f, key, sums)
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.verify(key, sums)
+}
+
+// verify checks the stored object against the expected sums
+func (s *S3) verify(key string, sums *fileSums) error {
+	var resp, err = s.do(http.MethodHead, key, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HEAD %q: unexpected status %s", key, resp.Status)
+	}
+
+	if resp.ContentLength != sums.size {
+		return fmt.Errorf("object %q has size %d; expected %d", key, resp.ContentLength, sums.size)
+	}
+	var etag = strings.Trim(resp.Header.Get("ETag"), `"`)
+	if etag != sums.etag {
+		return fmt.Errorf("object %q has ETag %q; expected %q", key, etag, sums.etag)
+	}
+	var sha = resp.Header.Get(shaMetaHeader)
+	if sha != sums.sha256 {
+		return fmt.Errorf("object %q has SHA256 %q; expected %q", key, sha, sums.sha256)
+	}
+	return nil
+}
+
+func (s *S3) putObject(f fileEntry, key string, sums *fileSums) error {
+	var data, err = os.ReadFile(f.path)
+	if err != nil {
+		return err
+	}
+
+	var h = http.Header{}
+	h.Set(shaMetaHeader, sums.sha256)
+	var resp *http.Response
+	resp, err = s.do(http.MethodPut, key, nil, h, data)
+	if err != nil {
+		return err
+	}
+	return checkResponse(resp, nil)
+}
+
+// multipartUpload sends the file in PartSize pieces, aborting the upload if
+// anything fails so the bucket isn't left holding orphaned parts
+func (s *S3) multipartUpload(f fileEntry, key string, sums *fileSums) error {
+	var h = http.Header{}
+	h.Set(shaMetaHeader, sums.sha256)
+	var resp, err = s.do(http.MethodPost, key, url.Values{"uploads": {""}}, h, nil)
+	if err != nil {
+		return err
+	}
+	var initiated struct {
+		UploadID string `xml:"UploadId"`
+	}
+	err = checkResponse(resp, &initiated)
+	if err != nil {
+		return fmt.Errorf("starting multipart upload: %w", err)
+	}
+	if initiated.UploadID == "" {
+		return fmt.Errorf("starting multipart upload: no upload id returned")
+	}
+
+	err = s.uploadParts(f, key, initiated.UploadID, sums.parts)
+	if err != nil {
+		var abort, abortErr = s.do(http.MethodDelete, key, url.Values{"uploadId": {initiated.UploadID}}, nil, nil)
+		if abortErr == nil {
+			abort.Body.Close()
+		}
+		return err
+	}
+	return nil
+}
+
+type completedPart struct {
+	PartNumber int
+	ETag       string
+}
+
+func (s *S3) uploadParts(f fileEntry, key, uploadID string, count int) error {
+	var fh, err = os.Open(f.path)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+
+	var complete struct {
+		XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+		Parts   []completedPart `xml:"Part"`
+	}
+	var buf = make([]byte, s.PartSize)
+	for i := 1; i <= count; i++ {
+		var n int
+		n, err = io.ReadFull(fh, buf)
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return fmt.Errorf("reading part %d: %w", i, err)
+		}
+
+		var q = url.Values{"partNumber": {strconv.Itoa(i)}, "uploadId": {uploadID}}
+		var resp *http.Response
+		resp, err = s.do(http.MethodPut, key, q, nil, buf[:n])
+		if err != nil {
+			return err
+		}
+		err = checkResponse(resp, nil)
+		if err != nil {
+			return fmt.Errorf("uploading part %d: %w", i, err)
+		}
+		complete.Parts = append(complete.Parts, completedPart{PartNumber: i, ETag: resp.Header.Get("ETag")})
+	}
+
+	var body []byte
+	body, err = xml.Marshal(complete)
+	if err != nil {
+		return err
+	}
+	var resp *http.Response
+	resp, err = s.do(http.MethodPost, key, url.Values{"uploadId": {uploadID}}, nil, body)
+	if err != nil {
+		return err
+	}
+
+	// S3 can report a failed completion in the body of a 200 response, so the
+	// body has to be checked for an error document
+	var result struct {
+		XMLName xml.Name
+		Code    string
+		Message string
+	}
+	err = checkResponse(resp, &result)
+	if err != nil {
+		return fmt.Errorf("completing multipart upload: %w", err)
+	}
+	if result.XMLName.Local == "Error" {
+		return fmt.Errorf("completing multipart upload: %s: %s", result.Code, result.Message)
+	}
+	return nil
+}
+
+// checkResponse closes the response body after making sure the request
+// succeeded, decoding the body's XML into v when v isn't nil
+func checkResponse(resp *http.Response, v any) error {
+	defer resp.Body.Close()
+	var body, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	if v != nil {
+		err = xml.Unmarshal(body, v)
+		if err != nil {
+			return fmt.Errorf("reading response: %w", err)
+		}
+	}
+	return nil
+}
+
+// do sends a signed request for the given object key
+func (s *S3) do(method, key string, query url.Values, h http.Header, body []byte) (*http.Response, error) {
+	var u = *s.Endpoint
+	u.Path = path.Join("/", u.Path, s.Bucket, key)
+	u.RawPath = uriEncode(u.Path, false)
+	u.RawQuery = canonicalQuery(query)
+
+	var req, err = http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range h {
+		req.Header[k] = v
+	}
+	if body != nil {
+		var sum = md5.Sum(body)
+		req.Header.Set("Content-MD5", base64.StdEncoding.EncodeToString(sum[:]))
+	}
+	var payload = sha256.Sum256(body)
+	s.sign(req, hex.EncodeToString(payload[:]), s.now())
+
+	return s.Client.Do(req)
+}
+
+// sign adds AWS Signature Version 4 headers to req, signing the host and
+// every header already set on the request
+func (s *S3) sign(req *http.Request, payloadHash string, now time.Time) {
+	now = now.UTC()
+	var stamp = now.Format("20060102T150405Z")
+	var day = now.Format("20060102")
+	req.Header.Set("X-Amz-Date", stamp)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	var headers = map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		headers[strings.ToLower(k)] = strings.TrimSpace(strings.Join(v, ","))
+	}
+	var names []string
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonHeaders strings.Builder
+	for _, k := range names {
+		canonHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	var signed = strings.Join(names, ";")
+
+	var canonRequest = strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonHeaders.String(),
+		signed,
+		payloadHash,
+	}, "\n")
+	var reqHash = sha256.Sum256([]byte(canonRequest))
+
+	var scope = day + "/" + s.Region + "/s3/aws4_request"
+	var toSign = "AWS4-HMAC-SHA256\n" + stamp + "\n" + scope + "\n" + hex.EncodeToString(reqHash[:])
+
+	var key = hmacSHA256([]byte("AWS4"+s.SecretKey), day)
+	key = hmacSHA256(key, s.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	var sig = hex.EncodeToString(hmacSHA256(key, toSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.AccessKey, scope, signed, sig))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	var h = hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// canonicalQuery encodes query values the way SigV4 requires: sorted by key,
+// with every byte but the unreserved characters percent-encoded
+func canonicalQuery(q url.Values) string {
+	var keys []string
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var pairs []string
+	for _, k := range keys {
+		var vals = append([]string(nil), q[k]...)
+		sort.Strings(vals)
+		for _, v := range vals {
+			pairs = append(pairs, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// uriEncode percent-encodes everything but unreserved characters, and
+// slashes when encodeSlash is false
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		var c = s[i]
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
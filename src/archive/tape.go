@@ -0,0 +1,136 @@
+package archive
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/uoregon-libraries/gopkg/bagit"
+	"github.com/uoregon-libraries/gopkg/hasher"
+)
+
+// Tape archives batches by copying them into a staging directory for tape
+// software to pick up. Each batch gets a manifest listing every file's size
+// and SHA256 sum, and a "ready" marker is written last, so tape jobs can
+// wait for the marker and verify what they wrote against the manifest.
+//
+// The staged copy is verified against the bag's manifests before it's marked
+// ready. Once it is, tape software may move or remove it at any time, so Tape
+// deliberately isn't a LocalTarget: nothing may look at the copy afterward.
+//
+// For a batch named "foo", the staging directory ends up with:
+//
+//	foo/           the batch's files
+//	foo.manifest   tab-separated SHA256 sum, size, and path of each file
+//	foo.ready      empty marker file
+type Tape string
+
+// Location returns the batch's directory in the staging area
+func (t Tape) Location(name string) string {
+	return t.dir(name)
+}
+
+func (t Tape) dir(name string) string {
+	return filepath.Join(string(t), name)
+}
+
+// ManifestPath returns the path to the batch's tape manifest
+func (t Tape) ManifestPath(name string) string {
+	return filepath.Join(string(t), name+".manifest")
+}
+
+// ReadyPath returns the path to the batch's "ready" marker
+func (t Tape) ReadyPath(name string) string {
+	return filepath.Join(string(t), name+".ready")
+}
+
+// Archive copies the batch into the staging area, verifies the copy against
+// the bag's manifests, then writes the tape manifest and marks it ready. A
+// copy which doesn't match the bag is removed so a retry starts over.
+func (t Tape) Archive(dir, name string) error {
+	var err = syncDir(dir, string(t), name)
+	if err != nil {
+		return err
+	}
+
+	// The manifest is built from the copy, not the original, so it describes
+	// exactly what the tape software will read
+	var files []fileEntry
+	files, err = batchFiles(t.dir(name))
+	if err != nil {
+		return fmt.Errorf("reading staged batch %q: %w", t.dir(name), err)
+	}
+
+	var lines []string
+	var sums []*bagit.FileChecksum
+	for _, f := range files {
+		var sum string
+		sum, err = fileSHA256(f.path)
+		if err != nil {
+			return fmt.Errorf("hashing %q: %w", f.path, err)
+		}
+		lines = append(lines, fmt.Sprintf("%s\t%d\t%s/%s", sum, f.size, name, f.rel))
+		sums = append(sums, &bagit.FileChecksum{Path: f.rel, Checksum: sum})
+	}
+
+	err = verifyStagedBag(t.dir(name), sums)
+	if err != nil {
+		var rmErr = os.RemoveAll(t.dir(name))
+		if rmErr != nil {
+			return fmt.Errorf("verifying staged batch %q: %w (and removing it failed: %s)", t.dir(name), err, rmErr)
+		}
+		return fmt.Errorf("verifying staged batch %q: %w", t.dir(name), err)
+	}
+
+	var manifest = t.ManifestPath(name)
+	var tmp = manifest + ".tmp"
+	err = os.WriteFile(tmp, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+	if err == nil {
+		err = os.Rename(tmp, manifest)
+	}
+	if err != nil {
+		return fmt.Errorf("writing tape manifest %q: %w", manifest, err)
+	}
+
+	err = os.WriteFile(t.ReadyPath(name), nil, 0644)
+	if err != nil {
+		return fmt.Errorf("writing ready marker %q: %w", t.ReadyPath(name), err)
+	}
+	return nil
+}
+
+// verifyStagedBag compares the staged files' sums against the bag's manifest
+// and, if it has one, its tag manifest. The sums were already computed for
+// the tape manifest, so nothing is hashed a second time.
+func verifyStagedBag(root string, sums []*bagit.FileChecksum) error {
+	var b = bagit.New(root, hasher.NewSHA256())
+	var err = b.ReadManifests()
+	if err != nil {
+		return fmt.Errorf("reading bag manifests: %w", err)
+	}
+	if len(b.ManifestChecksums) == 0 {
+		return fmt.Errorf("bag manifest lists no files")
+	}
+
+	var payload, tags []*bagit.FileChecksum
+	for _, sum := range sums {
+		switch {
+		case strings.HasPrefix(sum.Path, "data/"):
+			payload = append(payload, sum)
+		case !strings.Contains(sum.Path, "/") && !strings.HasPrefix(sum.Path, "tagmanifest-"):
+			tags = append(tags, sum)
+		}
+	}
+
+	var discrepancies = bagit.Compare("manifest", b.ManifestChecksums, payload)
+	if len(b.ManifestTagSums) > 0 {
+		discrepancies = append(discrepancies, bagit.Compare("tag manifest", b.ManifestTagSums, tags)...)
+	}
+	if len(discrepancies) > 0 {
+		sort.Strings(discrepancies)
+		return fmt.Errorf("%s", strings.Join(discrepancies, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package batchvalidator
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// coordTolerance allows for rounding when ALTO coordinates are compared to
+// the page's size
+const coordTolerance = 1.0
+
+// altoPage holds the page size found in an ALTO file
+type altoPage struct {
+	unit   string
+	width  float64
+	height float64
+}
+
+// inches returns the page's width and height in inches, or zeroes if the
+// ALTO measurement unit doesn't allow the conversion
+func (p altoPage) inches() (float64, float64) {
+	switch p.unit {
+	case "inch1200":
+		return p.width / 1200, p.height / 1200
+	case "mm10":
+		return p.width / 254, p.height / 254
+	}
+	return 0, 0
+}
+
+// validateALTO checks that an ALTO file is well-formed, has a page size, and
+// has no text outside the page
+func (v *Validator) validateALTO(rel string) (altoPage, bool) {
+	var f, err = os.Open(v.fullPath(rel))
+	if err != nil {
+		v.report.add("alto", rel, "unable to read: %s", err)
+		return altoPage{}, false
+	}
+	defer f.Close()
+
+	var pg altoPage
+	var problems []string
+	pg, problems, err = scanALTO(f)
+	if err != nil {
+		v.report.add("alto", rel, "not well-formed XML: %s", err)
+		return pg, false
+	}
+	for _, p := range problems {
+		v.report.add("alto", rel, "%s", p)
+	}
+	return pg, len(problems) == 0
+}
+
+// scanALTO reads an ALTO document, returning its page size and a list of
+// problems with the page and its elements' coordinates. An error is returned
+// only if the XML can't be parsed.
+func scanALTO(r io.Reader) (altoPage, []string, error) {
+	var pg altoPage
+	var problems []string
+	var sawRoot, sawPage, inUnit bool
+
+	var d = xml.NewDecoder(r)
+	for {
+		var tok, err = d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return pg, problems, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if !sawRoot {
+				sawRoot = true
+				if t.Name.Local != "alto" {
+					problems = append(problems, fmt.Sprintf("root element must be <alto>, not <%s>", t.Name.Local))
+				}
+			}
+			var attrs = altoAttrs(t)
+			switch t.Name.Local {
+			case "MeasurementUnit":
+				inUnit = true
+			case "Page":
+				sawPage = true
+				pg.width, pg.height = attrs["WIDTH"], attrs["HEIGHT"]
+				if pg.width <= 0 || pg.height <= 0 {
+					problems = append(problems, "page has no valid WIDTH and HEIGHT")
+				}
+			default:
+				if sawPage && pg.width > 0 && pg.height > 0 {
+					var p = checkCoords(t.Name.Local, attrs, pg)
+					if p != "" {
+						problems = append(problems, p)
+					}
+				}
+			}
+		case xml.CharData:
+			if inUnit {
+				pg.unit += strings.TrimSpace(string(t))
+			}
+		case xml.EndElement:
+			if t.Name.Local == "MeasurementUnit" {
+				inUnit = false
+			}
+		}
+	}
+
+	if !sawRoot {
+		return pg, problems, fmt.Errorf("document is empty")
+	}
+	if !sawPage {
+		problems = append(problems, "no <Page> element")
+	}
+	return pg, problems, nil
+}
+
+// altoAttrs returns an element's numeric attributes. Attributes which aren't
+// numbers are ignored, since only coordinates are checked.
+func altoAttrs(t xml.StartElement) map[string]float64 {
+	var m = make(map[string]float64)
+	for _, a := range t.Attr {
+		switch a.Name.Local {
+		case "HPOS", "VPOS", "WIDTH", "HEIGHT":
+			var f, err = strconv.ParseFloat(a.Value, 64)
+			if err == nil {
+				m[a.Name.Local] = f
+			} else {
+				m[a.Name.Local] = -1
+			}
+		}
+	}
+	return m
+}
+
+// checkCoords returns a description of the problem if an element's box
+// isn't inside the page
+func checkCoords(name string, attrs map[string]float64, pg altoPage) string {
+	var h, hasH = attrs["HPOS"]
+	var vp, hasV = attrs["VPOS"]
+	if !hasH && !hasV {
+		return ""
+	}
+	var w, ht = attrs["WIDTH"], attrs["HEIGHT"]
+	if h < 0 || vp < 0 || w < 0 || ht < 0 {
+		return fmt.Sprintf("<%s> at (%g, %g) has negative or invalid coordinates", name, h, vp)
+	}
+	if h+w > pg.width+coordTolerance || vp+ht > pg.height+coordTolerance {
+		return fmt.Sprintf("<%s> at (%g, %g) size %gx%g extends past the %gx%g page", name, h, vp, w, ht, pg.width, pg.height)
+	}
+	return ""
+}
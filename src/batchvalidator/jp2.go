@@ -0,0 +1,319 @@
+package batchvalidator
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// signatureBox is the JPEG 2000 signature box every JP2 starts with
+var signatureBox = []byte{0, 0, 0, 0x0C, 'j', 'P', ' ', ' ', 0x0D, 0x0A, 0x87, 0x0A}
+
+// rsizPart2 is the SIZ capabilities bit set when a codestream uses JPEG 2000
+// Part 2 extensions, which NDNP doesn't allow
+const rsizPart2 = 0x8000
+
+// jp2Info holds what we read from a JP2's boxes and codestream header
+type jp2Info struct {
+	width      uint32
+	height     uint32
+	components uint16
+	bitDepth   int
+	signed     bool
+	dpi        float64 // From the capture or display resolution box, if present
+	rsiz       uint16
+	sizWidth   uint32
+	sizHeight  uint32
+	sizComps   uint16
+}
+
+// box is a single JP2 box's type and content
+type box struct {
+	kind string
+	data []byte
+}
+
+// maxHeaderBox limits how much of a non-codestream box we'll read; header
+// boxes are tiny, so anything bigger means the file is corrupt
+const maxHeaderBox = 1 << 20
+
+// readBoxes reads the top-level boxes of a JP2. Only the first bytes of the
+// codestream box are read, since we only need its main header.
+func readBoxes(r io.Reader) ([]box, error) {
+	var boxes []box
+	for {
+		var hdr [8]byte
+		var _, err = io.ReadFull(r, hdr[:])
+		if err == io.EOF {
+			return boxes, nil
+		}
+		if err != nil {
+			return boxes, fmt.Errorf("reading box header: %w", err)
+		}
+
+		var size = uint64(binary.BigEndian.Uint32(hdr[:4]))
+		var kind = string(hdr[4:])
+		var hdrLen uint64 = 8
+		if size == 1 {
+			var ext [8]byte
+			_, err = io.ReadFull(r, ext[:])
+			if err != nil {
+				return boxes, fmt.Errorf("reading %q box size: %w", kind, err)
+			}
+			size = binary.BigEndian.Uint64(ext[:])
+			hdrLen = 16
+		}
+
+		if kind == "jp2c" {
+			var buf = make([]byte, 64)
+			var n, _ = io.ReadFull(r, buf)
+			return append(boxes, box{kind: kind, data: buf[:n]}), nil
+		}
+		if size == 0 || size < hdrLen || size-hdrLen > maxHeaderBox {
+			return boxes, fmt.Errorf("%q box has an invalid length", kind)
+		}
+
+		var data = make([]byte, size-hdrLen)
+		_, err = io.ReadFull(r, data)
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return boxes, fmt.Errorf("reading %q box: %w", kind, err)
+		}
+		boxes = append(boxes, box{kind: kind, data: data})
+	}
+}
+
+// parseJP2 reads the header information from a JP2, returning an error if
+// the file's structure is invalid
+func parseJP2(r io.Reader) (*jp2Info, error) {
+	// The signature box is checked on its own so files which aren't JP2s at
+	// all get a clear error rather than a complaint about their "boxes"
+	var sig [12]byte
+	var _, err = io.ReadFull(r, sig[:])
+	if err != nil || !bytes.Equal(sig[:], signatureBox) {
+		return nil, errors.New("missing JPEG 2000 signature")
+	}
+
+	var boxes []box
+	boxes, err = readBoxes(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(boxes) < 1 || boxes[0].kind != "ftyp" || !isJP2Brand(boxes[0].data) {
+		return nil, errors.New(`file type box doesn't declare "jp2 " compatibility`)
+	}
+
+	var info = &jp2Info{}
+	var sawHeader, sawCodestream bool
+	for _, b := range boxes[1:] {
+		switch b.kind {
+		case "jp2h":
+			sawHeader = true
+			err = info.readHeader(b.data)
+		case "jp2c":
+			sawCodestream = true
+			err = info.readSIZ(b.data)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !sawHeader {
+		return nil, errors.New("missing JP2 header box")
+	}
+	if !sawCodestream {
+		return nil, errors.New("missing codestream")
+	}
+	return info, nil
+}
+
+func isJP2Brand(ftyp []byte) bool {
+	if len(ftyp) < 8 {
+		return false
+	}
+	if string(ftyp[:4]) == "jp2 " {
+		return true
+	}
+	for i := 8; i+4 <= len(ftyp); i += 4 {
+		if string(ftyp[i:i+4]) == "jp2 " {
+			return true
+		}
+	}
+	return false
+}
+
+// readHeader reads the image header and resolution boxes from the JP2
+// header superbox
+func (info *jp2Info) readHeader(data []byte) error {
+	var children, err = readBoxes(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("JP2 header: %w", err)
+	}
+	if len(children) == 0 || children[0].kind != "ihdr" || len(children[0].data) < 14 {
+		return errors.New("JP2 header doesn't start with a valid image header")
+	}
+
+	var ihdr = children[0].data
+	info.height = binary.BigEndian.Uint32(ihdr[0:4])
+	info.width = binary.BigEndian.Uint32(ihdr[4:8])
+	info.components = binary.BigEndian.Uint16(ihdr[8:10])
+	info.bitDepth = int(ihdr[10]&0x7F) + 1
+	info.signed = ihdr[10]&0x80 != 0
+
+	for _, c := range children[1:] {
+		if c.kind != "res " {
+			continue
+		}
+		var resBoxes, _ = readBoxes(bytes.NewReader(c.data))
+		for _, rb := range resBoxes {
+			if (rb.kind == "resc" || rb.kind == "resd") && len(rb.data) >= 10 {
+				info.dpi = resolutionDPI(rb.data)
+			}
+		}
+	}
+	return nil
+}
+
+// resolutionDPI converts a capture or display resolution box, which stores
+// pixels per meter as a fraction and exponent, to horizontal dots per inch
+func resolutionDPI(data []byte) float64 {
+	var num = float64(binary.BigEndian.Uint16(data[4:6]))
+	var den = float64(binary.BigEndian.Uint16(data[6:8]))
+	var exp = float64(int8(data[9]))
+	if den == 0 {
+		return 0
+	}
+	return num / den * math.Pow(10, exp) * 0.0254
+}
+
+// readSIZ reads the image size marker from the start of the codestream
+func (info *jp2Info) readSIZ(cs []byte) error {
+	if len(cs) < 42 || cs[0] != 0xFF || cs[1] != 0x4F || cs[2] != 0xFF || cs[3] != 0x51 {
+		return errors.New("codestream doesn't start with a valid SIZ marker")
+	}
+	var siz = cs[6:]
+	info.rsiz = binary.BigEndian.Uint16(siz[0:2])
+	var x, y = binary.BigEndian.Uint32(siz[2:6]), binary.BigEndian.Uint32(siz[6:10])
+	var xo, yo = binary.BigEndian.Uint32(siz[10:14]), binary.BigEndian.Uint32(siz[14:18])
+	info.sizWidth, info.sizHeight = x-xo, y-yo
+	info.sizComps = binary.BigEndian.Uint16(siz[34:36])
+	return nil
+}
+
+// profileProblems returns the ways the JP2 doesn't meet the NDNP profile:
+// an 8-bit grayscale or color JPEG 2000 Part 1 image whose header matches
+// its codestream
+func (info *jp2Info) profileProblems() []string {
+	var list []string
+	if info.width == 0 || info.height == 0 {
+		list = append(list, "image has no width or height")
+	}
+	if info.components != 1 && info.components != 3 {
+		list = append(list, fmt.Sprintf("image has %d components; it must be grayscale or RGB", info.components))
+	}
+	if info.bitDepth != 8 || info.signed {
+		list = append(list, fmt.Sprintf("image must use unsigned 8-bit samples, not %d-bit", info.bitDepth))
+	}
+	if info.rsiz&rsizPart2 != 0 {
+		list = append(list, "codestream uses JPEG 2000 Part 2 extensions")
+	}
+	if info.sizWidth != info.width || info.sizHeight != info.height || info.sizComps != info.components {
+		list = append(list, fmt.Sprintf("JP2 header (%dx%d, %d components) doesn't match codestream (%dx%d, %d components)",
+			info.width, info.height, info.components, info.sizWidth, info.sizHeight, info.sizComps))
+	}
+	return list
+}
+
+// validatePage checks a page's ALTO and JP2, including whether the image's
+// resolution is high enough for the page's physical size
+func (v *Validator) validatePage(p page) {
+	var pg altoPage
+	var altoOK bool
+	if p.alto != "" {
+		pg, altoOK = v.validateALTO(p.alto)
+	}
+	if p.jp2 == "" {
+		return
+	}
+
+	var info = v.validateJP2(p.jp2)
+	if info == nil || v.MinDPI == 0 {
+		return
+	}
+
+	// The ALTO page size is the most reliable measure of the physical page,
+	// since our JP2s rarely have a resolution box. If the ALTO is broken, the
+	// JP2's own resolution is the best we can do.
+	var dpi = info.dpi
+	var wIn, _ = pg.inches()
+	if altoOK && wIn > 0 {
+		dpi = float64(info.width) / wIn
+	}
+	if dpi > 0 && dpi < float64(v.MinDPI)*0.98 {
+		v.report.add("jp2", p.jp2, "resolution is about %.0f DPI; at least %d is required", dpi, v.MinDPI)
+	}
+}
+
+// validateJP2 checks a JP2's structure and profile, and that it can be
+// decoded, returning its header info if the structure was readable
+func (v *Validator) validateJP2(rel string) *jp2Info {
+	var f, err = os.Open(v.fullPath(rel))
+	if err != nil {
+		v.report.add("jp2", rel, "unable to read: %s", err)
+		return nil
+	}
+	var info *jp2Info
+	info, err = parseJP2(f)
+	f.Close()
+	if err != nil {
+		v.report.add("jp2", rel, "invalid JP2: %s", err)
+		return nil
+	}
+
+	for _, p := range info.profileProblems() {
+		v.report.add("jp2", rel, "%s", p)
+	}
+
+	if v.OPJDecompress != "" {
+		var msg = decodeJP2(v.OPJDecompress, v.fullPath(rel))
+		if msg != "" {
+			v.report.add("jp2", rel, "unable to decode: %s", msg)
+		}
+	}
+
+	return info
+}
+
+// decodeJP2 runs the JP2 through opj_decompress at a reduced resolution, as
+// the JP2 generator does when it verifies new files, returning the tool's
+// last line of output if it fails
+func decodeJP2(opj, fname string) string {
+	var dir, err = os.MkdirTemp("", "nca-validate-")
+	if err != nil {
+		return fmt.Sprintf("unable to create temporary directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	var cmd = exec.Command(opj, "-i", fname, "-r", "4", "-o", filepath.Join(dir, "test.png"))
+	var out []byte
+	out, err = cmd.CombinedOutput()
+	if err == nil {
+		return ""
+	}
+
+	var lines = strings.Split(strings.TrimSpace(string(out)), "\n")
+	var last = strings.TrimSpace(lines[len(lines)-1])
+	if last == "" {
+		return err.Error()
+	}
+	return fmt.Sprintf("%s (%s)", last, err)
+}
@@ -0,0 +1,146 @@
+package batchvalidator
+
+import (
+	"encoding/xml"
+	"os"
+	"path"
+	"strings"
+)
+
+// metsXML is the subset of an issue's METS file we validate
+type metsXML struct {
+	XMLName xml.Name
+	Profile string `xml:"PROFILE,attr"`
+	Files   []struct {
+		ID     string `xml:"ID,attr"`
+		Use    string `xml:"USE,attr"`
+		FLocat struct {
+			Href string `xml:"http://www.w3.org/1999/xlink href,attr"`
+		} `xml:"FLocat"`
+	} `xml:"fileSec>fileGrp>file"`
+	Divs []metsDiv `xml:"structMap>div"`
+}
+
+type metsDiv struct {
+	Type  string `xml:"TYPE,attr"`
+	Fptrs []struct {
+		FileID string `xml:"FILEID,attr"`
+	} `xml:"fptr"`
+	Divs []metsDiv `xml:"div"`
+}
+
+// pageDivs returns all divs of type "np:page" at or below d
+func (d metsDiv) pageDivs() []metsDiv {
+	var list []metsDiv
+	if d.Type == "np:page" {
+		list = append(list, d)
+	}
+	for _, child := range d.Divs {
+		list = append(list, child.pageDivs()...)
+	}
+	return list
+}
+
+// validateMETS checks an issue's METS file and returns the files of each
+// page it describes which exist
+func (v *Validator) validateMETS(rel string) []page {
+	var m metsXML
+	var err = decodeFile(v.fullPath(rel), &m)
+	if err != nil {
+		v.report.add("mets", rel, "not well-formed XML: %s", err)
+		return nil
+	}
+	if m.XMLName.Space != NSMETS || m.XMLName.Local != "mets" {
+		v.report.add("mets", rel, "root element must be <mets> in the %q namespace", NSMETS)
+	}
+	if m.Profile != METSProfile {
+		v.report.add("mets", rel, "PROFILE is %q, expected %q", m.Profile, METSProfile)
+	}
+
+	// Map each file to its path relative to the batch root
+	var dir = path.Dir(rel)
+	type metsFile struct {
+		use  string
+		path string
+	}
+	var files = make(map[string]metsFile)
+	for _, f := range m.Files {
+		var href = f.FLocat.Href
+		if href == "" || path.IsAbs(href) || strings.HasPrefix(path.Clean(href), "..") {
+			v.report.add("mets", rel, "file %q has an invalid location %q", f.ID, href)
+			continue
+		}
+		files[f.ID] = metsFile{use: f.Use, path: path.Join(dir, href)}
+	}
+
+	var divs []metsDiv
+	for _, d := range m.Divs {
+		divs = append(divs, d.pageDivs()...)
+	}
+	v.report.Pages += len(divs)
+	v.checkPageCounts(rel, len(divs))
+
+	var pages []page
+	for n, d := range divs {
+		var p page
+		var hasPDF bool
+		for _, fptr := range d.Fptrs {
+			var f, ok = files[fptr.FileID]
+			if !ok {
+				v.report.add("mets", rel, "page %d refers to undefined file %q", n+1, fptr.FileID)
+				continue
+			}
+			if !v.exists(rel, f.path) {
+				continue
+			}
+			switch f.use {
+			case "service":
+				p.jp2 = f.path
+			case "ocr":
+				p.alto = f.path
+			case "derivative":
+				hasPDF = true
+			}
+		}
+		if p.jp2 == "" {
+			v.report.add("mets", rel, "page %d has no page image", n+1)
+		}
+		if p.alto == "" {
+			v.report.add("mets", rel, "page %d has no OCR file", n+1)
+		}
+		if !hasPDF {
+			v.report.add("mets", rel, "page %d has no PDF", n+1)
+		}
+		pages = append(pages, p)
+	}
+
+	return pages
+}
+
+// checkPageCounts compares the number of pages in a METS file with what NCA
+// expected and with the page images on disk
+func (v *Validator) checkPageCounts(rel string, count int) {
+	if count == 0 {
+		v.report.add("pages", rel, "describes no pages")
+	}
+	for _, i := range v.Issues {
+		if i.METSPath == rel && i.Pages != count {
+			v.report.add("pages", rel, "describes %d page(s), but the issue has %d", count, i.Pages)
+		}
+	}
+
+	var entries, err = os.ReadDir(v.fullPath(path.Dir(rel)))
+	if err != nil {
+		v.report.add("pages", rel, "unable to read issue directory: %s", err)
+		return
+	}
+	var jp2s int
+	for _, e := range entries {
+		if strings.EqualFold(path.Ext(e.Name()), ".jp2") {
+			jp2s++
+		}
+	}
+	if jp2s != count {
+		v.report.add("pages", rel, "describes %d page(s), but the issue directory has %d JP2 file(s)", count, jp2s)
+	}
+}
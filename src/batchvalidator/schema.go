@@ -0,0 +1,82 @@
+package batchvalidator
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Schema files which must be in the validator's SchemaPath
+const (
+	BatchSchema = "batch.xsd"
+	METSSchema  = "mets.xsd"
+	ALTOSchema  = "alto.xsd"
+)
+
+// schemaChunk limits how many files we hand xmllint at once so huge batches
+// don't exceed the system's argument length limit
+const schemaChunk = 200
+
+// validateSchemas runs xmllint against the batch XML, METS, and ALTO files
+func (v *Validator) validateSchemas(mets, alto []string) {
+	if v.XMLLint == "" || v.SchemaPath == "" {
+		return
+	}
+	v.report.SchemaChecked = true
+
+	v.validateSchema(BatchSchema, []string{"data/batch.xml"})
+	v.validateSchema(METSSchema, mets)
+	v.validateSchema(ALTOSchema, alto)
+}
+
+// validateSchema validates the given files against one schema
+func (v *Validator) validateSchema(schema string, files []string) {
+	for len(files) > 0 {
+		var n = min(schemaChunk, len(files))
+		v.runXMLLint(schema, files[:n])
+		files = files[n:]
+	}
+}
+
+// runXMLLint validates a set of files, adding a problem for each error
+// xmllint reports. xmllint prefixes errors with the file's path, which we
+// convert back to the batch-relative path.
+func (v *Validator) runXMLLint(schema string, files []string) {
+	var args = []string{"--noout", "--nonet", "--schema", filepath.Join(v.SchemaPath, schema)}
+	var byPath = make(map[string]string)
+	for _, f := range files {
+		var full = v.fullPath(f)
+		byPath[full] = f
+		args = append(args, full)
+	}
+
+	var out, err = exec.Command(v.XMLLint, args...).CombinedOutput()
+	if err == nil {
+		return
+	}
+
+	var found bool
+	for _, line := range strings.Split(string(out), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasSuffix(line, " validates") || strings.HasSuffix(line, " fails to validate") {
+			continue
+		}
+		for full, rel := range byPath {
+			if strings.HasPrefix(line, full+":") {
+				found = true
+				v.report.add("schema", rel, "%s", strings.TrimPrefix(line, full+":"))
+				break
+			}
+		}
+	}
+
+	// If xmllint failed without any file-specific errors, the schema itself
+	// is probably the problem, and every file in this run is unvalidated
+	if !found {
+		var msg = strings.TrimSpace(string(out))
+		if msg == "" {
+			msg = err.Error()
+		}
+		v.report.add("schema", schema, "xmllint failed: %s", msg)
+	}
+}
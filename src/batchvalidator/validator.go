@@ -0,0 +1,243 @@
+// Package batchvalidator checks a built batch against the NDNP rules ONI
+// relies on, so problems are found before a batch reaches staging rather than
+// by a reviewer during QC or, worse, by ONI halfway through an ingest.
+package batchvalidator
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Namespaces and profiles the batch files must declare
+const (
+	NSNDNP      = "http://www.loc.gov/ndnp"
+	NSMETS      = "http://www.loc.gov/METS/"
+	NSXLink     = "http://www.w3.org/1999/xlink"
+	METSProfile = "urn:library-of-congress:mets:profiles:ndnp:issue:v1.5"
+)
+
+// maxProblemsPerFile keeps a single badly broken file, such as an ALTO file
+// with thousands of out-of-bounds words, from burying every other problem
+const maxProblemsPerFile = 10
+
+// Problem is a single rule a batch failed
+type Problem struct {
+	Check   string // Which kind of check failed: "batch", "mets", "alto", "jp2", "files", "pages", or "schema"
+	Path    string // File the problem was found in, relative to the batch root
+	Message string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("[%s] %s: %s", p.Check, p.Path, p.Message)
+}
+
+// Report summarizes a batch validation
+type Report struct {
+	Issues        int
+	Pages         int
+	Files         int
+	SchemaChecked bool
+	Problems      []Problem
+
+	perFile map[string]int
+}
+
+// OK returns true if no problems were found
+func (r *Report) OK() bool {
+	return len(r.Problems) == 0
+}
+
+// Summary describes the report's results in a single line
+func (r *Report) Summary() string {
+	var s = fmt.Sprintf("%d issue(s), %d page(s), %d file(s) checked", r.Issues, r.Pages, r.Files)
+	if !r.SchemaChecked {
+		s += ", schema validation skipped"
+	}
+	if r.OK() {
+		return s + ": no problems found"
+	}
+	return fmt.Sprintf("%s: %d problem(s) found", s, len(r.Problems))
+}
+
+func (r *Report) add(check, fpath, format string, args ...any) {
+	if r.perFile == nil {
+		r.perFile = make(map[string]int)
+	}
+	r.perFile[fpath]++
+	var n = r.perFile[fpath]
+	if n > maxProblemsPerFile {
+		return
+	}
+	var msg = fmt.Sprintf(format, args...)
+	if n == maxProblemsPerFile {
+		msg += " (further problems in this file are not listed)"
+	}
+	r.Problems = append(r.Problems, Problem{Check: check, Path: fpath, Message: msg})
+}
+
+// Issue describes what the batch builder expected to put into the batch for
+// a single issue
+type Issue struct {
+	METSPath string // Path to the issue's METS file, relative to the batch root
+	Pages    int
+}
+
+// Validator holds the configuration for validating a single batch
+type Validator struct {
+	// Root is the batch's directory, which holds its bag files and "data"
+	Root string
+
+	// Name is the batch's full name, which batch.xml must declare
+	Name string
+
+	// Issues lists the issues NCA put into the batch
+	Issues []Issue
+
+	// MinDPI is the lowest resolution a page image may have, computed from
+	// its pixel size and the page dimensions in its ALTO file. Zero skips the
+	// resolution check.
+	MinDPI int
+
+	// OPJDecompress is used to verify each JP2 decodes. If it's empty, JP2s
+	// are only checked structurally.
+	OPJDecompress string
+
+	// XMLLint and SchemaPath enable XSD validation: SchemaPath must hold
+	// batch.xsd, mets.xsd, and alto.xsd. Schema validation is skipped if
+	// either is empty.
+	XMLLint    string
+	SchemaPath string
+
+	report *Report
+}
+
+// page holds the files a METS structMap lists for a single page
+type page struct {
+	jp2  string
+	alto string
+}
+
+// Validate runs every check against the batch and returns the results
+func (v *Validator) Validate() *Report {
+	v.report = &Report{}
+
+	var metsFiles = v.validateBatchXML()
+	var altoFiles []string
+	for _, mets := range metsFiles {
+		var pages = v.validateMETS(mets)
+		for _, p := range pages {
+			v.validatePage(p)
+			if p.alto != "" {
+				altoFiles = append(altoFiles, p.alto)
+			}
+		}
+	}
+
+	v.validateSchemas(metsFiles, altoFiles)
+	return v.report
+}
+
+// fullPath returns the absolute path to a file given its path relative to
+// the batch root
+func (v *Validator) fullPath(rel string) string {
+	return filepath.Join(v.Root, filepath.FromSlash(rel))
+}
+
+// exists checks that a file is present and counts it toward the report's
+// total, reporting it as missing if it isn't there
+func (v *Validator) exists(from, rel string) bool {
+	var info, err = os.Stat(v.fullPath(rel))
+	if err != nil || info.IsDir() {
+		v.report.add("files", from, "references %q, which doesn't exist", rel)
+		return false
+	}
+	v.report.Files++
+	return true
+}
+
+// batchXML is the subset of batch.xml we validate
+type batchXML struct {
+	XMLName xml.Name
+	Name    string `xml:"name,attr"`
+	Issues  []struct {
+		LCCN string `xml:"lccn,attr"`
+		Date string `xml:"issueDate,attr"`
+		Path string `xml:",chardata"`
+	} `xml:"issue"`
+}
+
+// validateBatchXML checks data/batch.xml, returning the paths of the METS
+// files it lists which exist
+func (v *Validator) validateBatchXML() []string {
+	const rel = "data/batch.xml"
+	if !v.exists("batch", rel) {
+		return nil
+	}
+
+	var b batchXML
+	var err = decodeFile(v.fullPath(rel), &b)
+	if err != nil {
+		v.report.add("batch", rel, "not well-formed XML: %s", err)
+		return nil
+	}
+	if b.XMLName.Space != NSNDNP || b.XMLName.Local != "batch" {
+		v.report.add("batch", rel, "root element must be <batch> in the %q namespace", NSNDNP)
+	}
+	if v.Name != "" && b.Name != v.Name {
+		v.report.add("batch", rel, "batch name is %q, expected %q", b.Name, v.Name)
+	}
+
+	var expected = make(map[string]Issue)
+	for _, i := range v.Issues {
+		expected[i.METSPath] = i
+	}
+
+	var seen = make(map[string]bool)
+	var list []string
+	for _, i := range b.Issues {
+		var p = strings.TrimSpace(i.Path)
+		var mets = path.Join("data", p)
+		if p == "" || path.IsAbs(p) || strings.HasPrefix(path.Clean(p), "..") {
+			v.report.add("batch", rel, "issue %s %s has an invalid METS path %q", i.LCCN, i.Date, p)
+			continue
+		}
+		if seen[mets] {
+			v.report.add("batch", rel, "%q is listed more than once", p)
+			continue
+		}
+		seen[mets] = true
+		if len(expected) > 0 {
+			if _, ok := expected[mets]; !ok {
+				v.report.add("batch", rel, "lists %q, which isn't one of the batch's issues", p)
+			}
+		}
+		if v.exists(rel, mets) {
+			list = append(list, mets)
+		}
+	}
+
+	for _, i := range v.Issues {
+		if !seen[i.METSPath] {
+			v.report.add("batch", rel, "doesn't list the issue at %q", strings.TrimPrefix(i.METSPath, "data/"))
+		}
+	}
+
+	v.report.Issues = len(list)
+	sort.Strings(list)
+	return list
+}
+
+// decodeFile parses an XML file into dest
+func decodeFile(fname string, dest any) error {
+	var f, err = os.Open(fname)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return xml.NewDecoder(f).Decode(dest)
+}
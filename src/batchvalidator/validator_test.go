@@ -0,0 +1,315 @@
+package batchvalidator
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// makeBox returns a JP2 box with the given type and content
+func makeBox(kind string, data []byte) []byte {
+	var b = make([]byte, 8, 8+len(data))
+	binary.BigEndian.PutUint32(b, uint32(8+len(data)))
+	copy(b[4:], kind)
+	return append(b, data...)
+}
+
+// makeJP2 builds the header boxes and codestream main header of a JP2, which
+// is all the structural checks read
+func makeJP2(w, h uint32, comps uint16, rsiz uint16) []byte {
+	var ihdr = make([]byte, 14)
+	binary.BigEndian.PutUint32(ihdr[0:], h)
+	binary.BigEndian.PutUint32(ihdr[4:], w)
+	binary.BigEndian.PutUint16(ihdr[8:], comps)
+	ihdr[10] = 7
+	ihdr[11] = 7
+
+	var cs = []byte{0xFF, 0x4F, 0xFF, 0x51, 0, 41}
+	var siz = make([]byte, 36)
+	binary.BigEndian.PutUint16(siz[0:], rsiz)
+	binary.BigEndian.PutUint32(siz[2:], w)
+	binary.BigEndian.PutUint32(siz[6:], h)
+	binary.BigEndian.PutUint16(siz[34:], comps)
+	cs = append(cs, siz...)
+	cs = append(cs, make([]byte, 16)...)
+
+	var buf bytes.Buffer
+	buf.Write(signatureBox)
+	buf.Write(makeBox("ftyp", []byte("jp2 \x00\x00\x00\x00jp2 ")))
+	buf.Write(makeBox("jp2h", makeBox("ihdr", ihdr)))
+	buf.Write(makeBox("jp2c", cs))
+	return buf.Bytes()
+}
+
+func makeALTO(w, h int, words ...string) string {
+	return fmt.Sprintf(`<alto xmlns="http://schema.ccs-gmbh.com/ALTO">
+  <Description><MeasurementUnit>inch1200</MeasurementUnit></Description>
+  <Layout>
+    <Page ID="PAGE.0" WIDTH="%d" HEIGHT="%d">
+      <PrintSpace HPOS="0.0" VPOS="0.0" WIDTH="%d.0" HEIGHT="%d.0">
+        %s
+      </PrintSpace>
+    </Page>
+  </Layout>
+</alto>`, w, h, w, h, strings.Join(words, "\n"))
+}
+
+func makeMETS(pages int) string {
+	var files, divs string
+	for n := 1; n <= pages; n++ {
+		files += fmt.Sprintf(`<fileGrp>
+      <file ID="service%[1]d" USE="service"><FLocat xlink:href="%04[1]d.jp2" /></file>
+      <file ID="pdf%[1]d" USE="derivative"><FLocat xlink:href="%04[1]d.pdf" /></file>
+      <file ID="ocr%[1]d" USE="ocr"><FLocat xlink:href="%04[1]d.xml" /></file>
+    </fileGrp>`, n)
+		divs += fmt.Sprintf(`<div TYPE="np:page"><fptr FILEID="service%[1]d" /><fptr FILEID="pdf%[1]d" /><fptr FILEID="ocr%[1]d" /></div>`, n)
+	}
+	return fmt.Sprintf(`<mets xmlns="http://www.loc.gov/METS/" xmlns:xlink="http://www.w3.org/1999/xlink" PROFILE="%s">
+  <fileSec>%s</fileSec>
+  <structMap><div TYPE="np:issue">%s</div></structMap>
+</mets>`, METSProfile, files, divs)
+}
+
+const issueDir = "data/sn12345678/print/2020010101"
+
+// makeBatch writes a valid two-page batch and returns its root
+func makeBatch(t *testing.T) string {
+	var root = t.TempDir()
+	var write = func(rel string, data []byte) {
+		var full = filepath.Join(root, rel)
+		var err = os.MkdirAll(filepath.Dir(full), 0755)
+		if err == nil {
+			err = os.WriteFile(full, data, 0644)
+		}
+		if err != nil {
+			t.Fatalf("Unable to write %s: %s", rel, err)
+		}
+	}
+
+	write("data/batch.xml", []byte(`<ndnp:batch xmlns:ndnp="http://www.loc.gov/ndnp" xmlns="http://www.loc.gov/ndnp" name="batch_oru_test_ver01">
+  <issue lccn="sn12345678" issueDate="2020-01-01" editionOrder="01">sn12345678/print/2020010101/2020010101.xml</issue>
+</ndnp:batch>`))
+	write(issueDir+"/2020010101.xml", []byte(makeMETS(2)))
+	for n := 1; n <= 2; n++ {
+		var prefix = fmt.Sprintf("%s/%04d", issueDir, n)
+		write(prefix+".pdf", []byte("%PDF"))
+
+		// A 10x15 inch page at 150 DPI
+		write(prefix+".xml", []byte(makeALTO(12000, 18000, `<String HPOS="100" VPOS="100" WIDTH="500" HEIGHT="200" CONTENT="x" />`)))
+		write(prefix+".jp2", makeJP2(1500, 2250, 1, 0))
+	}
+	return root
+}
+
+func TestValidate(t *testing.T) {
+	var tests = map[string]struct {
+		modify   func(root string)
+		pages    int
+		problems []string
+	}{
+		"Valid batch": {
+			pages: 2,
+		},
+		"Page count doesn't match NCA": {
+			pages:    3,
+			problems: []string{"[pages] " + issueDir + "/2020010101.xml: describes 2 page(s), but the issue has 3"},
+		},
+		"Missing JP2": {
+			modify: func(root string) { os.Remove(filepath.Join(root, issueDir, "0002.jp2")) },
+			pages:  2,
+			problems: []string{
+				"[pages] " + issueDir + "/2020010101.xml: describes 2 page(s), but the issue directory has 1 JP2 file(s)",
+				`[files] ` + issueDir + `/2020010101.xml: references "` + issueDir + `/0002.jp2", which doesn't exist`,
+				"[mets] " + issueDir + "/2020010101.xml: page 2 has no page image",
+			},
+		},
+		"Word outside the page": {
+			modify: func(root string) {
+				var alto = makeALTO(12000, 18000, `<String HPOS="11900" VPOS="100" WIDTH="500" HEIGHT="200" CONTENT="x" />`)
+				os.WriteFile(filepath.Join(root, issueDir, "0001.xml"), []byte(alto), 0644)
+			},
+			pages:    2,
+			problems: []string{"[alto] " + issueDir + "/0001.xml: <String> at (11900, 100) size 500x200 extends past the 12000x18000 page"},
+		},
+		"Broken ALTO": {
+			modify: func(root string) {
+				os.WriteFile(filepath.Join(root, issueDir, "0001.xml"), []byte("<alto><Page></alto>"), 0644)
+			},
+			pages:    2,
+			problems: []string{"[alto] " + issueDir + "/0001.xml: not well-formed XML: XML syntax error on line 1: element <Page> closed by </alto>"},
+		},
+		"Low resolution JP2": {
+			modify: func(root string) {
+				os.WriteFile(filepath.Join(root, issueDir, "0002.jp2"), makeJP2(1000, 1500, 1, 0), 0644)
+			},
+			pages:    2,
+			problems: []string{"[jp2] " + issueDir + "/0002.jp2: resolution is about 100 DPI; at least 150 is required"},
+		},
+		"Wrong METS profile": {
+			modify: func(root string) {
+				var mets = strings.Replace(makeMETS(2), METSProfile, "bogus", 1)
+				os.WriteFile(filepath.Join(root, issueDir, "2020010101.xml"), []byte(mets), 0644)
+			},
+			pages:    2,
+			problems: []string{`[mets] ` + issueDir + `/2020010101.xml: PROFILE is "bogus", expected "` + METSProfile + `"`},
+		},
+		"Wrong batch name": {
+			modify: func(root string) {
+				var fname = filepath.Join(root, "data", "batch.xml")
+				var data, _ = os.ReadFile(fname)
+				os.WriteFile(fname, bytes.Replace(data, []byte("ver01"), []byte("ver02"), 1), 0644)
+			},
+			pages:    2,
+			problems: []string{`[batch] data/batch.xml: batch name is "batch_oru_test_ver02", expected "batch_oru_test_ver01"`},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var root = makeBatch(t)
+			if tc.modify != nil {
+				tc.modify(root)
+			}
+			var v = &Validator{
+				Root:   root,
+				Name:   "batch_oru_test_ver01",
+				Issues: []Issue{{METSPath: issueDir + "/2020010101.xml", Pages: tc.pages}},
+				MinDPI: 150,
+			}
+			var r = v.Validate()
+
+			var got []string
+			for _, p := range r.Problems {
+				got = append(got, p.String())
+			}
+			if strings.Join(got, "\n") != strings.Join(tc.problems, "\n") {
+				t.Errorf("Expected problems:\n%s\nGot:\n%s", strings.Join(tc.problems, "\n"), strings.Join(got, "\n"))
+			}
+			if r.OK() != (len(tc.problems) == 0) {
+				t.Errorf("OK() should be %v", len(tc.problems) == 0)
+			}
+		})
+	}
+}
+
+func TestValidateCounts(t *testing.T) {
+	var v = &Validator{Root: makeBatch(t)}
+	var r = v.Validate()
+	if r.Issues != 1 || r.Pages != 2 || r.Files != 8 {
+		t.Errorf("Expected 1 issue, 2 pages, 8 files; got %d, %d, %d", r.Issues, r.Pages, r.Files)
+	}
+	if r.SchemaChecked {
+		t.Errorf("Schema validation shouldn't run without xmllint")
+	}
+}
+
+func TestJP2Profile(t *testing.T) {
+	var tests = map[string]struct {
+		data     []byte
+		err      string
+		problems []string
+	}{
+		"Grayscale": {data: makeJP2(100, 200, 1, 0)},
+		"RGB":       {data: makeJP2(100, 200, 3, 0)},
+		"Two components": {
+			data:     makeJP2(100, 200, 2, 0),
+			problems: []string{"image has 2 components; it must be grayscale or RGB"},
+		},
+		"Part 2": {
+			data:     makeJP2(100, 200, 1, 0x8000),
+			problems: []string{"codestream uses JPEG 2000 Part 2 extensions"},
+		},
+		"Not a JP2": {
+			data: []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x00"),
+			err:  "missing JPEG 2000 signature",
+		},
+		"Truncated": {
+			data: makeJP2(100, 200, 1, 0)[:40],
+			err:  `reading "jp2h" box: unexpected EOF`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var info, err = parseJP2(bytes.NewReader(tc.data))
+			if tc.err != "" {
+				if err == nil || err.Error() != tc.err {
+					t.Fatalf("Expected error %q, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			var got = info.profileProblems()
+			if strings.Join(got, "\n") != strings.Join(tc.problems, "\n") {
+				t.Errorf("Expected problems %q, got %q", tc.problems, got)
+			}
+		})
+	}
+}
+
+func TestResolutionDPI(t *testing.T) {
+	// 11811 pixels per meter is 300 DPI
+	var data = make([]byte, 10)
+	binary.BigEndian.PutUint16(data[0:], 11811)
+	binary.BigEndian.PutUint16(data[2:], 1)
+	binary.BigEndian.PutUint16(data[4:], 11811)
+	binary.BigEndian.PutUint16(data[6:], 1)
+	var dpi = resolutionDPI(data)
+	if dpi < 299.9 || dpi > 300.1 {
+		t.Errorf("Expected 300 DPI, got %f", dpi)
+	}
+}
+
+func TestSchemaValidation(t *testing.T) {
+	var xmllint, err = exec.LookPath("xmllint")
+	if err != nil {
+		t.Skip("xmllint isn't installed")
+	}
+
+	// Our test schemas accept any METS and ALTO, but batch.xml must have an
+	// awardee, which the test batch lacks
+	var lax = func(ns, root string) string {
+		return `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" targetNamespace="` + ns + `" elementFormDefault="qualified">
+  <xs:element name="` + root + `">
+    <xs:complexType>
+      <xs:sequence><xs:any minOccurs="0" maxOccurs="unbounded" processContents="skip" namespace="##any" /></xs:sequence>
+      <xs:anyAttribute processContents="skip" />
+    </xs:complexType>
+  </xs:element>
+</xs:schema>`
+	}
+	var batchXSD = `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" targetNamespace="http://www.loc.gov/ndnp" elementFormDefault="qualified">
+  <xs:element name="batch">
+    <xs:complexType>
+      <xs:sequence><xs:any minOccurs="0" maxOccurs="unbounded" processContents="skip" namespace="##any" /></xs:sequence>
+      <xs:attribute name="name" type="xs:string" use="required" />
+      <xs:attribute name="awardee" type="xs:string" use="required" />
+    </xs:complexType>
+  </xs:element>
+</xs:schema>`
+
+	var schemaPath = t.TempDir()
+	os.WriteFile(filepath.Join(schemaPath, BatchSchema), []byte(batchXSD), 0644)
+	os.WriteFile(filepath.Join(schemaPath, METSSchema), []byte(lax(NSMETS, "mets")), 0644)
+	os.WriteFile(filepath.Join(schemaPath, ALTOSchema), []byte(lax("http://schema.ccs-gmbh.com/ALTO", "alto")), 0644)
+
+	var v = &Validator{Root: makeBatch(t), XMLLint: xmllint, SchemaPath: schemaPath}
+	var r = v.Validate()
+	if !r.SchemaChecked {
+		t.Errorf("Schema validation should have run")
+	}
+	if len(r.Problems) != 1 {
+		t.Fatalf("Expected exactly one problem, got %q", r.Problems)
+	}
+	var p = r.Problems[0]
+	if p.Check != "schema" || p.Path != "data/batch.xml" || !strings.Contains(p.Message, "awardee") {
+		t.Errorf("Expected a schema problem about batch.xml's awardee, got %s", p)
+	}
+}
@@ -5,10 +5,11 @@ import (
 )
 
 // BatchMetadata holds the high-level batch metadata: name and URL to query
-// detailed batch information
+// detailed batch information, and when the batch was ingested
 type BatchMetadata struct {
-	Name string
-	URL  string
+	Name     string
+	URL      string
+	Ingested string
 }
 
 // BatchesListJSON is what we get from a batches API request.  It stores the
@@ -16,7 +16,7 @@ import (
 
 type _opts struct {
 	cli.BaseOptions
-	Environment string `long:"environment" short:"e" description:"'staging' or 'production'" required:"true"`
+	Environment string `long:"environment" short:"e" description:"name of the ONI environment, e.g., 'staging' or 'production'" required:"true"`
 }
 
 var opts _opts
@@ -47,7 +47,7 @@ var aliases = map[string]string{
 var validCmds = []string{cmdLoadBatch, cmdPurgeBatch, cmdStatus, cmdLogs, cmdEnsureAwardee, cmdLoadTitle}
 
 func setUsage(c *cli.CLI) {
-	c.AppendUsage(`Allows testing ONI Agents as well as running common commands against staging and production`)
+	c.AppendUsage(`Allows testing ONI Agents as well as running common commands against any configured ONI environment`)
 	var aliasmap = make(map[string][]string)
 	for alias, cmd := range aliases {
 		aliasmap[cmd] = append(aliasmap[cmd], alias)
@@ -68,20 +68,28 @@ func getOpts() (rpc *openoni.RPC, command string, args []string) {
 	setUsage(c)
 	var conf = c.GetConf()
 
-	var connection string
-	switch opts.Environment {
-	case "staging", "stag", "s":
-		connection = conf.StagingAgentConnection
-	case "production", "prod", "p":
-		connection = conf.ProductionAgentConnection
-	default:
-		c.UsageFail("Invalid environment %q", opts.Environment)
+	// Allow the old shortcuts for the standard environment names
+	var name = opts.Environment
+	switch name {
+	case "stag", "s":
+		name = "staging"
+	case "prod", "p":
+		name = "production"
+	}
+
+	var env = conf.ONIEnvironment(name)
+	if env == nil {
+		var names []string
+		for _, e := range conf.ONIEnvironments {
+			names = append(names, e.Name)
+		}
+		c.UsageFail("Invalid environment %q: must be one of %s", opts.Environment, strings.Join(names, ", "))
 	}
 
 	var err error
-	rpc, err = openoni.New(connection)
+	rpc, err = openoni.ForEnvironment(env)
 	if err != nil {
-		log.Fatalf("Unable to initialize %s ONI Agent RPC (connection string %q)", opts.Environment, connection)
+		log.Fatalf("Unable to initialize %s ONI Agent RPC (connection string %q): %s", env.Name, env.AgentConnection, err)
 	}
 
 	if len(c.Args) == 0 {
@@ -106,7 +114,7 @@ func getOpts() (rpc *openoni.RPC, command string, args []string) {
 	if err != nil {
 		log.Fatalf("Error requesting agent version: %s", err)
 	}
-	log.Printf("Connected to ONI Agent on %s: version %q", opts.Environment, version)
+	log.Printf("Connected to ONI Agent on %s: version %q", env.Name, version)
 
 	return rpc, command, args
 }
@@ -0,0 +1,123 @@
+// backfill-title-marc pulls and stores MARC records for titles which were
+// validated before NCA kept each title's MARC XML
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/internal/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/internal/marc"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/cli"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/dbi"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/jobs"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/marcprovider"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+)
+
+// Command-line options
+type _opts struct {
+	cli.BaseOptions
+	NoSync bool `long:"no-sync" description:"Store records without queueing jobs to push titles to ONI"`
+}
+
+var opts _opts
+
+func getOpts() *config.Config {
+	var c = cli.New(&opts)
+	c.AppendUsage("Pulls MARC XML from the configured MARC providers for every " +
+		"title with a valid LCCN but no stored MARC record, stores it as the " +
+		"title's first MARC version, and queues jobs to push the title to ONI.")
+	c.AppendUsage("Titles are left alone if no provider has a usable record. " +
+		"Those must be fixed by hand, e.g., by uploading their MARC XML.")
+	var conf = c.GetConf()
+	var err = dbi.DBConnect(conf.DatabaseConnect)
+	if err != nil {
+		logger.Fatalf("Error trying to connect to database: %s", err)
+	}
+
+	return conf
+}
+
+func main() {
+	var conf = getOpts()
+	var titles, err = models.Titles()
+	if err != nil {
+		logger.Fatalf("Unable to read titles: %s", err)
+	}
+
+	var providers = marcprovider.FromConfig(conf)
+	if len(providers) == 0 {
+		logger.Fatalf("No MARC providers are configured")
+	}
+
+	var stored, failed int
+	for _, t := range titles {
+		if !t.ValidLCCN || t.MARCXML != "" {
+			continue
+		}
+
+		err = backfill(t, providers)
+		if err != nil {
+			logger.Errorf("Unable to backfill MARC for %q: %s", t.LCCN, err)
+			failed++
+			continue
+		}
+		stored++
+		logger.Infof("Stored MARC for %q", t.LCCN)
+
+		if opts.NoSync {
+			continue
+		}
+		err = jobs.QueueTitleSync(t, conf)
+		if err != nil {
+			logger.Errorf("Unable to queue ONI sync for %q: %s", t.LCCN, err)
+		}
+	}
+
+	fmt.Printf("Stored MARC records for %d title(s)\n", stored)
+	if failed > 0 {
+		logger.Warnf("%d title(s) had no usable MARC record and need to be fixed by hand", failed)
+	}
+}
+
+// backfill tries each provider in order, storing the first usable record on
+// the title. Only the MARC XML is stored: the title's other data was set when
+// it was validated, and a curator may have edited it since.
+func backfill(t *models.Title, providers []*marcprovider.Provider) error {
+	var errs []error
+	for _, p := range providers {
+		var rec, err = fetch(t.LCCN, p)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("provider %q: %w", p.Name, err))
+			continue
+		}
+
+		_, err = t.SaveMARC(string(rec.XML), rec.Provider, rec.Source, models.SystemUser.ID)
+		return err
+	}
+	return errors.Join(errs...)
+}
+
+// fetch returns the provider's record for lccn if NCA can use it
+func fetch(lccn string, p *marcprovider.Provider) (*marcprovider.Record, error) {
+	var rec, err = p.Fetch(lccn)
+	if err != nil {
+		return nil, err
+	}
+
+	var m *marc.MARC
+	m, err = marc.ParseXML(bytes.NewReader(rec.XML))
+	if err != nil {
+		return nil, fmt.Errorf("parsing MARC XML from %q: %w", rec.Source, err)
+	}
+	for _, prob := range m.Validate() {
+		if prob.Severity == marc.SeverityError {
+			return nil, fmt.Errorf("validating MARC XML from %q: %s", rec.Source, prob)
+		}
+	}
+
+	return rec, nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/internal/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/cli"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/dbi"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/retention"
+)
+
+// Command-line options
+type _opts struct {
+	cli.BaseOptions
+	Live bool `long:"live" description:"Delete what's past its retention period rather than just reporting what would be deleted"`
+}
+
+var opts _opts
+
+func getConfig() *config.Config {
+	var c = cli.New(&opts)
+	c.AppendUsage("Reports on (or, with --live, deletes) files past the retention " +
+		"periods set in RETENTION_RULES: errored issues, original upload backups, " +
+		"and the workflow files of issues in archived batches. Every directory " +
+		"must be tied to an issue in the database whose state shows the files " +
+		"are no longer needed; anything failing those checks is listed and kept.")
+
+	var conf = c.GetConf()
+	var err = dbi.DBConnect(conf.DatabaseConnect)
+	if err != nil {
+		logger.Fatalf("Error trying to connect to database: %s", err)
+	}
+	return conf
+}
+
+func main() {
+	var conf = getConfig()
+	if len(conf.RetentionRules) == 0 {
+		logger.Infof("No retention rules are configured (RETENTION_RULES is empty); nothing to do")
+		return
+	}
+
+	if opts.Live {
+		var _, err = retention.Enforce(conf)
+		if err != nil {
+			logger.Fatalf("Unable to complete retention enforcement: %s", err)
+		}
+		return
+	}
+
+	var r, err = retention.Find(conf, time.Now())
+	if err != nil {
+		logger.Fatalf("Unable to scan for files past their retention period: %s", err)
+	}
+	printReport(conf, r)
+}
+
+func printReport(conf *config.Config, r *retention.Report) {
+	fmt.Println("Rules:")
+	for _, rule := range conf.RetentionRules {
+		fmt.Printf("  - %s\n", rule)
+	}
+
+	fmt.Println()
+	fmt.Printf("(DRY RUN) Would delete %d directories, freeing %s:\n", len(r.Eligible), r.Size())
+	for _, c := range r.Eligible {
+		fmt.Printf("  - %s\n", c.Describe())
+	}
+
+	if len(r.Skipped) > 0 {
+		fmt.Println()
+		fmt.Printf("Keeping %d directories which are past their retention period but failed a safety check:\n", len(r.Skipped))
+		for _, c := range r.Skipped {
+			fmt.Printf("  - %s: %s\n", c.Describe(), c.Problem)
+		}
+	}
+
+	fmt.Println()
+	fmt.Println("Run again with --live to delete the eligible directories.")
+}
@@ -0,0 +1,165 @@
+// issue-cache reports on and prunes the cached live-site files NCA keeps in
+// ISSUE_CACHE_PATH
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/internal/datasize"
+	"github.com/uoregon-libraries/newspaper-curation-app/internal/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/cli"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/httpcache"
+)
+
+// Command-line options
+type _opts struct {
+	cli.BaseOptions
+	MaxSize   string `long:"max-size" description:"prune: remove least recently used files until the cache is this size (e.g., 500M); defaults to ISSUE_CACHE_MAX_SIZE"`
+	MaxUnused string `long:"max-unused" description:"prune: remove files which haven't been used in this long (e.g., 720h)"`
+	DryRun    bool   `long:"dry-run" description:"prune: show what would be removed without removing anything"`
+}
+
+var opts _opts
+
+const (
+	cmdStats = "stats"
+	cmdList  = "list"
+	cmdPrune = "prune"
+)
+
+func main() {
+	var c = cli.New(&opts)
+	c.AppendUsage("Inspects and prunes the cache of live-site JSON under ISSUE_CACHE_PATH.")
+	c.AppendUsage(fmt.Sprintf("Valid commands: %q summarizes the cache; %q shows "+
+		"every cached file, least recently used first; %q removes files based on "+
+		"--max-size and --max-unused.", cmdStats, cmdList, cmdPrune))
+	var conf = c.GetConf()
+
+	if len(c.Args) != 1 {
+		c.UsageFail("You must specify exactly one command")
+	}
+
+	var root = conf.IssueCachePath
+	switch c.Args[0] {
+	case cmdStats:
+		stats(root)
+	case cmdList:
+		list(root)
+	case cmdPrune:
+		var popts = httpcache.PruneOptions{MaxSize: int64(conf.IssueCacheMaxSize), DryRun: opts.DryRun}
+		if opts.MaxSize != "" {
+			var size, err = datasize.New(opts.MaxSize)
+			if err != nil {
+				c.UsageFail("Invalid --max-size %q: %s", opts.MaxSize, err)
+			}
+			popts.MaxSize = int64(size)
+		}
+		if opts.MaxUnused != "" {
+			var d, err = time.ParseDuration(opts.MaxUnused)
+			if err != nil {
+				c.UsageFail("Invalid --max-unused %q: %s", opts.MaxUnused, err)
+			}
+			popts.MaxUnused = d
+		}
+		if popts.MaxSize == 0 && popts.MaxUnused == 0 {
+			c.UsageFail("Nothing to prune: ISSUE_CACHE_MAX_SIZE isn't set, and neither --max-size nor --max-unused was given")
+		}
+		prune(root, popts)
+	default:
+		c.UsageFail("%q is not a valid command", c.Args[0])
+	}
+}
+
+func entries(root string) []*httpcache.Entry {
+	var list, err = httpcache.Entries(root)
+	if err != nil {
+		logger.Fatalf("Unable to read cache in %q: %s", root, err)
+	}
+	return list
+}
+
+func size(n int64) string {
+	var d = datasize.Datasize(n)
+	return d.String()
+}
+
+func stats(root string) {
+	type group struct {
+		count int
+		size  int64
+	}
+	var groups = make(map[string]*group)
+	var total group
+	var list = entries(root)
+	for _, e := range list {
+		var rel, _ = filepath.Rel(root, filepath.Dir(e.Path))
+		if groups[rel] == nil {
+			groups[rel] = &group{}
+		}
+		groups[rel].count++
+		groups[rel].size += e.Size
+		total.count++
+		total.size += e.Size
+	}
+
+	var names []string
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var w = tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "Directory\tFiles\tSize")
+	for _, name := range names {
+		fmt.Fprintf(w, "%s\t%d\t%s\n", name, groups[name].count, size(groups[name].size))
+	}
+	fmt.Fprintf(w, "Total\t%d\t%s\n", total.count, size(total.size))
+	w.Flush()
+
+	if len(list) > 0 {
+		fmt.Printf("\nLeast recently used: %s (%s)\n", list[0].Used.Format(time.RFC3339), list[0].URL)
+		fmt.Printf("Most recently used: %s (%s)\n", list[len(list)-1].Used.Format(time.RFC3339), list[len(list)-1].URL)
+	}
+}
+
+func list(root string) {
+	var w = tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "Last used\tFetched\tSize\tValidators\tURL")
+	for _, e := range entries(root) {
+		var validators []string
+		if e.ETag != "" {
+			validators = append(validators, "etag")
+		}
+		if e.LastModified != "" {
+			validators = append(validators, "last-modified")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", e.Used.Format(time.RFC3339), e.Fetched.Format(time.RFC3339),
+			size(e.Size), strings.Join(validators, ","), e.URL)
+	}
+	w.Flush()
+}
+
+func prune(root string, popts httpcache.PruneOptions) {
+	var removed, err = httpcache.Prune(root, popts)
+	var verb = "Removed"
+	if popts.DryRun {
+		verb = "Would remove"
+	}
+
+	var total int64
+	for _, e := range removed {
+		fmt.Printf("%s %s (%s)\n", verb, e.URL, size(e.Size))
+		total += e.Size
+	}
+	fmt.Printf("%s %d file(s), %s\n", verb, len(removed), size(total))
+
+	if err != nil {
+		logger.Fatalf("Unable to finish pruning: %s", err)
+	}
+}
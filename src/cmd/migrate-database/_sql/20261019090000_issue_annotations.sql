@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE `issue_annotations` (
+  `id` BIGINT NOT NULL AUTO_INCREMENT,
+  `action_id` BIGINT NOT NULL,
+  `issue_id` BIGINT NOT NULL,
+  `page_number` INT NOT NULL DEFAULT 0,
+  `field_name` TINYTEXT COLLATE utf8_bin,
+  `resolved_at` DATETIME,
+  `resolved_by_user_id` BIGINT NOT NULL DEFAULT 0,
+  PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_bin;
+
+CREATE INDEX issue_annotations_issue_id ON `issue_annotations` (`issue_id`);
+CREATE INDEX issue_annotations_action_id ON `issue_annotations` (`action_id`);
+
+-- +goose Down
+DROP TABLE `issue_annotations`;
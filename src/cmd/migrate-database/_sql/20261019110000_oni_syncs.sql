@@ -0,0 +1,19 @@
+-- +goose Up
+ALTER TABLE `titles` ADD COLUMN `marc_xml` MEDIUMTEXT;
+
+CREATE TABLE `oni_syncs` (
+  `id` BIGINT NOT NULL AUTO_INCREMENT,
+  `object_type` VARCHAR(64) COLLATE utf8_bin NOT NULL,
+  `object_id` BIGINT NOT NULL,
+  `environment` VARCHAR(64) COLLATE utf8_bin NOT NULL,
+  `status` VARCHAR(64) COLLATE utf8_bin NOT NULL,
+  `oni_agent_job_id` BIGINT NOT NULL DEFAULT 0,
+  `message` TEXT COLLATE utf8_bin,
+  `updated_at` DATETIME,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `oni_syncs_object_environment` (`object_type`, `object_id`, `environment`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_bin;
+
+-- +goose Down
+DROP TABLE `oni_syncs`;
+ALTER TABLE `titles` DROP COLUMN `marc_xml`;
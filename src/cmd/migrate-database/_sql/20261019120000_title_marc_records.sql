@@ -0,0 +1,20 @@
+-- +goose Up
+CREATE TABLE `title_marc_records` (
+  `id` BIGINT NOT NULL AUTO_INCREMENT,
+  `title_id` BIGINT NOT NULL,
+  `version` INT NOT NULL,
+  `marc_xml` MEDIUMTEXT,
+  `source` TEXT COLLATE utf8_bin,
+  `user_id` BIGINT NOT NULL DEFAULT 0,
+  `created_at` DATETIME,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `title_marc_records_title_version` (`title_id`, `version`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_bin;
+
+-- Existing records become each title's first version
+INSERT INTO `title_marc_records` (`title_id`, `version`, `marc_xml`, `source`, `created_at`)
+  SELECT `id`, 1, `marc_xml`, 'existing record', NOW() FROM `titles`
+  WHERE `marc_xml` IS NOT NULL AND `marc_xml` <> '';
+
+-- +goose Down
+DROP TABLE `title_marc_records`;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE `title_marc_records` ADD `provider` VARCHAR(255) NOT NULL DEFAULT '' AFTER `marc_xml`;
+
+-- +goose Down
+ALTER TABLE `title_marc_records` DROP COLUMN `provider`;
@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE `title_uploads` (
+  `id` BIGINT NOT NULL AUTO_INCREMENT,
+  `title_id` BIGINT NOT NULL,
+  `path` TEXT COLLATE utf8_bin,
+  `size` BIGINT NOT NULL DEFAULT 0,
+  `protocol` VARCHAR(255) NOT NULL DEFAULT '',
+  `received_at` DATETIME,
+  PRIMARY KEY (`id`),
+  KEY `title_uploads_title_received` (`title_id`, `received_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_bin;
+
+-- +goose Down
+DROP TABLE `title_uploads`;
@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE `batch_fixity_checks` (
+  `id` BIGINT NOT NULL AUTO_INCREMENT,
+  `batch_id` BIGINT NOT NULL,
+  `copy` VARCHAR(255) NOT NULL,
+  `location` TEXT COLLATE utf8_bin,
+  `status` VARCHAR(255) NOT NULL,
+  `files` INT NOT NULL DEFAULT 0,
+  `details` MEDIUMTEXT COLLATE utf8_bin,
+  `started_at` DATETIME,
+  `finished_at` DATETIME,
+  PRIMARY KEY (`id`),
+  KEY `batch_fixity_checks_batch_copy` (`batch_id`, `copy`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_bin;
+
+-- +goose Down
+DROP TABLE `batch_fixity_checks`;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE `batches` ADD `replaces_batch_id` INT(11) NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE `batches` DROP COLUMN `replaces_batch_id`;
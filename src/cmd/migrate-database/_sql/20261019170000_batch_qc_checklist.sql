@@ -0,0 +1,34 @@
+-- +goose Up
+ALTER TABLE `batches` ADD `qc_round` INT NOT NULL DEFAULT 0;
+
+CREATE TABLE `batch_qc_results` (
+  `id` BIGINT NOT NULL AUTO_INCREMENT,
+  `batch_id` INT(11) NOT NULL,
+  `qc_round` INT NOT NULL,
+  `check_name` VARCHAR(255) NOT NULL,
+  `check_label` TEXT COLLATE utf8_bin,
+  `result` VARCHAR(16) NOT NULL,
+  `notes` TEXT COLLATE utf8_bin,
+  `user_id` INT(11) NOT NULL,
+  `updated_at` DATETIME,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `batch_qc_results_check` (`batch_id`, `qc_round`, `check_name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_bin;
+
+CREATE TABLE `batch_qc_findings` (
+  `id` BIGINT NOT NULL AUTO_INCREMENT,
+  `batch_id` INT(11) NOT NULL,
+  `qc_round` INT NOT NULL,
+  `issue_id` INT(11) NOT NULL,
+  `check_name` VARCHAR(255) NOT NULL,
+  `note` TEXT COLLATE utf8_bin,
+  `user_id` INT(11) NOT NULL,
+  `created_at` DATETIME,
+  PRIMARY KEY (`id`),
+  KEY `batch_qc_findings_batch` (`batch_id`, `qc_round`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_bin;
+
+-- +goose Down
+DROP TABLE `batch_qc_findings`;
+DROP TABLE `batch_qc_results`;
+ALTER TABLE `batches` DROP COLUMN `qc_round`;
@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TABLE `batch_validation_reports` (
+  `id` BIGINT NOT NULL AUTO_INCREMENT,
+  `batch_id` INT(11) NOT NULL,
+  `status` VARCHAR(16) NOT NULL,
+  `issues` INT NOT NULL DEFAULT 0,
+  `pages` INT NOT NULL DEFAULT 0,
+  `files` INT NOT NULL DEFAULT 0,
+  `schema_checked` TINYINT NOT NULL DEFAULT 0,
+  `details` MEDIUMTEXT COLLATE utf8_bin,
+  `started_at` DATETIME,
+  `finished_at` DATETIME,
+  PRIMARY KEY (`id`),
+  KEY `batch_validation_reports_batch_id` (`batch_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_bin;
+
+-- +goose Down
+DROP TABLE `batch_validation_reports`;
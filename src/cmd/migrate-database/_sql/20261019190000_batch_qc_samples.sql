@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE `batch_qc_samples` (
+  `id` BIGINT NOT NULL AUTO_INCREMENT,
+  `batch_id` INT(11) NOT NULL,
+  `qc_round` INT NOT NULL,
+  `issue_id` INT(11) NOT NULL,
+  `sequence` INT NOT NULL,
+  `reasons` TEXT COLLATE utf8_bin,
+  `reviewed_by_user_id` INT(11) NOT NULL DEFAULT 0,
+  `reviewed_at` DATETIME,
+  PRIMARY KEY (`id`),
+  KEY `batch_qc_samples_batch` (`batch_id`, `qc_round`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_bin;
+
+-- +goose Down
+DROP TABLE `batch_qc_samples`;
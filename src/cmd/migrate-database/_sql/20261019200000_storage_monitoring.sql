@@ -0,0 +1,27 @@
+-- +goose Up
+CREATE TABLE `storage_samples` (
+  `id` BIGINT NOT NULL AUTO_INCREMENT,
+  `location` VARCHAR(255) NOT NULL,
+  `path` TEXT COLLATE utf8_bin,
+  `free_bytes` BIGINT NOT NULL DEFAULT 0,
+  `total_bytes` BIGINT NOT NULL DEFAULT 0,
+  `sampled_at` DATETIME NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `storage_samples_location` (`location`, `sampled_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_bin;
+
+CREATE TABLE `storage_holds` (
+  `id` BIGINT NOT NULL AUTO_INCREMENT,
+  `pipeline_id` BIGINT NOT NULL,
+  `location` VARCHAR(255) NOT NULL,
+  `needed_bytes` BIGINT NOT NULL DEFAULT 0,
+  `free_bytes` BIGINT NOT NULL DEFAULT 0,
+  `held_at` DATETIME NOT NULL,
+  `checked_at` DATETIME NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `storage_holds_pipeline` (`pipeline_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_bin;
+
+-- +goose Down
+DROP TABLE `storage_holds`;
+DROP TABLE `storage_samples`;
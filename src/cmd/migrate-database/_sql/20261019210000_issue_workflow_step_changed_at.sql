@@ -0,0 +1,12 @@
+-- +goose Up
+ALTER TABLE `issues` ADD `workflow_step_changed_at` DATETIME;
+
+-- Without a better record, an issue entered its current step no later than
+-- its most recent action
+UPDATE `issues` SET `workflow_step_changed_at` = (
+  SELECT MAX(`created_at`) FROM `actions`
+  WHERE `actions`.`object_type` = 'issue' AND `actions`.`object_id` = `issues`.`id`
+);
+
+-- +goose Down
+ALTER TABLE `issues` DROP COLUMN `workflow_step_changed_at`;
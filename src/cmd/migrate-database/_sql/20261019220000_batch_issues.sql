@@ -0,0 +1,23 @@
+-- +goose Up
+CREATE TABLE `batch_issues` (
+  `batch_id` INT(11) NOT NULL,
+  `issue_id` INT(11) NOT NULL,
+  PRIMARY KEY (`batch_id`, `issue_id`),
+  KEY `batch_issues_issue` (`issue_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_bin;
+
+-- Every batch which has gone live keeps the issues it went live with
+INSERT INTO `batch_issues` (`batch_id`, `issue_id`)
+  SELECT i.batch_id, i.id FROM `issues` i
+  JOIN `batches` b ON b.id = i.batch_id
+  WHERE b.status IN ('live', 'live_archived', 'live_done', 'superseded');
+
+-- Batches already being corrected had their issues moved to the new version,
+-- which is the best record left of what they held
+INSERT IGNORE INTO `batch_issues` (`batch_id`, `issue_id`)
+  SELECT b.replaces_batch_id, i.id FROM `issues` i
+  JOIN `batches` b ON b.id = i.batch_id
+  WHERE b.replaces_batch_id <> 0 AND b.status <> 'deleted';
+
+-- +goose Down
+DROP TABLE `batch_issues`;
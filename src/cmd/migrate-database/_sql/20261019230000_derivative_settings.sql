@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE `derivative_settings` (
+  `id` BIGINT NOT NULL AUTO_INCREMENT,
+  `issue_id` INT(11) NOT NULL,
+  `jp2_source` VARCHAR(16) COLLATE utf8_bin NOT NULL,
+  `jp2_dpi` INT NOT NULL,
+  `jp2_quality` DOUBLE NOT NULL,
+  `alto_dpi` INT NOT NULL,
+  `created_at` DATETIME,
+  PRIMARY KEY (`id`),
+  KEY `derivative_settings_issue` (`issue_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_bin;
+
+-- +goose Down
+DROP TABLE `derivative_settings`;
@@ -14,6 +14,8 @@ type _opts struct {
 	Redo         bool `long:"redo" description:"only queue issues needing a re-batch"`
 	MinBatchSize int  `long:"min-batch-size" description:"Don't create a batch with fewer than this many pages (overrides the configuration setting 'MIN_BATCH_SIZE')"`
 	MaxBatchSize int  `long:"max-batch-size" description:"Don't create a batch with more than this many pages (overrides the configuration setting 'MAX_BATCH_SIZE')"`
+
+	ONIEnvironments []string `long:"oni-environment" description:"Load batches into this ONI environment (may be repeated); defaults to every environment with the staging role"`
 }
 
 var opts _opts
@@ -29,7 +31,8 @@ func getOpts() *config.Config {
 		`rebatching" state in order to be queued. This is not a state NCA sets ` +
 		`normally, and is only needed when there are manual fixes that require ` +
 		`hacking the database. In other words, if you don't know what this means, ` +
-		`you don't need it.`)
+		`you don't need it. To fix a batch that's already live, use the "Correct ` +
+		`Batch" action on the batch's page instead.`)
 	var conf = c.GetConf()
 	var err = dbi.DBConnect(conf.DatabaseConnect)
 	if err != nil {
@@ -48,6 +51,19 @@ func getOpts() *config.Config {
 		logger.Fatalf("Terminating: minimum batch size (%d) is greater than maximum batch size (%d)", conf.MinBatchSize, conf.MaxBatchSize)
 	}
 
+	if len(opts.ONIEnvironments) == 0 {
+		opts.ONIEnvironments = []string{string(config.ONIRoleStaging)}
+	}
+	var envs []*config.ONIEnvironment
+	envs, err = conf.ResolveONIEnvironments(opts.ONIEnvironments)
+	if err != nil {
+		logger.Fatalf("Terminating: invalid --oni-environment: %s", err)
+	}
+	opts.ONIEnvironments = nil
+	for _, env := range envs {
+		opts.ONIEnvironments = append(opts.ONIEnvironments, env.Name)
+	}
+
 	return conf
 }
 
@@ -75,7 +91,7 @@ func main() {
 
 		// Queue the batch
 		logger.Infof("Sending %q to job runner for creation", batch.Name)
-		err = jobs.QueueMakeBatch(batch, conf)
+		err = jobs.QueueMakeBatch(batch, conf, opts.ONIEnvironments...)
 		if err != nil {
 			logger.Fatalf("Unable to queue batch %q: %s", batch.Name, err)
 		}
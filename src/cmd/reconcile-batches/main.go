@@ -0,0 +1,82 @@
+// reconcile-batches compares NCA's batch records with what each ONI
+// environment serves, optionally queueing jobs to fix what it can
+package main
+
+import (
+	"fmt"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/internal/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/cli"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/dbi"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/jobs"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/reconcile"
+)
+
+// Command-line options
+type _opts struct {
+	cli.BaseOptions
+	RequeueLoads  bool `long:"requeue-loads" description:"Queue jobs to load batches ONI is missing"`
+	RequeuePurges bool `long:"requeue-purges" description:"Queue jobs to purge batches (or stale batch versions) ONI shouldn't have"`
+}
+
+var opts _opts
+
+func getOpts() *config.Config {
+	var c = cli.New(&opts)
+	c.AppendUsage("Compares NCA's batches with the batches served by every " +
+		"configured ONI environment, and reports batches ONI is missing, " +
+		"batches ONI has that it shouldn't, batches NCA doesn't know about, " +
+		"and version mismatches.")
+	c.AppendUsage("By default nothing is changed. Use --requeue-loads and/or " +
+		"--requeue-purges to queue jobs fixing the batches NCA knows about. " +
+		"Batches NCA has no record of must be dealt with by hand.")
+	var conf = c.GetConf()
+	var err = dbi.DBConnect(conf.DatabaseConnect)
+	if err != nil {
+		logger.Fatalf("Error trying to connect to database: %s", err)
+	}
+
+	return conf
+}
+
+func main() {
+	var conf = getOpts()
+	var report, err = reconcile.Run(conf)
+	if err != nil {
+		logger.Fatalf("Unable to reconcile batches: %s", err)
+	}
+
+	if len(report.Discrepancies) == 0 {
+		fmt.Println("NCA and ONI agree on all batches")
+		return
+	}
+
+	for _, d := range report.Discrepancies {
+		fmt.Printf("%s: %s (%s): %s\n", d.Environment.Name, d.ONIName, d.Kind, d.Kind.Describe())
+		if d.NeedsReview() {
+			logger.Warnf("%s on %s needs manual review: %s", d.ONIName, d.Environment.Name, d.ReviewAdvice())
+		}
+
+		var action = d.Action()
+		switch {
+		case action == "load" && opts.RequeueLoads:
+			err = jobs.QueueReconcileLoad(d.Batch, d.Environment)
+		case action == "purge" && opts.RequeuePurges:
+			err = jobs.QueueReconcilePurge(d.Batch, d.Environment, d.ONIName)
+		default:
+			continue
+		}
+
+		if err != nil {
+			logger.Errorf("Unable to queue %s of %q on %s: %s", action, d.ONIName, d.Environment.Name, err)
+			continue
+		}
+		logger.Infof("Queued %s of %q on %s", action, d.ONIName, d.Environment.Name)
+	}
+
+	var n = len(report.NeedsReview())
+	if n > 0 {
+		logger.Warnf("%d batch(es) need manual review: NCA can't fix these", n)
+	}
+}
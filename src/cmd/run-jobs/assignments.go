@@ -0,0 +1,43 @@
+package main
+
+import (
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/internal/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/workload"
+)
+
+// assignIssues runs the workload balancer once, logging each assignment
+func assignIssues(c *config.Config) {
+	var list, err = workload.Run(c.AutoAssignMaxLoad)
+	for _, a := range list {
+		logger.Infof("Assigned issue %d (%s) to %s", a.Issue.ID, a.Issue.Key(), a.Worker.User.Login)
+	}
+	if err != nil {
+		logger.Errorf("Unable to complete automatic issue assignment: %s", err)
+	}
+}
+
+// watchAssignments periodically hands out issues awaiting metadata entry or
+// review, including those whose claims have expired. This does nothing if
+// automatic assignment isn't enabled.
+func watchAssignments(c *config.Config) {
+	if c.AutoAssignMaxLoad == 0 {
+		logger.Infof("Automatic issue assignment is disabled (AUTO_ASSIGN_MAX_LOAD is 0)")
+		return
+	}
+
+	logger.Infof("Watching for issues to assign (max load: %d)", c.AutoAssignMaxLoad)
+
+	var nextAttempt time.Time
+	for !done() {
+		if time.Now().After(nextAttempt) {
+			assignIssues(c)
+			nextAttempt = time.Now().Add(10 * time.Minute)
+		}
+
+		// Try not to eat all the CPU
+		time.Sleep(time.Second)
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/internal/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/jobs"
+)
+
+// watchFixity verifies live batches' archive and live copies on a rolling
+// schedule: whichever copy has gone longest without a check is verified next,
+// one at a time, so the disk load is spread out rather than hitting every
+// batch at once. This does nothing if fixity audits aren't enabled.
+func watchFixity(c *config.Config) {
+	if c.FixityAuditInterval == 0 {
+		logger.Infof("Fixity audits are disabled (FIXITY_AUDIT_INTERVAL is not set)")
+		return
+	}
+
+	logger.Infof("Watching for batches due for a fixity audit (interval: %s)", c.FixityAuditInterval)
+
+	var nextAttempt time.Time
+	for !done() {
+		if time.Now().After(nextAttempt) {
+			var checked, err = jobs.AuditNextFixity(c)
+			if err != nil {
+				logger.Errorf("Unable to complete fixity audit: %s", err)
+			}
+
+			// When a batch was due, we check right away for another; otherwise we
+			// can wait a while, as nothing becomes due very quickly
+			if !checked || err != nil {
+				nextAttempt = time.Now().Add(10 * time.Minute)
+			}
+		}
+
+		// Try not to eat all the CPU
+		time.Sleep(time.Second)
+	}
+}
@@ -90,6 +90,24 @@ func getOpts() (*config.Config, []string) {
 		"ready to be moved for metadata entry. No job is associated with this action, " +
 		"hence it must run on its own, and should only have one copy running at a time. " +
 		"This is also " + warning + "not recommended." + reset)
+	c.AppendUsage(command + "watch-assignments" + reset + ": Periodically assigns issues awaiting " +
+		"metadata entry or review to users who have opted into automatic assignment, including " +
+		"issues whose claims have expired. Does nothing unless AUTO_ASSIGN_MAX_LOAD is set. " +
+		`This is included in "watchall", and should only have one copy running at a time.`)
+	c.AppendUsage(command + "watch-fixity" + reset + ": Verifies live batches' archive and live " +
+		"copies against their bag manifests on a rolling schedule, recording results and alerting " +
+		"on any problems. Does nothing unless FIXITY_AUDIT_INTERVAL is set. " +
+		`This is included in "watchall", and should only have one copy running at a time.`)
+	c.AppendUsage(command + "watch-retention" + reset + ": Deletes errored issues, original " +
+		"upload backups, and finished issues' workflow files once a day as they pass the " +
+		"retention periods in RETENTION_RULES, recording each deletion in the issue's " +
+		`activity log. Does nothing unless RETENTION_RULES is set. This is included in "watchall", ` +
+		"and should only have one copy running at a time.")
+	c.AppendUsage(command + "watch-storage" + reset + ": Records free space on NCA's storage " +
+		"locations every 15 minutes so the web app can forecast when space will run out, " +
+		"logging a warning when a forecast raises an alert. Heavy pipelines are held " +
+		"for lack of space whether or not this is running, but forecasts need its history. " +
+		`This is included in "watchall", and should only have one copy running at a time.`)
 	c.AppendUsage(command + "force-rerun" + reset + " <job id>: Creates a new job by cloning the " +
 		"given job and running the new clone. This is NOT a good idea unless you know " +
 		"exactly what the job(s) you're cloning can affect. This is wonderful for " +
@@ -165,6 +183,14 @@ func main() {
 		watchDigitizedScans(conf)
 	case "watch-page-review":
 		watchPageReview(conf)
+	case "watch-assignments":
+		watchAssignments(conf)
+	case "watch-fixity":
+		watchFixity(conf)
+	case "watch-retention":
+		watchRetention(conf)
+	case "watch-storage":
+		watchStorage(conf)
 	case "run-one":
 		runSingleJob(conf)
 	case "watchall":
@@ -333,6 +359,10 @@ func runAllQueues(conf *config.Config) {
 	waitFor(
 		func() { watchPageReview(conf) },
 		func() { watchDigitizedScans(conf) },
+		func() { watchAssignments(conf) },
+		func() { watchFixity(conf) },
+		func() { watchRetention(conf) },
+		func() { watchStorage(conf) },
 		func() {
 			// Jobs which are exclusively (or primarily) disk IO are in the first
 			// runner to avoid too much FS stuff hapenning concurrently
@@ -343,6 +373,8 @@ func runAllQueues(conf *config.Config) {
 				models.JobTypeVerifyRecursive,
 				models.JobTypeKillDir,
 				models.JobTypeWriteBagitManifest,
+				models.JobTypeValidateBagit,
+				models.JobTypeArchiveBatch,
 				models.JobTypeMakeManifest,
 			)
 		},
@@ -352,6 +384,7 @@ func runAllQueues(conf *config.Config) {
 			watchJobTypes(conf,
 				models.JobTypePageSplit,
 				models.JobTypeMakeDerivatives,
+				models.JobTypeValidateBatch,
 			)
 		},
 		func() {
@@ -394,6 +427,7 @@ func runAllQueues(conf *config.Config) {
 				models.JobTypeBatchAction,
 				models.JobTypeCancelJob,
 				models.JobTypeDeleteBatch,
+				models.JobTypeUnbatchIssues,
 			)
 			addRunner(r)
 			r.Watch(time.Second * 1)
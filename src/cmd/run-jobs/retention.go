@@ -0,0 +1,38 @@
+package main
+
+import (
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/internal/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/retention"
+)
+
+// watchRetention deletes files past the configured retention periods once a
+// day. This does nothing if no retention rules are configured.
+func watchRetention(c *config.Config) {
+	if len(c.RetentionRules) == 0 {
+		logger.Infof("Retention enforcement is disabled (RETENTION_RULES is empty)")
+		return
+	}
+
+	logger.Infof("Watching for files past their retention period (rules: %v)", c.RetentionRules)
+
+	var nextAttempt time.Time
+	for !done() {
+		if time.Now().After(nextAttempt) {
+			var r, err = retention.Enforce(c)
+			if r != nil {
+				logger.Infof("Retention: deleted %d of %d eligible directories; kept %d which failed safety checks",
+					len(r.Deleted), len(r.Eligible), len(r.Skipped))
+			}
+			if err != nil {
+				logger.Errorf("Unable to complete retention enforcement: %s", err)
+			}
+			nextAttempt = time.Now().Add(24 * time.Hour)
+		}
+
+		// Try not to eat all the CPU
+		time.Sleep(time.Second)
+	}
+}
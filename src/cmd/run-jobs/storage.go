@@ -0,0 +1,65 @@
+package main
+
+import (
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/internal/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/storage"
+)
+
+// How often free space is sampled, and how long samples are kept
+const (
+	storageSampleInterval = 15 * time.Minute
+	storageSampleMaxAge   = 90 * 24 * time.Hour
+)
+
+// watchStorage records free space on every monitored filesystem so storage
+// forecasts have a history to work from, and warns when a forecast raises an
+// alert. Each alert is logged once, when it first appears or changes.
+func watchStorage(c *config.Config) {
+	logger.Infof("Watching storage (reserve: %s)", c.StorageReserve.String())
+
+	var alerts = make(map[string]string)
+	var nextAttempt time.Time
+	for !done() {
+		if time.Now().After(nextAttempt) {
+			sampleStorage(c, alerts)
+			nextAttempt = time.Now().Add(storageSampleInterval)
+		}
+
+		// Try not to eat all the CPU
+		time.Sleep(time.Second)
+	}
+}
+
+// sampleStorage records a single round of samples, prunes old ones, and logs
+// any new forecast alerts
+func sampleStorage(c *config.Config, alerts map[string]string) {
+	var now = time.Now()
+	var _, err = storage.RecordSamples(c, now)
+	if err != nil {
+		logger.Errorf("Unable to record storage samples: %s", err)
+		return
+	}
+
+	err = models.PruneStorageSamples(now.Add(-storageSampleMaxAge))
+	if err != nil {
+		logger.Errorf("Unable to prune old storage samples: %s", err)
+	}
+
+	var list []*storage.Forecast
+	list, err = storage.Forecasts(c, now)
+	if err != nil {
+		logger.Errorf("Unable to forecast storage: %s", err)
+		return
+	}
+	for _, f := range list {
+		var alert = f.Alert()
+		if alert != "" && alert != alerts[f.Name] {
+			logger.Warnf("Storage alert for %s (%q, %s free): %s", f.Name, f.Path, f.FreeSize(), alert)
+		}
+		alerts[f.Name] = alert
+	}
+}
@@ -39,6 +39,7 @@ var actionLookup = map[string][]models.AuditAction{
 		models.AuditActionQueueForReview,
 		models.AuditActionSaveDraft,
 		models.AuditActionSaveQueue,
+		models.AuditActionResolveAnnotation,
 	},
 }
 
@@ -18,9 +18,14 @@ func Can(u *models.User, b *Batch) *CanValidation {
 }
 
 // View returns true if the user's privileges allow seeing details for our
-// batch, based primarily on its status
+// batch, based primarily on its status. Pending batches are only visible if
+// they failed validation, so people can see what's wrong, or if the user can
+// unbatch them.
 func (c *CanValidation) View() bool {
-	return c.batch.Status != models.BatchStatusDeleted && c.batch.Status != models.BatchStatusPending
+	if c.batch.Status == models.BatchStatusPending {
+		return c.batch.ValidationFailed() || c.Unbatch()
+	}
+	return c.batch.Status != models.BatchStatusDeleted
 }
 
 // Archive is true if the user can archive batches and batch is ready for archiving
@@ -40,6 +45,16 @@ func (c *CanValidation) Approve() bool {
 	return c.batch.Status == models.BatchStatusQCReady
 }
 
+// RecordQC is true if the user can approve or reject in-QC batches and batch
+// is ready for QC, allowing the QC checklist to be filled out
+func (c *CanValidation) RecordQC() bool {
+	if !c.user.PermittedTo(privilege.ApproveQCReadyBatches) && !c.user.PermittedTo(privilege.RejectQCReadyBatches) {
+		return false
+	}
+
+	return c.batch.Status == models.BatchStatusQCReady
+}
+
 // Reject is true if the user can reject in-QC batches and batch is ready for QC
 func (c *CanValidation) Reject() bool {
 	if !c.user.PermittedTo(privilege.RejectQCReadyBatches) {
@@ -58,3 +73,33 @@ func (c *CanValidation) FlagIssues() bool {
 
 	return c.batch.Status == models.BatchStatusQCFlagIssues
 }
+
+// Correct is true if the user can correct live batches and batch is live,
+// still has its issues in NCA, and isn't already being corrected
+func (c *CanValidation) Correct() bool {
+	if !c.user.PermittedTo(privilege.CorrectLiveBatches) {
+		return false
+	}
+
+	return c.batch.Correctable() && c.batch.CorrectedBy == nil
+}
+
+// Rebuild is true if the user can correct live batches and batch is a new
+// version waiting on its issues to be fixed
+func (c *CanValidation) Rebuild() bool {
+	if !c.user.PermittedTo(privilege.CorrectLiveBatches) {
+		return false
+	}
+
+	return c.batch.Status == models.BatchStatusCorrecting
+}
+
+// Unbatch is true if the user can unbatch batches and batch hasn't yet been
+// approved
+func (c *CanValidation) Unbatch() bool {
+	if !c.user.PermittedTo(privilege.UnbatchBatches) {
+		return false
+	}
+
+	return c.batch.Unbatchable()
+}
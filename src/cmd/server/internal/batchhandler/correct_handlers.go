@@ -0,0 +1,175 @@
+package batchhandler
+
+import (
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/internal/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/jobs"
+)
+
+// prepCorrecting verifies the user can start a correction of the requested
+// batch, returning the responder if so
+func prepCorrecting(w http.ResponseWriter, req *http.Request) (r *Responder, ok bool) {
+	r, ok = getBatchResponder(w, req)
+	if !ok {
+		return r, false
+	}
+	if !r.batch.Can().Correct() {
+		r.Error(http.StatusForbidden, "You are not permitted to correct this batch")
+		return r, false
+	}
+
+	r.Vars.Title = "Correcting batch " + r.batch.Name
+	return r, true
+}
+
+func correctFormHandler(w http.ResponseWriter, req *http.Request) {
+	var r, ok = prepCorrecting(w, req)
+	if ok {
+		r.Render(correctFormTmpl)
+	}
+}
+
+// correctHandler creates the batch's next version, sending the chosen issues
+// back to metadata entry
+func correctHandler(w http.ResponseWriter, req *http.Request) {
+	var r, ok = prepCorrecting(w, req)
+	if !ok {
+		return
+	}
+
+	var err = req.ParseForm()
+	if err != nil {
+		logger.Errorf("Unable to read form in correctHandler: %s", err)
+		r.Error(http.StatusInternalServerError, "Error processing submission. Try again or contact support.")
+		return
+	}
+
+	var reason = strings.TrimSpace(req.Form.Get("reason"))
+	var ids []int64
+	for _, val := range req.Form["issue-id"] {
+		var id, err = strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			r.Error(http.StatusBadRequest, "Invalid request. Try again or contact support.")
+			return
+		}
+		ids = append(ids, id)
+	}
+
+	r.Vars.Data["Reason"] = reason
+	if reason == "" || len(ids) == 0 {
+		r.Vars.Alert = template.HTML("You must describe the problem and choose at least one issue to correct.")
+		r.Render(correctFormTmpl)
+		return
+	}
+
+	var nb, cerr = r.batch.StartCorrection(r.Vars.User.ID, reason, ids)
+	if cerr != nil {
+		logger.Errorf("Unable to start correction of batch %d (%s): %s", r.batch.ID, r.batch.FullName, cerr)
+		r.Vars.Alert = template.HTML("Unable to start the correction. Try again or contact support.")
+		r.Render(correctFormTmpl)
+		return
+	}
+
+	var msg = nb.FullName + ": created to correct " + r.batch.FullName + ", issues are ready for metadata fixes"
+	http.SetCookie(r.Writer, &http.Cookie{Name: "Info", Value: msg, Path: "/"})
+	http.Redirect(w, req, batchURL(&Batch{Batch: nb}), http.StatusFound)
+}
+
+// rebuildHandler queues the build of a corrected batch once all its issues
+// are ready
+func rebuildHandler(w http.ResponseWriter, req *http.Request) {
+	var r, ok = getBatchResponder(w, req)
+	if !ok {
+		return
+	}
+	if !r.batch.Can().Rebuild() {
+		r.Error(http.StatusForbidden, "You are not permitted to rebuild this batch")
+		return
+	}
+	if !r.batch.ReadyForRebuild() {
+		r.Vars.Title = "Error rebuilding batch"
+		r.Vars.Alert = template.HTML("This batch still has issues awaiting correction.")
+		r.Render(viewTmpl)
+		return
+	}
+
+	var err = req.ParseForm()
+	if err != nil {
+		logger.Errorf("Unable to read form in rebuildHandler: %s", err)
+		r.Error(http.StatusInternalServerError, "Error processing submission. Try again or contact support.")
+		return
+	}
+	var envs = rebuildChoices().Read(req)
+	if len(envs) == 0 {
+		r.Vars.Title = "Error rebuilding batch"
+		r.Vars.Alert = template.HTML("You must choose at least one ONI environment to load the corrected batch into.")
+		r.Render(viewTmpl)
+		return
+	}
+
+	err = jobs.QueueMakeBatch(r.batch.Batch, conf, envs...)
+	if err != nil {
+		logger.Errorf("Unable to queue rebuild of batch %d (%s): %s", r.batch.ID, r.batch.FullName, err)
+
+		r, ok = getBatchResponder(w, req)
+		if !ok {
+			return
+		}
+		r.Vars.Title = "Error rebuilding batch"
+		r.Vars.Alert = template.HTML("Unable to queue the batch build. Try again or contact support.")
+		r.Render(viewTmpl)
+		return
+	}
+
+	http.SetCookie(r.Writer, &http.Cookie{Name: "Info", Value: r.batch.Name + ": corrected batch queued for build", Path: "/"})
+	http.Redirect(w, req, basePath, http.StatusFound)
+}
+
+// abandonCorrectionHandler gives up on a correction which hasn't been built,
+// returning its issues to the live batch
+func abandonCorrectionHandler(w http.ResponseWriter, req *http.Request) {
+	var r, ok = getBatchResponder(w, req)
+	if !ok {
+		return
+	}
+	if !r.batch.Can().Rebuild() {
+		r.Error(http.StatusForbidden, "You are not permitted to abandon this correction")
+		return
+	}
+
+	var err = req.ParseForm()
+	if err != nil {
+		logger.Errorf("Unable to read form in abandonCorrectionHandler: %s", err)
+		r.Error(http.StatusInternalServerError, "Error processing submission. Try again or contact support.")
+		return
+	}
+	var reason = strings.TrimSpace(req.Form.Get("reason"))
+	if reason == "" {
+		r.Vars.Title = "Error abandoning correction"
+		r.Vars.Alert = template.HTML("You must explain why the correction is being abandoned.")
+		r.Render(viewTmpl)
+		return
+	}
+
+	err = r.batch.AbandonCorrection(r.Vars.User.ID, reason)
+	if err != nil {
+		logger.Errorf("Unable to abandon correction %d (%s): %s", r.batch.ID, r.batch.FullName, err)
+
+		r, ok = getBatchResponder(w, req)
+		if !ok {
+			return
+		}
+		r.Vars.Title = "Error abandoning correction"
+		r.Vars.Alert = template.HTML("Unable to abandon the correction. If any issues are being processed, wait for them to finish and try again.")
+		r.Render(viewTmpl)
+		return
+	}
+
+	var old = r.batch.ReplacesBatch
+	http.SetCookie(r.Writer, &http.Cookie{Name: "Info", Value: r.batch.Name + ": correction abandoned, issues returned to " + old.FullName, Path: "/"})
+	http.Redirect(w, req, batchURL(&Batch{Batch: old}), http.StatusFound)
+}
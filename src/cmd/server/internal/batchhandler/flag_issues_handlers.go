@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/uoregon-libraries/newspaper-curation-app/internal/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/cmd/server/internal/responder"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/jobs"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
 )
@@ -59,7 +60,16 @@ func prepFlagging(w http.ResponseWriter, req *http.Request) (r *Responder, ok bo
 		return r, false
 	}
 
+	var oniJobs, err = models.FindBatchONIJobs(r.batch.ID)
+	if err != nil {
+		logger.Errorf("Unable to read jobs for batch %d (%s): %s", r.batch.ID, r.batch.FullName, err)
+		r.Error(http.StatusInternalServerError, "Error reading the batch's jobs. Try again or contact support.")
+		return r, false
+	}
+
 	r.Vars.Data["RemainingIssues"] = len(r.batch.Issues) - len(r.batch.FlaggedIssues)
+	r.Vars.Data["PurgeChoices"] = purgeChoices(r.batch, oniJobs)
+	r.Vars.Data["LoadChoices"] = rebuildChoices()
 	r.Vars.Title = "Rejecting batch " + r.batch.Name
 	return r, true
 }
@@ -237,6 +247,19 @@ func abortBatch(r *Responder) {
 }
 
 func finalizeBatch(r *Responder) {
+	// A batch which is being rebuilt has to be loaded somewhere, so we check
+	// that before saving anything
+	var deleting = len(r.batch.Issues) == len(r.batch.FlaggedIssues)
+	var load = r.Vars.Data["LoadChoices"].(*responder.ONIChoices).Read(r.Request)
+	if !deleting && len(load) == 0 {
+		r.Vars.Alert = template.HTML("You must choose at least one ONI environment to load the rebuilt batch into.")
+		r.Render(flagIssuesFormTmpl)
+		return
+	}
+
+	// The rebuilt batch gets a fresh QC checklist; this round's results and
+	// findings stay in the database for reporting
+	r.batch.QCRound++
 	var err = r.batch.Save(models.ActionTypeFinalizeBatch, r.Vars.User.ID, "")
 	if err != nil {
 		logger.Errorf(`Unable to log "finalize batch" action for batch %d (%s): %s`, r.batch.ID, r.batch.Name, err)
@@ -245,14 +268,15 @@ func finalizeBatch(r *Responder) {
 	}
 
 	// If all issues were flagged for removal, we delete the batch entirely
-	if len(r.batch.Issues) == len(r.batch.FlaggedIssues) {
+	if deleting {
 		queueDeleteBatchJob(r)
 		return
 	}
 
 	// There are enough moving pieces here that we have to queue this up in the
 	// background rather than just run a quick DB operation or something
-	err = jobs.QueueBatchFinalizeIssueFlagging(r.batch.Batch, r.batch.FlaggedIssues, conf)
+	var purge = r.Vars.Data["PurgeChoices"].(*responder.ONIChoices).Read(r.Request)
+	err = jobs.QueueBatchFinalizeIssueFlagging(r.batch.Batch, r.batch.FlaggedIssues, purge, load, conf)
 	if err != nil {
 		logger.Errorf("Unable to queue job to finalize issue flagging for batch %d (%s): %s", r.batch.ID, r.batch.Name, err)
 		r.Error(http.StatusInternalServerError, "Error trying to finalize the batch. Try again or contact support.")
@@ -275,7 +299,8 @@ func undoBatch(r *Responder) {
 }
 
 func queueDeleteBatchJob(r *Responder) {
-	var err = jobs.QueueBatchForDeletion(r.batch.Batch, r.batch.FlaggedIssues, conf)
+	var purge = r.Vars.Data["PurgeChoices"].(*responder.ONIChoices).Read(r.Request)
+	var err = jobs.QueueBatchForDeletion(r.batch.Batch, r.batch.FlaggedIssues, purge, conf)
 	if err != nil {
 		logger.Errorf("Unable to queue job to delete batch %d (%s): %s", r.batch.ID, r.batch.Name, err)
 		r.Error(http.StatusInternalServerError, "Error trying to finalize the batch. Try again or contact support.")
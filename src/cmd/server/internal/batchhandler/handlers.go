@@ -8,6 +8,7 @@ import (
 
 	"github.com/uoregon-libraries/newspaper-curation-app/internal/logger"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/cmd/server/internal/responder"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/jobs"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
 )
@@ -84,11 +85,38 @@ func qcApproveFormHandler(w http.ResponseWriter, req *http.Request) {
 		r.Error(http.StatusForbidden, "You are not permitted to approve this batch for a production load")
 		return
 	}
+	if !r.batch.QCChecklist.Passed() {
+		r.Error(http.StatusForbidden, "This batch can't be approved until every QC check is answered with no failures or findings")
+		return
+	}
+	if r.batch.AwaitingReplacedArchive() {
+		r.Error(http.StatusForbidden, "This batch can't be approved until the batch it replaces is flagged as archived")
+		return
+	}
 
 	r.Vars.Title = "Approve batch?"
+	r.Vars.Data["ONIChoices"] = goLiveChoices()
 	r.Render(approveFormTmpl)
 }
 
+// goLiveChoices returns the ONI environments an approved batch can be loaded
+// into, with the production environments checked
+func goLiveChoices() *responder.ONIChoices {
+	return responder.NewONIChoices(conf, "oni_env", "Load the batch into:", []string{string(config.ONIRoleProduction)})
+}
+
+// purgeChoices returns the ONI environments a batch can be purged from, with
+// those it's currently loaded on checked
+func purgeChoices(b *Batch, oniJobs []*models.Job) *responder.ONIChoices {
+	return responder.NewONIChoices(conf, "oni_purge", "Purge the batch from:", jobs.LoadedONIEnvironments(b.Batch, oniJobs))
+}
+
+// rebuildChoices returns the ONI environments a rebuilt batch can be loaded
+// into, with the staging environments checked
+func rebuildChoices() *responder.ONIChoices {
+	return responder.NewONIChoices(conf, "oni_load", "Load the rebuilt batch into:", []string{string(config.ONIRoleStaging)})
+}
+
 func qcApproveHandler(w http.ResponseWriter, req *http.Request) {
 	var r, ok = getBatchResponder(w, req)
 	if !ok {
@@ -98,13 +126,36 @@ func qcApproveHandler(w http.ResponseWriter, req *http.Request) {
 		r.Error(http.StatusForbidden, "You are not permitted to approve this batch for a production load")
 		return
 	}
+	if !r.batch.QCChecklist.Passed() {
+		r.Error(http.StatusForbidden, "This batch can't be approved until every QC check is answered with no failures or findings")
+		return
+	}
+	if r.batch.AwaitingReplacedArchive() {
+		r.Error(http.StatusForbidden, "This batch can't be approved until the batch it replaces is flagged as archived")
+		return
+	}
+
+	var err = req.ParseForm()
+	if err != nil {
+		logger.Errorf("Unable to read form in qcApproveHandler: %s", err)
+		r.Error(http.StatusInternalServerError, "Error processing submission. Try again or contact support.")
+		return
+	}
+	var choices = goLiveChoices()
+	var envs = choices.Read(req)
+	if len(envs) == 0 {
+		r.Vars.Title = "Approve batch?"
+		r.Vars.Data["ONIChoices"] = choices
+		r.Vars.Alert = template.HTML("You must choose at least one ONI environment to load the batch into.")
+		r.Render(approveFormTmpl)
+		return
+	}
 
-	// TODO: send job to ONI to load batch live
-	var err = r.batch.Save(models.ActionTypeApproveBatch, r.Vars.User.ID, "")
+	err = r.batch.Save(models.ActionTypeApproveBatch, r.Vars.User.ID, "QC checklist: "+r.batch.QCChecklist.Summary()+"; QC sample: "+r.batch.SampleCoverage())
 	if err != nil {
 		logger.Errorf(`Unable to log "approve batch" action for batch %d (%s): %s`, r.batch.ID, r.batch.FullName, err)
 	} else {
-		err = jobs.QueueBatchGoLive(r.batch.Batch, conf)
+		err = jobs.QueueBatchGoLive(r.batch.Batch, envs, conf)
 		if err != nil {
 			logger.Errorf(`Unable to queue go-live job for batch %d (%s): %s`, r.batch.ID, r.batch.FullName, err)
 		}
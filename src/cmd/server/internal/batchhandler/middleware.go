@@ -30,3 +30,11 @@ func canReject(h http.HandlerFunc) http.Handler {
 func canArchive(h http.HandlerFunc) http.Handler {
 	return responder.MustHavePrivilege(privilege.ArchiveBatches, h)
 }
+
+func canCorrect(h http.HandlerFunc) http.Handler {
+	return responder.MustHavePrivilege(privilege.CorrectLiveBatches, h)
+}
+
+func canUnbatch(h http.HandlerFunc) http.Handler {
+	return responder.MustHavePrivilege(privilege.UnbatchBatches, h)
+}
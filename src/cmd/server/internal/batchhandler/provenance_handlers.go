@@ -0,0 +1,56 @@
+package batchhandler
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/uoregon-libraries/newspaper-curation-app/internal/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/provenance"
+)
+
+// provenanceFormat describes one of the provenance package's download formats
+type provenanceFormat struct {
+	contentType string
+	suffix      string
+	write       func(p *provenance.Package, w io.Writer) error
+}
+
+var provenanceFormats = map[string]provenanceFormat{
+	"csv":    {"text/csv", "-provenance.csv", (*provenance.Package).WriteCSV},
+	"json":   {"application/json", "-provenance.json", (*provenance.Package).WriteJSON},
+	"premis": {"application/xml", "-premis.xml", (*provenance.Package).WritePREMIS},
+}
+
+// provenanceHandler sends the batch's provenance package as a download in the
+// requested format
+func provenanceHandler(w http.ResponseWriter, req *http.Request) {
+	var r, ok = getBatchResponder(w, req)
+	if !ok {
+		return
+	}
+	if !r.batch.Can().View() {
+		r.Error(http.StatusForbidden, "You are not permitted to view this batch")
+		return
+	}
+	var f, found = provenanceFormats[mux.Vars(req)["format"]]
+	if !found {
+		r.Error(http.StatusNotFound, "Unknown provenance format")
+		return
+	}
+
+	var p, err = provenance.New(r.batch.Batch, time.Now())
+	if err != nil {
+		logger.Errorf("Unable to build provenance package for batch %d (%s): %s", r.batch.ID, r.batch.FullName, err)
+		r.Error(http.StatusInternalServerError, "Error trying to read the batch's provenance - try again or contact support")
+		return
+	}
+
+	w.Header().Add("Content-Type", f.contentType)
+	w.Header().Add("Content-Disposition", `attachment; filename="`+r.batch.FullName+f.suffix+`"`)
+	err = f.write(p, w)
+	if err != nil {
+		logger.Errorf("Unable to write provenance package for batch %d (%s): %s", r.batch.ID, r.batch.FullName, err)
+	}
+}
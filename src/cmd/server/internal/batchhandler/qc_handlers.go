@@ -0,0 +1,220 @@
+package batchhandler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/internal/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+)
+
+// qcHandler receives all QC checklist form POST requests, dispatching to the
+// correct "sub-handler" based on the form's action
+func qcHandler(w http.ResponseWriter, req *http.Request) {
+	var r, ok = getBatchResponder(w, req)
+	if !ok {
+		return
+	}
+	if !r.batch.Can().RecordQC() {
+		r.Error(http.StatusForbidden, "You are not permitted to record QC results for this batch")
+		return
+	}
+
+	var err = req.ParseForm()
+	if err != nil {
+		logger.Errorf("Unable to read form in qcHandler: %s", err)
+		r.Error(http.StatusInternalServerError, "Error processing submission. Try again or contact support.")
+		return
+	}
+
+	switch req.Form.Get("action") {
+	case "save-checklist":
+		saveQCChecklist(r)
+	case "add-finding":
+		addQCFinding(r)
+	case "remove-finding":
+		removeQCFinding(r)
+	case "generate-sample":
+		generateQCSample(r)
+	case "review-sample":
+		reviewQCSample(r)
+	default:
+		r.Error(http.StatusBadRequest, "Invalid request. Try again or contact support.")
+	}
+}
+
+// qcRedirect sends the user back to the batch's QC checklist with the given
+// message
+func qcRedirect(r *Responder, cookie, msg string) {
+	http.SetCookie(r.Writer, &http.Cookie{Name: cookie, Value: msg, Path: "/"})
+	http.Redirect(r.Writer, r.Request, batchURL(r.batch)+"#qc-checklist", http.StatusFound)
+}
+
+// saveQCChecklist stores the result and notes of every check on the form.
+// Checks left unanswered are skipped rather than clearing a prior answer.
+func saveQCChecklist(r *Responder) {
+	var saved int
+	for _, item := range r.batch.QCChecklist.Items {
+		var result = r.Request.Form.Get("result-" + item.Name)
+		var notes = strings.TrimSpace(r.Request.Form.Get("notes-" + item.Name))
+		if result == "" {
+			continue
+		}
+		if !models.ValidQCResult(result) {
+			r.Error(http.StatusBadRequest, fmt.Sprintf("Invalid result for %q. Try again or contact support.", item.Label))
+			return
+		}
+
+		var qcr = item.Result
+		if qcr == nil {
+			qcr = &models.BatchQCResult{BatchID: r.batch.ID, QCRound: r.batch.QCRound, CheckName: item.Name}
+		}
+		qcr.CheckLabel = item.Label
+		qcr.Result = result
+		qcr.Notes = notes
+		qcr.UserID = r.Vars.User.ID
+		var err = qcr.Save()
+		if err != nil {
+			logger.Errorf("Unable to save QC result %q for batch %d (%s): %s", item.Name, r.batch.ID, r.batch.FullName, err)
+			r.Error(http.StatusInternalServerError, "Error saving the QC checklist. Try again or contact support.")
+			return
+		}
+		saved++
+	}
+
+	qcRedirect(r, "Info", fmt.Sprintf("Saved %d QC checklist result(s)", saved))
+}
+
+// addQCFinding records a problem with one of the batch's issues
+func addQCFinding(r *Responder) {
+	var form = r.Request.Form
+	var issueID, _ = strconv.ParseInt(form.Get("issue-id"), 10, 64)
+	var issue *models.Issue
+	for _, i := range r.batch.Issues {
+		if i.ID == issueID {
+			issue = i
+		}
+	}
+	if issue == nil {
+		qcRedirect(r, "Alert", "Choose an issue from this batch for the finding")
+		return
+	}
+
+	var checkName = form.Get("check-name")
+	var valid bool
+	for _, item := range r.batch.QCChecklist.Items {
+		if item.Name == checkName {
+			valid = true
+		}
+	}
+	if !valid {
+		qcRedirect(r, "Alert", "Choose which QC check the finding is for")
+		return
+	}
+
+	var note = strings.TrimSpace(form.Get("note"))
+	if note == "" {
+		qcRedirect(r, "Alert", "Describe the problem you found")
+		return
+	}
+
+	var f = &models.BatchQCFinding{
+		BatchID:   r.batch.ID,
+		QCRound:   r.batch.QCRound,
+		IssueID:   issue.ID,
+		CheckName: checkName,
+		Note:      note,
+		UserID:    r.Vars.User.ID,
+	}
+	var err = f.Save()
+	if err != nil {
+		logger.Errorf("Unable to save QC finding for issue %d in batch %d (%s): %s", issue.ID, r.batch.ID, r.batch.FullName, err)
+		r.Error(http.StatusInternalServerError, "Error saving the QC finding. Try again or contact support.")
+		return
+	}
+
+	qcRedirect(r, "Info", fmt.Sprintf("Recorded a finding for %s", issue.Key()))
+}
+
+// removeQCFinding deletes a finding from the batch's current QC round
+func removeQCFinding(r *Responder) {
+	var id, _ = strconv.ParseInt(r.Request.Form.Get("finding-id"), 10, 64)
+	var f, err = models.FindBatchQCFinding(id)
+	if err != nil {
+		logger.Errorf("Unable to look up QC finding %d: %s", id, err)
+		r.Error(http.StatusInternalServerError, "Error removing the QC finding. Try again or contact support.")
+		return
+	}
+	if f == nil || f.BatchID != r.batch.ID || f.QCRound != r.batch.QCRound {
+		qcRedirect(r, "Alert", "That finding isn't part of this batch's current QC review")
+		return
+	}
+
+	err = f.Delete()
+	if err != nil {
+		logger.Errorf("Unable to delete QC finding %d: %s", id, err)
+		r.Error(http.StatusInternalServerError, "Error removing the QC finding. Try again or contact support.")
+		return
+	}
+
+	qcRedirect(r, "Info", "Removed the QC finding")
+}
+
+// sampleRedirect sends the user back to the batch's QC sample with the given
+// message
+func sampleRedirect(r *Responder, cookie, msg string) {
+	http.SetCookie(r.Writer, &http.Cookie{Name: cookie, Value: msg, Path: "/"})
+	http.Redirect(r.Writer, r.Request, batchURL(r.batch)+"#qc-sample", http.StatusFound)
+}
+
+// generateQCSample picks and stores the pages to review for the batch's
+// current QC round
+func generateQCSample(r *Responder) {
+	if len(r.batch.QCSample) > 0 {
+		sampleRedirect(r, "Alert", "This batch already has a sample for its current QC review")
+		return
+	}
+
+	var list, err = buildQCSample(r.batch)
+	if err == nil {
+		err = r.batch.SaveQCSample(list)
+	}
+	if err != nil {
+		logger.Errorf("Unable to generate QC sample for batch %d (%s): %s", r.batch.ID, r.batch.FullName, err)
+		r.Error(http.StatusInternalServerError, "Error generating the QC sample. Try again or contact support.")
+		return
+	}
+
+	sampleRedirect(r, "Info", fmt.Sprintf("Sampled %d page(s) for review", len(list)))
+}
+
+// reviewQCSample records or clears a reviewer's check of a sampled page
+func reviewQCSample(r *Responder) {
+	var id, _ = strconv.ParseInt(r.Request.Form.Get("sample-id"), 10, 64)
+	var page *SamplePage
+	for _, p := range r.batch.QCSample {
+		if p.ID == id {
+			page = p
+		}
+	}
+	if page == nil {
+		sampleRedirect(r, "Alert", "That page isn't part of this batch's current QC sample")
+		return
+	}
+
+	var reviewed = r.Request.Form.Get("reviewed") == "1"
+	var err = page.SetReviewed(r.Vars.User.ID, reviewed)
+	if err != nil {
+		logger.Errorf("Unable to record review of QC sample %d for batch %d (%s): %s", id, r.batch.ID, r.batch.FullName, err)
+		r.Error(http.StatusInternalServerError, "Error recording the page review. Try again or contact support.")
+		return
+	}
+
+	var verb = "Marked"
+	if !reviewed {
+		verb = "Unmarked"
+	}
+	sampleRedirect(r, "Info", fmt.Sprintf("%s %s page %d as checked", verb, page.Issue.Key(), page.Sequence))
+}
@@ -4,10 +4,12 @@ import (
 	"net/url"
 	"path"
 	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/cmd/server/internal/responder"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/web/tmpl"
 )
 
@@ -35,6 +37,13 @@ var (
 	// flagIssuesFormTmpl is the form for defining what needs to be fixed on a
 	// batch which failed QC
 	flagIssuesFormTmpl *tmpl.Template
+
+	// correctFormTmpl is the form for choosing which issues of a live batch
+	// need to be fixed in a new version
+	correctFormTmpl *tmpl.Template
+
+	// unbatchFormTmpl is the form for confirming a batch should be taken apart
+	unbatchFormTmpl *tmpl.Template
 )
 
 func batchNewsURL(root string, b *Batch) string {
@@ -43,6 +52,42 @@ func batchNewsURL(root string, b *Batch) string {
 	return u.String() + "/"
 }
 
+// oniLink is a link to a batch on one ONI environment
+type oniLink struct {
+	Name string
+	URL  string
+}
+
+// batchONILinks returns links to the batch on every environment it should be
+// loaded into, based on its status
+func batchONILinks(b *Batch) []oniLink {
+	var roles []config.ONIRole
+	if b.StatusMeta.Staging {
+		roles = append(roles, config.ONIRoleStaging)
+	}
+	if b.StatusMeta.Live {
+		roles = append(roles, config.ONIRoleProduction)
+	}
+
+	var links []oniLink
+	for _, role := range roles {
+		for _, env := range conf.ONIEnvironmentsFor(role) {
+			links = append(links, oniLink{Name: env.Name, URL: batchNewsURL(env.Webroot, b)})
+		}
+	}
+	return links
+}
+
+// stagingRootURLs returns the webroot of every staging environment for use in
+// examples; any environment's URLs are accepted when flagging issues
+func stagingRootURLs() []string {
+	var list []string
+	for _, env := range conf.ONIEnvironmentsFor(config.ONIRoleStaging) {
+		list = append(list, strings.TrimRight(env.Webroot, "/"))
+	}
+	return list
+}
+
 func batchURL(b *Batch, other ...string) string {
 	var parts = []string{basePath, strconv.FormatInt(b.ID, 10)}
 	if len(other) > 0 {
@@ -64,7 +109,9 @@ func Setup(r *mux.Router, baseWebPath string, c *config.Config) {
 	s.Path("/{batch_id}").Methods("GET").Handler(canView(viewHandler))
 	s.Path("/{batch_id}/approve").Methods("GET").Handler(canApprove(qcApproveFormHandler))
 	s.Path("/{batch_id}/approve").Methods("POST").Handler(canApprove(qcApproveHandler))
+	s.Path("/{batch_id}/qc").Methods("POST").Handler(canView(qcHandler))
 	s.Path("/{batch_id}/archive").Methods("POST").Handler(canArchive(setArchivedHandler))
+	s.Path("/{batch_id}/provenance/{format}").Methods("GET").Handler(canView(provenanceHandler))
 
 	// All these paths are related to the same multi-step operation (rejecting a
 	// batch, flagging issues, and finalizing it for rebuilding)
@@ -73,17 +120,37 @@ func Setup(r *mux.Router, baseWebPath string, c *config.Config) {
 	s.Path("/{batch_id}/flag-issues").Methods("GET").Handler(canReject(flagIssuesFormHandler))
 	s.Path("/{batch_id}/flag-issues").Methods("POST").Handler(canReject(flagIssuesHandler))
 
+	// Correcting a live batch: pulling issues back for fixes, then rebuilding
+	// the batch as a new version once they're ready
+	s.Path("/{batch_id}/correct").Methods("GET").Handler(canCorrect(correctFormHandler))
+	s.Path("/{batch_id}/correct").Methods("POST").Handler(canCorrect(correctHandler))
+	s.Path("/{batch_id}/rebuild").Methods("POST").Handler(canCorrect(rebuildHandler))
+	s.Path("/{batch_id}/abandon-correction").Methods("POST").Handler(canCorrect(abandonCorrectionHandler))
+
+	// Unbatching a batch which hasn't been approved, returning its issues to
+	// the batching queue
+	s.Path("/{batch_id}/unbatch").Methods("GET").Handler(canUnbatch(unbatchFormHandler))
+	s.Path("/{batch_id}/unbatch").Methods("POST").Handler(canUnbatch(unbatchHandler))
+
 	layout = responder.Layout.Clone()
 	layout.Funcs(tmpl.FuncMap{
-		"BatchesHomeURL":  func() string { return basePath },
-		"StagingRootURL":  func() string { return conf.StagingNewsWebroot },
-		"ViewURL":         func(b *Batch) string { return batchURL(b) },
-		"SetArchivedURL":  func(b *Batch) string { return batchURL(b, "archive") },
-		"ApproveURL":      func(b *Batch) string { return batchURL(b, "approve") },
-		"RejectURL":       func(b *Batch) string { return batchURL(b, "reject") },
-		"FlagIssuesURL":   flagIssuesURL,
-		"StagingBatchURL": func(b *Batch) string { return batchNewsURL(conf.StagingNewsWebroot, b) },
-		"ProdBatchURL":    func(b *Batch) string { return batchNewsURL(conf.NewsWebroot, b) },
+		"BatchesHomeURL":    func() string { return basePath },
+		"StagingRootURLs":   stagingRootURLs,
+		"ViewURL":           func(b *Batch) string { return batchURL(b) },
+		"SetArchivedURL":    func(b *Batch) string { return batchURL(b, "archive") },
+		"ApproveURL":        func(b *Batch) string { return batchURL(b, "approve") },
+		"RejectURL":         func(b *Batch) string { return batchURL(b, "reject") },
+		"QCURL":             func(b *Batch) string { return batchURL(b, "qc") },
+		"FlagIssuesURL":     flagIssuesURL,
+		"CorrectURL":        func(b *Batch) string { return batchURL(b, "correct") },
+		"RebuildURL":        func(b *Batch) string { return batchURL(b, "rebuild") },
+		"AbandonURL":        func(b *Batch) string { return batchURL(b, "abandon-correction") },
+		"UnbatchURL":        func(b *Batch) string { return batchURL(b, "unbatch") },
+		"ProvenanceURL":     func(b *Batch, format string) string { return batchURL(b, "provenance", format) },
+		"BatchURL":          func(b *models.Batch) string { return path.Join(basePath, strconv.FormatInt(b.ID, 10)) },
+		"ONILinks":          batchONILinks,
+		"RebuildONIChoices": rebuildChoices,
+		"QCResultOptions":   func() []string { return models.QCResults },
 	})
 	layout.Path = path.Join(layout.Path, "batches")
 
@@ -93,4 +160,6 @@ func Setup(r *mux.Router, baseWebPath string, c *config.Config) {
 	approveFormTmpl = layout.MustBuild("approve_form.go.html")
 	rejectFormTmpl = layout.MustBuild("reject_form.go.html")
 	flagIssuesFormTmpl = layout.MustBuild("flag_issues_form.go.html")
+	correctFormTmpl = layout.MustBuild("correct_form.go.html")
+	unbatchFormTmpl = layout.MustBuild("unbatch_form.go.html")
 }
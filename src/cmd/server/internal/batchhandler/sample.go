@@ -0,0 +1,163 @@
+package batchhandler
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/qcsample"
+)
+
+// recentRejectionWindow is how far back a metadata rejection still counts as
+// a risk signal when sampling a batch
+const recentRejectionWindow = 90 * 24 * time.Hour
+
+// samplePagesPerIssue is the most pages picked from each sampled issue
+const samplePagesPerIssue = 3
+
+// SamplePage decorates a sampled page for display
+type SamplePage struct {
+	*models.BatchQCSample
+	Issue *models.Issue
+}
+
+// PageLinks returns links to the page on each staging ONI environment
+func (p *SamplePage) PageLinks() []oniLink {
+	return stagingIssueLinks(p.Issue, fmt.Sprintf("seq-%d", p.Sequence))
+}
+
+// IssueLinks returns links to the page's issue on each staging ONI environment
+func (p *SamplePage) IssueLinks() []oniLink {
+	return stagingIssueLinks(p.Issue)
+}
+
+// stagingIssueLinks builds ONI links for an issue on every staging
+// environment, with any extra path parts appended
+func stagingIssueLinks(i *models.Issue, other ...string) []oniLink {
+	var links []oniLink
+	for _, env := range conf.ONIEnvironmentsFor(config.ONIRoleStaging) {
+		var u, _ = url.Parse(env.Webroot)
+		var parts = []string{u.Path, "lccn", i.LCCN, i.Date, fmt.Sprintf("ed-%d", i.Edition)}
+		u.Path = path.Join(append(parts, other...)...)
+		links = append(links, oniLink{Name: env.Name, URL: u.String() + "/"})
+	}
+	return links
+}
+
+// qcSample loads the batch's sample for its current QC round and pairs each
+// page with its issue
+func qcSample(b *Batch) ([]*SamplePage, error) {
+	var list, err = b.Batch.QCSample()
+	if err != nil {
+		return nil, err
+	}
+
+	var issues = make(map[int64]*models.Issue)
+	for _, i := range b.Issues {
+		issues[i.ID] = i
+	}
+
+	var pages []*SamplePage
+	for _, s := range list {
+		var i = issues[s.IssueID]
+		if i == nil {
+			return nil, fmt.Errorf("sampled issue %d is not part of the batch", s.IssueID)
+		}
+		pages = append(pages, &SamplePage{BatchQCSample: s, Issue: i})
+	}
+	return pages, nil
+}
+
+// SampleReviewed returns how many sampled pages have been checked
+func (b *Batch) SampleReviewed() int {
+	var n int
+	for _, p := range b.QCSample {
+		if p.Reviewed() {
+			n++
+		}
+	}
+	return n
+}
+
+// SampleCoverage describes how much of the QC sample has been reviewed
+func (b *Batch) SampleCoverage() string {
+	if len(b.QCSample) == 0 {
+		return "no sample taken"
+	}
+	return fmt.Sprintf("%d of %d sampled pages checked", b.SampleReviewed(), len(b.QCSample))
+}
+
+// buildQCSample gathers the batch's risk signals and OCR word counts, then
+// picks the pages QC staff should review
+func buildQCSample(b *Batch) ([]*models.BatchQCSample, error) {
+	var signals, err = b.Batch.QCRiskSignals(time.Now().Add(-recentRejectionWindow))
+	if err != nil {
+		return nil, fmt.Errorf("reading risk signals: %w", err)
+	}
+
+	// The earliest born-digital issue of each title is the publisher's first
+	// if the title has never had born-digital issues go live
+	var firstIssue = make(map[string]*models.Issue)
+	for _, i := range b.Issues {
+		if i.IsFromScanner || signals.LiveBornDigitalTitles[i.LCCN] {
+			continue
+		}
+		var prev = firstIssue[i.LCCN]
+		if prev == nil || i.Date < prev.Date || (i.Date == prev.Date && i.Edition < prev.Edition) {
+			firstIssue[i.LCCN] = i
+		}
+	}
+
+	var candidates []*qcsample.Issue
+	for _, i := range b.Issues {
+		var c = &qcsample.Issue{ID: i.ID}
+		switch {
+		case !signals.LiveTitles[i.LCCN]:
+			c.Reasons = append(c.Reasons, "new title")
+		case firstIssue[i.LCCN] == i:
+			c.Reasons = append(c.Reasons, "first publisher issue")
+		}
+		var n = signals.Rejections[i.ID]
+		if n > 0 {
+			c.Reasons = append(c.Reasons, fmt.Sprintf("%d recent metadata rejection(s)", n))
+		}
+
+		var dir = filepath.Join(b.Location, "data", i.LCCN, "print", i.DateEdition())
+		for seq := 1; seq <= i.PageCount; seq++ {
+			c.Pages = append(c.Pages, qcsample.Page{
+				Sequence: seq,
+				Words:    altoWordCount(filepath.Join(dir, fmt.Sprintf("%04d.xml", seq))),
+			})
+		}
+		candidates = append(candidates, c)
+	}
+
+	var seed = qcsample.Seed(b.ID, b.QCRound)
+	var picks = qcsample.Sample(candidates, seed, qcsample.IssueCount(len(candidates)), samplePagesPerIssue)
+	var list = make([]*models.BatchQCSample, len(picks))
+	for idx, p := range picks {
+		list[idx] = &models.BatchQCSample{
+			IssueID:  p.IssueID,
+			Sequence: p.Sequence,
+			Reasons:  strings.Join(p.Reasons, "\n"),
+		}
+	}
+	return list, nil
+}
+
+// altoWordCount returns the number of words in an ALTO file, or -1 if the
+// file can't be read
+func altoWordCount(fname string) int {
+	var data, err = os.ReadFile(fname)
+	if err != nil {
+		return -1
+	}
+	return bytes.Count(data, []byte("<String"))
+}
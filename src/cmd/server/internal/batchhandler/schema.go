@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/schema"
 )
 
 // Batch wraps models.Batch to decorate for template use
@@ -13,6 +14,12 @@ type Batch struct {
 	UnflaggedIssues []*models.Issue
 	Issues          []*models.Issue
 	ActivityLog     []*models.Action
+	FixityChecks    []*models.BatchFixityCheck
+	Validations     []*models.BatchValidationReport
+	ReplacesBatch   *models.Batch
+	CorrectedBy     *models.Batch
+	QCChecklist     *models.QCChecklist
+	QCSample        []*SamplePage
 	PageCount       int
 	cv              *CanValidation
 }
@@ -49,9 +56,59 @@ func wrapBatch(batch *models.Batch, currentUser *models.User) (*Batch, error) {
 		return nil, fmt.Errorf("fetching batch %d (%q) actions: %w", b.Batch.ID, b.Batch.Name, err)
 	}
 
+	b.FixityChecks, err = b.Batch.FixityChecks()
+	if err != nil {
+		return nil, fmt.Errorf("fetching batch %d (%q) fixity checks: %w", b.Batch.ID, b.Batch.Name, err)
+	}
+
+	b.Validations, err = b.Batch.ValidationReports()
+	if err != nil {
+		return nil, fmt.Errorf("fetching batch %d (%q) validation reports: %w", b.Batch.ID, b.Batch.Name, err)
+	}
+
+	b.ReplacesBatch, err = b.Batch.Replaces()
+	if err != nil {
+		return nil, fmt.Errorf("fetching batch %d (%q) previous version: %w", b.Batch.ID, b.Batch.Name, err)
+	}
+
+	b.CorrectedBy, err = b.Batch.Correction()
+	if err != nil {
+		return nil, fmt.Errorf("fetching batch %d (%q) correction: %w", b.Batch.ID, b.Batch.Name, err)
+	}
+
+	b.QCChecklist, err = qcChecklist(b.Batch)
+	if err != nil {
+		return nil, fmt.Errorf("fetching batch %d (%q) QC checklist: %w", b.Batch.ID, b.Batch.Name, err)
+	}
+
+	b.QCSample, err = qcSample(b)
+	if err != nil {
+		return nil, fmt.Errorf("fetching batch %d (%q) QC sample: %w", b.Batch.ID, b.Batch.Name, err)
+	}
+
 	return b, nil
 }
 
+// qcChecklist pairs the configured QC checks with what's been recorded for
+// the batch's current QC round
+func qcChecklist(batch *models.Batch) (*models.QCChecklist, error) {
+	var results, err = batch.QCResults()
+	if err != nil {
+		return nil, err
+	}
+	var findings []*models.BatchQCFinding
+	findings, err = batch.QCFindings()
+	if err != nil {
+		return nil, err
+	}
+
+	var items = make([]*models.QCChecklistItem, len(conf.QCChecks))
+	for i, c := range conf.QCChecks {
+		items[i] = &models.QCChecklistItem{Name: c.Name, Label: c.Label, Help: c.Help}
+	}
+	return models.NewQCChecklist(items, results, findings), nil
+}
+
 func wrapBatches(list []*models.Batch, currentUser *models.User) ([]*Batch, error) {
 	var err error
 	var batches = make([]*Batch, len(list))
@@ -85,6 +142,63 @@ func (b *Batch) ReadyForFlaggingIssues() bool {
 	return b.Status == models.BatchStatusQCFlagIssues
 }
 
+// IssuesAwaitingCorrection returns the issues of a batch being corrected which
+// aren't yet ready to be rebuilt into the new version
+func (b *Batch) IssuesAwaitingCorrection() []*models.Issue {
+	var list []*models.Issue
+	for _, i := range b.Issues {
+		if i.WorkflowStep != schema.WSReadyForBatching {
+			list = append(list, i)
+		}
+	}
+	return list
+}
+
+// AwaitingReplacedArchive is true if the batch is a corrected version which
+// can't go live yet, because the batch it replaces hasn't been flagged as
+// archived. Going live removes the old version's live files, so they must be
+// archived first.
+func (b *Batch) AwaitingReplacedArchive() bool {
+	return b.ReplacesBatch != nil && !b.ReplacesBatch.Archived()
+}
+
+// ReadyForRebuild is true if the batch is a corrected version whose issues
+// are all fixed and ready to be built
+func (b *Batch) ReadyForRebuild() bool {
+	return b.Status == models.BatchStatusCorrecting && len(b.Issues) > 0 && len(b.IssuesAwaitingCorrection()) == 0
+}
+
+// ValidationFailed is true if the batch is still being built and its most
+// recent validation found problems, meaning its build is stopped until
+// someone fixes them
+func (b *Batch) ValidationFailed() bool {
+	return b.Status == models.BatchStatusPending && len(b.Validations) > 0 && !b.Validations[0].OK()
+}
+
+// ShowQCChecklist is true if the batch is in QC or has results recorded for
+// its current QC round
+func (b *Batch) ShowQCChecklist() bool {
+	if b.ReadyForQC() || b.ReadyForFlaggingIssues() {
+		return true
+	}
+	for _, item := range b.QCChecklist.Items {
+		if item.Result != nil || len(item.Findings) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// FindingIssue returns the batch's issue a QC finding refers to
+func (b *Batch) FindingIssue(f *models.BatchQCFinding) *models.Issue {
+	for _, i := range b.Issues {
+		if i.ID == f.IssueID {
+			return i
+		}
+	}
+	return nil
+}
+
 // Can returns our CanValidation data for the currently logged in user and this
 // batch so we aren't asking for globals in the HTML template just to check
 // permissions
@@ -111,9 +225,38 @@ func (b *Batch) Actions() []string {
 		actions = append(actions, "archive")
 	}
 
+	if b.Can().Correct() {
+		actions = append(actions, "correct")
+	}
+
+	if b.Can().Rebuild() {
+		actions = append(actions, "rebuild")
+	}
+
+	if b.Can().Unbatch() {
+		actions = append(actions, "unbatch")
+	}
+
 	if len(actions) == 0 {
 		actions = append(actions, "none")
 	}
 
 	return actions
 }
+
+// FixityProblems returns the most recent fixity check of each copy of the
+// batch, if that check didn't pass
+func (b *Batch) FixityProblems() []*models.BatchFixityCheck {
+	var seen = make(map[string]bool)
+	var list []*models.BatchFixityCheck
+	for _, c := range b.FixityChecks {
+		if seen[c.Copy] {
+			continue
+		}
+		seen[c.Copy] = true
+		if !c.OK() {
+			list = append(list, c)
+		}
+	}
+	return list
+}
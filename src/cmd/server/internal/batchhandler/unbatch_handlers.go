@@ -0,0 +1,85 @@
+package batchhandler
+
+import (
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/internal/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/cmd/server/internal/responder"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/jobs"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+)
+
+// prepUnbatching verifies the user can unbatch the requested batch and reads
+// its jobs so the form can say what will happen, returning the responder if
+// all went well
+func prepUnbatching(w http.ResponseWriter, req *http.Request) (r *Responder, ok bool) {
+	r, ok = getBatchResponder(w, req)
+	if !ok {
+		return r, false
+	}
+	if !r.batch.Can().Unbatch() {
+		r.Error(http.StatusForbidden, "You are not permitted to unbatch this batch")
+		return r, false
+	}
+
+	var summary, err = r.batch.JobSummary()
+	var oniJobs []*models.Job
+	if err == nil {
+		oniJobs, err = models.FindBatchONIJobs(r.batch.ID)
+	}
+	if err != nil {
+		logger.Errorf("Unable to read jobs for batch %d (%s): %s", r.batch.ID, r.batch.FullName, err)
+		r.Error(http.StatusInternalServerError, "Error reading the batch's jobs. Try again or contact support.")
+		return r, false
+	}
+
+	r.Vars.Title = "Unbatching " + r.batch.Name
+	r.Vars.Data["Jobs"] = summary
+	r.Vars.Data["ONIChoices"] = purgeChoices(r.batch, oniJobs)
+	return r, true
+}
+
+func unbatchFormHandler(w http.ResponseWriter, req *http.Request) {
+	var r, ok = prepUnbatching(w, req)
+	if ok {
+		r.Render(unbatchFormTmpl)
+	}
+}
+
+// unbatchHandler queues the jobs to take the batch apart, as long as nothing
+// is still running on it
+func unbatchHandler(w http.ResponseWriter, req *http.Request) {
+	var r, ok = prepUnbatching(w, req)
+	if !ok {
+		return
+	}
+
+	var err = req.ParseForm()
+	if err != nil {
+		logger.Errorf("Unable to read form in unbatchHandler: %s", err)
+		r.Error(http.StatusInternalServerError, "Error processing submission. Try again or contact support.")
+		return
+	}
+
+	var reason = strings.TrimSpace(req.Form.Get("reason"))
+	r.Vars.Data["Reason"] = reason
+	if reason == "" {
+		r.Vars.Alert = template.HTML("You must explain why the batch is being unbatched.")
+		r.Render(unbatchFormTmpl)
+		return
+	}
+
+	var purge = r.Vars.Data["ONIChoices"].(*responder.ONIChoices).Read(req)
+	err = jobs.QueueUnbatch(r.batch.Batch, r.Vars.User.ID, reason, purge, conf)
+	if err != nil {
+		logger.Errorf("Unable to queue unbatch of batch %d (%s): %s", r.batch.ID, r.batch.FullName, err)
+		r.Vars.Alert = template.HTML("Unable to queue the unbatch jobs. Try again or contact support.")
+		r.Render(unbatchFormTmpl)
+		return
+	}
+
+	http.SetCookie(r.Writer, &http.Cookie{Name: "Info", Value: r.batch.Name + ": queued for unbatching", Path: "/"})
+	http.Redirect(w, req, basePath, http.StatusFound)
+}
@@ -190,6 +190,7 @@ func getGenerateFormQueues(w http.ResponseWriter, req *http.Request) (r *respond
 	r.Vars.Data["Queues"] = queues
 	r.Vars.Data["MaxPages"] = maxpages
 	r.Vars.Data["MOCIssueAggregations"] = aggs
+	r.Vars.Data["ONIChoices"] = responder.NewONIChoices(conf, "oni_env", "Load the new batches into:", []string{string(config.ONIRoleStaging)})
 
 	return r, queues, false
 }
@@ -215,6 +216,14 @@ func generateBatches(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	var envs = r.Vars.Data["ONIChoices"].(*responder.ONIChoices).Read(req)
+	if len(envs) == 0 {
+		r.Vars.Title = "Generate Batches?"
+		r.Vars.Alert = template.HTML("You must choose at least one ONI environment to load the new batches into.")
+		r.Render(showGenerateFormTmpl)
+		return
+	}
+
 	var batches []*models.Batch
 	for _, next := range queues {
 		var dbIssues = next.Queue.DBIssues()
@@ -224,7 +233,7 @@ func generateBatches(w http.ResponseWriter, req *http.Request) {
 			r.Error(http.StatusInternalServerError, "Error processing request - try again or contact support")
 			return
 		}
-		err = jobs.QueueMakeBatch(batch, conf)
+		err = jobs.QueueMakeBatch(batch, conf, envs...)
 		if err != nil {
 			logger.Errorf("Unable to queue batch %d (%q): %s", batch.ID, batch.FullName, err)
 			logger.Errorf("Batch %d (%q) will likely need to be manually fixed in the database!", batch.ID, batch.FullName)
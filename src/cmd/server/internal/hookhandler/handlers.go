@@ -0,0 +1,148 @@
+// Package hookhandler receives notifications from external services. These
+// requests come from machines rather than people, so they're authenticated
+// with a shared secret instead of Apache's user login.
+package hookhandler
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/uoregon-libraries/newspaper-curation-app/internal/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/issuefinder"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/issuewatcher"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+)
+
+var (
+	conf    *config.Config
+	watcher *issuewatcher.Watcher
+)
+
+// maxEventSize caps the request body we'll read: SFTPGo events are small
+// JSON documents, so anything large is not something we want
+const maxEventSize = 64 * 1024
+
+// Setup sets up all the routing rules and other configuration
+func Setup(r *mux.Router, baseWebPath string, c *config.Config, w *issuewatcher.Watcher) {
+	conf = c
+	watcher = w
+	var s = r.PathPrefix(baseWebPath).Subrouter()
+	s.Path("/sftpgo-upload").Methods("POST").Handler(mustHaveToken(sftpgoUploadHandler))
+}
+
+// mustHaveToken rejects requests which don't carry the configured hook
+// token as a bearer token. The token is never accepted in the URL, where it
+// would end up in access logs. If no token is configured, hooks are disabled
+// entirely.
+func mustHaveToken(f http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if conf.SFTPGoHookToken == "" {
+			http.NotFound(w, req)
+			return
+		}
+
+		var token, ok = strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(conf.SFTPGoHookToken)) != 1 {
+			logger.Warnf("Rejected hook request from %q: invalid token", req.RemoteAddr)
+			writeJSON(w, http.StatusUnauthorized, "invalid token")
+			return
+		}
+
+		f(w, req)
+	})
+}
+
+// sftpgoEvent holds the parts of an SFTPGo filesystem event we care about
+type sftpgoEvent struct {
+	Action      string `json:"action"`
+	Username    string `json:"username"`
+	Path        string `json:"path"`
+	VirtualPath string `json:"virtual_path"`
+	FileSize    int64  `json:"file_size"`
+	Status      int    `json:"status"`
+	Protocol    string `json:"protocol"`
+}
+
+// succeeded returns true if SFTPGo says the action completed. Status is 1 on
+// success; older SFTPGo versions don't send it, leaving it zero.
+func (e *sftpgoEvent) succeeded() bool {
+	return e.Status == 0 || e.Status == 1
+}
+
+// writeJSON sends a short JSON status response
+func writeJSON(w http.ResponseWriter, code int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]string{"status": http.StatusText(code), "message": msg})
+}
+
+// sftpgoUploadHandler records a publisher's completed upload and asks the
+// issue watcher to rescan that publisher's title, so new issues show up
+// without waiting for the next full scan
+func sftpgoUploadHandler(w http.ResponseWriter, req *http.Request) {
+	var ev sftpgoEvent
+	var err = json.NewDecoder(http.MaxBytesReader(w, req.Body, maxEventSize)).Decode(&ev)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, "invalid event: "+err.Error())
+		return
+	}
+
+	// SFTPGo can be configured to send more than uploads, and failed uploads
+	// aren't activity we want to record
+	if ev.Action != "upload" || !ev.succeeded() {
+		writeJSON(w, http.StatusOK, "event ignored")
+		return
+	}
+
+	// Titles without an SFTP account have an empty username, so an event
+	// without a username must not be looked up
+	if ev.Username == "" {
+		writeJSON(w, http.StatusBadRequest, "invalid event: no username")
+		return
+	}
+
+	var t *models.Title
+	t, err = models.FindTitleBySFTPUser(ev.Username)
+	if err != nil {
+		logger.Errorf("Unable to look up title for SFTP user %q: %s", ev.Username, err)
+		writeJSON(w, http.StatusInternalServerError, "unable to look up title")
+		return
+	}
+	if t.ID == 0 {
+		logger.Warnf("Received upload event for SFTP user %q, who has no title", ev.Username)
+		writeJSON(w, http.StatusNotFound, "no title for user")
+		return
+	}
+
+	var path = ev.VirtualPath
+	if path == "" {
+		path = ev.Path
+	}
+	var u = &models.TitleUpload{
+		TitleID:    t.ID,
+		Path:       path,
+		Size:       ev.FileSize,
+		Protocol:   ev.Protocol,
+		ReceivedAt: time.Now(),
+	}
+	err = u.Save()
+	if err != nil {
+		logger.Errorf("Unable to record upload %q for title %q: %s", path, t.LCCN, err)
+		writeJSON(w, http.StatusInternalServerError, "unable to record upload")
+		return
+	}
+
+	watcher.RequestRefresh(issuewatcher.TitleDir{
+		Namespace: issuefinder.SFTPUpload,
+		Path:      filepath.Join(conf.PDFUploadPath, t.SFTPUser),
+	})
+
+	logger.Infof("Received upload %q (%d bytes) for title %q", path, ev.FileSize, t.LCCN)
+	writeJSON(w, http.StatusAccepted, "upload recorded")
+}
@@ -10,12 +10,15 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/uoregon-libraries/newspaper-curation-app/internal/logger"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/cmd/server/internal/responder"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/jobs"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/web/tmpl"
 )
 
 var (
 	basePath string
+	conf     *config.Config
 
 	// layout is the base template, cloned from the responder's layout, from
 	// which all subpages are built
@@ -29,7 +32,8 @@ var (
 )
 
 // Setup sets up all the routing rules and other configuration
-func Setup(r *mux.Router, baseWebPath string) {
+func Setup(r *mux.Router, baseWebPath string, c *config.Config) {
+	conf = c
 	basePath = baseWebPath
 	var s = r.PathPrefix(basePath).Subrouter()
 	s.Path("").Handler(canView(listHandler))
@@ -96,6 +100,7 @@ func createMOC(r *responder.Responder) {
 		return
 	}
 
+	queueSync(moc)
 	r.Audit(models.AuditActionCreateMoc, code)
 	http.SetCookie(r.Writer, &http.Cookie{Name: "Info", Value: "New MOC created", Path: "/"})
 	http.Redirect(r.Writer, r.Request, basePath, http.StatusFound)
@@ -123,6 +128,7 @@ func updateMOC(r *responder.Responder) {
 		return
 	}
 
+	queueSync(moc)
 	r.Audit(models.AuditActionUpdateMoc, fmt.Sprintf("previous: %#v, new: %#v", oldMOC, moc))
 	http.SetCookie(r.Writer, &http.Cookie{Name: "Info", Value: "MOC updated", Path: "/"})
 	http.Redirect(r.Writer, r.Request, basePath, http.StatusFound)
@@ -160,6 +166,15 @@ func editHandler(w http.ResponseWriter, req *http.Request) {
 	r.Render(formTmpl)
 }
 
+// queueSync queues jobs to create or verify the MOC's awardee in every ONI
+// environment. The MOC is already saved, so failures are only logged.
+func queueSync(moc *models.MOC) {
+	var err = jobs.QueueMOCSync(moc, conf)
+	if err != nil {
+		logger.Errorf("Unable to queue ONI sync for MOC %q: %s", moc.Code, err)
+	}
+}
+
 func getMOC(r *responder.Responder) (moc *models.MOC, handled bool) {
 	var idStr = r.Request.FormValue("id")
 	var id, _ = strconv.ParseInt(idStr, 10, 64)
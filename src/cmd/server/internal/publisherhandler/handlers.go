@@ -0,0 +1,184 @@
+// Package publisherhandler is the publisher upload portal: a browser-based
+// alternative to SFTP for delivering born-digital PDFs. Files are uploaded in
+// resumable chunks and land in the same PDF_UPLOAD_PATH/<title>/<date>
+// layout SFTP uploads use, so the rest of NCA treats them identically.
+package publisherhandler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+
+	"github.com/gorilla/mux"
+	"github.com/uoregon-libraries/newspaper-curation-app/internal/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/cmd/server/internal/responder"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/issuewatcher"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/uploads"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/web/tmpl"
+)
+
+// chunkSize is how much data the browser sends per request. It's small
+// enough that a dropped connection loses little work, and large enough that
+// request overhead doesn't dominate.
+const chunkSize = 4 << 20
+
+var (
+	basePath string
+	conf     *config.Config
+	watcher  *issuewatcher.Watcher
+
+	// layout is the base template, cloned from the responder's layout, from
+	// which all subpages are built
+	layout *tmpl.TRoot
+
+	// uploadTmpl is the upload form
+	uploadTmpl *tmpl.Template
+)
+
+// Setup sets up all the routing rules and other configuration
+func Setup(r *mux.Router, baseWebPath string, c *config.Config, w *issuewatcher.Watcher) {
+	conf = c
+	watcher = w
+	basePath = baseWebPath
+	var s = r.PathPrefix(basePath).Subrouter()
+	s.Path("").Handler(canUpload(homeHandler))
+	s.Path("/upload").Methods("GET").Handler(canUpload(statusHandler))
+	s.Path("/upload").Methods("POST").Handler(canUpload(chunkHandler))
+	s.Path("/issue").Methods("GET").Handler(canUpload(issueHandler))
+
+	layout = responder.Layout.Clone()
+	layout.Funcs(tmpl.FuncMap{
+		"PublisherHomeURL": func() string { return basePath },
+		"ChunkSize":        func() int { return chunkSize },
+	})
+	layout.Path = path.Join(layout.Path, "publisher")
+	uploadTmpl = layout.MustBuild("upload.go.html")
+}
+
+// homeHandler shows the upload form
+func homeHandler(w http.ResponseWriter, req *http.Request) {
+	var r = responder.Response(w, req)
+	r.Vars.Title = "Upload Issues"
+
+	var titles, err = uploadableTitles(r.Vars.User)
+	if err != nil {
+		logger.Errorf("Unable to load titles for publisher upload form: %s", err)
+		r.Error(http.StatusInternalServerError, "Error trying to load titles; try again or contact support")
+		return
+	}
+
+	r.Vars.Data["Titles"] = titles
+	r.Render(uploadTmpl)
+}
+
+// jsonResponse is what the upload and issue endpoints send back to the
+// browser
+type jsonResponse struct {
+	Code     int          `json:"code"`
+	Message  string       `json:"message,omitempty"`
+	Offset   int64        `json:"offset"`
+	Complete bool         `json:"complete"`
+	Issue    *issueStatus `json:"issue,omitempty"`
+}
+
+func writeJSON(w http.ResponseWriter, response *jsonResponse) {
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(response.Code)
+	var data, err = json.Marshal(response)
+	if err != nil {
+		logger.CriticalFixNeeded(fmt.Sprintf("Unable to marshal publisher upload JSON %#v", response), err)
+		data = []byte(`{"code": 500, "message": "Internal error"}`)
+	}
+	w.Write(data)
+}
+
+// statusHandler tells the browser how much of a file has been received, so
+// an interrupted upload can pick up where it left off
+func statusHandler(w http.ResponseWriter, req *http.Request) {
+	var r = responder.Response(w, req)
+	var ur, err = parseUploadRequest(r)
+	if err != nil {
+		writeJSON(w, &jsonResponse{Code: http.StatusBadRequest, Message: err.Error()})
+		return
+	}
+
+	var lock = ur.lock()
+	defer lock.Unlock()
+
+	var cf = ur.chunkedFile()
+	var offset int64
+	offset, err = cf.Offset()
+	if err != nil {
+		logger.Errorf("Unable to read staged upload %q: %s", cf.PartPath, err)
+		writeJSON(w, &jsonResponse{Code: http.StatusInternalServerError, Message: "Unable to read upload status"})
+		return
+	}
+	writeJSON(w, &jsonResponse{Code: http.StatusOK, Offset: offset, Complete: ur.delivered()})
+}
+
+// chunkHandler appends a chunk of data to a staged upload, delivering the
+// file to the upload area once all its data has arrived
+func chunkHandler(w http.ResponseWriter, req *http.Request) {
+	var r = responder.Response(w, req)
+	var ur, err = parseUploadRequest(r)
+	if err != nil {
+		writeJSON(w, &jsonResponse{Code: http.StatusBadRequest, Message: err.Error()})
+		return
+	}
+
+	var lock = ur.lock()
+	defer lock.Unlock()
+
+	var cf = ur.chunkedFile()
+	var offset int64
+	offset, err = cf.WriteChunk(ur.offset, http.MaxBytesReader(w, req.Body, chunkSize))
+	var offErr *uploads.OffsetError
+	switch {
+	case errors.As(err, &offErr):
+		writeJSON(w, &jsonResponse{Code: http.StatusConflict, Message: err.Error(), Offset: offErr.Expected})
+		return
+	case errors.Is(err, uploads.ErrFileExists):
+		writeJSON(w, &jsonResponse{Code: http.StatusConflict, Message: err.Error(), Offset: offset, Complete: true})
+		return
+	case err != nil:
+		logger.Warnf("Unable to write chunk for %q (user %q): %s", cf.FinalPath, r.Vars.User.Login, err)
+		writeJSON(w, &jsonResponse{Code: http.StatusBadRequest, Message: "Unable to store upload: " + err.Error(), Offset: offset})
+		return
+	}
+
+	if offset < cf.Size {
+		writeJSON(w, &jsonResponse{Code: http.StatusOK, Offset: offset})
+		return
+	}
+
+	err = cf.Finish()
+	if errors.Is(err, uploads.ErrNotPDF) {
+		writeJSON(w, &jsonResponse{Code: http.StatusBadRequest, Message: fmt.Sprintf("%q is not a valid PDF", ur.filename)})
+		return
+	}
+	if err != nil {
+		logger.Errorf("Unable to deliver publisher upload %q: %s", cf.FinalPath, err)
+		writeJSON(w, &jsonResponse{Code: http.StatusInternalServerError, Message: "Unable to save the uploaded file; try again or contact support", Offset: offset})
+		return
+	}
+
+	r.Audit(models.AuditActionPublisherUpload, fmt.Sprintf("%s (%d bytes)", cf.FinalPath, cf.Size))
+	writeJSON(w, &jsonResponse{Code: http.StatusOK, Offset: offset, Complete: true, Issue: ur.issueStatus()})
+}
+
+// issueHandler reports on the files already uploaded for an issue and any
+// validation problems NCA sees with them
+func issueHandler(w http.ResponseWriter, req *http.Request) {
+	var r = responder.Response(w, req)
+	var ir, err = parseIssueRequest(r)
+	if err != nil {
+		writeJSON(w, &jsonResponse{Code: http.StatusBadRequest, Message: err.Error()})
+		return
+	}
+
+	writeJSON(w, &jsonResponse{Code: http.StatusOK, Issue: ir.issueStatus()})
+}
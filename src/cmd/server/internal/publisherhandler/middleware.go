@@ -0,0 +1,13 @@
+package publisherhandler
+
+import (
+	"net/http"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/cmd/server/internal/responder"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/privilege"
+)
+
+// canUpload verifies the user can use the publisher upload portal
+func canUpload(h http.HandlerFunc) http.Handler {
+	return responder.MustHavePrivilege(privilege.UploadPublisherIssues, h)
+}
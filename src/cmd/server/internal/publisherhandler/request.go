@@ -0,0 +1,206 @@
+package publisherhandler
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/uoregon-libraries/gopkg/fileutil"
+	"github.com/uoregon-libraries/newspaper-curation-app/internal/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/cmd/server/internal/responder"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/issuefinder"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/privilege"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/uploads"
+)
+
+// validFilename restricts uploaded filenames to a safe subset: no paths, no
+// hidden files, and always a PDF extension
+var validFilename = regexp.MustCompile(`(?i)^[a-z0-9][a-z0-9._-]*\.pdf$`)
+
+// fileLocks keeps two requests from writing to the same staged file at once,
+// which can happen when a browser retries a chunk it thinks timed out
+var fileLocks sync.Map
+
+// uploadableTitles returns the titles the user may upload issues for: those
+// whose SFTP username matches the user's login, or all titles with an SFTP
+// username for users who manage uploaded issues
+func uploadableTitles(u *models.User) (models.TitleList, error) {
+	var all, err = models.Titles()
+	if err != nil {
+		return nil, err
+	}
+
+	var list models.TitleList
+	var allTitles = u.PermittedTo(privilege.ModifyUploadedIssues)
+	for _, t := range all {
+		if t.SFTPUser != "" && (allTitles || t.SFTPUser == u.Login) {
+			list = append(list, t)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return strings.ToLower(list[i].Name) < strings.ToLower(list[j].Name) })
+
+	return list, nil
+}
+
+// issueRequest identifies a single issue directory in the upload area
+type issueRequest struct {
+	title *models.Title
+	date  string
+}
+
+// parseIssueRequest reads the title and issue date from the request, making
+// sure the user is allowed to upload to the title
+func parseIssueRequest(r *responder.Responder) (*issueRequest, error) {
+	var q = r.Request.URL.Query()
+	var titles, err = uploadableTitles(r.Vars.User)
+	if err != nil {
+		logger.Errorf("Unable to load titles for publisher upload: %s", err)
+		return nil, fmt.Errorf("unable to load titles; try again or contact support")
+	}
+
+	var ir = &issueRequest{title: titles.FindByLCCN(q.Get("lccn")), date: q.Get("date")}
+	if ir.title == nil {
+		return nil, fmt.Errorf("invalid title")
+	}
+
+	// We require dates to be exact: NCA's scanner would show a malformed date
+	// to curators as an error, but there's no reason to let it get that far
+	var dt time.Time
+	dt, err = time.Parse("2006-01-02", ir.date)
+	if err != nil || dt.Format("2006-01-02") != ir.date {
+		return nil, fmt.Errorf("issue date must be in YYYY-MM-DD format")
+	}
+
+	return ir, nil
+}
+
+// titlePath returns the title's directory in the upload area
+func (ir *issueRequest) titlePath() string {
+	return filepath.Join(conf.PDFUploadPath, ir.title.SFTPUser)
+}
+
+// issuePath returns the issue's directory in the upload area
+func (ir *issueRequest) issuePath() string {
+	return filepath.Join(ir.titlePath(), ir.date)
+}
+
+// uploadRequest identifies a single file within an issue as well as the
+// chunk of data being sent, if any
+type uploadRequest struct {
+	*issueRequest
+	filename string
+	size     int64
+	offset   int64
+}
+
+// parseUploadRequest reads and validates the request's file information
+func parseUploadRequest(r *responder.Responder) (*uploadRequest, error) {
+	var ir, err = parseIssueRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var q = r.Request.URL.Query()
+	var ur = &uploadRequest{issueRequest: ir, filename: q.Get("name")}
+	if !validFilename.MatchString(ur.filename) {
+		return nil, fmt.Errorf("invalid filename %q: only PDFs are accepted, and names may only contain letters, numbers, periods, dashes, and underscores", ur.filename)
+	}
+
+	ur.size, err = strconv.ParseInt(q.Get("size"), 10, 64)
+	if err != nil || ur.size < 1 {
+		return nil, fmt.Errorf("invalid file size")
+	}
+
+	if q.Get("offset") != "" {
+		ur.offset, err = strconv.ParseInt(q.Get("offset"), 10, 64)
+		if err != nil || ur.offset < 0 {
+			return nil, fmt.Errorf("invalid offset")
+		}
+	}
+
+	return ur, nil
+}
+
+// chunkedFile returns the staged upload this request refers to
+func (ur *uploadRequest) chunkedFile() *uploads.ChunkedFile {
+	var stagingDir = filepath.Join(conf.PublisherStagingPath, ur.title.SFTPUser, ur.date)
+	return uploads.NewChunkedFile(stagingDir, filepath.Join(ur.issuePath(), ur.filename), ur.size)
+}
+
+// delivered returns true if the file is already in the upload area
+func (ur *uploadRequest) delivered() bool {
+	return fileutil.Exists(filepath.Join(ur.issuePath(), ur.filename))
+}
+
+// lock acquires and returns the mutex for this request's file
+func (ur *uploadRequest) lock() *sync.Mutex {
+	var m, _ = fileLocks.LoadOrStore(filepath.Join(ur.issuePath(), ur.filename), &sync.Mutex{})
+	var mutex = m.(*sync.Mutex)
+	mutex.Lock()
+	return mutex
+}
+
+// fileStatus describes a file already delivered to an issue directory
+type fileStatus struct {
+	Name   string   `json:"name"`
+	Size   int64    `json:"size"`
+	Errors []string `json:"errors,omitempty"`
+}
+
+// issueStatus is the result of validating an issue directory
+type issueStatus struct {
+	Files    []*fileStatus `json:"files"`
+	Errors   []string      `json:"errors"`
+	Warnings []string      `json:"warnings"`
+}
+
+// issueStatus scans the issue's title directory and runs the uploaded-issue
+// validations against it. The age checks are skipped, since an issue which
+// is being uploaded is always "too new".
+func (ir *issueRequest) issueStatus() *issueStatus {
+	var status = &issueStatus{Files: []*fileStatus{}, Errors: []string{}, Warnings: []string{}}
+	if !fileutil.IsDir(ir.issuePath()) {
+		return status
+	}
+
+	var s, err = issuefinder.NewSearcher(issuefinder.SFTPUpload, conf.PDFUploadPath)
+	if err == nil {
+		err = s.FindSFTPIssuesForTitle(ir.titlePath(), conf.PDFBatchMARCOrgCode)
+	}
+	if err != nil {
+		logger.Errorf("Unable to scan %q for publisher upload validation: %s", ir.titlePath(), err)
+		status.Errors = append(status.Errors, "Unable to validate the issue; try again or contact support")
+		return status
+	}
+
+	for _, si := range s.Issues {
+		if si.Location != ir.issuePath() {
+			continue
+		}
+
+		var issue = uploads.New(si, watcher.Scanner, conf)
+		issue.ValidateContents()
+		for _, e := range issue.Errors.All() {
+			if e.Warning() {
+				status.Warnings = append(status.Warnings, e.Message())
+			} else {
+				status.Errors = append(status.Errors, e.Message())
+			}
+		}
+		for _, f := range issue.Files {
+			var fs = &fileStatus{Name: f.Name, Size: f.Size}
+			for _, e := range f.Errors.All() {
+				fs.Errors = append(fs.Errors, e.Message())
+			}
+			status.Files = append(status.Files, fs)
+		}
+	}
+
+	return status
+}
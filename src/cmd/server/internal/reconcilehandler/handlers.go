@@ -0,0 +1,119 @@
+package reconcilehandler
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/uoregon-libraries/newspaper-curation-app/internal/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/cmd/server/internal/responder"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/jobs"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/privilege"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/reconcile"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/web/tmpl"
+)
+
+var (
+	basePath string
+	conf     *config.Config
+
+	// layout is the base template, cloned from the responder's layout, from
+	// which all subpages are built
+	layout *tmpl.TRoot
+
+	// reportTmpl shows all discrepancies and the actions which can fix them
+	reportTmpl *tmpl.Template
+)
+
+// canReconcile is middleware to verify the user can view and act on the
+// reconciliation report
+func canReconcile(h http.HandlerFunc) http.Handler {
+	return responder.MustHavePrivilege(privilege.ReconcileBatches, h)
+}
+
+// Setup sets up all the routing rules and other configuration
+func Setup(r *mux.Router, baseWebPath string, c *config.Config) {
+	conf = c
+	basePath = baseWebPath
+	var s = r.PathPrefix(basePath).Subrouter()
+	s.Path("").Handler(canReconcile(reportHandler))
+	s.Path("/requeue").Methods("POST").Handler(canReconcile(requeueHandler))
+
+	layout = responder.Layout.Clone()
+	layout.Funcs(tmpl.FuncMap{"ReconcileHomeURL": func() string { return basePath }})
+	layout.Path = path.Join(layout.Path, "reconcile")
+
+	reportTmpl = layout.MustBuild("report.go.html")
+}
+
+// reportHandler compares NCA to every ONI environment and shows the results
+func reportHandler(w http.ResponseWriter, req *http.Request) {
+	var r = responder.Response(w, req)
+	r.Vars.Title = "Reconcile batches with ONI"
+
+	var report, err = reconcile.Run(conf)
+	if err != nil {
+		logger.Errorf("Unable to generate reconciliation report: %s", err)
+		r.Error(http.StatusInternalServerError, "Error comparing NCA with ONI - an ONI site may be down. Try again or contact support.")
+		return
+	}
+
+	r.Vars.Data["Report"] = report
+	r.Render(reportTmpl)
+}
+
+// requeueHandler queues a load or purge for a single discrepancy. The
+// environment is checked again first, so a stale page can't queue jobs for a
+// problem that's already been fixed.
+func requeueHandler(w http.ResponseWriter, req *http.Request) {
+	var r = responder.Response(w, req)
+
+	var env = conf.ONIEnvironment(req.FormValue("environment"))
+	if env == nil {
+		r.Error(http.StatusBadRequest, "Invalid ONI environment - try again or contact support")
+		return
+	}
+	var batchID, _ = strconv.ParseInt(req.FormValue("batch_id"), 10, 64)
+	var oniName = req.FormValue("oni_name")
+	var action = req.FormValue("action")
+
+	var list, err = reconcile.ForEnvironment(env)
+	if err != nil {
+		logger.Errorf("Unable to reconcile ONI environment %q: %s", env.Name, err)
+		r.Error(http.StatusInternalServerError, "Error comparing NCA with ONI - the ONI site may be down. Try again or contact support.")
+		return
+	}
+
+	var d *reconcile.Discrepancy
+	for _, d2 := range list {
+		if d2.Batch != nil && d2.Batch.ID == batchID && d2.ONIName == oniName && d2.Action() == action {
+			d = d2
+		}
+	}
+	if d == nil {
+		http.SetCookie(w, &http.Cookie{Name: "Alert", Value: "That problem no longer exists - no jobs were queued", Path: "/"})
+		http.Redirect(w, req, basePath, http.StatusFound)
+		return
+	}
+
+	switch action {
+	case "load":
+		err = jobs.QueueReconcileLoad(d.Batch, env)
+	case "purge":
+		err = jobs.QueueReconcilePurge(d.Batch, env, oniName)
+	}
+	if err != nil {
+		logger.Errorf("Unable to queue %s of %q on %s: %s", action, oniName, env.Name, err)
+		r.Error(http.StatusInternalServerError, "Error queueing jobs - try again or contact support")
+		return
+	}
+
+	r.Audit(models.AuditActionReconcileBatch, fmt.Sprintf("%s %q on %s", action, oniName, env.Name))
+	var msg = fmt.Sprintf("Queued %s of %s on %s", action, oniName, env.Name)
+	http.SetCookie(w, &http.Cookie{Name: "Info", Value: msg, Path: "/"})
+	http.Redirect(w, req, basePath, http.StatusFound)
+}
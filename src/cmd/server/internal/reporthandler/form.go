@@ -0,0 +1,104 @@
+package reporthandler
+
+import (
+	"fmt"
+	"html/template"
+	"net/url"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/cmd/server/internal/responder"
+)
+
+// form holds the report's date range filters
+type form struct {
+	PresetDate  string
+	StartString string
+	EndString   string
+	Start       time.Time
+	End         time.Time
+}
+
+// getForm reads the date range from the request, defaulting to the past
+// twelve months since that's what most funder reports need
+func getForm(r *responder.Responder) *form {
+	var vfn = r.Request.FormValue
+	var f = &form{
+		PresetDate:  vfn("preset-date"),
+		StartString: vfn("custom-date-start"),
+		EndString:   vfn("custom-date-end"),
+	}
+	r.Vars.Data["Form"] = f
+
+	if f.PresetDate == "custom" {
+		var err = f.parseCustomDate()
+		if err == nil {
+			return f
+		}
+		r.Vars.Alert = template.HTML("Invalid date range: " + err.Error())
+		f.PresetDate = ""
+	}
+
+	f.applyPreset(time.Now())
+	return f
+}
+
+// applyPreset fills in start and end dates for preset ranges. End dates are
+// exclusive, so most ranges end at the start of tomorrow.
+func (f *form) applyPreset(now time.Time) {
+	var y, m, d = now.Date()
+	var loc = now.Location()
+	f.End = time.Date(y, m, d+1, 0, 0, 0, 0, loc)
+
+	switch f.PresetDate {
+	case "ytd":
+		f.Start = time.Date(y, 1, 1, 0, 0, 0, 0, loc)
+	case "lastmonth":
+		f.Start = time.Date(y, m-1, 1, 0, 0, 0, 0, loc)
+		f.End = time.Date(y, m, 1, 0, 0, 0, 0, loc)
+	case "thismonth":
+		f.Start = time.Date(y, m, 1, 0, 0, 0, 0, loc)
+	case "all":
+		f.Start = time.Date(2010, 1, 1, 0, 0, 0, 0, loc)
+	default:
+		f.PresetDate = "past12m"
+		f.Start = time.Date(y-1, m, 1, 0, 0, 0, 0, loc)
+	}
+
+	// Make sure the custom dates are helpful if the user wants to switch from a
+	// preset to custom. The end date is shown inclusively, hence the day before.
+	f.StartString = f.Start.Format("2006-01-02")
+	f.EndString = f.End.AddDate(0, 0, -1).Format("2006-01-02")
+}
+
+// QueryString encodes the form values for reuse in an href
+func (f *form) QueryString() template.URL {
+	var v = url.Values{}
+	v.Set("preset-date", f.PresetDate)
+	if f.PresetDate == "custom" {
+		v.Set("custom-date-start", f.StartString)
+		v.Set("custom-date-end", f.EndString)
+	}
+	return template.URL(v.Encode())
+}
+
+// parseCustomDate reads the custom start and end dates. The end date is
+// inclusive for users, so we store the start of the following day.
+func (f *form) parseCustomDate() error {
+	var err error
+	f.Start, err = time.ParseInLocation("2006-01-02", f.StartString, time.Local)
+	if err != nil {
+		return fmt.Errorf("start date is missing or invalid")
+	}
+
+	f.End, err = time.ParseInLocation("2006-01-02", f.EndString, time.Local)
+	if err != nil {
+		return fmt.Errorf("end date is missing or invalid")
+	}
+
+	if f.End.Before(f.Start) {
+		return fmt.Errorf("start must come before end")
+	}
+
+	f.End = f.End.AddDate(0, 0, 1)
+	return nil
+}
@@ -0,0 +1,222 @@
+package reporthandler
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/uoregon-libraries/newspaper-curation-app/internal/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/cmd/server/internal/responder"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/privilege"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/throughput"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/web/tmpl"
+)
+
+var (
+	basePath string
+
+	// layout is the base template, cloned from the responder's layout, from
+	// which all subpages are built
+	layout *tmpl.TRoot
+
+	// throughputTmpl is the template which shows the productivity report
+	throughputTmpl *tmpl.Template
+)
+
+// canView is middleware to verify the user can view productivity reports
+func canView(h http.HandlerFunc) http.Handler {
+	return responder.MustHavePrivilege(privilege.ViewProductivityReports, h)
+}
+
+// Setup sets up all the routing rules and other configuration
+func Setup(r *mux.Router, baseWebPath string) {
+	basePath = baseWebPath
+	var s = r.PathPrefix(basePath).Subrouter()
+	s.Path("").Handler(canView(throughputHandler))
+	s.Path("/csv/{report}").Handler(canView(csvHandler))
+
+	layout = responder.Layout.Clone()
+	layout.Funcs(tmpl.FuncMap{
+		"ReportsHomeURL": func() string { return basePath },
+		"days":           days,
+		"pct":            func(f float64) string { return fmt.Sprintf("%.1f%%", f) },
+	})
+	layout.Path = path.Join(layout.Path, "reports")
+	layout.MustReadPartials("_stats.go.html")
+
+	throughputTmpl = layout.MustBuild("throughput.go.html")
+}
+
+// days formats a duration as a number of days for display, or a dash if
+// there was no data
+func days(d time.Duration) string {
+	if d == 0 {
+		return "-"
+	}
+	return strconv.FormatFloat(d.Hours()/24, 'f', 1, 64)
+}
+
+// loadReport computes the report for the requested time range, rendering an
+// error and returning nil on failure
+func loadReport(r *responder.Responder, f *form) *throughput.Report {
+	var report, err = throughput.Load(f.Start, f.End)
+	if err != nil {
+		logger.Errorf("Unable to compute throughput report: %s", err)
+		r.Error(http.StatusInternalServerError, "Error trying to compute report - try again or contact support")
+		return nil
+	}
+	return report
+}
+
+// throughputHandler shows the curation/review productivity report
+func throughputHandler(w http.ResponseWriter, req *http.Request) {
+	var r = responder.Response(w, req)
+	var f = getForm(r)
+	var report = loadReport(r, f)
+	if report == nil {
+		return
+	}
+
+	r.Vars.Title = "Curation and Review Throughput"
+	r.Vars.Data["Report"] = report
+	r.Render(throughputTmpl)
+}
+
+// statsHeaders are the CSV headers shared by all reports
+var statsHeaders = []string{
+	"Issues Curated", "Pages Curated", "Curations Rejected", "Curation Rejection Rate",
+	"Issues Approved", "Issues Rejected", "Pages Reviewed", "Review Rejection Rate",
+	"Avg Days Awaiting Entry", "Median Days Awaiting Entry",
+	"Avg Days Awaiting Review", "Median Days Awaiting Review",
+}
+
+// statsRow returns the CSV cells for a single set of stats
+func statsRow(s *throughput.Stats) []string {
+	var i = strconv.Itoa
+	var f = func(v float64) string { return strconv.FormatFloat(v, 'f', 1, 64) }
+	var d = func(v time.Duration) string { return f(v.Hours() / 24) }
+	return []string{
+		i(s.IssuesCurated), i(s.PagesCurated), i(s.CurationsReject), f(s.CurationRejectionRate()),
+		i(s.IssuesApproved), i(s.IssuesRejected), i(s.PagesReviewed), f(s.RejectionRate()),
+		d(s.AverageEntryWait()), d(s.MedianEntryWait()),
+		d(s.AverageReviewWait()), d(s.MedianReviewWait()),
+	}
+}
+
+// csvHandler streams one of the report's tables as a CSV file
+func csvHandler(w http.ResponseWriter, req *http.Request) {
+	var r = responder.Response(w, req)
+	var which = mux.Vars(req)["report"]
+	if which == "qc" {
+		qcCSV(r)
+		return
+	}
+	if which != "monthly" && which != "users" && which != "titles" {
+		r.Error(http.StatusNotFound, "Unknown report")
+		return
+	}
+
+	var f = getForm(r)
+	var report = loadReport(r, f)
+	if report == nil {
+		return
+	}
+
+	var fname = fmt.Sprintf("throughput-%s-%s-%s.csv", which, f.StartString, f.EndString)
+	w.Header().Add("Content-Type", "text/csv")
+	w.Header().Add("Content-Disposition", `attachment; filename="`+fname+`"`)
+	var cw = csv.NewWriter(w)
+
+	switch which {
+	case "monthly":
+		cw.Write(append([]string{"Month"}, statsHeaders...))
+		for _, m := range report.Months {
+			cw.Write(append([]string{m.Label()}, statsRow(&m.Stats)...))
+		}
+		cw.Write(append([]string{"Total"}, statsRow(&report.Totals)...))
+	case "users":
+		cw.Write(append([]string{"User"}, statsHeaders...))
+		for _, u := range report.Users {
+			cw.Write(append([]string{u.Login}, statsRow(&u.Stats)...))
+		}
+	case "titles":
+		cw.Write(append([]string{"LCCN", "Title"}, statsHeaders...))
+		for _, t := range report.Titles {
+			cw.Write(append([]string{t.LCCN, t.Name}, statsRow(&t.Stats)...))
+		}
+	}
+	cw.Flush()
+}
+
+// qcCSV streams all batch QC checklist results and findings recorded in the
+// requested date range. Results and findings share columns so the file can
+// be sorted and filtered by batch or check as a single list.
+func qcCSV(r *responder.Responder) {
+	var f = getForm(r)
+	var results, err = models.QCResultsBetween(f.Start, f.End)
+	var findings []*models.BatchQCFinding
+	if err == nil {
+		findings, err = models.QCFindingsBetween(f.Start, f.End)
+	}
+	if err != nil {
+		logger.Errorf("Unable to read batch QC data: %s", err)
+		r.Error(http.StatusInternalServerError, "Error trying to read QC results - try again or contact support")
+		return
+	}
+
+	var batchNames = make(map[int64]string)
+	var batchName = func(id int64) string {
+		var name, ok = batchNames[id]
+		if !ok {
+			var b, err = models.FindBatch(id)
+			if err != nil {
+				logger.Warnf("Unable to look up batch %d for QC report: %s", id, err)
+			}
+			name = fmt.Sprintf("batch %d", id)
+			if b != nil {
+				name = b.FullName
+			}
+			batchNames[id] = name
+		}
+		return name
+	}
+	var userLogin = func(id int64) string {
+		var u = models.FindUserByID(id)
+		if u == nil {
+			return ""
+		}
+		return u.Login
+	}
+
+	var fname = fmt.Sprintf("batch-qc-%s-%s.csv", f.StartString, f.EndString)
+	r.Writer.Header().Add("Content-Type", "text/csv")
+	r.Writer.Header().Add("Content-Disposition", `attachment; filename="`+fname+`"`)
+	var cw = csv.NewWriter(r.Writer)
+	cw.Write([]string{"Batch", "QC Round", "Check", "Issue", "Result", "Notes", "User", "Recorded"})
+	for _, qr := range results {
+		cw.Write([]string{
+			batchName(qr.BatchID), strconv.Itoa(qr.QCRound), qr.CheckLabel, "", qr.Result, qr.Notes,
+			userLogin(qr.UserID), qr.UpdatedAt.Format(time.RFC3339),
+		})
+	}
+	for _, qf := range findings {
+		var key = fmt.Sprintf("issue %d", qf.IssueID)
+		var i, err = models.FindIssue(qf.IssueID)
+		if err != nil {
+			logger.Warnf("Unable to look up issue %d for QC report: %s", qf.IssueID, err)
+		}
+		if i != nil {
+			key = i.Key()
+		}
+		cw.Write([]string{
+			batchName(qf.BatchID), strconv.Itoa(qf.QCRound), qf.CheckName, key, "finding", qf.Note,
+			userLogin(qf.UserID), qf.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	cw.Flush()
+}
@@ -0,0 +1,54 @@
+package responder
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+)
+
+// ONIChoice is a single ONI environment on a form which loads a batch into
+// ONI or purges it
+type ONIChoice struct {
+	*config.ONIEnvironment
+	Checked bool
+}
+
+// ONIChoices is the data for the "oni-environment-choices" template: a
+// checkbox for each ONI environment, all submitted under the same field name
+type ONIChoices struct {
+	Field   string
+	Legend  string
+	Choices []*ONIChoice
+}
+
+// NewONIChoices returns a checkbox for every configured ONI environment. The
+// environments named in checked start out checked; role names stand for all
+// of the role's environments, as in [config.Config.ResolveONIEnvironments].
+// Unknown names are ignored.
+func NewONIChoices(c *config.Config, field, legend string, checked []string) *ONIChoices {
+	var on []string
+	for _, name := range checked {
+		var envs, _ = c.ResolveONIEnvironments([]string{name})
+		for _, env := range envs {
+			on = append(on, env.Name)
+		}
+	}
+
+	var choices = &ONIChoices{Field: field, Legend: legend}
+	for _, env := range c.ONIEnvironments {
+		choices.Choices = append(choices.Choices, &ONIChoice{ONIEnvironment: env, Checked: slices.Contains(on, env.Name)})
+	}
+	return choices
+}
+
+// Read replaces the checked states with those submitted on the form, and
+// returns the names of the environments which were checked. The request's
+// form must already be parsed.
+func (o *ONIChoices) Read(req *http.Request) []string {
+	var names = req.Form[o.Field]
+	for _, choice := range o.Choices {
+		choice.Checked = slices.Contains(names, choice.Name)
+	}
+	return names
+}
@@ -124,33 +124,40 @@ func InitRootTemplate(templatePath string) {
 
 		// We have functions for our privileges since they need to be "global" and
 		// easily verified at template compile time
-		"ListTitles":            func() *privilege.Privilege { return privilege.ListTitles },
-		"ModifyTitles":          func() *privilege.Privilege { return privilege.ModifyTitles },
-		"ManageMOCs":            func() *privilege.Privilege { return privilege.ManageMOCs },
-		"ViewMetadataWorkflow":  func() *privilege.Privilege { return privilege.ViewMetadataWorkflow },
-		"EnterIssueMetadata":    func() *privilege.Privilege { return privilege.EnterIssueMetadata },
-		"ReviewIssueMetadata":   func() *privilege.Privilege { return privilege.ReviewIssueMetadata },
-		"ReviewOwnMetadata":     func() *privilege.Privilege { return privilege.ReviewOwnMetadata },
-		"ReviewUnfixableIssues": func() *privilege.Privilege { return privilege.ReviewUnfixableIssues },
-		"ListUsers":             func() *privilege.Privilege { return privilege.ListUsers },
-		"ModifyUsers":           func() *privilege.Privilege { return privilege.ModifyUsers },
-		"ViewUploadedIssues":    func() *privilege.Privilege { return privilege.ViewUploadedIssues },
-		"ModifyUploadedIssues":  func() *privilege.Privilege { return privilege.ModifyUploadedIssues },
-		"SearchIssues":          func() *privilege.Privilege { return privilege.SearchIssues },
-		"GenerateBatches":       func() *privilege.Privilege { return privilege.GenerateBatches },
-		"ViewBatchStatus":       func() *privilege.Privilege { return privilege.ViewBatchStatus },
-		"ViewQCReadyBatches":    func() *privilege.Privilege { return privilege.ViewQCReadyBatches },
-		"ApproveQCReadyBatches": func() *privilege.Privilege { return privilege.ApproveQCReadyBatches },
-		"RejectQCReadyBatches":  func() *privilege.Privilege { return privilege.RejectQCReadyBatches },
-		"ArchiveBatches":        func() *privilege.Privilege { return privilege.ArchiveBatches },
-		"ModifyValidatedLCCNs":  func() *privilege.Privilege { return privilege.ModifyValidatedLCCNs },
-		"ListAuditLogs":         func() *privilege.Privilege { return privilege.ListAuditLogs },
+		"ListTitles":              func() *privilege.Privilege { return privilege.ListTitles },
+		"ModifyTitles":            func() *privilege.Privilege { return privilege.ModifyTitles },
+		"ManageMOCs":              func() *privilege.Privilege { return privilege.ManageMOCs },
+		"ViewMetadataWorkflow":    func() *privilege.Privilege { return privilege.ViewMetadataWorkflow },
+		"EnterIssueMetadata":      func() *privilege.Privilege { return privilege.EnterIssueMetadata },
+		"ReviewIssueMetadata":     func() *privilege.Privilege { return privilege.ReviewIssueMetadata },
+		"ReviewOwnMetadata":       func() *privilege.Privilege { return privilege.ReviewOwnMetadata },
+		"ViewWorkQueues":          func() *privilege.Privilege { return privilege.ViewWorkQueues },
+		"ViewProductivityReports": func() *privilege.Privilege { return privilege.ViewProductivityReports },
+		"ReviewUnfixableIssues":   func() *privilege.Privilege { return privilege.ReviewUnfixableIssues },
+		"ListUsers":               func() *privilege.Privilege { return privilege.ListUsers },
+		"ModifyUsers":             func() *privilege.Privilege { return privilege.ModifyUsers },
+		"ViewUploadedIssues":      func() *privilege.Privilege { return privilege.ViewUploadedIssues },
+		"ModifyUploadedIssues":    func() *privilege.Privilege { return privilege.ModifyUploadedIssues },
+		"UploadPublisherIssues":   func() *privilege.Privilege { return privilege.UploadPublisherIssues },
+		"SearchIssues":            func() *privilege.Privilege { return privilege.SearchIssues },
+		"GenerateBatches":         func() *privilege.Privilege { return privilege.GenerateBatches },
+		"ViewBatchStatus":         func() *privilege.Privilege { return privilege.ViewBatchStatus },
+		"ViewQCReadyBatches":      func() *privilege.Privilege { return privilege.ViewQCReadyBatches },
+		"ApproveQCReadyBatches":   func() *privilege.Privilege { return privilege.ApproveQCReadyBatches },
+		"RejectQCReadyBatches":    func() *privilege.Privilege { return privilege.RejectQCReadyBatches },
+		"ArchiveBatches":          func() *privilege.Privilege { return privilege.ArchiveBatches },
+		"ReconcileBatches":        func() *privilege.Privilege { return privilege.ReconcileBatches },
+		"CorrectLiveBatches":      func() *privilege.Privilege { return privilege.CorrectLiveBatches },
+		"UnbatchBatches":          func() *privilege.Privilege { return privilege.UnbatchBatches },
+		"ViewStorage":             func() *privilege.Privilege { return privilege.ViewStorage },
+		"ModifyValidatedLCCNs":    func() *privilege.Privilege { return privilege.ModifyValidatedLCCNs },
+		"ListAuditLogs":           func() *privilege.Privilege { return privilege.ListAuditLogs },
 	}
 
 	// Set up the layout and then our global templates
 	Layout = tmpl.Root("layout", templatePath)
 	Layout.Funcs(templateFunctions)
-	Layout.MustReadPartials("layout.go.html")
+	Layout.MustReadPartials("layout.go.html", "_oni_environments.go.html")
 	InsufficientPrivileges = Layout.MustBuild("insufficient-privileges.go.html")
 	Empty = Layout.MustBuild("empty.go.html")
 	Home = Layout.MustBuild("home.go.html")
@@ -0,0 +1,96 @@
+package storagehandler
+
+import (
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/uoregon-libraries/newspaper-curation-app/internal/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/cmd/server/internal/responder"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/privilege"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/storage"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/web/tmpl"
+)
+
+var (
+	basePath string
+	conf     *config.Config
+
+	// layout is the base template, cloned from the responder's layout, from
+	// which all subpages are built
+	layout *tmpl.TRoot
+
+	// reportTmpl shows each filesystem's forecast and any held pipelines
+	reportTmpl *tmpl.Template
+)
+
+// Hold wraps a storage hold with its pipeline for display
+type Hold struct {
+	*models.StorageHold
+	Pipeline *models.Pipeline
+}
+
+// Needed returns a human-friendly amount of space the pipeline needs
+func (h *Hold) Needed() string {
+	return storage.Size(h.NeededBytes)
+}
+
+// Free returns a human-friendly amount of space that was available to the
+// pipeline when it was last checked
+func (h *Hold) Free() string {
+	return storage.Size(h.FreeBytes)
+}
+
+// Setup sets up all the routing rules and other configuration
+func Setup(r *mux.Router, baseWebPath string, c *config.Config) {
+	conf = c
+	basePath = baseWebPath
+	var s = r.PathPrefix(basePath).Subrouter()
+	s.Path("").Handler(responder.MustHavePrivilege(privilege.ViewStorage, reportHandler))
+
+	layout = responder.Layout.Clone()
+	layout.Path = path.Join(layout.Path, "storage")
+
+	reportTmpl = layout.MustBuild("report.go.html")
+}
+
+// reportHandler measures every monitored location and shows its forecast
+// alongside the pipelines being held for lack of space
+func reportHandler(w http.ResponseWriter, req *http.Request) {
+	var r = responder.Response(w, req)
+	r.Vars.Title = "Storage"
+
+	var list, err = storage.Forecasts(conf, time.Now())
+	if err != nil {
+		logger.Errorf("Unable to forecast storage: %s", err)
+		r.Error(http.StatusInternalServerError, "Error reading storage - try again or contact support")
+		return
+	}
+
+	var holds []*models.StorageHold
+	holds, err = models.FindStorageHolds()
+	if err != nil {
+		logger.Errorf("Unable to look up storage holds: %s", err)
+		r.Error(http.StatusInternalServerError, "Error reading storage holds - try again or contact support")
+		return
+	}
+
+	var held []*Hold
+	for _, h := range holds {
+		var p, err = h.Pipeline()
+		if err != nil {
+			logger.Errorf("Unable to look up pipeline %d for storage hold: %s", h.PipelineID, err)
+			r.Error(http.StatusInternalServerError, "Error reading storage holds - try again or contact support")
+			return
+		}
+		held = append(held, &Hold{StorageHold: h, Pipeline: p})
+	}
+
+	r.Vars.Data["Forecasts"] = list
+	r.Vars.Data["Holds"] = held
+	r.Vars.Data["TrendDays"] = int(storage.TrendWindow.Hours() / 24)
+	r.Render(reportTmpl)
+}
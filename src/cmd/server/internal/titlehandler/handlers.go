@@ -10,16 +10,19 @@ import (
 	"net/http"
 	"path"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/uoregon-libraries/newspaper-curation-app/internal/datasize"
 	"github.com/uoregon-libraries/newspaper-curation-app/internal/logger"
 	"github.com/uoregon-libraries/newspaper-curation-app/internal/marc"
-	"github.com/uoregon-libraries/newspaper-curation-app/internal/openoni"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/cmd/server/internal/responder"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/dbi"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/duration"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/jobs"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/marcprovider"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/privilege"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/web/tmpl"
@@ -47,24 +50,22 @@ var (
 	// uploadResultsTmpl tells the user what happened when uploading MARC files
 	uploadResultsTmpl *tmpl.Template
 
-	// ONI Agent RPCs
-	stagAgent, prodAgent *openoni.RPC
+	// marcTmpl shows a readable view of a title's MARC record and its history
+	marcTmpl *tmpl.Template
+
+	// marcDiffTmpl shows what changed between two versions of a MARC record
+	marcDiffTmpl *tmpl.Template
+
+	// sftpPasswordTmpl shows a newly reset SFTP password
+	sftpPasswordTmpl *tmpl.Template
 )
 
 // Setup sets up all the routing rules and other configuration
 func Setup(r *mux.Router, baseWebPath string, c *config.Config) {
-	var err error
 	conf = c
 	basePath = baseWebPath
+	providers = marcprovider.FromConfig(c)
 	uploadMARCPath = path.Join(basePath, "upload-marc")
-	stagAgent, err = openoni.New(conf.StagingAgentConnection)
-	if err != nil {
-		panic(fmt.Sprintf("Staging ONI Agent connection string %q is invalid: %s", conf.StagingAgentConnection, err))
-	}
-	prodAgent, err = openoni.New(conf.ProductionAgentConnection)
-	if err != nil {
-		panic(fmt.Sprintf("Production ONI Agent connection string %q is invalid: %s", conf.ProductionAgentConnection, err))
-	}
 
 	var s = r.PathPrefix(basePath).Subrouter()
 	s.Path("").Handler(canView(listHandler))
@@ -72,6 +73,12 @@ func Setup(r *mux.Router, baseWebPath string, c *config.Config) {
 	s.Path("/edit").Handler(canModify(editHandler))
 	s.Path("/save").Methods("POST").Handler(canModify(saveHandler))
 	s.Path("/validate").Methods("POST").Handler(canModify(validateHandler))
+	s.Path("/resync").Methods("POST").Handler(canModify(resyncHandler))
+	s.Path("/sftp-status").Methods("POST").Handler(canModify(sftpStatusHandler))
+	s.Path("/sftp-password").Methods("POST").Handler(canModify(sftpPasswordHandler))
+	s.Path("/sftp-keys").Methods("POST").Handler(canModify(sftpKeysHandler))
+	s.Path("/marc").Handler(canView(marcHandler))
+	s.Path("/marc-diff").Handler(canView(marcDiffHandler))
 	s.Path("/upload-marc").Methods("GET").Handler(canModify(showMARCFormHandler))
 	s.Path("/upload-marc").Methods("POST").Handler(canModify(processMARCUploadHandler))
 
@@ -80,8 +87,9 @@ func Setup(r *mux.Router, baseWebPath string, c *config.Config) {
 		"TitlesHomeURL":       func() string { return basePath },
 		"TitlesUploadMARCURL": func() string { return uploadMARCPath },
 		"SFTPGoEnabled":       func() bool { return c.SFTPGoEnabled },
-		"StagingRootURL":      func() string { return conf.StagingNewsWebroot },
-		"ProdRootURL":         func() string { return conf.NewsWebroot },
+		"ONIEnvironments":     func() []*config.ONIEnvironment { return conf.ONIEnvironments },
+		"MARCRecordURL":       marcRecordURL,
+		"MARCDiffURL":         marcDiffURL,
 	})
 	layout.Path = path.Join(layout.Path, "titles")
 
@@ -89,6 +97,9 @@ func Setup(r *mux.Router, baseWebPath string, c *config.Config) {
 	formTmpl = layout.MustBuild("form.go.html")
 	uploadMARCTmpl = layout.MustBuild("upload-marc.go.html")
 	uploadResultsTmpl = layout.MustBuild("upload-results.go.html")
+	marcTmpl = layout.MustBuild("marc.go.html")
+	marcDiffTmpl = layout.MustBuild("marc-diff.go.html")
+	sftpPasswordTmpl = layout.MustBuild("sftp-password.go.html")
 }
 
 func getTitle(r *responder.Responder) (t *Title, handled bool) {
@@ -124,6 +135,7 @@ func getTitle(r *responder.Responder) (t *Title, handled bool) {
 		}
 
 		wrapped.SFTPQuota = datasize.Datasize(u.QuotaSize)
+		wrapped.SFTPAccount = u
 	}
 
 	return wrapped, false
@@ -140,7 +152,18 @@ func listHandler(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	var uploads map[int64]time.Time
+	uploads, err = models.LastUploadTimes()
+	if err != nil {
+		logger.Errorf("Unable to load title upload times: %s", err)
+		r.Error(http.StatusInternalServerError, "Error trying to pull title list - try again or contact support")
+		return
+	}
+
 	var titles = WrapTitles(dbTitles)
+	for _, t := range titles {
+		t.LastUpload = uploads[t.ID]
+	}
 	SortTitles(titles)
 	r.Vars.Data["Titles"] = titles
 	r.Render(listTmpl)
@@ -154,6 +177,9 @@ func newHandler(w http.ResponseWriter, req *http.Request) {
 	r.Render(formTmpl)
 }
 
+// recentUploadCount is how many uploads the edit form shows
+const recentUploadCount = 10
+
 // editHandler loads the title by id and renders the edit form
 func editHandler(w http.ResponseWriter, req *http.Request) {
 	var r = responder.Response(w, req)
@@ -162,7 +188,36 @@ func editHandler(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	var syncs, err = titleSyncs(t.Title)
+	if err != nil {
+		logger.Errorf("Unable to read ONI sync status for title %d: %s", t.ID, err)
+		r.Error(http.StatusInternalServerError, "Unable to read title's ONI status - try again or contact support")
+		return
+	}
+
+	var rec *models.TitleMARCRecord
+	rec, err = t.LatestMARCRecord()
+	if err != nil {
+		logger.Errorf("Unable to read MARC record for title %d: %s", t.ID, err)
+		r.Error(http.StatusInternalServerError, "Unable to read title's MARC record - try again or contact support")
+		return
+	}
+
+	var uploads []*models.TitleUpload
+	uploads, err = t.RecentUploads(recentUploadCount)
+	if err != nil {
+		logger.Errorf("Unable to read uploads for title %d: %s", t.ID, err)
+		r.Error(http.StatusInternalServerError, "Unable to read title's upload activity - try again or contact support")
+		return
+	}
+
 	r.Vars.Data["Title"] = t
+	r.Vars.Data["MARCRecord"] = rec
+	r.Vars.Data["Uploads"] = uploads
+	if t.SFTPAccount != nil {
+		r.Vars.Data["SFTPAccount"] = newSFTPAccount(t.SFTPAccount)
+	}
+	r.Vars.Data["ONISyncs"] = syncs
 	r.Vars.Title = "Editing " + t.Name
 	r.Render(formTmpl)
 }
@@ -304,8 +359,13 @@ func saveHandler(w http.ResponseWriter, req *http.Request) {
 	// database; this data is useful, but not critical to NCA's operations, so we
 	// run it in the background and let it do its thing when it can.  This should
 	// probably be a new job or something, though.
+	//
+	// A successful MARC pull queues an ONI sync, so we only queue one here if
+	// the title's record is already known.
 	if !t.ValidLCCN {
-		go pullMARCForTitle(t)
+		go pullMARCForTitle(t, r.Vars.User.ID)
+	} else if t.MARCXML != "" {
+		queueSync(t.Title)
 	}
 
 	r.Audit(models.AuditActionSaveTitle, fmt.Sprintf("%#v", r.Request.Form))
@@ -386,16 +446,21 @@ func validateHandler(w http.ResponseWriter, req *http.Request) {
 	}
 
 	// When validation is explicitly requested, the user waits for a response
-	pullMARCForTitle(t)
-	r.Audit(models.AuditActionValidateTitle, fmt.Sprintf("%q %q", t.MARCTitle, t.MARCLocation))
+	var provider, warnings = pullMARCForTitle(t, r.Vars.User.ID)
+	r.Audit(models.AuditActionValidateTitle, fmt.Sprintf("%q %q (provider %q)", t.MARCTitle, t.MARCLocation, provider))
 
 	var alertLevel = "Info"
-	var response = "Validated LCCN"
+	var response = "Validated LCCN using MARC provider " + provider
+	if len(warnings) > 0 {
+		alertLevel = "Alert"
+		response += ", but the record has problems NCA can live with. Fix them in the catalog when you can: " + strings.Join(warnings, "; ")
+	}
 	if !t.ValidLCCN {
 		alertLevel = "Alert"
-		response = "LCCN was not able to be validated at this time - Chronicling America may be down or the LCCN may not be in their database"
+		response = "LCCN was not able to be validated at this time - MARC providers may be down or none of them may have a valid record for this LCCN"
 	}
-	http.SetCookie(w, &http.Cookie{Name: alertLevel, Value: response, Path: "/"})
+	// Warnings can have characters which aren't valid in a cookie
+	http.SetCookie(w, &http.Cookie{Name: alertLevel, Value: "base64" + base64.StdEncoding.EncodeToString([]byte(response)), Path: "/"})
 	http.Redirect(w, r.Request, basePath, http.StatusFound)
 }
 
@@ -405,21 +470,19 @@ func showMARCFormHandler(w http.ResponseWriter, req *http.Request) {
 	r.Render(uploadMARCTmpl)
 }
 
-// loadTitle tries to read, parse, and then send the given uploaded file to
-// both staging and production ONIs. If parsing is successful, a [marc.MARC]
-// will be returned. On any errors, a human-friendly string is returned to
-// explain the problem.
-func loadTitle(fh *multipart.FileHeader) (m *marc.MARC, message string) {
+// loadTitle tries to read and parse the given uploaded file. If parsing is
+// successful, a [marc.MARC] and the raw XML will be returned. On any errors, a
+// human-friendly string is returned to explain the problem.
+func loadTitle(fh *multipart.FileHeader) (m *marc.MARC, upload []byte, message string) {
 	var fname = fh.Filename
 	var f, err = fh.Open()
-	var upload []byte
 	if err == nil {
 		upload, err = ioutil.ReadAll(f)
 		f.Close()
 	}
 	if err != nil {
 		logger.Errorf("Unable to get uploaded file %q: %s", fname, err)
-		return nil, "Internal error reading file"
+		return nil, nil, "Internal error reading file"
 	}
 
 	// Do a quick sanity check that the data is valid MARC
@@ -427,24 +490,10 @@ func loadTitle(fh *multipart.FileHeader) (m *marc.MARC, message string) {
 	m, err = marc.ParseXML(buf)
 	if err != nil {
 		logger.Errorf("Invalid XML uploaded in file %q: %s", fh.Filename, err)
-		return nil, "File is invalid or doesn't contain MARC XML"
-	}
-
-	var stID, prID int64
-	stID, err = stagAgent.LoadTitle(upload)
-	if err != nil {
-		logger.Errorf("Error queueing title load from ONI Agent (staging) for %q: error: %s", fname, err)
-		return m, "Failed to load into staging ONI"
-	}
-	prID, err = prodAgent.LoadTitle(upload)
-	if err != nil {
-		logger.Errorf("Error queueing title load from ONI Agent (prod) for %q: error: %s", fname, err)
-		return m, "Failed to load into production ONI"
+		return nil, nil, "File is invalid or doesn't contain MARC XML"
 	}
 
-	logger.Infof("Title load for %q started: job ids %d / %d (staging / prod)", fh.Filename, stID, prID)
-
-	return m, ""
+	return m, upload, ""
 }
 
 func processMARCUploadHandler(w http.ResponseWriter, req *http.Request) {
@@ -475,16 +524,32 @@ func processMARCUploadHandler(w http.ResponseWriter, req *http.Request) {
 		MARC         *marc.MARC
 		New          bool
 		EditTitleURL string
+		DiffURL      string
+		Warnings     []string
 		ErrorMessage string
+		Problems     []string
+		SyncError    string
 	}
 	var successes, failures []*uploadResult
 	for _, fh := range fhs {
-		var m, errmsg = loadTitle(fh)
+		var m, upload, errmsg = loadTitle(fh)
 		var result = &uploadResult{Filename: fh.Filename, MARC: m, ErrorMessage: errmsg}
 		if errmsg != "" {
 			failures = append(failures, result)
 			continue
 		}
+
+		var problems = m.Validate()
+		result.Problems = marcErrors(problems)
+		if len(result.Problems) > 0 {
+			result.ErrorMessage = "MARC record is missing data NCA and ONI need"
+			failures = append(failures, result)
+			continue
+		}
+		for _, p := range problems {
+			result.Warnings = append(result.Warnings, p.String())
+		}
+
 		var t, err = models.FindTitleByLCCN(m.LCCN())
 		if err != nil {
 			logger.Errorf("After-upload title work: getting title by LCCN %q: %s", m.LCCN(), err)
@@ -505,9 +570,10 @@ func processMARCUploadHandler(w http.ResponseWriter, req *http.Request) {
 		t.ValidLCCN = true
 		t.MARCTitle = m.Title()
 		t.MARCLocation = m.Location()
-		t.LangCode3 = m.Language()
+		setLanguage(t, m)
 
-		err = t.Save()
+		var rec *models.TitleMARCRecord
+		rec, err = t.SaveMARC(string(upload), "upload", fh.Filename, r.Vars.User.ID)
 		if err != nil {
 			logger.Errorf("After-upload title work: saving title (%q / %q): %s", t.Name, t.LCCN, err)
 			result.ErrorMessage = "Internal error processing title. Try again or contact support."
@@ -515,8 +581,17 @@ func processMARCUploadHandler(w http.ResponseWriter, req *http.Request) {
 			continue
 		}
 
+		err = jobs.QueueTitleSync(t, conf)
+		if err != nil {
+			logger.Errorf("After-upload title work: queueing ONI sync for title (%q / %q): %s", t.Name, t.LCCN, err)
+			result.SyncError = "Title was saved, but NCA was unable to queue it for loading into ONI. Use the title's resync button to try again."
+		}
+
 		successes = append(successes, result)
 		result.EditTitleURL = path.Join(basePath, "edit?id="+strconv.FormatInt(t.ID, 10))
+		if rec != nil && rec.Version > 1 {
+			result.DiffURL = marcDiffURL(t.ID, rec.Version)
+		}
 		r.Audit(models.AuditActionUploadMARC, fmt.Sprintf("Filename %q, LCCN %q, MARC Title %q", fh.Filename, m.LCCN(), m.Title()))
 	}
 
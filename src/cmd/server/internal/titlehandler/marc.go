@@ -1,90 +1,115 @@
 package titlehandler
 
 import (
+	"bytes"
 	"fmt"
-	"io"
-	"net/http"
-	"os"
 	"strings"
 
 	"github.com/uoregon-libraries/newspaper-curation-app/internal/logger"
 	"github.com/uoregon-libraries/newspaper-curation-app/internal/marc"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/marcprovider"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
 )
 
-// pullMARCForTitle pulls the MARC record from the library of congress and sets the
-// title's data if successful
-func pullMARCForTitle(t *Title) {
+// providers holds the configured MARC providers, in the order they're tried
+var providers []*marcprovider.Provider
+
+// pullMARCForTitle asks each configured MARC provider for the title's record,
+// and sets the title's data from the first usable record found. A changed
+// record is stored as a new version, attributed to the given user. The name
+// of the provider which supplied the record is returned along with any
+// warnings about the record, or an empty string if no provider had a usable
+// record.
+func pullMARCForTitle(t *Title, userID int64) (provider string, warnings []string) {
 	t.ValidLCCN = false
 	t.MARCTitle = ""
 	t.MARCLocation = ""
 
-	var marcLocs = []string{
-		strings.Replace(conf.MARCLocation1, "{{lccn}}", t.LCCN, -1),
-		strings.Replace(conf.MARCLocation2, "{{lccn}}", t.LCCN, -1),
-	}
-
-	var last = len(marcLocs) - 1
-	for i := 0; i <= last; i++ {
-		var err = lookupMARC(t, marcLocs[i])
+	for i, p := range providers {
+		var found, err = lookupMARC(t, p, userID)
 		if err == nil {
-			return
+			for _, w := range found {
+				logger.Warnf("MARC XML for %q from provider %q: %s", t.LCCN, p.Name, w)
+			}
+			queueSync(t.Title)
+			return p.Name, found
 		}
-		var msg = "Unable to pull MARC XML from %q: %s"
-		if i >= last {
-			logger.Errorf(msg, marcLocs[i], err)
-			return
+		var msg = "Unable to pull MARC XML for %q from provider %q: %s"
+		if i == len(providers)-1 {
+			logger.Errorf(msg, t.LCCN, p.Name, err)
+			return "", nil
 		}
-		logger.Warnf(msg+" -- trying next location", marcLocs[i], err)
+		logger.Warnf(msg+" -- trying next provider", t.LCCN, p.Name, err)
 	}
-}
 
-func lookupMARC(t *Title, marcLoc string) error {
-	logger.Infof("Looking up MARC for %q in configured location %q", t.LCCN, marcLoc)
+	logger.Errorf("Unable to pull MARC XML for %q: no MARC providers are configured", t.LCCN)
+	return "", nil
+}
 
-	var reader io.ReadCloser
-	var err error
+// lookupMARC fetches the title's record from a single provider and, if NCA
+// can use it, stores it on the title. Problems which don't make the record
+// unusable, such as an unreadable 008, are returned as warnings.
+func lookupMARC(t *Title, p *marcprovider.Provider, userID int64) (warnings []string, err error) {
+	logger.Infof("Looking up MARC for %q using provider %q (%s)", t.LCCN, p.Name, p.Type)
 
-	if marcLoc[:4] == "http" {
-		reader, err = getMarcHTTP(marcLoc)
-	} else {
-		reader, err = getMarcLocal(marcLoc)
-	}
+	var rec *marcprovider.Record
+	rec, err = p.Fetch(t.LCCN)
 	if err != nil {
-		return fmt.Errorf("preparing MARC XML reader: %w", err)
+		return nil, fmt.Errorf("fetching MARC XML: %w", err)
 	}
-	defer reader.Close()
 
 	var m *marc.MARC
-	m, err = marc.ParseXML(reader)
+	m, err = marc.ParseXML(bytes.NewReader(rec.XML))
 	if err != nil {
-		return fmt.Errorf("parsing MARC XML: %w", err)
+		return nil, fmt.Errorf("parsing MARC XML from %q: %w", rec.Source, err)
 	}
 
-	t.MARCTitle = m.Title()
-	t.MARCLocation = m.Location()
-	t.LangCode3 = m.Language()
-	if t.MARCTitle == "" || t.MARCLocation == "" {
-		return fmt.Errorf("parsing MARC XML: title and location must not be blank")
+	var problems = m.Validate()
+	var errs = marcErrors(problems)
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("validating MARC XML from %q: %s", rec.Source, strings.Join(errs, "; "))
 	}
 
+	t.MARCTitle = m.Title()
+	t.MARCLocation = m.Location()
+	setLanguage(t.Title, m)
 	t.ValidLCCN = true
 
-	err = t.Save()
+	_, err = t.SaveMARC(string(rec.XML), rec.Provider, rec.Source, userID)
 	if err != nil {
-		return fmt.Errorf("saving title (id %d) after MARC XML read: %w", t.ID, err)
+		return nil, fmt.Errorf("saving title (id %d) after MARC XML read: %w", t.ID, err)
 	}
 
-	return nil
+	return marcWarnings(problems), nil
 }
 
-func getMarcHTTP(uri string) (io.ReadCloser, error) {
-	var resp, err = http.Get(uri)
-	if err != nil {
-		return nil, err
+// setLanguage sets the title's language from the MARC record's 008, leaving
+// it alone if the 008 doesn't have a valid language code
+func setLanguage(t *models.Title, m *marc.MARC) {
+	var lang = m.Language()
+	if lang != "" {
+		t.LangCode3 = lang
 	}
-	return resp.Body, nil
 }
 
-func getMarcLocal(loc string) (io.ReadCloser, error) {
-	return os.Open(loc)
+// marcErrors returns the problems which make a record unusable, formatted for
+// display
+func marcErrors(problems []marc.Problem) []string {
+	return marcProblems(problems, marc.SeverityError)
+}
+
+// marcWarnings returns the problems which don't stop NCA from using a record,
+// formatted for display
+func marcWarnings(problems []marc.Problem) []string {
+	return marcProblems(problems, marc.SeverityWarning)
+}
+
+func marcProblems(problems []marc.Problem, sev marc.Severity) []string {
+	var list []string
+	for _, p := range problems {
+		if p.Severity == sev {
+			list = append(list, p.String())
+		}
+	}
+	return list
 }
@@ -0,0 +1,130 @@
+package titlehandler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/internal/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/internal/marc"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/cmd/server/internal/responder"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+)
+
+func marcRecordURL(titleID int64, version int) string {
+	return fmt.Sprintf("%s/marc?id=%d&version=%d", basePath, titleID, version)
+}
+
+func marcDiffURL(titleID int64, version int) string {
+	return fmt.Sprintf("%s/marc-diff?id=%d&version=%d", basePath, titleID, version)
+}
+
+// getMARCRecords loads the title and all its MARC record versions, then finds
+// the version requested, or the latest version if none was requested
+func getMARCRecords(r *responder.Responder) (t *Title, list []*models.TitleMARCRecord, rec *models.TitleMARCRecord, handled bool) {
+	t, handled = getTitle(r)
+	if handled {
+		return nil, nil, nil, true
+	}
+
+	var err error
+	list, err = t.MARCRecords()
+	if err != nil {
+		logger.Errorf("Unable to read MARC records for title %d: %s", t.ID, err)
+		r.Error(http.StatusInternalServerError, "Unable to read title's MARC records - try again or contact support")
+		return nil, nil, nil, true
+	}
+	if len(list) == 0 {
+		r.Error(http.StatusNotFound, "NCA has no MARC record for this title. Validate its LCCN or upload its MARC XML first.")
+		return nil, nil, nil, true
+	}
+
+	var version, _ = strconv.Atoi(r.Request.FormValue("version"))
+	if version == 0 {
+		return t, list, list[0], false
+	}
+	for _, l := range list {
+		if l.Version == version {
+			return t, list, l, false
+		}
+	}
+
+	r.Error(http.StatusNotFound, "Invalid MARC record version - try again or contact support")
+	return nil, nil, nil, true
+}
+
+// parseRecord parses a stored MARC record for display, reporting any error to
+// the client
+func parseRecord(r *responder.Responder, rec *models.TitleMARCRecord) (m *marc.MARC, handled bool) {
+	var err error
+	m, err = marc.ParseXML(strings.NewReader(rec.MARCXML))
+	if err != nil {
+		logger.Errorf("Unable to parse MARC record %d (title %d, version %d): %s", rec.ID, rec.TitleID, rec.Version, err)
+		r.Error(http.StatusInternalServerError, "Unable to read MARC record - try again or contact support")
+		return nil, true
+	}
+	return m, false
+}
+
+// marcHandler shows a readable view of one version of a title's MARC record,
+// along with any validation problems and the record's history
+func marcHandler(w http.ResponseWriter, req *http.Request) {
+	var r = responder.Response(w, req)
+	var t, list, rec, handled = getMARCRecords(r)
+	if handled {
+		return
+	}
+
+	var m *marc.MARC
+	m, handled = parseRecord(r, rec)
+	if handled {
+		return
+	}
+
+	r.Vars.Title = fmt.Sprintf("MARC record for %s (version %d)", t.Name, rec.Version)
+	r.Vars.Data["Title"] = t
+	r.Vars.Data["Record"] = rec
+	r.Vars.Data["Records"] = list
+	r.Vars.Data["Lines"] = m.Lines()
+	r.Vars.Data["Problems"] = m.Validate()
+	r.Render(marcTmpl)
+}
+
+// marcDiffHandler shows the differences between a version of a title's MARC
+// record and the version it replaced
+func marcDiffHandler(w http.ResponseWriter, req *http.Request) {
+	var r = responder.Response(w, req)
+	var t, list, rec, handled = getMARCRecords(r)
+	if handled {
+		return
+	}
+
+	var after, before *marc.MARC
+	after, handled = parseRecord(r, rec)
+	if handled {
+		return
+	}
+
+	// The list is newest first, so the version this one replaced (if any) is
+	// right after it
+	var prev *models.TitleMARCRecord
+	for i, l := range list {
+		if l == rec && i+1 < len(list) {
+			prev = list[i+1]
+		}
+	}
+	if prev != nil {
+		before, handled = parseRecord(r, prev)
+		if handled {
+			return
+		}
+	}
+
+	r.Vars.Title = fmt.Sprintf("MARC record changes for %s (version %d)", t.Name, rec.Version)
+	r.Vars.Data["Title"] = t
+	r.Vars.Data["Record"] = rec
+	r.Vars.Data["Previous"] = prev
+	r.Vars.Data["Diff"] = marc.Diff(before, after)
+	r.Render(marcDiffTmpl)
+}
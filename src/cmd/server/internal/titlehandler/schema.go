@@ -4,8 +4,10 @@ import (
 	"regexp"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/uoregon-libraries/newspaper-curation-app/internal/datasize"
+	"github.com/uoregon-libraries/newspaper-curation-app/internal/sftpgo"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/duration"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/schema"
@@ -20,6 +22,14 @@ type Title struct {
 	SortName  string
 	SFTPPass  string // SFTPPass is a temp field so we can send password updates to SFTPGo
 	SFTPQuota datasize.Datasize
+
+	// SFTPAccount is the title's SFTPGo user, if SFTPGo is enabled and the
+	// title is connected to it
+	SFTPAccount *sftpgo.User
+
+	// LastUpload is when SFTPGo last told us about a file from this title's
+	// publisher, or the zero time if it never has
+	LastUpload time.Time
 }
 
 // WrapTitle converts a models.Title to a Title, giving it a useful "SortName"
@@ -0,0 +1,204 @@
+package titlehandler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/internal/datasize"
+	"github.com/uoregon-libraries/newspaper-curation-app/internal/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/internal/sftpgo"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/cmd/server/internal/responder"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/dbi"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+	"golang.org/x/crypto/ssh"
+)
+
+// sftpKey describes one of a publisher's SSH public keys for display
+type sftpKey struct {
+	Type        string
+	Fingerprint string
+	Comment     string
+}
+
+// sftpAccount is the SFTPGo state of a title's publisher account
+type sftpAccount struct {
+	Enabled   bool
+	Used      datasize.Datasize
+	Quota     datasize.Datasize
+	Files     int64
+	LastLogin time.Time
+	Keys      []*sftpKey
+}
+
+func newSFTPAccount(u *sftpgo.User) *sftpAccount {
+	var a = &sftpAccount{
+		Enabled:   u.Enabled(),
+		Used:      datasize.Datasize(u.UsedQuotaSize),
+		Quota:     datasize.Datasize(u.QuotaSize),
+		Files:     u.UsedQuotaFiles,
+		LastLogin: u.LastLoginTime(),
+	}
+	for _, k := range u.PublicKeys {
+		var key, err = parseKey(k)
+		if err != nil {
+			// Keys added outside NCA may not parse; we still show them so they can
+			// be removed
+			logger.Warnf("Unable to parse SFTP public key for user %q: %s", u.Username, err)
+			key = &sftpKey{Type: "unknown", Fingerprint: k}
+		}
+		a.Keys = append(a.Keys, key)
+	}
+	return a
+}
+
+// parseKey reads a key in authorized_keys format
+func parseKey(k string) (*sftpKey, error) {
+	var pub, comment, _, _, err = ssh.ParseAuthorizedKey([]byte(k))
+	if err != nil {
+		return nil, err
+	}
+	return &sftpKey{Type: pub.Type(), Fingerprint: ssh.FingerprintSHA256(pub), Comment: comment}, nil
+}
+
+// getSFTPTitle loads the title for an SFTP account request, making sure it
+// actually has an account to manage
+func getSFTPTitle(w http.ResponseWriter, r *responder.Responder) (t *Title, handled bool) {
+	t, handled = getTitle(r)
+	if handled {
+		return nil, true
+	}
+	if t.SFTPAccount == nil {
+		http.SetCookie(w, &http.Cookie{Name: "Alert", Value: "This title has no SFTP account to manage", Path: "/"})
+		http.Redirect(w, r.Request, editURL(t), http.StatusFound)
+		return nil, true
+	}
+	return t, false
+}
+
+func editURL(t *Title) string {
+	return fmt.Sprintf("%s/edit?id=%d", basePath, t.ID)
+}
+
+// sftpDone audits a successful change and sends the user back to the title
+func sftpDone(w http.ResponseWriter, r *responder.Responder, t *Title, action models.AuditAction, msg string) {
+	r.Audit(action, fmt.Sprintf("SFTP user %q (title %q): %s", t.SFTPUser, t.LCCN, msg))
+	http.SetCookie(w, &http.Cookie{Name: "Info", Value: "SFTP account updated: " + msg, Path: "/"})
+	http.Redirect(w, r.Request, editURL(t), http.StatusFound)
+}
+
+// sftpFailed reports an SFTPGo error
+func sftpFailed(r *responder.Responder, t *Title, what string, err error) {
+	logger.Errorf("Unable to %s for SFTP user %q: %s", what, t.SFTPUser, err)
+	r.Error(http.StatusInternalServerError, fmt.Sprintf("Unable to %s - try again or contact support", what))
+}
+
+// sftpStatusHandler enables or disables a title's SFTP account
+func sftpStatusHandler(w http.ResponseWriter, req *http.Request) {
+	var r = responder.Response(w, req)
+	var t, handled = getSFTPTitle(w, r)
+	if handled {
+		return
+	}
+
+	var enable = req.FormValue("enabled") == "1"
+	var err = dbi.SFTP().SetEnabled(t.SFTPUser, enable)
+	if err != nil {
+		sftpFailed(r, t, "change account status", err)
+		return
+	}
+
+	var msg = "account disabled"
+	if enable {
+		msg = "account enabled"
+	}
+	sftpDone(w, r, t, models.AuditActionSFTPSetStatus, msg)
+}
+
+// sftpPasswordHandler gives a title's SFTP account a new random password and
+// shows it. SFTPGo can't tell us the password later, so this is the only time
+// anybody will see it.
+func sftpPasswordHandler(w http.ResponseWriter, req *http.Request) {
+	var r = responder.Response(w, req)
+	var t, handled = getSFTPTitle(w, r)
+	if handled {
+		return
+	}
+
+	var pass, err = dbi.SFTP().ResetPassword(t.SFTPUser)
+	if err != nil {
+		sftpFailed(r, t, "reset password", err)
+		return
+	}
+
+	r.Audit(models.AuditActionSFTPResetPassword, fmt.Sprintf("SFTP user %q (title %q): password reset", t.SFTPUser, t.LCCN))
+	w.Header().Set("Cache-Control", "no-store")
+	r.Vars.Title = "New SFTP password for " + t.Name
+	r.Vars.Data["Title"] = t
+	r.Vars.Data["Password"] = pass
+	r.Render(sftpPasswordTmpl)
+}
+
+// sftpKeysHandler adds or removes an SSH public key on a title's SFTP account
+func sftpKeysHandler(w http.ResponseWriter, req *http.Request) {
+	var r = responder.Response(w, req)
+	var t, handled = getSFTPTitle(w, r)
+	if handled {
+		return
+	}
+
+	var keys = t.SFTPAccount.PublicKeys
+	var action models.AuditAction
+	var msg string
+
+	switch req.FormValue("action") {
+	case "add":
+		var raw = strings.TrimSpace(req.FormValue("key"))
+		var key, err = parseKey(raw)
+		if err != nil {
+			http.SetCookie(w, &http.Cookie{Name: "Alert", Value: "Invalid SSH public key: paste a single key in OpenSSH format, e.g., the contents of id_ed25519.pub", Path: "/"})
+			http.Redirect(w, req, editURL(t), http.StatusFound)
+			return
+		}
+		for _, k := range keys {
+			var existing, _ = parseKey(k)
+			if existing != nil && existing.Fingerprint == key.Fingerprint {
+				http.SetCookie(w, &http.Cookie{Name: "Alert", Value: "This key is already on the account", Path: "/"})
+				http.Redirect(w, req, editURL(t), http.StatusFound)
+				return
+			}
+		}
+		keys = append(keys, raw)
+		action, msg = models.AuditActionSFTPAddKey, "added key "+key.Fingerprint
+
+	case "remove":
+		var fingerprint = req.FormValue("fingerprint")
+		var kept []string
+		for _, k := range keys {
+			var key, _ = parseKey(k)
+			if (key != nil && key.Fingerprint == fingerprint) || k == fingerprint {
+				continue
+			}
+			kept = append(kept, k)
+		}
+		if len(kept) == len(keys) {
+			http.SetCookie(w, &http.Cookie{Name: "Alert", Value: "That key isn't on the account", Path: "/"})
+			http.Redirect(w, req, editURL(t), http.StatusFound)
+			return
+		}
+		keys = kept
+		action, msg = models.AuditActionSFTPRemoveKey, "removed key "+fingerprint
+
+	default:
+		r.Error(http.StatusBadRequest, "Invalid request - try again or contact support")
+		return
+	}
+
+	var err = dbi.SFTP().SetPublicKeys(t.SFTPUser, keys)
+	if err != nil {
+		sftpFailed(r, t, "update SSH keys", err)
+		return
+	}
+	sftpDone(w, r, t, action, msg)
+}
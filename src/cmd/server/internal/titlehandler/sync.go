@@ -0,0 +1,77 @@
+package titlehandler
+
+import (
+	"net/http"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/internal/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/cmd/server/internal/responder"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/jobs"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+)
+
+// oniSync pairs a configured ONI environment with the title's sync record
+// for it. Sync is nil if the title has never been sent to the environment.
+type oniSync struct {
+	Environment *config.ONIEnvironment
+	Sync        *models.ONISync
+}
+
+// titleSyncs returns the title's sync status for every configured ONI
+// environment, in configuration order
+func titleSyncs(t *models.Title) ([]*oniSync, error) {
+	var list, err = t.ONISyncs()
+	if err != nil {
+		return nil, err
+	}
+
+	var byEnv = make(map[string]*models.ONISync)
+	for _, s := range list {
+		byEnv[s.Environment] = s
+	}
+
+	var syncs []*oniSync
+	for _, env := range conf.ONIEnvironments {
+		syncs = append(syncs, &oniSync{Environment: env, Sync: byEnv[env.Name]})
+	}
+	return syncs, nil
+}
+
+// queueSync queues jobs to push the title to ONI, logging any errors. Syncing
+// is a side effect of title changes, so failures are visible on the title's
+// page rather than failing the request.
+func queueSync(t *models.Title) {
+	var err = jobs.QueueTitleSync(t, conf)
+	if err != nil {
+		logger.Errorf("Unable to queue ONI sync for title %q: %s", t.LCCN, err)
+	}
+}
+
+// resyncHandler queues jobs to push the title's MARC record to every ONI
+// environment
+func resyncHandler(w http.ResponseWriter, req *http.Request) {
+	var r = responder.Response(w, req)
+	var t, handled = getTitle(r)
+	if handled {
+		return
+	}
+
+	var editURL = basePath + "/edit?id=" + req.FormValue("id")
+	if t.MARCXML == "" {
+		var msg = "NCA has no MARC record for this title. Validate its LCCN or upload its MARC XML first."
+		http.SetCookie(w, &http.Cookie{Name: "Alert", Value: msg, Path: "/"})
+		http.Redirect(w, req, editURL, http.StatusFound)
+		return
+	}
+
+	var err = jobs.QueueTitleSync(t.Title, conf)
+	if err != nil {
+		logger.Errorf("Unable to queue ONI sync for title %q: %s", t.LCCN, err)
+		r.Error(http.StatusInternalServerError, "Error trying to queue title for ONI sync - try again or contact support")
+		return
+	}
+
+	r.Audit(models.AuditActionResyncTitle, t.LCCN)
+	http.SetCookie(w, &http.Cookie{Name: "Info", Value: "Title queued for ONI sync", Path: "/"})
+	http.Redirect(w, req, editURL, http.StatusFound)
+}
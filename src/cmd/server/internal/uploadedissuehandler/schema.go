@@ -5,6 +5,7 @@ import (
 	"html/template"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/uoregon-libraries/newspaper-curation-app/internal/logger"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
@@ -40,6 +41,11 @@ type Title struct {
 	Issues      []*Issue
 	IssueLookup map[string]*Issue
 	Type        TitleType
+
+	// nextValidation is when the next of the title's issues will be old enough
+	// that its age-based validations would give a different result, or zero if
+	// none of the issues are new enough to matter
+	nextValidation time.Time
 }
 
 func (t *Title) decorateIssues(issueList []*schema.Issue) {
@@ -62,12 +68,25 @@ func (t *Title) appendSchemaIssue(i *schema.Issue) *Issue {
 	issue.decorateFiles(uIssue.Files)
 	issue.decoratePriorJobLogs()
 	issue.ValidateFast()
+	t.trackAge(issue.LastModified())
 	t.Issues = append(t.Issues, issue)
 	t.IssueLookup[issue.Slug] = issue
 
 	return issue
 }
 
+// trackAge updates the title's next validation time if an issue last
+// modified at lastMod will cross an age threshold sooner
+func (t *Title) trackAge(lastMod time.Time) {
+	var now = time.Now()
+	for _, d := range []time.Duration{conf.IssueDangerousDuration, conf.IssueNewDuration} {
+		var at = lastMod.Add(d)
+		if at.After(now) && (t.nextValidation.IsZero() || at.Before(t.nextValidation)) {
+			t.nextValidation = at
+		}
+	}
+}
+
 // Show returns true if the title has any issues or errors.  If there are no
 // errors and no issues, there's no reason to display it.
 func (t *Title) Show() bool {
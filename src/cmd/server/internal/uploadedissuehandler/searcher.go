@@ -10,15 +10,22 @@ import (
 	"github.com/uoregon-libraries/newspaper-curation-app/internal/logger"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/apperr"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/issuefinder"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/issuewatcher"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/schema"
 )
 
 // secondsBetweenIssueReload should be a value that ensures nearly real-time
-// data, but avoids hammering the disk if a lot of refreshing happens
+// data, but avoids hammering the disk if a lot of refreshing happens. This is
+// only used when the issue watcher can't tell us about changes as they occur.
 const secondsBetweenIssueReload = 60
 
+// secondsBetweenFullRescan is how often we rescan everything when the issue
+// watcher is telling us about changes. This is just a safety net in case an
+// event was somehow missed.
+const secondsBetweenFullRescan = 60 * 30
+
 // maxLoadFailures is the number of times in a row a scan may fail before we
 // consider the system in a failed state and respond to requests with an error
 const maxLoadFailures = 5
@@ -47,30 +54,126 @@ func newSearcher(conf *config.Config) *Searcher {
 	return s
 }
 
-// watch checks the time since the last load, and loads issues from the
-// filesystem if necessary.  If issues were loaded, the various types are
-// decorated as needed for web presentation.  This should be run in a goroutine
-// as it loops forever.
+// watch keeps the searcher's data current, and should be run in a goroutine
+// as it loops forever. When the issue watcher is tracking upload changes, we
+// refresh only the titles which changed, or which have issues old enough that
+// their validation results would change. Otherwise, or when changes may have
+// been missed, we rescan everything.
 func (s *Searcher) watch() {
+	var changesSince time.Time
 	for {
+		var dirs []issuewatcher.TitleDir
+		var lost bool
+		var interval = time.Second * secondsBetweenIssueReload
+		var uploads = watcher.Uploads()
+		if uploads != nil {
+			dirs, lost, changesSince = uploads.Changes(changesSince)
+			interval = time.Second * secondsBetweenFullRescan
+		}
+
 		s.RLock()
 		var since = time.Since(s.lastLoaded)
+		var ready = s.scanner != nil
 		s.RUnlock()
 
-		if since >= time.Second*secondsBetweenIssueReload {
-			var err = s.scan()
-			if err != nil {
-				s.Lock()
-				s.fails++
-				s.Unlock()
-				logger.Errorf("Searcher.scan(): %s", err)
+		var err error
+		if !ready || lost || since >= interval {
+			err = s.scan()
+		} else {
+			dirs = append(dirs, s.agedTitles()...)
+			if len(dirs) > 0 {
+				err = s.refreshTitles(dirs)
 			}
 		}
 
+		if err != nil {
+			s.Lock()
+			s.fails++
+			s.Unlock()
+			logger.Errorf("Searcher.scan(): %s", err)
+		}
+
 		time.Sleep(time.Second)
 	}
 }
 
+// agedTitles returns the directories of titles which have an issue that just
+// crossed an age threshold
+func (s *Searcher) agedTitles() []issuewatcher.TitleDir {
+	s.RLock()
+	defer s.RUnlock()
+
+	var dirs []issuewatcher.TitleDir
+	var now = time.Now()
+	for _, t := range s.titles {
+		if t.nextValidation.IsZero() || t.nextValidation.After(now) {
+			continue
+		}
+
+		var ns = issuefinder.ScanUpload
+		if t.Type == TitleTypeBornDigital {
+			ns = issuefinder.SFTPUpload
+		}
+		dirs = append(dirs, issuewatcher.TitleDir{Namespace: ns, Path: t.Location})
+	}
+
+	return dirs
+}
+
+// refreshTitles rescans only the given title directories and redecorates the
+// titles found there, leaving all other titles alone
+func (s *Searcher) refreshTitles(dirs []issuewatcher.TitleDir) error {
+	var err = s.BuildInProcessList()
+	if err != nil {
+		return fmt.Errorf("unable to build in-process issue list: %w", err)
+	}
+
+	s.RLock()
+	var scanner = s.scanner
+	var titles = s.titles
+	s.RUnlock()
+
+	var nextScanner *issuewatcher.Scanner
+	nextScanner, err = scanner.RefreshTitles(dirs)
+	if err != nil {
+		return fmt.Errorf("unable to refresh titles: %w", err)
+	}
+
+	var refreshed = make(map[string]bool)
+	for _, td := range dirs {
+		refreshed[td.Path] = true
+	}
+
+	var nextTitles = make([]*Title, 0, len(titles))
+	var nextTitleLookup = make(map[string]*Title)
+	for _, t := range titles {
+		if !refreshed[t.Location] {
+			nextTitles = append(nextTitles, t)
+			nextTitleLookup[t.Slug()] = t
+		}
+	}
+	for _, t := range nextScanner.Finder.Titles {
+		if !refreshed[t.Location] {
+			continue
+		}
+		var title, err = s.makeTitle(t)
+		if err != nil {
+			logger.Errorf("Unable to build title: %s", err)
+			continue
+		}
+		nextTitles = append(nextTitles, title)
+		nextTitleLookup[title.Slug()] = title
+	}
+
+	s.Lock()
+	s.scanner = nextScanner
+	s.fails = 0
+	s.Unlock()
+	s.swapTitleData(nextTitles, nextTitleLookup)
+
+	return nil
+}
+
 func (s *Searcher) scan() error {
 	var err = s.BuildInProcessList()
 	if err != nil {
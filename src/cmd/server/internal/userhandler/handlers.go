@@ -153,7 +153,21 @@ func getUserForSave(r *responder.Responder) (u *models.User, handled bool) {
 		u = models.NewUser(login)
 	}
 
-	return u, applyRoles(r, u)
+	handled = applyRoles(r, u)
+	if !handled {
+		applyAutoAssign(r, u)
+	}
+	return u, handled
+}
+
+// applyAutoAssign reads the auto-assign checkbox. The form sends a hidden "0"
+// ahead of the checkbox so we can tell "unchecked" from "not on the form"; if
+// the checkbox is set, its "1" is the last value.
+func applyAutoAssign(r *responder.Responder, u *models.User) {
+	var vals = r.Request.Form["auto_assign"]
+	if len(vals) > 0 {
+		u.AutoAssign = vals[len(vals)-1] == "1"
+	}
 }
 
 // applyRoles parses the form to figure out grants / denies and sets them on
@@ -251,7 +265,7 @@ func saveHandler(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	r.Audit(models.AuditActionSaveUser, fmt.Sprintf("Login: %q, roles: %q", u.Login, u.RolesString))
+	r.Audit(models.AuditActionSaveUser, fmt.Sprintf("Login: %q, roles: %q, auto-assign: %t", u.Login, u.RolesString, u.AutoAssign))
 	http.SetCookie(w, &http.Cookie{Name: "Info", Value: "User data saved", Path: "/"})
 	http.Redirect(w, req, basePath, http.StatusFound)
 }
@@ -0,0 +1,116 @@
+package workflowhandler
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/uoregon-libraries/newspaper-curation-app/internal/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/cmd/server/internal/responder"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+)
+
+// readAnnotations pulls the list of page/field annotations from the reject
+// form. Rows with no message are skipped so reviewers can leave blank rows in
+// the form without worrying about them. Invalid rows result in an error
+// suitable for showing to the reviewer.
+func readAnnotations(resp *responder.Responder, i *Issue) ([]*models.Annotation, error) {
+	var form = resp.Request.Form
+	var pages, fields, messages = form["annotation_page"], form["annotation_field"], form["annotation_message"]
+	if len(pages) != len(messages) || len(fields) != len(messages) {
+		return nil, fmt.Errorf("annotation form data is incomplete")
+	}
+
+	var numPages = len(i.JP2Files())
+	var list []*models.Annotation
+	for idx, msg := range messages {
+		if msg == "" {
+			continue
+		}
+
+		var page int
+		if pages[idx] != "" {
+			var err error
+			page, err = strconv.Atoi(pages[idx])
+			if err != nil || page < 1 || page > numPages {
+				return nil, fmt.Errorf("annotation %d: page must be a number from 1 to %d", idx+1, numPages)
+			}
+		}
+
+		var a = models.NewAnnotation(i.ID, page, fields[idx], msg)
+		var err = a.Validate()
+		if err != nil {
+			return nil, fmt.Errorf("annotation %d: %w", idx+1, err)
+		}
+		list = append(list, a)
+	}
+
+	return list, nil
+}
+
+// findAnnotation looks up the annotation from the request's path and verifies
+// it belongs to the given issue. On failure, an error response is rendered
+// and nil is returned.
+func findAnnotation(resp *responder.Responder, i *Issue) *models.Annotation {
+	var idStr = mux.Vars(resp.Request)["annotation_id"]
+	var id, _ = strconv.ParseInt(idStr, 10, 64)
+	if id == 0 {
+		resp.Error(http.StatusBadRequest, "Invalid annotation")
+		return nil
+	}
+
+	var a, err = models.FindAnnotation(id)
+	if err != nil {
+		logger.Errorf("Unable to look up annotation %d: %s", id, err)
+		resp.Error(http.StatusInternalServerError, "Database error; try again or contact the system administrator")
+		return nil
+	}
+	if a == nil || a.IssueID != i.ID {
+		resp.Error(http.StatusNotFound, "Annotation not found")
+		return nil
+	}
+
+	return a
+}
+
+// resolveAnnotation marks the requested annotation as resolved and sends the
+// user back to the page they came from
+func resolveAnnotation(resp *responder.Responder, i *Issue, returnPath string) {
+	var a = findAnnotation(resp, i)
+	if a == nil {
+		return
+	}
+
+	if a.Resolved() {
+		http.SetCookie(resp.Writer, &http.Cookie{Name: "Info", Value: "Annotation was already resolved", Path: "/"})
+		http.Redirect(resp.Writer, resp.Request, i.Path(returnPath), http.StatusFound)
+		return
+	}
+
+	var err = a.Resolve(resp.Vars.User.ID)
+	if err != nil {
+		logger.Errorf("Unable to resolve annotation %d on issue id %d: %s", a.ID, i.ID, err)
+		resp.Vars.Alert = template.HTML("Error trying to resolve the annotation; try again or contact support")
+		resp.Writer.WriteHeader(http.StatusInternalServerError)
+		resp.Render(responder.Empty)
+		return
+	}
+
+	resp.Audit(models.AuditActionResolveAnnotation, fmt.Sprintf("issue id %d, annotation id %d", i.ID, a.ID))
+	http.SetCookie(resp.Writer, &http.Cookie{Name: "Info", Value: "Annotation resolved", Path: "/"})
+	http.Redirect(resp.Writer, resp.Request, i.Path(returnPath), http.StatusFound)
+}
+
+// resolveCuratorAnnotationHandler lets the curator resolve an annotation from
+// the metadata entry page
+func resolveCuratorAnnotationHandler(resp *responder.Responder, i *Issue) {
+	resolveAnnotation(resp, i, "metadata")
+}
+
+// resolveReviewerAnnotationHandler lets the reviewer resolve an annotation
+// from the metadata review page
+func resolveReviewerAnnotationHandler(resp *responder.Responder, i *Issue) {
+	resolveAnnotation(resp, i, "review/metadata")
+}
@@ -1,6 +1,7 @@
 package workflowhandler
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"html/template"
@@ -261,13 +262,21 @@ func rejectIssueMetadataFormHandler(resp *responder.Responder, i *Issue) {
 
 func rejectIssueMetadataHandler(resp *responder.Responder, i *Issue) {
 	var notes = resp.Request.FormValue("notes")
-	if notes == "" {
+	var annotations, err = readAnnotations(resp, i)
+	if err != nil {
+		var msg = "Invalid annotation: " + template.HTMLEscapeString(err.Error())
+		http.SetCookie(resp.Writer, &http.Cookie{Name: "Alert", Value: "base64" + base64.StdEncoding.EncodeToString([]byte(msg)), Path: "/"})
+		http.Redirect(resp.Writer, resp.Request, i.Path("review/reject-form"), http.StatusFound)
+		return
+	}
+
+	if notes == "" && len(annotations) == 0 {
 		http.SetCookie(resp.Writer, &http.Cookie{Name: "Info", Value: "Rejection notes empty; no action taken", Path: "/"})
 		http.Redirect(resp.Writer, resp.Request, i.Path("review/metadata"), http.StatusFound)
 		return
 	}
 
-	var err = i.RejectMetadata(resp.Vars.User.ID, notes)
+	err = i.RejectMetadata(resp.Vars.User.ID, notes, annotations...)
 	if err != nil {
 		logger.Errorf("Unable to save issue id %d's rejection notes (POST: %#v): %s", i.ID, resp.Request.Form, err)
 		resp.Vars.Alert = template.HTML("Error trying to save rejection notes; try again or contact support")
@@ -276,7 +285,7 @@ func rejectIssueMetadataHandler(resp *responder.Responder, i *Issue) {
 		return
 	}
 
-	resp.Audit(models.AuditActionRejectMetadata, fmt.Sprintf("issue id %d", i.ID))
+	resp.Audit(models.AuditActionRejectMetadata, fmt.Sprintf("issue id %d, %d annotation(s)", i.ID, len(annotations)))
 	http.SetCookie(resp.Writer, &http.Cookie{Name: "Info", Value: "Issue rejected", Path: "/"})
 	http.Redirect(resp.Writer, resp.Request, basePath, http.StatusFound)
 }
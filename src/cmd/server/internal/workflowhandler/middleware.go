@@ -92,6 +92,11 @@ func canView(h HandlerFunc) HandlerFunc {
 	return MustHavePrivilege(privilege.ViewMetadataWorkflow, h)
 }
 
+// canViewQueues verifies user can see everybody's work queues
+func canViewQueues(h HandlerFunc) HandlerFunc {
+	return MustHavePrivilege(privilege.ViewWorkQueues, h)
+}
+
 func canHandler(h HandlerFunc, canFunc func(*CanValidation, *Issue)) HandlerFunc {
 	return HandlerFunc(func(resp *responder.Responder, i *Issue) {
 		var can = Can(resp.Vars.User)
@@ -0,0 +1,24 @@
+package workflowhandler
+
+import (
+	"net/http"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/internal/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/cmd/server/internal/responder"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/workload"
+)
+
+// queuesHandler shows managers what is on each person's desk
+func queuesHandler(resp *responder.Responder, _ *Issue) {
+	var queues, err = workload.Queues()
+	if err != nil {
+		logger.Errorf("Unable to load work queues: %s", err)
+		resp.Error(http.StatusInternalServerError, "Error trying to load work queues - try again or contact support")
+		return
+	}
+
+	resp.Vars.Title = "Work Queues"
+	resp.Vars.Data["Queues"] = queues
+	resp.Vars.Data["MaxLoad"] = conf.AutoAssignMaxLoad
+	resp.Render(QueuesTmpl)
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/uoregon-libraries/newspaper-curation-app/src/cmd/server/internal/responder"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/issuewatcher"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/web/tmpl"
 )
 
@@ -49,6 +50,9 @@ var (
 
 	// ViewIssueTmpl renders a read-only display of an issue
 	ViewIssueTmpl *tmpl.Template
+
+	// QueuesTmpl renders everybody's claimed issues for workflow managers
+	QueuesTmpl *tmpl.Template
 )
 
 // Setup sets up all the workflow-specific routing rules and does any other
@@ -62,6 +66,7 @@ func Setup(r *mux.Router, webPath string, c *config.Config, w *issuewatcher.Watc
 	var s = r.PathPrefix(basePath).Subrouter()
 	s.Path("").Handler(handle(canView(homeHandler)))
 	s.Path("/json").Handler(handle(canView(jsonHandler)))
+	s.Path("/queues").Handler(handle(canViewQueues(queuesHandler)))
 
 	// All other paths are centered around a specific issue
 	var s2 = s.PathPrefix("/{issue_id}").Subrouter()
@@ -78,6 +83,7 @@ func Setup(r *mux.Router, webPath string, c *config.Config, w *issuewatcher.Watc
 	s2.Path("/metadata/save").Methods("POST").Handler(handle(canEnterMetadata(saveMetadataHandler)))
 	s2.Path("/report-error").Handler(handle(canEnterMetadata(enterErrorHandler)))
 	s2.Path("/report-error/save").Methods("POST").Handler(handle(canEnterMetadata(saveErrorHandler)))
+	s2.Path("/metadata/annotations/{annotation_id}/resolve").Methods("POST").Handler(handle(canEnterMetadata(resolveCuratorAnnotationHandler)))
 
 	// Review paths
 	var s3 = s2.PathPrefix("/review").Subrouter()
@@ -85,6 +91,7 @@ func Setup(r *mux.Router, webPath string, c *config.Config, w *issuewatcher.Watc
 	s3.Path("/reject-form").Handler(handle(canReviewMetadata(rejectIssueMetadataFormHandler)))
 	s3.Path("/reject").Methods("POST").Handler(handle(canReviewMetadata(rejectIssueMetadataHandler)))
 	s3.Path("/approve").Methods("POST").Handler(handle(canReviewMetadata(approveIssueMetadataHandler)))
+	s3.Path("/annotations/{annotation_id}/resolve").Methods("POST").Handler(handle(canReviewMetadata(resolveReviewerAnnotationHandler)))
 
 	// Error review paths
 	var s4 = s2.PathPrefix("/errors").Subrouter()
@@ -96,8 +103,9 @@ func Setup(r *mux.Router, webPath string, c *config.Config, w *issuewatcher.Watc
 
 	Layout = responder.Layout.Clone()
 	Layout.Funcs(tmpl.FuncMap{
-		"Can":             Can,
-		"WorkflowHomeURL": func() string { return basePath },
+		"Can":              Can,
+		"WorkflowHomeURL":  func() string { return basePath },
+		"AnnotationFields": func() []models.AnnotationField { return models.AnnotationFields },
 	})
 	Layout.Path = path.Join(Layout.Path, "workflow")
 	Layout.MustReadPartials("_osdjs.go.html", "_view_issue.go.html")
@@ -110,4 +118,5 @@ func Setup(r *mux.Router, webPath string, c *config.Config, w *issuewatcher.Watc
 	RemoveIssueFromNCATmpl = Layout.MustBuild("error_remove_form.go.html")
 	RejectIssueTmpl = Layout.MustBuild("reject_issue.go.html")
 	ViewIssueTmpl = Layout.MustBuild("view_issue.go.html")
+	QueuesTmpl = Layout.MustBuild("queues.go.html")
 }
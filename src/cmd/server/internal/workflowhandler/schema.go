@@ -2,6 +2,7 @@ package workflowhandler
 
 import (
 	"encoding/base64"
+	"fmt"
 	"path"
 	"strconv"
 	"time"
@@ -192,6 +193,51 @@ func (i *Issue) ValidateMetadata() {
 	for _, err := range i.si.Errors.All() {
 		addError(err)
 	}
+
+	var numAnnotations = len(i.UnresolvedAnnotations())
+	if numAnnotations > 0 {
+		var msg = fmt.Sprintf("%d reviewer annotation(s) have not been resolved", numAnnotations)
+		addError(unresolvedAnnotationsWarning{apperr.BaseError{ErrorString: msg}})
+	}
+}
+
+// FieldAnnotations returns the unresolved annotations tied to the given
+// metadata field, for highlighting the field on the metadata entry form
+func (i *Issue) FieldAnnotations(field string) []*models.Annotation {
+	var list []*models.Annotation
+	for _, a := range i.UnresolvedAnnotations() {
+		if a.FieldName == field {
+			list = append(list, a)
+		}
+	}
+	return list
+}
+
+// PageAnnotations returns a map of zero-based page index to the text of any
+// unresolved annotations on that page, for the page viewer's JavaScript
+func (i *Issue) PageAnnotations() map[int][]string {
+	var m = make(map[int][]string)
+	for _, a := range i.UnresolvedAnnotations() {
+		if a.PageNumber > 0 {
+			var text = a.Message()
+			if a.FieldName != "" {
+				text = a.FieldLabel() + ": " + text
+			}
+			m[a.PageNumber-1] = append(m[a.PageNumber-1], text)
+		}
+	}
+	return m
+}
+
+// unresolvedAnnotationsWarning is a warning-level error for issues which have
+// reviewer annotations nobody has marked as resolved
+type unresolvedAnnotationsWarning struct {
+	apperr.BaseError
+}
+
+// Warning is always true: curators may decide an annotation needs no changes
+func (e unresolvedAnnotationsWarning) Warning() bool {
+	return true
 }
 
 // Errors returns validation errors
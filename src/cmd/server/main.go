@@ -15,10 +15,15 @@ import (
 	"github.com/uoregon-libraries/newspaper-curation-app/src/cmd/server/internal/audithandler"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/cmd/server/internal/batchhandler"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/cmd/server/internal/batchmakerhandler"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/cmd/server/internal/hookhandler"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/cmd/server/internal/issuefinderhandler"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/cmd/server/internal/mochandler"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/cmd/server/internal/publisherhandler"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/cmd/server/internal/reconcilehandler"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/cmd/server/internal/reporthandler"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/cmd/server/internal/responder"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/cmd/server/internal/settings"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/cmd/server/internal/storagehandler"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/cmd/server/internal/titlehandler"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/cmd/server/internal/uploadedissuehandler"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/cmd/server/internal/userhandler"
@@ -103,7 +108,7 @@ func startServer() {
 	r.NewRoute().PathPrefix(staticPrefix).Handler(http.StripPrefix(staticPrefix, fileServer))
 
 	var watcher = issuewatcher.New(conf)
-	go watcher.Watch(5 * time.Minute)
+	go watcher.Watch(5*time.Minute, 30*time.Minute)
 
 	var waited, lastWaited int
 	for watcher.Scanner.Finder.Issues == nil {
@@ -112,6 +117,10 @@ func startServer() {
 				"several minutes if the issues haven't been scanned in a while.  If this " +
 				"is the first time scanning the live site, expect 10 minutes or more to " +
 				"build the web JSON cache.")
+			if conf.LiveDiscovery != config.LiveDiscoveryIncremental {
+				logger.Infof("Setting LIVE_DISCOVERY to %q can make future scans of the "+
+					"live site much faster.", config.LiveDiscoveryIncremental)
+			}
 		} else if waited/30 > lastWaited {
 			logger.Infof("Still waiting...")
 			lastWaited = waited / 30
@@ -122,14 +131,19 @@ func startServer() {
 
 	// Set up routing for various "sub-apps"
 	uploadedissuehandler.Setup(r, path.Join(hp, "uploadedissues"), conf, watcher)
+	publisherhandler.Setup(r, path.Join(hp, "publisher"), conf, watcher)
 	workflowhandler.Setup(r, path.Join(hp, "workflow"), conf, watcher)
 	issuefinderhandler.Setup(r, path.Join(hp, "find"), watcher)
-	mochandler.Setup(r, path.Join(hp, "mocs"))
+	mochandler.Setup(r, path.Join(hp, "mocs"), conf)
 	batchhandler.Setup(r, path.Join(hp, "batches"), conf)
 	userhandler.Setup(r, path.Join(hp, "users"))
 	titlehandler.Setup(r, path.Join(hp, "titles"), conf)
 	audithandler.Setup(r, path.Join(hp, "logs"))
+	reporthandler.Setup(r, path.Join(hp, "reports"))
 	batchmakerhandler.Setup(r, path.Join(hp, "batchmaker"), conf)
+	reconcilehandler.Setup(r, path.Join(hp, "reconcile"), conf)
+	storagehandler.Setup(r, path.Join(hp, "storage"), conf)
+	hookhandler.Setup(r, path.Join(hp, "hooks"), conf, watcher)
 
 	r.NewRoute().Path(hp).HandlerFunc(home)
 
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/internal/datasize"
+)
+
+// ArchiveTargetType tells NCA where live batches are archived
+type ArchiveTargetType string
+
+// All valid archive target types
+const (
+	// ArchiveFilesystem copies batches into BATCH_ARCHIVE_PATH, which is how
+	// NCA has always archived batches
+	ArchiveFilesystem ArchiveTargetType = "filesystem"
+
+	// ArchiveS3 uploads batches to an S3-compatible object store
+	ArchiveS3 ArchiveTargetType = "s3"
+
+	// ArchiveTape copies batches into a staging directory along with a
+	// manifest, for tape software to pick up
+	ArchiveTape ArchiveTargetType = "tape"
+)
+
+// DefaultArchiveS3PartSize is the size of each piece of a multipart upload
+// when ARCHIVE_S3_PART_SIZE isn't set. Files no larger than this are uploaded
+// in a single request.
+const DefaultArchiveS3PartSize datasize.Datasize = 64 * datasize.MB
+
+// MinArchiveS3PartSize is the smallest part S3 allows in a multipart upload
+const MinArchiveS3PartSize datasize.Datasize = 5 * datasize.MB
+
+// ArchiveTarget describes where live batches are archived
+type ArchiveTarget struct {
+	Type ArchiveTargetType
+
+	// Path is the archive directory for filesystem targets, or the staging
+	// directory for tape targets
+	Path string
+
+	// S3 connection settings
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3Prefix    string
+	S3AccessKey string
+	S3SecretKey string
+	S3PartSize  datasize.Datasize
+}
+
+// parseArchiveTarget reads the ARCHIVE_TARGET setting and the settings its
+// type needs. With no ARCHIVE_TARGET, batches go to BATCH_ARCHIVE_PATH as
+// they always have.
+func parseArchiveTarget(get func(string) string, archivePath string) (*ArchiveTarget, []string) {
+	var t = &ArchiveTarget{Type: ArchiveTargetType(get("ARCHIVE_TARGET"))}
+	var errors []string
+	var required = func(setting, val string) {
+		if val == "" {
+			errors = append(errors, fmt.Sprintf("invalid %s: required when ARCHIVE_TARGET is %q", setting, t.Type))
+		}
+	}
+
+	switch t.Type {
+	case "", ArchiveFilesystem:
+		t.Type = ArchiveFilesystem
+		t.Path = archivePath
+
+	case ArchiveTape:
+		t.Path = get("ARCHIVE_TAPE_STAGING_PATH")
+		required("ARCHIVE_TAPE_STAGING_PATH", t.Path)
+
+	case ArchiveS3:
+		t.S3Endpoint = get("ARCHIVE_S3_ENDPOINT")
+		t.S3Region = get("ARCHIVE_S3_REGION")
+		t.S3Bucket = get("ARCHIVE_S3_BUCKET")
+		t.S3Prefix = get("ARCHIVE_S3_PREFIX")
+		t.S3AccessKey = get("ARCHIVE_S3_ACCESS_KEY")
+		t.S3SecretKey = get("ARCHIVE_S3_SECRET_KEY")
+		if t.S3Region == "" {
+			t.S3Region = "us-east-1"
+		}
+		if t.S3Endpoint != "" && !isHTTPURL(t.S3Endpoint) {
+			errors = append(errors, "invalid ARCHIVE_S3_ENDPOINT: must be an http or https URL")
+		}
+		required("ARCHIVE_S3_ENDPOINT", t.S3Endpoint)
+		required("ARCHIVE_S3_BUCKET", t.S3Bucket)
+		required("ARCHIVE_S3_ACCESS_KEY", t.S3AccessKey)
+		required("ARCHIVE_S3_SECRET_KEY", t.S3SecretKey)
+
+		t.S3PartSize = DefaultArchiveS3PartSize
+		var size = get("ARCHIVE_S3_PART_SIZE")
+		if size != "" {
+			var err error
+			t.S3PartSize, err = datasize.New(size)
+			if err != nil {
+				errors = append(errors, fmt.Sprintf("invalid ARCHIVE_S3_PART_SIZE: %s", err))
+			} else if t.S3PartSize < MinArchiveS3PartSize {
+				errors = append(errors, "invalid ARCHIVE_S3_PART_SIZE: must be at least 5mb")
+			}
+		}
+
+	default:
+		errors = append(errors, fmt.Sprintf("invalid ARCHIVE_TARGET: must be %q, %q, or %q", ArchiveFilesystem, ArchiveS3, ArchiveTape))
+	}
+
+	return t, errors
+}
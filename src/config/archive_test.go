@@ -0,0 +1,73 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/internal/datasize"
+)
+
+func TestParseArchiveTarget(t *testing.T) {
+	var s3 = map[string]string{
+		"ARCHIVE_TARGET":        "s3",
+		"ARCHIVE_S3_ENDPOINT":   "https://minio.example.org:9000",
+		"ARCHIVE_S3_BUCKET":     "archive",
+		"ARCHIVE_S3_ACCESS_KEY": "key",
+		"ARCHIVE_S3_SECRET_KEY": "secret",
+	}
+
+	var tests = map[string]struct {
+		settings    map[string]string
+		override    map[string]string
+		typ         ArchiveTargetType
+		path        string
+		partSize    datasize.Datasize
+		errContains string
+	}{
+		"default":         {settings: map[string]string{}, typ: ArchiveFilesystem, path: "/mnt/archive"},
+		"filesystem":      {settings: map[string]string{"ARCHIVE_TARGET": "filesystem"}, typ: ArchiveFilesystem, path: "/mnt/archive"},
+		"tape":            {settings: map[string]string{"ARCHIVE_TARGET": "tape", "ARCHIVE_TAPE_STAGING_PATH": "/mnt/tape"}, typ: ArchiveTape, path: "/mnt/tape"},
+		"tape no path":    {settings: map[string]string{"ARCHIVE_TARGET": "tape"}, errContains: "invalid ARCHIVE_TAPE_STAGING_PATH"},
+		"s3":              {settings: s3, typ: ArchiveS3, partSize: DefaultArchiveS3PartSize},
+		"s3 part size":    {settings: s3, override: map[string]string{"ARCHIVE_S3_PART_SIZE": "16mb"}, typ: ArchiveS3, partSize: 16 * datasize.MB},
+		"s3 tiny part":    {settings: s3, override: map[string]string{"ARCHIVE_S3_PART_SIZE": "1mb"}, errContains: "at least 5mb"},
+		"s3 bad part":     {settings: s3, override: map[string]string{"ARCHIVE_S3_PART_SIZE": "lots"}, errContains: "invalid ARCHIVE_S3_PART_SIZE"},
+		"s3 bad endpoint": {settings: s3, override: map[string]string{"ARCHIVE_S3_ENDPOINT": "minio:9000"}, errContains: "invalid ARCHIVE_S3_ENDPOINT"},
+		"s3 no bucket":    {settings: s3, override: map[string]string{"ARCHIVE_S3_BUCKET": ""}, errContains: "invalid ARCHIVE_S3_BUCKET"},
+		"s3 no secret":    {settings: s3, override: map[string]string{"ARCHIVE_S3_SECRET_KEY": ""}, errContains: "invalid ARCHIVE_S3_SECRET_KEY"},
+		"unknown":         {settings: map[string]string{"ARCHIVE_TARGET": "floppy"}, errContains: "invalid ARCHIVE_TARGET"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var get = func(key string) string {
+				var val, ok = tc.override[key]
+				if ok {
+					return val
+				}
+				return tc.settings[key]
+			}
+
+			var target, errs = parseArchiveTarget(get, "/mnt/archive")
+			if tc.errContains != "" {
+				var joined = strings.Join(errs, "; ")
+				if !strings.Contains(joined, tc.errContains) {
+					t.Fatalf("Expected error containing %q, got %q", tc.errContains, joined)
+				}
+				return
+			}
+			if len(errs) > 0 {
+				t.Fatalf("Unexpected errors: %v", errs)
+			}
+			if target.Type != tc.typ {
+				t.Errorf("Expected type %q, got %q", tc.typ, target.Type)
+			}
+			if target.Path != tc.path {
+				t.Errorf("Expected path %q, got %q", tc.path, target.Path)
+			}
+			if target.S3PartSize != tc.partSize {
+				t.Errorf("Expected part size %d, got %d", tc.partSize, target.S3PartSize)
+			}
+		})
+	}
+}
@@ -6,6 +6,9 @@ package config
 import (
 	"fmt"
 	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -13,6 +16,12 @@ import (
 	"github.com/uoregon-libraries/newspaper-curation-app/internal/datasize"
 )
 
+// Valid LIVE_DISCOVERY modes
+const (
+	LiveDiscoveryCrawl       = "crawl"
+	LiveDiscoveryIncremental = "incremental"
+)
+
 // Config holds the configuration needed for this application to work
 type Config struct {
 	// DatabaseConnect is the all-in-one database connection value built from the
@@ -29,9 +38,13 @@ type Config struct {
 	SFTPGoAdminAPIKey  string `setting:"SFTPGO_ADMIN_API_KEY"`
 	SFTPGoNewUserQuota datasize.Datasize
 
-	// ONI Agent connection strings
-	StagingAgentConnection    string `setting:"STAGING_AGENT"`
-	ProductionAgentConnection string `setting:"PRODUCTION_AGENT"`
+	// SFTPGoHookToken is the shared secret SFTPGo must send with upload
+	// notifications; if it's empty, notifications are refused
+	SFTPGoHookToken string
+
+	// ONIEnvironments lists every ONI instance NCA manages, built from the
+	// ONI_ENVIRONMENTS setting (or the older staging/production settings)
+	ONIEnvironments []*ONIEnvironment
 
 	// Binary paths
 	GhostScript    string `setting:"GHOSTSCRIPT"`
@@ -41,16 +54,33 @@ type Config struct {
 	PDFSeparate    string `setting:"PDF_SEPARATE"`
 	PDFToText      string `setting:"PDF_TO_TEXT"`
 
+	// XMLLint and BatchSchemaPath are optional: when both are set, built
+	// batches are validated against the NDNP XSDs in BatchSchemaPath
+	XMLLint         string
+	BatchSchemaPath string
+
 	// Web configuration
-	Webroot            string `setting:"WEBROOT" type:"url"`
-	BindAddress        string `setting:"BIND_ADDRESS"`
-	IIIFBaseURL        string `setting:"IIIF_BASE_URL" type:"url"`
-	NewsWebroot        string `setting:"NEWS_WEBROOT" type:"url"`
-	StagingNewsWebroot string `setting:"STAGING_NEWS_WEBROOT" type:"url"`
+	Webroot     string `setting:"WEBROOT" type:"url"`
+	BindAddress string `setting:"BIND_ADDRESS"`
+	IIIFBaseURL string `setting:"IIIF_BASE_URL" type:"url"`
+	NewsWebroot string `setting:"NEWS_WEBROOT" type:"url"`
+
+	// IssueCacheMaxSize is the most space the live site's cached JSON may use
+	// in IssueCachePath; zero means there's no limit
+	IssueCacheMaxSize datasize.Datasize
 
-	// MARC location(s) for getting XML for unknown titles
-	MARCLocation1 string `setting:"MARC_LOCATION_1"`
-	MARCLocation2 string `setting:"MARC_LOCATION_2"`
+	// LiveDiscovery is how the issue watcher finds what's on the live site:
+	// LiveDiscoveryCrawl or LiveDiscoveryIncremental
+	LiveDiscovery string
+
+	// MARCProviders lists the sources NCA asks for titles' MARC records, in
+	// the order they're tried, built from the MARC_PROVIDERS setting (or the
+	// older MARC_LOCATION_1 and MARC_LOCATION_2 settings)
+	MARCProviders []*MARCProvider
+
+	// QCChecks is the checklist batch reviewers complete before approving a
+	// batch, built from the QC_CHECKS setting
+	QCChecks []*QCCheck
 
 	// Paths to the various places we expect to find files
 	PDFUploadPath        string `setting:"PDF_UPLOAD_PATH" type:"path,create"`
@@ -67,6 +97,14 @@ type Config struct {
 	METSXMLTemplatePath  string `setting:"METS_XML_TEMPLATE_PATH" type:"file"`
 	BatchXMLTemplatePath string `setting:"BATCH_XML_TEMPLATE_PATH" type:"file"`
 
+	// ArchiveTarget is where live batches are archived, built from the
+	// ARCHIVE_TARGET setting and its related settings
+	ArchiveTarget *ArchiveTarget
+
+	// PublisherStagingPath holds in-progress uploads from the publisher portal
+	// until each file is complete and can be moved into PDFUploadPath
+	PublisherStagingPath string
+
 	// Issue processor / batch maker rules
 	MinimumIssuePages      int    `setting:"MINIMUM_ISSUE_PAGES" type:"int"`
 	PDFBatchMARCOrgCode    string `setting:"PDF_BATCH_MARC_ORG_CODE"`
@@ -77,6 +115,21 @@ type Config struct {
 	IssueDangerousDuration time.Duration
 	IssueNewDuration       time.Duration
 
+	// Workload balancing: zero means automatic assignment is disabled
+	AutoAssignMaxLoad int
+
+	// FixityAuditInterval is how often each copy of a live batch is verified
+	// against its bag manifest; zero means fixity audits are disabled
+	FixityAuditInterval time.Duration
+
+	// RetentionRules say how long leftover files are kept before the retention
+	// engine deletes them; no rules means nothing is ever deleted
+	RetentionRules []*RetentionRule
+
+	// StorageReserve is how much free space must be left on a filesystem after
+	// a heavy pipeline writes to it; pipelines that would cut into it are held
+	StorageReserve datasize.Datasize
+
 	// Derivative generation rules
 	DPI           int     `setting:"DPI" type:"int"`
 	Quality       float64 `setting:"QUALITY" type:"float"`
@@ -113,6 +166,11 @@ func Parse(filename string) (*Config, error) {
 	}
 	c.SFTPGoEnabled = (c.SFTPGoAPIURL != nil)
 
+	c.SFTPGoHookToken = bc.Get("SFTPGO_HOOK_TOKEN")
+	if c.SFTPGoHookToken != "" && len(c.SFTPGoHookToken) < 16 {
+		errors = append(errors, "invalid SFTPGO_HOOK_TOKEN: must be at least 16 characters")
+	}
+
 	// We validate the quota here rather than just reading it as a string - then
 	// end-users could get hit with errors just because they used the default
 	var quota = bc.Get("SFTPGO_NEW_USER_QUOTA")
@@ -135,6 +193,94 @@ func Parse(filename string) (*Config, error) {
 		errors = append(errors, fmt.Sprintf("invalid DURATION_ISSUE_CONSIDERED_NEW value: %s", err))
 	}
 
+	// Auto-assignment is optional, so we don't want to require the setting in
+	// existing configurations
+	var maxLoad = bc.Get("AUTO_ASSIGN_MAX_LOAD")
+	if maxLoad != "" {
+		c.AutoAssignMaxLoad, err = strconv.Atoi(maxLoad)
+		if err != nil || c.AutoAssignMaxLoad < 0 {
+			errors = append(errors, "invalid AUTO_ASSIGN_MAX_LOAD: must be a non-negative number")
+		}
+	}
+
+	// Fixity audits are optional, and off unless an interval is given
+	var fixity = bc.Get("FIXITY_AUDIT_INTERVAL")
+	if fixity != "" {
+		c.FixityAuditInterval, err = time.ParseDuration(fixity)
+		if err != nil || c.FixityAuditInterval < 0 {
+			errors = append(errors, "invalid FIXITY_AUDIT_INTERVAL: must be a duration such as \"2160h\"")
+		}
+	}
+
+	// The cache size limit is optional, and unlimited by default
+	var cacheMax = bc.Get("ISSUE_CACHE_MAX_SIZE")
+	if cacheMax != "" {
+		c.IssueCacheMaxSize, err = datasize.New(cacheMax)
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("invalid ISSUE_CACHE_MAX_SIZE: %s", err))
+		}
+	}
+
+	// The storage reserve is optional, and zero by default so pipelines are
+	// only held when they'd fill a disk outright
+	var reserve = bc.Get("STORAGE_RESERVE")
+	if reserve != "" {
+		c.StorageReserve, err = datasize.New(reserve)
+		if err != nil || c.StorageReserve < 0 {
+			errors = append(errors, fmt.Sprintf("invalid STORAGE_RESERVE: %q must be a size such as \"50G\"", reserve))
+		}
+	}
+
+	// Live discovery mode is optional so existing configurations keep crawling
+	c.LiveDiscovery = bc.Get("LIVE_DISCOVERY")
+	switch c.LiveDiscovery {
+	case "":
+		c.LiveDiscovery = LiveDiscoveryCrawl
+	case LiveDiscoveryCrawl, LiveDiscoveryIncremental:
+	default:
+		errors = append(errors, fmt.Sprintf("invalid LIVE_DISCOVERY: must be %q or %q", LiveDiscoveryCrawl, LiveDiscoveryIncremental))
+	}
+
+	// Schema validation is optional, but if it's configured, the schemas must
+	// all be present so a typo doesn't silently skip validation
+	c.XMLLint = bc.Get("XMLLINT")
+	c.BatchSchemaPath = bc.Get("BATCH_SCHEMA_PATH")
+	if c.XMLLint != "" && c.BatchSchemaPath != "" {
+		for _, name := range []string{"batch.xsd", "mets.xsd", "alto.xsd"} {
+			var info, err = os.Stat(filepath.Join(c.BatchSchemaPath, name))
+			if err != nil || info.IsDir() {
+				errors = append(errors, fmt.Sprintf("invalid BATCH_SCHEMA_PATH: %q is missing %s", c.BatchSchemaPath, name))
+			}
+		}
+	}
+
+	var envErrors []string
+	c.ONIEnvironments, envErrors = parseONIEnvironments(bc.Get)
+	errors = append(errors, envErrors...)
+
+	var archiveErrors []string
+	c.ArchiveTarget, archiveErrors = parseArchiveTarget(bc.Get, c.BatchArchivePath)
+	errors = append(errors, archiveErrors...)
+
+	var marcErrors []string
+	c.MARCProviders, marcErrors = parseMARCProviders(bc.Get)
+	errors = append(errors, marcErrors...)
+
+	var qcErrors []string
+	c.QCChecks, qcErrors = parseQCChecks(bc.Get)
+	errors = append(errors, qcErrors...)
+
+	var retentionErrors []string
+	c.RetentionRules, retentionErrors = parseRetentionRules(bc.Get)
+	errors = append(errors, retentionErrors...)
+
+	// The publisher portal's staging area defaults to a directory under the
+	// issue cache so existing configurations needn't change
+	c.PublisherStagingPath = bc.Get("PUBLISHER_UPLOAD_STAGING_PATH")
+	if c.PublisherStagingPath == "" {
+		c.PublisherStagingPath = filepath.Join(c.IssueCachePath, "publisher-uploads")
+	}
+
 	if c.DPI < 72 {
 		errors = append(errors, "invalid DPI: must be numeric and at least 72 (150 or higher is preferred)")
 	}
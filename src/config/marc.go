@@ -0,0 +1,181 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/uoregon-libraries/gopkg/fileutil"
+)
+
+// MARCProviderType tells NCA how a MARC provider's location is used to look
+// up a record
+type MARCProviderType string
+
+// All valid MARC provider types
+const (
+	// MARCProviderFile reads a local file; the location is a path template
+	// containing "{{lccn}}"
+	MARCProviderFile MARCProviderType = "file"
+
+	// MARCProviderDir reads "<lccn>.xml" or "<lccn>/marc.xml" from a local
+	// directory
+	MARCProviderDir MARCProviderType = "dir"
+
+	// MARCProviderHTTP requests a URL; the location is a URL template
+	// containing "{{lccn}}"
+	MARCProviderHTTP MARCProviderType = "http"
+
+	// MARCProviderSRU queries an SRU endpoint (such as a Z39.50 server's SRU
+	// gateway) for MARCXML
+	MARCProviderSRU MARCProviderType = "sru"
+
+	// MARCProviderDump finds records in a local MARCXML collection file, such
+	// as a bulk export from a catalog, which is indexed by LCCN
+	MARCProviderDump MARCProviderType = "dump"
+)
+
+// DefaultMARCProviderTimeout is how long a provider gets to return a record
+// when its TIMEOUT isn't set
+const DefaultMARCProviderTimeout = 30 * time.Second
+
+// DefaultSRUQuery is the CQL query sent to SRU providers when their QUERY
+// isn't set
+const DefaultSRUQuery = `bath.lccn="{{lccn}}"`
+
+// MARCProvider describes a single source of MARC records for titles
+type MARCProvider struct {
+	Name     string
+	Type     MARCProviderType
+	Location string
+	Timeout  time.Duration
+
+	// Query is the CQL query template for SRU providers
+	Query string
+}
+
+// parseMARCProviders reads the providers named in MARC_PROVIDERS, in order.
+// If that setting is empty, MARC_LOCATION_1 and MARC_LOCATION_2 are turned
+// into providers named "location1" and "location2" so older configurations
+// keep working.
+func parseMARCProviders(get func(string) string) ([]*MARCProvider, []string) {
+	var names = strings.Fields(get("MARC_PROVIDERS"))
+	if len(names) == 0 {
+		return parseLegacyMARCProviders(get)
+	}
+
+	var providers []*MARCProvider
+	var errors []string
+	var seen = make(map[string]bool)
+	for _, name := range names {
+		if !validEnvName.MatchString(name) {
+			errors = append(errors, fmt.Sprintf("invalid MARC_PROVIDERS: %q must be lowercase letters, numbers, and underscores", name))
+			continue
+		}
+		if seen[name] {
+			errors = append(errors, fmt.Sprintf("invalid MARC_PROVIDERS: %q is listed more than once", name))
+			continue
+		}
+		seen[name] = true
+
+		var prefix = "MARC_" + strings.ToUpper(name) + "_"
+		var p = &MARCProvider{
+			Name:     name,
+			Type:     MARCProviderType(get(prefix + "TYPE")),
+			Location: get(prefix + "LOCATION"),
+			Timeout:  DefaultMARCProviderTimeout,
+			Query:    get(prefix + "QUERY"),
+		}
+		if p.Query == "" {
+			p.Query = DefaultSRUQuery
+		}
+
+		var timeout = get(prefix + "TIMEOUT")
+		if timeout != "" {
+			var d, err = time.ParseDuration(timeout)
+			if err != nil || d <= 0 {
+				errors = append(errors, fmt.Sprintf("invalid %sTIMEOUT: must be a positive duration, such as \"10s\"", prefix))
+			}
+			p.Timeout = d
+		}
+
+		errors = append(errors, validateMARCProvider(p, prefix)...)
+		providers = append(providers, p)
+	}
+
+	return providers, errors
+}
+
+func parseLegacyMARCProviders(get func(string) string) ([]*MARCProvider, []string) {
+	var providers []*MARCProvider
+	var errors []string
+	for i, setting := range []string{"MARC_LOCATION_1", "MARC_LOCATION_2"} {
+		var loc = get(setting)
+		if loc == "" {
+			continue
+		}
+
+		var p = &MARCProvider{
+			Name:     fmt.Sprintf("location%d", i+1),
+			Type:     MARCProviderFile,
+			Location: loc,
+			Timeout:  DefaultMARCProviderTimeout,
+		}
+		if strings.HasPrefix(loc, "http") {
+			p.Type = MARCProviderHTTP
+		}
+		providers = append(providers, p)
+
+		if !strings.Contains(loc, "{{lccn}}") {
+			errors = append(errors, fmt.Sprintf("invalid %s: must contain {{lccn}}", setting))
+		}
+	}
+
+	return providers, errors
+}
+
+// validateMARCProvider checks that the provider's location makes sense for
+// its type. prefix is used to report which setting has a problem.
+func validateMARCProvider(p *MARCProvider, prefix string) []string {
+	var errors []string
+	var locErr = func(msg string) {
+		errors = append(errors, fmt.Sprintf("invalid %sLOCATION: %s", prefix, msg))
+	}
+
+	switch p.Type {
+	case MARCProviderFile:
+		if !strings.Contains(p.Location, "{{lccn}}") {
+			locErr("must contain {{lccn}}")
+		}
+	case MARCProviderHTTP:
+		if !isHTTPURL(p.Location) || !strings.Contains(p.Location, "{{lccn}}") {
+			locErr("must be a full http or https URL containing {{lccn}}")
+		}
+	case MARCProviderDir:
+		if !fileutil.IsDir(p.Location) {
+			locErr(fmt.Sprintf("%q is not a directory", p.Location))
+		}
+	case MARCProviderSRU:
+		if !isHTTPURL(p.Location) {
+			locErr("must be a full http or https URL")
+		}
+		if !strings.Contains(p.Query, "{{lccn}}") {
+			errors = append(errors, fmt.Sprintf("invalid %sQUERY: must contain {{lccn}}", prefix))
+		}
+	case MARCProviderDump:
+		if !fileutil.IsFile(p.Location) {
+			locErr(fmt.Sprintf("%q is not a file", p.Location))
+		}
+	default:
+		errors = append(errors, fmt.Sprintf("invalid %sTYPE: must be one of %q, %q, %q, %q, or %q", prefix,
+			MARCProviderFile, MARCProviderDir, MARCProviderHTTP, MARCProviderSRU, MARCProviderDump))
+	}
+
+	return errors
+}
+
+func isHTTPURL(s string) bool {
+	var u, err = url.Parse(s)
+	return s != "" && err == nil && strings.HasPrefix(u.Scheme, "http") && u.Host != ""
+}
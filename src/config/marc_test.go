@@ -0,0 +1,97 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseMARCProviders(t *testing.T) {
+	var dir = t.TempDir()
+	var dump = filepath.Join(dir, "dump.xml")
+	var err = os.WriteFile(dump, nil, 0600)
+	if err != nil {
+		t.Fatalf("Unable to write dump: %s", err)
+	}
+
+	var base = map[string]string{
+		"MARC_PROVIDERS":        "local catalog dump loc",
+		"MARC_LOCAL_TYPE":       "dir",
+		"MARC_LOCAL_LOCATION":   dir,
+		"MARC_CATALOG_TYPE":     "sru",
+		"MARC_CATALOG_LOCATION": "https://catalog.example.org/sru",
+		"MARC_CATALOG_TIMEOUT":  "5s",
+		"MARC_DUMP_TYPE":        "dump",
+		"MARC_DUMP_LOCATION":    dump,
+		"MARC_LOC_TYPE":         "http",
+		"MARC_LOC_LOCATION":     "https://chroniclingamerica.loc.gov/lccn/{{lccn}}/marc.xml",
+	}
+	var legacy = map[string]string{
+		"MARC_LOCATION_1": "/var/local/marc/{{lccn}}/marc.xml",
+		"MARC_LOCATION_2": "https://chroniclingamerica.loc.gov/lccn/{{lccn}}/marc.xml",
+	}
+
+	var tests = map[string]struct {
+		settings    map[string]string
+		override    map[string]string
+		names       []string
+		types       []MARCProviderType
+		errContains string
+	}{
+		"valid":           {settings: base, names: []string{"local", "catalog", "dump", "loc"}, types: []MARCProviderType{"dir", "sru", "dump", "http"}},
+		"legacy":          {settings: legacy, names: []string{"location1", "location2"}, types: []MARCProviderType{"file", "http"}},
+		"legacy one":      {settings: legacy, override: map[string]string{"MARC_LOCATION_1": ""}, names: []string{"location2"}, types: []MARCProviderType{"http"}},
+		"none":            {settings: map[string]string{}},
+		"duplicate":       {settings: base, override: map[string]string{"MARC_PROVIDERS": "loc loc"}, errContains: "more than once"},
+		"bad type":        {settings: base, override: map[string]string{"MARC_LOC_TYPE": "z3950"}, errContains: "invalid MARC_LOC_TYPE"},
+		"bad timeout":     {settings: base, override: map[string]string{"MARC_CATALOG_TIMEOUT": "soon"}, errContains: "invalid MARC_CATALOG_TIMEOUT"},
+		"no placeholder":  {settings: base, override: map[string]string{"MARC_LOC_LOCATION": "https://example.org/marc.xml"}, errContains: "invalid MARC_LOC_LOCATION"},
+		"bad dir":         {settings: base, override: map[string]string{"MARC_LOCAL_LOCATION": dump}, errContains: "not a directory"},
+		"bad dump":        {settings: base, override: map[string]string{"MARC_DUMP_LOCATION": dir}, errContains: "not a file"},
+		"bad sru query":   {settings: base, override: map[string]string{"MARC_CATALOG_QUERY": "dc.title=foo"}, errContains: "invalid MARC_CATALOG_QUERY"},
+		"legacy no lccn":  {settings: legacy, override: map[string]string{"MARC_LOCATION_2": "https://example.org"}, errContains: "invalid MARC_LOCATION_2"},
+		"bad sru address": {settings: base, override: map[string]string{"MARC_CATALOG_LOCATION": "catalog:210"}, errContains: "invalid MARC_CATALOG_LOCATION"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var get = func(key string) string {
+				var val, ok = tc.override[key]
+				if ok {
+					return val
+				}
+				return tc.settings[key]
+			}
+
+			var providers, errs = parseMARCProviders(get)
+			if tc.errContains != "" {
+				var all = strings.Join(errs, "; ")
+				if !strings.Contains(all, tc.errContains) {
+					t.Fatalf("Expected an error containing %q, got %q", tc.errContains, all)
+				}
+				return
+			}
+			if len(errs) > 0 {
+				t.Fatalf("Expected no errors, got %q", errs)
+			}
+
+			if len(providers) != len(tc.names) {
+				t.Fatalf("Expected providers %q, got %d providers", tc.names, len(providers))
+			}
+			for i, p := range providers {
+				if p.Name != tc.names[i] || p.Type != tc.types[i] {
+					t.Errorf("Expected provider %d to be %q (%s), got %q (%s)", i, tc.names[i], tc.types[i], p.Name, p.Type)
+				}
+				var want = DefaultMARCProviderTimeout
+				if p.Name == "catalog" {
+					want = 5 * time.Second
+				}
+				if p.Timeout != want {
+					t.Errorf("Expected provider %q's timeout to be %s, got %s", p.Name, want, p.Timeout)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/uoregon-libraries/gopkg/fileutil"
+)
+
+// ONIRole is the part an ONI environment plays in a batch's lifecycle
+type ONIRole string
+
+// All valid ONI environment roles
+const (
+	// ONIRoleStaging environments get batches as soon as they're built so they
+	// can be QCed, and have them purged if QC fails
+	ONIRoleStaging ONIRole = "staging"
+
+	// ONIRoleProduction environments get batches when they go live
+	ONIRoleProduction ONIRole = "production"
+)
+
+// ONIEnvironment describes a single ONI instance and the agent NCA uses to
+// manage it
+type ONIEnvironment struct {
+	Name    string
+	Role    ONIRole
+	Webroot string
+
+	// Agent connection settings: see openoni.Auth for details
+	AgentConnection string
+	AgentKnownHosts string
+	AgentUser       string
+	AgentKey        string
+}
+
+var validEnvName = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// ONIEnvironment returns the environment with the given name, or nil if no
+// such environment is configured
+func (c *Config) ONIEnvironment(name string) *ONIEnvironment {
+	for _, env := range c.ONIEnvironments {
+		if env.Name == name {
+			return env
+		}
+	}
+	return nil
+}
+
+// ONIEnvironmentsFor returns all environments with the given role, in the
+// order they were configured
+func (c *Config) ONIEnvironmentsFor(role ONIRole) []*ONIEnvironment {
+	var list []*ONIEnvironment
+	for _, env := range c.ONIEnvironments {
+		if env.Role == role {
+			list = append(list, env)
+		}
+	}
+	return list
+}
+
+// ResolveONIEnvironments returns the environments with the given names, in
+// the order given, skipping duplicates. A name which isn't an environment but
+// is one of the role names ("staging" or "production") stands for every
+// environment with that role: before environments were configurable, those
+// were the only names NCA used, and jobs queued back then still use them.
+// Any other unknown name is an error.
+func (c *Config) ResolveONIEnvironments(names []string) ([]*ONIEnvironment, error) {
+	var list []*ONIEnvironment
+	var seen = make(map[string]bool)
+	var add = func(env *ONIEnvironment) {
+		if !seen[env.Name] {
+			seen[env.Name] = true
+			list = append(list, env)
+		}
+	}
+
+	for _, name := range names {
+		var env = c.ONIEnvironment(name)
+		if env != nil {
+			add(env)
+			continue
+		}
+
+		var role = ONIRole(name)
+		if role != ONIRoleStaging && role != ONIRoleProduction {
+			return nil, fmt.Errorf("no ONI environment named %q", name)
+		}
+		for _, env := range c.ONIEnvironmentsFor(role) {
+			add(env)
+		}
+	}
+
+	return list, nil
+}
+
+// parseONIEnvironments reads the environments named in ONI_ENVIRONMENTS. If
+// that setting is empty, we fall back to the original fixed pair of staging
+// and production settings so older configurations keep working.
+func parseONIEnvironments(get func(string) string) ([]*ONIEnvironment, []string) {
+	var names = strings.Fields(get("ONI_ENVIRONMENTS"))
+	if len(names) == 0 {
+		return parseLegacyONIEnvironments(get)
+	}
+
+	var envs []*ONIEnvironment
+	var errors []string
+	var seen = make(map[string]bool)
+	for _, name := range names {
+		if !validEnvName.MatchString(name) {
+			errors = append(errors, fmt.Sprintf("invalid ONI_ENVIRONMENTS: %q must be lowercase letters, numbers, and underscores", name))
+			continue
+		}
+		if seen[name] {
+			errors = append(errors, fmt.Sprintf("invalid ONI_ENVIRONMENTS: %q is listed more than once", name))
+			continue
+		}
+		seen[name] = true
+
+		var prefix = "ONI_" + strings.ToUpper(name) + "_"
+		envs = append(envs, &ONIEnvironment{
+			Name:            name,
+			Role:            ONIRole(get(prefix + "ROLE")),
+			Webroot:         get(prefix + "WEBROOT"),
+			AgentConnection: get(prefix + "AGENT"),
+			AgentKnownHosts: get(prefix + "KNOWN_HOSTS"),
+			AgentUser:       get(prefix + "AGENT_USER"),
+			AgentKey:        get(prefix + "AGENT_KEY"),
+		})
+	}
+
+	return envs, append(errors, validateONIEnvironments(envs, func(env *ONIEnvironment, setting string) string {
+		return "ONI_" + strings.ToUpper(env.Name) + "_" + setting
+	})...)
+}
+
+func parseLegacyONIEnvironments(get func(string) string) ([]*ONIEnvironment, []string) {
+	var envs = []*ONIEnvironment{
+		{
+			Name:            string(ONIRoleStaging),
+			Role:            ONIRoleStaging,
+			Webroot:         get("STAGING_NEWS_WEBROOT"),
+			AgentConnection: get("STAGING_AGENT"),
+			AgentKnownHosts: get("STAGING_AGENT_KNOWN_HOSTS"),
+			AgentUser:       get("STAGING_AGENT_USER"),
+			AgentKey:        get("STAGING_AGENT_KEY"),
+		},
+		{
+			Name:            string(ONIRoleProduction),
+			Role:            ONIRoleProduction,
+			Webroot:         get("NEWS_WEBROOT"),
+			AgentConnection: get("PRODUCTION_AGENT"),
+			AgentKnownHosts: get("PRODUCTION_AGENT_KNOWN_HOSTS"),
+			AgentUser:       get("PRODUCTION_AGENT_USER"),
+			AgentKey:        get("PRODUCTION_AGENT_KEY"),
+		},
+	}
+
+	var settings = map[string]map[string]string{
+		"staging":    {"WEBROOT": "STAGING_NEWS_WEBROOT", "AGENT": "STAGING_AGENT", "KNOWN_HOSTS": "STAGING_AGENT_KNOWN_HOSTS"},
+		"production": {"WEBROOT": "NEWS_WEBROOT", "AGENT": "PRODUCTION_AGENT", "KNOWN_HOSTS": "PRODUCTION_AGENT_KNOWN_HOSTS"},
+	}
+	return envs, validateONIEnvironments(envs, func(env *ONIEnvironment, setting string) string {
+		return settings[env.Name][setting]
+	})
+}
+
+// validateONIEnvironments checks that each environment is complete, and that
+// the list as a whole can handle a batch's full lifecycle. settingName is used
+// to report which setting has a problem.
+func validateONIEnvironments(envs []*ONIEnvironment, settingName func(*ONIEnvironment, string) string) []string {
+	var errors []string
+	var roles = make(map[ONIRole]bool)
+	for _, env := range envs {
+		switch env.Role {
+		case ONIRoleStaging, ONIRoleProduction:
+			roles[env.Role] = true
+		default:
+			errors = append(errors, fmt.Sprintf("invalid %s: must be %q or %q", settingName(env, "ROLE"), ONIRoleStaging, ONIRoleProduction))
+		}
+
+		var u, err = url.Parse(env.Webroot)
+		if env.Webroot == "" || err != nil || !strings.HasPrefix(u.Scheme, "http") || u.Host == "" {
+			errors = append(errors, fmt.Sprintf("invalid %s: must be a full http or https URL", settingName(env, "WEBROOT")))
+		}
+		env.Webroot = strings.TrimRight(env.Webroot, "/")
+
+		if env.AgentConnection == "" {
+			errors = append(errors, fmt.Sprintf("invalid %s: must not be empty", settingName(env, "AGENT")))
+		}
+		if !fileutil.IsFile(env.AgentKnownHosts) {
+			errors = append(errors, fmt.Sprintf("invalid %s: %q is not a file", settingName(env, "KNOWN_HOSTS"), env.AgentKnownHosts))
+		}
+	}
+
+	for _, role := range []ONIRole{ONIRoleStaging, ONIRoleProduction} {
+		if !roles[role] {
+			errors = append(errors, fmt.Sprintf("invalid ONI environments: at least one environment must have the %q role", role))
+		}
+	}
+
+	return errors
+}
@@ -0,0 +1,160 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseONIEnvironments(t *testing.T) {
+	var knownHosts = filepath.Join(t.TempDir(), "known_hosts")
+	var err = os.WriteFile(knownHosts, nil, 0600)
+	if err != nil {
+		t.Fatalf("Unable to write known_hosts: %s", err)
+	}
+
+	var base = map[string]string{
+		"ONI_ENVIRONMENTS":           "staging production dr",
+		"ONI_STAGING_ROLE":           "staging",
+		"ONI_STAGING_WEBROOT":        "https://staging.example.org/",
+		"ONI_STAGING_AGENT":          "staging:22",
+		"ONI_STAGING_KNOWN_HOSTS":    knownHosts,
+		"ONI_PRODUCTION_ROLE":        "production",
+		"ONI_PRODUCTION_WEBROOT":     "https://example.org",
+		"ONI_PRODUCTION_AGENT":       "prod:22",
+		"ONI_PRODUCTION_KNOWN_HOSTS": knownHosts,
+		"ONI_DR_ROLE":                "production",
+		"ONI_DR_WEBROOT":             "https://dr.example.org",
+		"ONI_DR_AGENT":               "dr:2222",
+		"ONI_DR_KNOWN_HOSTS":         knownHosts,
+		"ONI_DR_AGENT_USER":          "nca",
+	}
+	var legacy = map[string]string{
+		"STAGING_NEWS_WEBROOT":         "https://staging.example.org",
+		"STAGING_AGENT":                "staging:22",
+		"STAGING_AGENT_KNOWN_HOSTS":    knownHosts,
+		"NEWS_WEBROOT":                 "https://example.org",
+		"PRODUCTION_AGENT":             "prod:22",
+		"PRODUCTION_AGENT_KNOWN_HOSTS": knownHosts,
+	}
+
+	var tests = map[string]struct {
+		settings    map[string]string
+		override    map[string]string
+		names       []string
+		errContains string
+	}{
+		"valid":            {settings: base, names: []string{"staging", "production", "dr"}},
+		"legacy":           {settings: legacy, names: []string{"staging", "production"}},
+		"bad name":         {settings: base, override: map[string]string{"ONI_ENVIRONMENTS": "staging production DR"}, errContains: `"DR" must be lowercase`},
+		"duplicate":        {settings: base, override: map[string]string{"ONI_ENVIRONMENTS": "staging production staging"}, errContains: "more than once"},
+		"bad role":         {settings: base, override: map[string]string{"ONI_DR_ROLE": "backup"}, errContains: "invalid ONI_DR_ROLE"},
+		"no staging":       {settings: base, override: map[string]string{"ONI_ENVIRONMENTS": "production dr"}, errContains: `"staging" role`},
+		"bad webroot":      {settings: base, override: map[string]string{"ONI_DR_WEBROOT": "dr.example.org"}, errContains: "invalid ONI_DR_WEBROOT"},
+		"no agent":         {settings: base, override: map[string]string{"ONI_DR_AGENT": ""}, errContains: "invalid ONI_DR_AGENT"},
+		"no known_hosts":   {settings: base, override: map[string]string{"ONI_DR_KNOWN_HOSTS": "/nonexistent"}, errContains: "invalid ONI_DR_KNOWN_HOSTS"},
+		"legacy no agent":  {settings: legacy, override: map[string]string{"PRODUCTION_AGENT": ""}, errContains: "invalid PRODUCTION_AGENT"},
+		"legacy bad hosts": {settings: legacy, override: map[string]string{"STAGING_AGENT_KNOWN_HOSTS": ""}, errContains: "invalid STAGING_AGENT_KNOWN_HOSTS"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var get = func(key string) string {
+				var val, ok = tc.override[key]
+				if ok {
+					return val
+				}
+				return tc.settings[key]
+			}
+
+			var envs, errs = parseONIEnvironments(get)
+			if tc.errContains != "" {
+				var all = strings.Join(errs, "; ")
+				if !strings.Contains(all, tc.errContains) {
+					t.Fatalf("Expected an error containing %q, got %q", tc.errContains, all)
+				}
+				return
+			}
+			if len(errs) > 0 {
+				t.Fatalf("Expected no errors, got %q", errs)
+			}
+
+			var names []string
+			for _, env := range envs {
+				names = append(names, env.Name)
+				if strings.HasSuffix(env.Webroot, "/") {
+					t.Errorf("Environment %q's webroot should have no trailing slash: %q", env.Name, env.Webroot)
+				}
+			}
+			if strings.Join(names, " ") != strings.Join(tc.names, " ") {
+				t.Errorf("Expected environments %q, got %q", tc.names, names)
+			}
+		})
+	}
+}
+
+func TestONIEnvironmentsFor(t *testing.T) {
+	var c = &Config{ONIEnvironments: []*ONIEnvironment{
+		{Name: "staging", Role: ONIRoleStaging},
+		{Name: "production", Role: ONIRoleProduction},
+		{Name: "preview", Role: ONIRoleStaging},
+	}}
+
+	var list = c.ONIEnvironmentsFor(ONIRoleStaging)
+	if len(list) != 2 || list[0].Name != "staging" || list[1].Name != "preview" {
+		t.Errorf("Expected staging and preview environments, got %#v", list)
+	}
+	if c.ONIEnvironment("preview") != c.ONIEnvironments[2] {
+		t.Errorf("Expected to find the preview environment by name")
+	}
+	if c.ONIEnvironment("dr") != nil {
+		t.Errorf("Expected no environment named dr")
+	}
+}
+
+func TestResolveONIEnvironments(t *testing.T) {
+	var c = &Config{ONIEnvironments: []*ONIEnvironment{
+		{Name: "stage_main", Role: ONIRoleStaging},
+		{Name: "production", Role: ONIRoleProduction},
+		{Name: "preview", Role: ONIRoleStaging},
+		{Name: "dr", Role: ONIRoleProduction},
+	}}
+
+	var tests = map[string]struct {
+		names       []string
+		expected    string
+		errContains string
+	}{
+		"names":          {names: []string{"dr", "preview"}, expected: "dr,preview"},
+		"none":           {names: nil, expected: ""},
+		"duplicates":     {names: []string{"dr", "dr", "preview"}, expected: "dr,preview"},
+		"legacy staging": {names: []string{"staging"}, expected: "stage_main,preview"},
+		"name wins":      {names: []string{"production"}, expected: "production"},
+		"mixed":          {names: []string{"preview", "staging"}, expected: "preview,stage_main"},
+		"unknown":        {names: []string{"dr", "backup"}, errContains: `"backup"`},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var list, err = c.ResolveONIEnvironments(tc.names)
+			if tc.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.errContains) {
+					t.Fatalf("Expected error containing %q, got %v", tc.errContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			var got []string
+			for _, env := range list {
+				got = append(got, env.Name)
+			}
+			if strings.Join(got, ",") != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, strings.Join(got, ","))
+			}
+		})
+	}
+}
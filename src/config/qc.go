@@ -0,0 +1,90 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// QCCheck is a single item on the batch QC checklist
+type QCCheck struct {
+	Name  string
+	Label string
+	Help  string
+}
+
+// defaultQCChecks is the checklist used when QC_CHECKS isn't set. Checks
+// named in QC_CHECKS can also use these labels and help text rather than
+// defining their own.
+var defaultQCChecks = []*QCCheck{
+	{
+		Name:  "images",
+		Label: "Page images render",
+		Help:  "Pages load and zoom on staging, and none are blank, rotated, or badly cropped.",
+	},
+	{
+		Name:  "ocr",
+		Label: "OCR is present",
+		Help:  "Pages have OCR text, and highlighted search results line up with the page image.",
+	},
+	{
+		Name:  "titles",
+		Label: "Title metadata is correct",
+		Help:  "Each title's name, place of publication, and dates are right on staging.",
+	},
+	{
+		Name:  "sample",
+		Label: "Sampled issues checked",
+		Help:  "Dates, editions, volume and issue numbers, and page labels match the pages of the sampled issues.",
+	},
+}
+
+// parseQCChecks reads the checks named in QC_CHECKS, in order. Each check's
+// label comes from QC_CHECK_<NAME>_LABEL, or the built-in label if the name
+// is one of the default checks. QC_CHECK_<NAME>_HELP optionally describes
+// what reviewers should look for.
+func parseQCChecks(get func(string) string) ([]*QCCheck, []string) {
+	var names = strings.Fields(get("QC_CHECKS"))
+	if len(names) == 0 {
+		return defaultQCChecks, nil
+	}
+
+	var defaults = make(map[string]*QCCheck)
+	for _, c := range defaultQCChecks {
+		defaults[c.Name] = c
+	}
+
+	var checks []*QCCheck
+	var errors []string
+	var seen = make(map[string]bool)
+	for _, name := range names {
+		if !validEnvName.MatchString(name) {
+			errors = append(errors, fmt.Sprintf("invalid QC_CHECKS: %q must be lowercase letters, numbers, and underscores", name))
+			continue
+		}
+		if seen[name] {
+			errors = append(errors, fmt.Sprintf("invalid QC_CHECKS: %q is listed more than once", name))
+			continue
+		}
+		seen[name] = true
+
+		var prefix = "QC_CHECK_" + strings.ToUpper(name) + "_"
+		var c = &QCCheck{Name: name, Label: get(prefix + "LABEL"), Help: get(prefix + "HELP")}
+		var def = defaults[name]
+		if def != nil && c.Label == "" {
+			c.Label = def.Label
+			if c.Help == "" {
+				c.Help = def.Help
+			}
+		}
+		if c.Label == "" {
+			errors = append(errors, fmt.Sprintf("invalid %sLABEL: required for custom QC check %q", prefix, name))
+			continue
+		}
+		checks = append(checks, c)
+	}
+
+	if len(checks) == 0 && len(errors) == 0 {
+		errors = append(errors, "invalid QC_CHECKS: no checks listed")
+	}
+	return checks, errors
+}
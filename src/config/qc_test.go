@@ -0,0 +1,67 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseQCChecks(t *testing.T) {
+	var tests = map[string]struct {
+		settings    map[string]string
+		names       []string
+		labels      []string
+		errContains string
+	}{
+		"default": {
+			settings: map[string]string{},
+			names:    []string{"images", "ocr", "titles", "sample"},
+		},
+		"subset of defaults": {
+			settings: map[string]string{"QC_CHECKS": "sample images"},
+			names:    []string{"sample", "images"},
+			labels:   []string{"Sampled issues checked", "Page images render"},
+		},
+		"custom": {
+			settings: map[string]string{"QC_CHECKS": "images mastheads", "QC_CHECK_MASTHEADS_LABEL": "Mastheads match the title"},
+			names:    []string{"images", "mastheads"},
+			labels:   []string{"Page images render", "Mastheads match the title"},
+		},
+		"relabeled default": {
+			settings: map[string]string{"QC_CHECKS": "ocr", "QC_CHECK_OCR_LABEL": "Text is searchable"},
+			names:    []string{"ocr"},
+			labels:   []string{"Text is searchable"},
+		},
+		"custom without label": {settings: map[string]string{"QC_CHECKS": "mastheads"}, errContains: "invalid QC_CHECK_MASTHEADS_LABEL"},
+		"duplicate":            {settings: map[string]string{"QC_CHECKS": "ocr ocr"}, errContains: "more than once"},
+		"bad name":             {settings: map[string]string{"QC_CHECKS": "Page-Images"}, errContains: "lowercase"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var get = func(key string) string { return tc.settings[key] }
+			var checks, errs = parseQCChecks(get)
+			if tc.errContains != "" {
+				var joined = strings.Join(errs, "; ")
+				if !strings.Contains(joined, tc.errContains) {
+					t.Fatalf("Expected error containing %q, got %q", tc.errContains, joined)
+				}
+				return
+			}
+			if len(errs) > 0 {
+				t.Fatalf("Unexpected errors: %v", errs)
+			}
+
+			var names, labels []string
+			for _, c := range checks {
+				names = append(names, c.Name)
+				labels = append(labels, c.Label)
+			}
+			if strings.Join(names, " ") != strings.Join(tc.names, " ") {
+				t.Errorf("Expected checks %v, got %v", tc.names, names)
+			}
+			if tc.labels != nil && strings.Join(labels, "|") != strings.Join(tc.labels, "|") {
+				t.Errorf("Expected labels %v, got %v", tc.labels, labels)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetentionTarget names a kind of file NCA leaves behind which a retention
+// rule can clean up
+type RetentionTarget string
+
+// All valid retention targets
+const (
+	// RetentionErroredIssues covers issues moved to ERRORED_ISSUES_PATH,
+	// measured from when they were moved there
+	RetentionErroredIssues RetentionTarget = "errored_issues"
+
+	// RetentionOriginalBackups covers born-digital uploads left in
+	// ORIGINAL_PDF_BACKUP_PATH, measured from when the issue's batch was
+	// archived
+	RetentionOriginalBackups RetentionTarget = "original_backups"
+
+	// RetentionFinishedIssues covers issues' workflow directories once their
+	// batch is live and archived, measured from when the batch was archived
+	RetentionFinishedIssues RetentionTarget = "finished_issues"
+)
+
+// retentionMinimums is the shortest retention each target allows. Files tied
+// to live batches must survive long enough for post-archive problems to be
+// caught, the same four weeks delete-live-done-issues has always required.
+var retentionMinimums = map[RetentionTarget]time.Duration{
+	RetentionErroredIssues:   0,
+	RetentionOriginalBackups: 4 * 7 * 24 * time.Hour,
+	RetentionFinishedIssues:  4 * 7 * 24 * time.Hour,
+}
+
+// RetentionRule says how long files of a given target are kept
+type RetentionRule struct {
+	Target RetentionTarget
+	Keep   time.Duration
+}
+
+// String describes the rule the way it's written in the settings
+func (r *RetentionRule) String() string {
+	var days = r.Keep / (24 * time.Hour)
+	if r.Keep == days*24*time.Hour {
+		return fmt.Sprintf("%s=%dd", r.Target, days)
+	}
+	return fmt.Sprintf("%s=%s", r.Target, r.Keep)
+}
+
+// parseRetentionDuration reads a Go duration, or a whole number of days with
+// a "d" suffix since retention periods are almost always in days
+func parseRetentionDuration(s string) (time.Duration, error) {
+	var days, found = strings.CutSuffix(s, "d")
+	if found {
+		var n, err = strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a whole number of days", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// parseRetentionRules reads RETENTION_RULES: a list of "<target>=<duration>"
+// pairs, e.g., "errored_issues=180d original_backups=30d". Targets not listed
+// are never cleaned up by the retention engine.
+func parseRetentionRules(get func(string) string) ([]*RetentionRule, []string) {
+	var rules []*RetentionRule
+	var errors []string
+	var seen = make(map[RetentionTarget]bool)
+	for _, field := range strings.Fields(get("RETENTION_RULES")) {
+		var name, val, ok = strings.Cut(field, "=")
+		if !ok {
+			errors = append(errors, fmt.Sprintf("invalid RETENTION_RULES: %q must be <target>=<duration>", field))
+			continue
+		}
+
+		var target = RetentionTarget(name)
+		var minimum, valid = retentionMinimums[target]
+		if !valid {
+			errors = append(errors, fmt.Sprintf("invalid RETENTION_RULES: unknown target %q", name))
+			continue
+		}
+		if seen[target] {
+			errors = append(errors, fmt.Sprintf("invalid RETENTION_RULES: %q is listed more than once", name))
+			continue
+		}
+		seen[target] = true
+
+		var keep, err = parseRetentionDuration(val)
+		if err != nil || keep < 0 {
+			errors = append(errors, fmt.Sprintf("invalid RETENTION_RULES: %q has an invalid duration", field))
+			continue
+		}
+		if keep < minimum {
+			errors = append(errors, fmt.Sprintf("invalid RETENTION_RULES: %q must keep files at least %s", name, minimum))
+			continue
+		}
+		rules = append(rules, &RetentionRule{Target: target, Keep: keep})
+	}
+
+	return rules, errors
+}
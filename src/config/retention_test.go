@@ -0,0 +1,58 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseRetentionRules(t *testing.T) {
+	var tests = map[string]struct {
+		rules       string
+		expected    []string
+		errContains string
+	}{
+		"none":            {rules: "", expected: nil},
+		"days":            {rules: "errored_issues=180d original_backups=30d", expected: []string{"errored_issues=180d", "original_backups=30d"}},
+		"go duration":     {rules: "finished_issues=720h", expected: []string{"finished_issues=30d"}},
+		"partial days":    {rules: "errored_issues=36h", expected: []string{"errored_issues=36h0m0s"}},
+		"zero":            {rules: "errored_issues=0d", expected: []string{"errored_issues=0d"}},
+		"missing equals":  {rules: "errored_issues", errContains: "<target>=<duration>"},
+		"unknown target":  {rules: "scans=30d", errContains: "unknown target"},
+		"duplicate":       {rules: "errored_issues=30d errored_issues=60d", errContains: "more than once"},
+		"bad duration":    {rules: "errored_issues=soon", errContains: "invalid duration"},
+		"bad days":        {rules: "errored_issues=1.5d", errContains: "invalid duration"},
+		"negative":        {rules: "errored_issues=-5h", errContains: "invalid duration"},
+		"below minimum":   {rules: "original_backups=7d", errContains: "at least"},
+		"exactly minimum": {rules: "finished_issues=28d", expected: []string{"finished_issues=28d"}},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var get = func(key string) string {
+				if key == "RETENTION_RULES" {
+					return tc.rules
+				}
+				return ""
+			}
+			var rules, errs = parseRetentionRules(get)
+			if tc.errContains != "" {
+				var joined = strings.Join(errs, "; ")
+				if !strings.Contains(joined, tc.errContains) {
+					t.Fatalf("Expected error containing %q, got %q", tc.errContains, joined)
+				}
+				return
+			}
+			if len(errs) > 0 {
+				t.Fatalf("Unexpected errors: %v", errs)
+			}
+
+			var got []string
+			for _, r := range rules {
+				got = append(got, r.String())
+			}
+			if strings.Join(got, " ") != strings.Join(tc.expected, " ") {
+				t.Errorf("Expected rules %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
@@ -25,13 +25,34 @@ func NewClient(cachepath string, tms int) *Client {
 }
 
 // Get is the most basic function for a Client.  No caching is done, and just
-// the response body is returned.  All external fetching eventually lands here.
+// the response body is returned.
 func (c *Client) Get(u string) (io.ReadCloser, error) {
+	var resp, err = c.do(u, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// do makes a GET request, returning the response if it's a 200, or a 304 when
+// meta is non-nil and the server says our copy is still good.  If meta has
+// validators (ETag or Last-Modified), the request is conditional.  All
+// external fetching eventually lands here.
+func (c *Client) do(u string, meta *Metadata) (*http.Response, error) {
 	req, err := http.NewRequest("GET", u, nil)
 	if err != nil {
 		return nil, err
 	}
 
+	if meta != nil {
+		if meta.ETag != "" {
+			req.Header.Set("If-None-Match", meta.ETag)
+		}
+		if meta.LastModified != "" {
+			req.Header.Set("If-Modified-Since", meta.LastModified)
+		}
+	}
+
 	if c.BeforeRequest != nil {
 		c.BeforeRequest(req)
 	}
@@ -44,32 +65,45 @@ func (c *Client) Get(u string) (io.ReadCloser, error) {
 		return nil, err
 	}
 
+	if c.ThrottleMS > 0 {
+		time.Sleep(time.Millisecond * time.Duration(c.ThrottleMS))
+	}
+
+	if resp.StatusCode == http.StatusNotModified && meta != nil {
+		return resp, nil
+	}
 	if resp.StatusCode != 200 {
 		resp.Body.Close()
 		return nil, fmt.Errorf("non-200 response for GET %s: %s", u, resp.Status)
 	}
 
-	if c.ThrottleMS > 0 {
-		time.Sleep(time.Millisecond * time.Duration(c.ThrottleMS))
-	}
-
-	return resp.Body, nil
+	return resp, nil
 }
 
 // GetCached attempts to find a file for the given request, and fetches it from
-// its source if the file isn't locally available
+// its source if the file isn't locally available.  If the request has a TTL
+// and the cached file is older than that, the source is asked whether our
+// copy is still current, and it's replaced only if it isn't.
 func (c *Client) GetCached(r *Request) (io.ReadCloser, error) {
 	fullpath, err := c.PrepCacheFile(r)
 	if err != nil {
 		return nil, err
 	}
 
-	f, err := os.Open(fullpath)
-	if err == nil {
-		return f, nil
+	meta, err := cachedMetadata(fullpath, r.URL)
+	if err != nil {
+		return nil, err
+	}
+	if meta == nil {
+		return c.GetAndStore(r.URL, fullpath)
+	}
+
+	if r.TTL == 0 || time.Since(meta.Fetched) < r.TTL {
+		touch(fullpath)
+		return os.Open(fullpath)
 	}
 
-	return c.GetAndStore(r.URL, fullpath)
+	return c.revalidate(r.URL, fullpath, meta)
 }
 
 // GetCachedBytes functions just like GetCached, but automatically reads all
@@ -84,14 +118,23 @@ func (c *Client) GetCachedBytes(r *Request) ([]byte, error) {
 	return io.ReadAll(body)
 }
 
-// ForceGet operates like GetCached except it overwrites a previously cached
-// file if one exists
+// ForceGet operates like GetCached except it always checks the source, no
+// matter how new the cached file is.  If the source supports conditional
+// requests, the file is only downloaded again if it has changed.
 func (c *Client) ForceGet(r *Request) (io.ReadCloser, error) {
 	fullpath, err := c.PrepCacheFile(r)
 	if err != nil {
 		return nil, err
 	}
-	return c.GetAndStore(r.URL, fullpath)
+
+	meta, err := cachedMetadata(fullpath, r.URL)
+	if err != nil {
+		return nil, err
+	}
+	if meta == nil {
+		return c.GetAndStore(r.URL, fullpath)
+	}
+	return c.revalidate(r.URL, fullpath, meta)
 }
 
 // ForceGetBytes functions just like ForceGet, but automatically reads all
@@ -126,24 +169,64 @@ func (c *Client) PrepCacheFile(r *Request) (string, error) {
 
 // GetAndStore downloads an external file and stores it at the given path
 func (c *Client) GetAndStore(u, filepath string) (io.ReadCloser, error) {
-	body, err := c.Get(u)
+	resp, err := c.do(u, nil)
+	if err != nil {
+		return nil, err
+	}
+	return store(u, filepath, resp)
+}
+
+// revalidate makes a conditional request for u, keeping the cached file if
+// the server says it hasn't changed, and replacing it otherwise
+func (c *Client) revalidate(u, filepath string, meta *Metadata) (io.ReadCloser, error) {
+	resp, err := c.do(u, meta)
 	if err != nil {
 		return nil, err
 	}
 
+	if resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		meta.Fetched = time.Now()
+		err = writeMetadata(filepath, meta)
+		if err != nil {
+			return nil, err
+		}
+		touch(filepath)
+		return os.Open(filepath)
+	}
+
+	return store(u, filepath, resp)
+}
+
+// store writes a response body to filepath and records its metadata
+func store(u, filepath string, resp *http.Response) (io.ReadCloser, error) {
+	defer resp.Body.Close()
+
 	f, err := os.Create(filepath)
 	if err != nil {
-		body.Close()
 		return nil, fmt.Errorf("unable to cache URL request: %w", err)
 	}
 	defer f.Close()
 
-	_, err = io.Copy(f, body)
-	body.Close()
+	_, err = io.Copy(f, resp.Body)
 	if err != nil {
+		// Don't leave a partial file where it could be mistaken for a good one
+		os.Remove(filepath)
+		os.Remove(metaPath(filepath))
 		return nil, fmt.Errorf("unable to cache URL request: %w", err)
 	}
 
+	var meta = &Metadata{
+		URL:          u,
+		Fetched:      time.Now(),
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}
+	err = writeMetadata(filepath, meta)
+	if err != nil {
+		return nil, err
+	}
+
 	// This is really stupid, but when I built this library apparently I didn't
 	// bother to figure out how to rewind the http body.  And hey, why start
 	// doing things the right way at this point?
@@ -0,0 +1,226 @@
+package httpcache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSource serves a body with an ETag, answering conditional requests
+// with a 304 when the ETag matches
+type fakeSource struct {
+	sync.Mutex
+	*httptest.Server
+	body     string
+	etag     string
+	full     int
+	notMod   int
+	lastCond string
+}
+
+func newFakeSource(t *testing.T) *fakeSource {
+	var src = &fakeSource{body: "v1", etag: `"v1"`}
+	src.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		src.Lock()
+		defer src.Unlock()
+		src.lastCond = r.Header.Get("If-None-Match")
+		if src.lastCond == src.etag {
+			src.notMod++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		src.full++
+		w.Header().Set("ETag", src.etag)
+		_, _ = w.Write([]byte(src.body))
+	}))
+	t.Cleanup(src.Close)
+	return src
+}
+
+func (src *fakeSource) set(body, etag string) {
+	src.Lock()
+	src.body, src.etag = body, etag
+	src.Unlock()
+}
+
+func (src *fakeSource) counts() (full, notMod int) {
+	src.Lock()
+	defer src.Unlock()
+	return src.full, src.notMod
+}
+
+// age pushes a cached file's fetch time into the past
+func age(t *testing.T, fullpath string, d time.Duration) {
+	var meta, err = readMetadata(fullpath)
+	if err != nil || meta == nil {
+		t.Fatalf("Unable to read metadata for %q: %v", fullpath, err)
+	}
+	meta.Fetched = meta.Fetched.Add(-d)
+	err = writeMetadata(fullpath, meta)
+	if err != nil {
+		t.Fatalf("Unable to write metadata for %q: %s", fullpath, err)
+	}
+}
+
+func TestGetCached(t *testing.T) {
+	var src = newFakeSource(t)
+	var c = NewClient(t.TempDir(), 0)
+	var r = AutoRequest(src.URL+"/batches/foo.json", "batches")
+	r.TTL = time.Hour
+	var fullpath = r.CachePath(c.CachePath)
+
+	var steps = []struct {
+		name       string
+		prep       func()
+		force      bool
+		wantBody   string
+		wantFull   int
+		wantNotMod int
+	}{
+		{name: "first fetch", wantBody: "v1", wantFull: 1},
+		{name: "fresh cache hit", wantBody: "v1", wantFull: 1},
+		{name: "expired but unchanged", prep: func() { age(t, fullpath, 2*time.Hour) }, wantBody: "v1", wantFull: 1, wantNotMod: 1},
+		{name: "revalidation renews the TTL", wantBody: "v1", wantFull: 1, wantNotMod: 1},
+		{name: "changed but fresh", prep: func() { src.set("v2", `"v2"`) }, wantBody: "v1", wantFull: 1, wantNotMod: 1},
+		{name: "forced", force: true, wantBody: "v2", wantFull: 2, wantNotMod: 1},
+		{name: "forced and unchanged", force: true, wantBody: "v2", wantFull: 2, wantNotMod: 2},
+	}
+
+	for _, step := range steps {
+		if step.prep != nil {
+			step.prep()
+		}
+		var body []byte
+		var err error
+		if step.force {
+			body, err = c.ForceGetBytes(r)
+		} else {
+			body, err = c.GetCachedBytes(r)
+		}
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", step.name, err)
+		}
+		if string(body) != step.wantBody {
+			t.Errorf("%s: expected body %q, got %q", step.name, step.wantBody, body)
+		}
+		var full, notMod = src.counts()
+		if full != step.wantFull || notMod != step.wantNotMod {
+			t.Errorf("%s: expected %d full and %d not-modified responses, got %d and %d",
+				step.name, step.wantFull, step.wantNotMod, full, notMod)
+		}
+	}
+}
+
+func TestGetCachedLegacyFile(t *testing.T) {
+	var src = newFakeSource(t)
+	var c = NewClient(t.TempDir(), 0)
+	var r = AutoRequest(src.URL+"/titles/foo.json", "titles")
+	r.TTL = time.Hour
+
+	// A file cached before metadata existed should be adopted, using its
+	// modification time as the fetch time
+	var fullpath, _ = c.PrepCacheFile(r)
+	var err = os.WriteFile(fullpath, []byte("old"), 0644)
+	if err != nil {
+		t.Fatalf("Unable to write legacy file: %s", err)
+	}
+	var old = time.Now().Add(-2 * time.Hour)
+	_ = os.Chtimes(fullpath, old, old)
+
+	var body []byte
+	body, err = c.GetCachedBytes(r)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if string(body) != "v1" {
+		t.Errorf("Expected expired legacy file to be refetched, got %q", body)
+	}
+	if src.lastCond != "" {
+		t.Errorf("Expected unconditional request for legacy file, got If-None-Match %q", src.lastCond)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	var root = t.TempDir()
+	var now = time.Now()
+	var files = []struct {
+		name string
+		size int
+		used time.Duration
+	}{
+		{"a/old.json", 10, 48 * time.Hour},
+		{"a/middle.json", 10, 2 * time.Hour},
+		{"b/new.json", 10, time.Minute},
+	}
+	for _, f := range files {
+		var fullpath = filepath.Join(root, f.name)
+		_ = os.MkdirAll(filepath.Dir(fullpath), 0755)
+		var err = os.WriteFile(fullpath, make([]byte, f.size), 0644)
+		if err == nil {
+			err = writeMetadata(fullpath, &Metadata{URL: "http://example.org/" + f.name, Fetched: now})
+		}
+		if err != nil {
+			t.Fatalf("Unable to set up %q: %s", f.name, err)
+		}
+		_ = os.Chtimes(fullpath, now.Add(-f.used), now.Add(-f.used))
+	}
+
+	// Files without metadata aren't ours and must never be touched
+	var foreign = filepath.Join(root, "a", "finder.cache")
+	_ = os.WriteFile(foreign, make([]byte, 1000), 0644)
+
+	var tests = map[string]struct {
+		opts PruneOptions
+		want []string
+	}{
+		"nothing":    {PruneOptions{}, nil},
+		"by age":     {PruneOptions{MaxUnused: 24 * time.Hour}, []string{"a/old.json"}},
+		"by size":    {PruneOptions{MaxSize: 15}, []string{"a/old.json", "a/middle.json"}},
+		"both":       {PruneOptions{MaxSize: 25, MaxUnused: time.Hour}, []string{"a/old.json", "a/middle.json"}},
+		"fits":       {PruneOptions{MaxSize: 30}, nil},
+		"everything": {PruneOptions{MaxSize: 1}, []string{"a/old.json", "a/middle.json", "b/new.json"}},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var opts = tc.opts
+			opts.DryRun = true
+			var removed, err = Prune(root, opts)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			var got []string
+			for _, e := range removed {
+				var rel, _ = filepath.Rel(root, e.Path)
+				got = append(got, rel)
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("Expected %q to be pruned, got %q", tc.want, got)
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Fatalf("Expected %q to be pruned, got %q", tc.want, got)
+				}
+			}
+		})
+	}
+
+	// A real prune removes the files and their metadata, but nothing else
+	var _, err = Prune(root, PruneOptions{MaxSize: 1})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	var list []*Entry
+	list, err = Entries(root)
+	if err != nil || len(list) != 0 {
+		t.Errorf("Expected an empty cache, got %d entries (err: %v)", len(list), err)
+	}
+	_, err = os.Stat(foreign)
+	if err != nil {
+		t.Errorf("Expected non-cache file to survive pruning: %s", err)
+	}
+}
@@ -0,0 +1,97 @@
+package httpcache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// metaExtension is appended to a cached file's path to get the path to its
+// metadata
+const metaExtension = ".httpcache"
+
+// Metadata is stored alongside each cached file, and tells us where the file
+// came from, when we last confirmed it was current, and what we need to ask
+// the source whether it's changed
+type Metadata struct {
+	URL          string
+	Fetched      time.Time
+	ETag         string
+	LastModified string
+}
+
+func metaPath(filepath string) string {
+	return filepath + metaExtension
+}
+
+// readMetadata returns the metadata for the cached file at filepath, or nil if
+// there isn't any
+func readMetadata(filepath string) (*Metadata, error) {
+	var data, err = os.ReadFile(metaPath(filepath))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read cache metadata: %w", err)
+	}
+
+	var meta = &Metadata{}
+	err = json.Unmarshal(data, meta)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cache metadata for %q: %w", filepath, err)
+	}
+	return meta, nil
+}
+
+func writeMetadata(filepath string, meta *Metadata) error {
+	var data, err = json.Marshal(meta)
+	if err == nil {
+		err = os.WriteFile(metaPath(filepath), data, 0644)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to write cache metadata: %w", err)
+	}
+	return nil
+}
+
+// cachedMetadata returns the metadata for the cached file at filepath, or nil
+// if the file isn't cached for the given URL.  Files cached before we stored metadata are given
+// metadata based on their modification time so they can expire and be pruned
+// like everything else.
+func cachedMetadata(filepath, u string) (*Metadata, error) {
+	var info, err = os.Stat(filepath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read cached file: %w", err)
+	}
+
+	var meta *Metadata
+	meta, err = readMetadata(filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	// A file cached from a different URL (e.g., a page of results whose "next"
+	// link has changed) has to be fetched again, not revalidated
+	if meta != nil {
+		if meta.URL != u {
+			return nil, nil
+		}
+		return meta, nil
+	}
+
+	meta = &Metadata{URL: u, Fetched: info.ModTime()}
+	return meta, writeMetadata(filepath, meta)
+}
+
+// touch sets a cached file's modification time to now so that pruning can
+// tell which files are still in use.  Failure isn't worth reporting: at worst
+// the file is pruned a bit early and fetched again.
+func touch(filepath string) {
+	var now = time.Now()
+	_ = os.Chtimes(filepath, now, now)
+}
@@ -0,0 +1,117 @@
+package httpcache
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Entry describes a single cached file
+type Entry struct {
+	Metadata
+	Path string
+	Size int64
+
+	// Used is the last time the file was read from (or written to) the cache
+	Used time.Time
+}
+
+// Entries returns every file stored under cachePath which has cache metadata,
+// least recently used first.  Other files (which a caller may keep under the
+// same path) are never returned, so they can't be pruned by accident.
+func Entries(cachePath string) ([]*Entry, error) {
+	var list []*Entry
+	var err = filepath.WalkDir(cachePath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(path, metaExtension) {
+			return nil
+		}
+
+		var fullpath = strings.TrimSuffix(path, metaExtension)
+		var info, serr = os.Stat(fullpath)
+		if serr != nil {
+			// Metadata without a file is junk left behind by a crash or a manual
+			// cleanup; it's harmless, so we just skip it
+			return nil
+		}
+
+		var meta, merr = readMetadata(fullpath)
+		if merr != nil {
+			return merr
+		}
+		list = append(list, &Entry{Metadata: *meta, Path: fullpath, Size: info.Size(), Used: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].Used.Before(list[j].Used) })
+	return list, nil
+}
+
+// PruneOptions controls which entries Prune removes.  Zero values disable
+// the given rule.
+type PruneOptions struct {
+	// MaxSize is the most space, in bytes, cached files may use.  The least
+	// recently used files are removed until the cache fits.
+	MaxSize int64
+
+	// MaxUnused removes files which haven't been used in this long
+	MaxUnused time.Duration
+
+	// DryRun reports what would be removed without removing anything
+	DryRun bool
+}
+
+// Prune removes cached files under cachePath according to opts, returning
+// the entries which were (or, for a dry run, would be) removed
+func Prune(cachePath string, opts PruneOptions) ([]*Entry, error) {
+	var list, err = Entries(cachePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	for _, e := range list {
+		total += e.Size
+	}
+
+	var removed []*Entry
+	for _, e := range list {
+		var tooOld = opts.MaxUnused > 0 && time.Since(e.Used) > opts.MaxUnused
+		var tooBig = opts.MaxSize > 0 && total > opts.MaxSize
+		if !tooOld && !tooBig {
+			continue
+		}
+
+		if !opts.DryRun {
+			err = Remove(e)
+			if err != nil {
+				return removed, err
+			}
+		}
+		total -= e.Size
+		removed = append(removed, e)
+	}
+
+	return removed, nil
+}
+
+// Remove deletes a cached file and its metadata
+func Remove(e *Entry) error {
+	var err = os.Remove(e.Path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	err = os.Remove(metaPath(e.Path))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"net/url"
 	"path"
 	"path/filepath"
+	"time"
 
 	"golang.org/x/crypto/sha3"
 )
@@ -17,6 +18,10 @@ type Request struct {
 	Filename     string
 	Extension    string
 	Subdirectory string
+
+	// TTL is how long a cached file is used before we check with the source
+	// to see if it's changed.  Zero means a cached file never expires.
+	TTL time.Duration
 }
 
 // AutoRequest uses the URL to figure out filename and extension, but requires
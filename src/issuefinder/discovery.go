@@ -0,0 +1,107 @@
+package issuefinder
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/internal/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/apperr"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/httpcache"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/schema"
+)
+
+// A LiveDiscoverer finds the batches, titles, and issues served by a live
+// site, storing them in the given (freshly initialized) web Searcher
+type LiveDiscoverer interface {
+	Discover(s *Searcher) error
+}
+
+// Crawler is the original LiveDiscoverer: it requests every batch's JSON on
+// every scan, relying on the disk cache to avoid re-downloading batches it
+// has seen before. A batch which is reloaded won't be noticed until its cached
+// JSON expires (see BatchJSONTTL).
+type Crawler struct {
+	CachePath string
+}
+
+// IncrementalDiscoverer keeps a persistent index of the live site's batches
+// and issues. Each scan reads the (small) batch list, fetches only batches
+// which are new or have been reingested since the last successful scan, and
+// drops batches which are no longer listed.
+type IncrementalDiscoverer struct {
+	CachePath string
+	IndexPath string
+}
+
+// NewIncrementalDiscoverer returns an IncrementalDiscoverer which stores its
+// index and batch list pages in cachePath
+func NewIncrementalDiscoverer(cachePath string) *IncrementalDiscoverer {
+	return &IncrementalDiscoverer{CachePath: cachePath, IndexPath: filepath.Join(cachePath, "live-index.json")}
+}
+
+// Discover implements LiveDiscoverer
+func (d *IncrementalDiscoverer) Discover(s *Searcher) error {
+	var list, err = s.findAllLiveBatches(d.CachePath)
+	if err != nil {
+		return fmt.Errorf("unable to load batch list from %#v: %w", s.Location, err)
+	}
+
+	var idx *liveIndex
+	idx, err = readLiveIndex(d.IndexPath)
+	if err != nil {
+		logger.Warnf("Unable to read live index %q, rebuilding it: %s", d.IndexPath, err)
+		idx = nil
+	}
+	if idx == nil || idx.Webroot != s.Location {
+		idx = newLiveIndex(s.Location)
+	}
+
+	// We throttle just like the crawler; this only matters when the index is
+	// new or a lot of batches have gone live since the last scan
+	var c = httpcache.NewClient(d.CachePath, 50)
+	var fetched, purged int
+	fetched, purged, err = idx.sync(c, list)
+
+	// Every batch in the index is complete, so even a failed sync leaves us
+	// with a usable index, and saving it means we won't have to fetch the same
+	// batches again next time
+	var serr = idx.write(d.IndexPath)
+	if err != nil {
+		return fmt.Errorf("unable to sync live index for %#v: %w", s.Location, err)
+	}
+	if serr != nil {
+		return fmt.Errorf("unable to save live index to %q: %w", d.IndexPath, serr)
+	}
+
+	logger.Debugf("Live index for %q: %d batch(es) fetched, %d purged batch(es) removed", s.Location, fetched, purged)
+	s.useLiveIndex(idx)
+	return nil
+}
+
+// useLiveIndex populates the searcher from an index's batches and titles
+func (s *Searcher) useLiveIndex(idx *liveIndex) {
+	for _, ib := range idx.sortedBatches() {
+		var batch, err = schema.ParseBatchname(ib.Name)
+		if err != nil {
+			s.Errors.Append(apperr.Errorf("invalid live batch at %q: %s", s.Location, err))
+			continue
+		}
+		batch.Location = ib.URL
+		s.Batches = append(s.Batches, batch)
+
+		for _, meta := range ib.Issues {
+			var t = s.titleByLoc[meta.Title.URL]
+			if t == nil {
+				var tJSON = idx.Titles[meta.Title.URL]
+				t = &schema.Title{
+					LCCN:               tJSON.LCCN,
+					Name:               tJSON.Name,
+					PlaceOfPublication: tJSON.PlaceOfPublication,
+					Location:           meta.Title.URL,
+				}
+				s.addTitle(t)
+			}
+			s.cacheLiveIssue(batch, t, meta)
+		}
+	}
+}
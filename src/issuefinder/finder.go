@@ -143,6 +143,13 @@ func (f *Finder) FindWebBatches(hostname, cachePath string) (*Searcher, error) {
 	return f.createAndProcessSearcher(Website, hostname, searchFn)
 }
 
+// DiscoverWebBatches creates a website batch Searcher, runs it with the given
+// LiveDiscoverer, aggregates its data, and returns any errors encountered
+func (f *Finder) DiscoverWebBatches(hostname string, d LiveDiscoverer) (*Searcher, error) {
+	var searchFn = func(s *Searcher) error { return d.Discover(s) }
+	return f.createAndProcessSearcher(Website, hostname, searchFn)
+}
+
 // FindInProcessIssues creates and runs an in-process issues (issues which are
 // in the workflow dir and have been indexed) searcher, aggregates its data,
 // and returns any errors encountered
@@ -0,0 +1,181 @@
+package issuefinder
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/chronam"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/httpcache"
+)
+
+// liveIndex is the persistent record of everything a live site serves, used
+// by the IncrementalDiscoverer to avoid refetching batches it already knows
+type liveIndex struct {
+	Webroot string
+	Scanned time.Time
+
+	// Batches is keyed by batch name
+	Batches map[string]*indexedBatch
+
+	// Titles is keyed by the title's JSON URL
+	Titles map[string]*chronam.TitleJSON
+}
+
+// indexedBatch holds a live batch's metadata and its issues
+type indexedBatch struct {
+	Name     string
+	URL      string
+	Ingested string
+	Issues   []*chronam.IssueMetadata
+}
+
+func newLiveIndex(webroot string) *liveIndex {
+	return &liveIndex{
+		Webroot: webroot,
+		Batches: make(map[string]*indexedBatch),
+		Titles:  make(map[string]*chronam.TitleJSON),
+	}
+}
+
+// readLiveIndex reads the index at path. If the file doesn't exist, a nil
+// index is returned with no error.
+func readLiveIndex(path string) (*liveIndex, error) {
+	var data, err = os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var idx = newLiveIndex("")
+	err = json.Unmarshal(data, idx)
+	if err != nil {
+		return nil, err
+	}
+	return idx, nil
+}
+
+// write stores the index at path, replacing it atomically so a crash can't
+// leave a partial index behind. The server and job runner may share a cache
+// path, so each write gets its own temp file.
+func (idx *liveIndex) write(path string) error {
+	var data, err = json.Marshal(idx)
+	if err != nil {
+		return err
+	}
+
+	var f *os.File
+	f, err = os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.Write(data)
+	var cerr = f.Close()
+	if err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// sync brings the index in line with the given batch list: batches which
+// aren't in the list are removed, and batches which are new (or whose ingest
+// time has changed, meaning they were reloaded) are fetched along with any
+// titles we haven't seen. The scan time is only updated if everything
+// succeeds.
+func (idx *liveIndex) sync(c *httpcache.Client, list []*chronam.BatchMetadata) (fetched, purged int, err error) {
+	var listed = make(map[string]bool)
+	for _, meta := range list {
+		listed[meta.Name] = true
+	}
+	for name := range idx.Batches {
+		if !listed[name] {
+			delete(idx.Batches, name)
+			purged++
+		}
+	}
+
+	for _, meta := range list {
+		var ib = idx.Batches[meta.Name]
+		if ib != nil && ib.URL == meta.URL && ib.Ingested == meta.Ingested {
+			continue
+		}
+
+		ib, err = idx.fetchBatch(c, meta)
+		if err != nil {
+			return fetched, purged, err
+		}
+		idx.Batches[meta.Name] = ib
+		fetched++
+	}
+
+	idx.Scanned = time.Now()
+	return fetched, purged, nil
+}
+
+// fetchBatch reads a batch's JSON and all titles it references which aren't
+// already indexed. The batch is only returned once all its titles are known,
+// so the index never refers to a title it doesn't have.
+func (idx *liveIndex) fetchBatch(c *httpcache.Client, meta *chronam.BatchMetadata) (*indexedBatch, error) {
+	var contents, err = getBytes(c, meta.URL)
+	if err != nil {
+		return nil, fmt.Errorf("unable to GET %#v: %w", meta.URL, err)
+	}
+
+	var batch *chronam.BatchJSON
+	batch, err = chronam.ParseBatchJSON(contents)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON in %#v: %w", meta.URL, err)
+	}
+
+	for _, issue := range batch.Issues {
+		var uri = issue.Title.URL
+		if idx.Titles[uri] != nil {
+			continue
+		}
+
+		contents, err = getBytes(c, uri)
+		if err != nil {
+			return nil, fmt.Errorf("unable to GET %#v: %w", uri, err)
+		}
+		var t *chronam.TitleJSON
+		t, err = chronam.ParseTitleJSON(contents)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse title JSON for %#v: %w", uri, err)
+		}
+		idx.Titles[uri] = t
+	}
+
+	return &indexedBatch{Name: meta.Name, URL: meta.URL, Ingested: meta.Ingested, Issues: batch.Issues}, nil
+}
+
+// sortedBatches returns the index's batches ordered by name
+func (idx *liveIndex) sortedBatches() []*indexedBatch {
+	var list = make([]*indexedBatch, 0, len(idx.Batches))
+	for _, ib := range idx.Batches {
+		list = append(list, ib)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// getBytes reads a URL without caching it to disk: the index is our cache
+func getBytes(c *httpcache.Client, uri string) ([]byte, error) {
+	var body, err = c.Get(uri)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return io.ReadAll(body)
+}
@@ -0,0 +1,142 @@
+package issuefinder
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/chronam"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/httpcache"
+)
+
+// fakeLiveSite serves batch and title JSON, counting requests
+type fakeLiveSite struct {
+	sync.Mutex
+	*httptest.Server
+	requests map[string]int
+}
+
+func newFakeLiveSite(t *testing.T) *fakeLiveSite {
+	var site = &fakeLiveSite{requests: make(map[string]int)}
+	site.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		site.Lock()
+		site.requests[r.URL.Path]++
+		site.Unlock()
+
+		var name = strings.TrimSuffix(filepath.Base(r.URL.Path), ".json")
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/batches/"):
+			fmt.Fprintf(w, `{"name": %q, "issues": [{"url": "%s/lccn/sn12345678/2020-01-02/ed-1.json", `+
+				`"date_issued": "2020-01-02", "title": {"url": "%s/lccn/sn12345678.json"}}]}`, name, site.URL, site.URL)
+		case strings.HasPrefix(r.URL.Path, "/lccn/"):
+			fmt.Fprintf(w, `{"lccn": %q, "name": "The Daily Test"}`, name)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(site.Close)
+	return site
+}
+
+func (site *fakeLiveSite) count(path string) int {
+	site.Lock()
+	defer site.Unlock()
+	return site.requests[path]
+}
+
+func (site *fakeLiveSite) batch(name, ingested string) *chronam.BatchMetadata {
+	return &chronam.BatchMetadata{Name: name, URL: site.URL + "/batches/" + name + ".json", Ingested: ingested}
+}
+
+func TestLiveIndexSync(t *testing.T) {
+	var site = newFakeLiveSite(t)
+	var c = httpcache.NewClient(t.TempDir(), 0)
+	var idx = newLiveIndex(site.URL)
+
+	var steps = []struct {
+		name        string
+		list        []*chronam.BatchMetadata
+		wantFetched int
+		wantPurged  int
+		wantBatches []string
+	}{
+		{
+			name:        "initial scan",
+			list:        []*chronam.BatchMetadata{site.batch("batch_oru_a_ver01", "t1"), site.batch("batch_oru_b_ver01", "t1")},
+			wantFetched: 2,
+			wantBatches: []string{"batch_oru_a_ver01", "batch_oru_b_ver01"},
+		},
+		{
+			name:        "no changes",
+			list:        []*chronam.BatchMetadata{site.batch("batch_oru_a_ver01", "t1"), site.batch("batch_oru_b_ver01", "t1")},
+			wantBatches: []string{"batch_oru_a_ver01", "batch_oru_b_ver01"},
+		},
+		{
+			name:        "new batch",
+			list:        []*chronam.BatchMetadata{site.batch("batch_oru_a_ver01", "t1"), site.batch("batch_oru_b_ver01", "t1"), site.batch("batch_oru_c_ver01", "t2")},
+			wantFetched: 1,
+			wantBatches: []string{"batch_oru_a_ver01", "batch_oru_b_ver01", "batch_oru_c_ver01"},
+		},
+		{
+			name:        "purged and reloaded",
+			list:        []*chronam.BatchMetadata{site.batch("batch_oru_b_ver01", "t3"), site.batch("batch_oru_c_ver01", "t2")},
+			wantFetched: 1,
+			wantPurged:  1,
+			wantBatches: []string{"batch_oru_b_ver01", "batch_oru_c_ver01"},
+		},
+	}
+
+	for _, step := range steps {
+		var fetched, purged, err = idx.sync(c, step.list)
+		if err != nil {
+			t.Fatalf("%s: sync error: %s", step.name, err)
+		}
+		if fetched != step.wantFetched || purged != step.wantPurged {
+			t.Errorf("%s: expected %d fetched and %d purged, got %d and %d", step.name, step.wantFetched, step.wantPurged, fetched, purged)
+		}
+
+		var names []string
+		for _, ib := range idx.sortedBatches() {
+			names = append(names, ib.Name)
+		}
+		if strings.Join(names, ",") != strings.Join(step.wantBatches, ",") {
+			t.Errorf("%s: expected batches %q, got %q", step.name, step.wantBatches, names)
+		}
+	}
+
+	// Titles are only ever fetched once no matter how many batches use them
+	if n := site.count("/lccn/sn12345678.json"); n != 1 {
+		t.Errorf("Expected the title to be fetched once, got %d", n)
+	}
+	if n := site.count("/batches/batch_oru_b_ver01.json"); n != 2 {
+		t.Errorf("Expected the reloaded batch to be fetched twice, got %d", n)
+	}
+
+	// A round trip through disk should give us the same index
+	var path = filepath.Join(t.TempDir(), "live-index.json")
+	var err = idx.write(path)
+	if err != nil {
+		t.Fatalf("Unable to write index: %s", err)
+	}
+	var idx2 *liveIndex
+	idx2, err = readLiveIndex(path)
+	if err != nil {
+		t.Fatalf("Unable to read index: %s", err)
+	}
+	if idx2.Webroot != idx.Webroot || len(idx2.Batches) != 2 || idx2.Titles[site.URL+"/lccn/sn12345678.json"].Name != "The Daily Test" {
+		t.Errorf("Index didn't survive a round trip: %#v", idx2)
+	}
+	if len(idx2.Batches["batch_oru_c_ver01"].Issues) != 1 {
+		t.Errorf("Expected one issue in batch_oru_c_ver01, got %#v", idx2.Batches["batch_oru_c_ver01"].Issues)
+	}
+
+	// A missing index isn't an error
+	idx2, err = readLiveIndex(filepath.Join(t.TempDir(), "nope.json"))
+	if idx2 != nil || err != nil {
+		t.Errorf("Expected nil index and error for missing file, got %#v, %v", idx2, err)
+	}
+}
@@ -0,0 +1,97 @@
+package issuefinder
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/uoregon-libraries/gopkg/fileutil"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/schema"
+)
+
+// RefreshTitle returns a copy of the searcher in which the given title
+// directory has been rescanned. All other titles' data is shared with the
+// original searcher, which isn't modified, so it remains safe to read from
+// while (and after) the refresh happens. If the title directory no longer
+// exists, the copy simply won't have the title or its issues.
+//
+// Only SFTP and scanned-upload searchers can refresh a single title. orgCode
+// is only used for SFTP titles.
+func (s *Searcher) RefreshTitle(titlePath, orgCode string) (*Searcher, error) {
+	if s.Namespace != SFTPUpload && s.Namespace != ScanUpload {
+		return nil, fmt.Errorf("cannot refresh a single title in namespace %d", s.Namespace)
+	}
+
+	var dbTitles, err = models.Titles()
+	if err != nil {
+		return nil, fmt.Errorf("reading titles from database to refresh %q: %w", titlePath, err)
+	}
+
+	var s2 = &Searcher{
+		Namespace:  s.Namespace,
+		Location:   s.Location,
+		Batches:    s.Batches,
+		dbTitles:   dbTitles,
+		titleByLoc: make(map[string]*schema.Title),
+	}
+	for _, e := range s.Errors.All() {
+		s2.Errors.Append(e)
+	}
+	for _, t := range s.Titles {
+		if t.Location != titlePath {
+			s2.addTitle(t)
+		}
+	}
+	var prefix = titlePath + string(filepath.Separator)
+	for _, i := range s.Issues {
+		if !strings.HasPrefix(i.Location, prefix) {
+			s2.Issues = append(s2.Issues, i)
+		}
+	}
+
+	if !fileutil.IsDir(titlePath) {
+		return s2, nil
+	}
+
+	if s.Namespace == SFTPUpload {
+		err = s2.findSFTPIssuesForTitlePath(titlePath, orgCode)
+	} else {
+		// Scanned titles live under a MOC directory. If the MOC isn't valid, a
+		// full scan reports it, so we just leave its titles out.
+		var moc = filepath.Base(filepath.Dir(titlePath))
+		if !models.ValidMOC(moc) {
+			return s2, nil
+		}
+		err = s2.findScannedIssuesForTitlePath(moc, titlePath)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s2, nil
+}
+
+// IssuesIn returns the searcher's issues which live under the given directory
+func (s *Searcher) IssuesIn(dir string) schema.IssueList {
+	var list schema.IssueList
+	var prefix = dir + string(filepath.Separator)
+	for _, i := range s.Issues {
+		if strings.HasPrefix(i.Location, prefix) {
+			list = append(list, i)
+		}
+	}
+	return list
+}
+
+// WithSearcher returns a new Finder holding the same searchers as f, except
+// that s replaces the searcher in its namespace. f isn't modified.
+func (f *Finder) WithSearcher(s *Searcher) *Finder {
+	var f2 = New()
+	for _, s2 := range f.Searchers {
+		f2.storeSearcher(s2)
+	}
+	f2.storeSearcher(s)
+	f2.Aggregate()
+	return f2
+}
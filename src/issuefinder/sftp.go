@@ -35,6 +35,17 @@ func (s *Searcher) FindSFTPIssues(orgCode string) error {
 	return nil
 }
 
+// FindSFTPIssuesForTitle aggregates the uploaded born-digital PDFs in a
+// single title's directory, for when a full scan would be overkill
+func (s *Searcher) FindSFTPIssuesForTitle(titlePath, orgCode string) error {
+	var err = s.init()
+	if err != nil {
+		return err
+	}
+
+	return s.findSFTPIssuesForTitlePath(titlePath, orgCode)
+}
+
 func findPDFDirs(root string) (results []string, err error) {
 	var infos []os.FileInfo
 	infos, err = fileutil.ReaddirSortedNumeric(root)
@@ -53,6 +53,7 @@ func (s *Searcher) findOrCreateWebTitle(c *httpcache.Client, uri string) (*schem
 	}
 
 	var request = httpcache.AutoRequest(uri, "titles")
+	request.TTL = TitleJSONTTL
 	var contents, err = c.GetCachedBytes(request)
 	if err != nil {
 		return nil, fmt.Errorf("unable to GET %#v: %w", uri, err)
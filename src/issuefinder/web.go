@@ -13,6 +13,14 @@ import (
 	"github.com/uoregon-libraries/newspaper-curation-app/src/schema"
 )
 
+// How long the crawler trusts cached JSON before asking the live site if it
+// has changed.  Batches can be reloaded, so we check them fairly often;
+// titles change far less.
+const (
+	BatchJSONTTL = 24 * time.Hour
+	TitleJSONTTL = 7 * 24 * time.Hour
+)
+
 // FindWebBatches reads through the JSON from the batch API URL (using
 // Searcher's Location as the web root) and grabs "next" page until there is no
 // next page.  Each batch is then read from the JSON cache path, or read from
@@ -22,19 +30,29 @@ import (
 // As with other searches, this returns an error only on unexpected behaviors,
 // like the site not responding.
 func (s *Searcher) FindWebBatches(cachePath string) error {
+	return s.DiscoverWebBatches(&Crawler{CachePath: cachePath})
+}
+
+// DiscoverWebBatches uses the given LiveDiscoverer to find all batches,
+// titles, and issues on the site at the Searcher's Location
+func (s *Searcher) DiscoverWebBatches(d LiveDiscoverer) error {
 	var err = s.init()
 	if err != nil {
 		return err
 	}
+	return d.Discover(s)
+}
 
-	var batchMetadataList []*chronam.BatchMetadata
-	batchMetadataList, err = s.findAllLiveBatches(cachePath)
+// Discover implements LiveDiscoverer by reading every batch's JSON, using the
+// disk cache where possible
+func (cr *Crawler) Discover(s *Searcher) error {
+	var batchMetadataList, err = s.findAllLiveBatches(cr.CachePath)
 	if err != nil {
 		return fmt.Errorf("unable to load batch list from %#v: %w", s.Location, err)
 	}
 
 	// We (slightly) throttle batch JSON requests as there can be a few hundred of these
-	var c = httpcache.NewClient(cachePath, 50)
+	var c = httpcache.NewClient(cr.CachePath, 50)
 	for _, batchMetadata := range batchMetadataList {
 		var batch, err = schema.ParseBatchname(batchMetadata.Name)
 		if err != nil {
@@ -88,6 +106,7 @@ func (s *Searcher) cacheLiveIssue(batch *schema.Batch, title *schema.Title, meta
 
 func (s *Searcher) findBatchedIssueMetadata(c *httpcache.Client, batchURL string) ([]*chronam.IssueMetadata, error) {
 	var request = httpcache.AutoRequest(batchURL, "batches")
+	request.TTL = BatchJSONTTL
 	var contents, err = c.GetCachedBytes(request)
 	if err != nil {
 		return nil, fmt.Errorf("unable to GET %#v: %w", batchURL, err)
@@ -18,6 +18,7 @@ type Scanner struct {
 	ScanUpload          string
 	PDFUpload           string
 	PDFBatchMARCOrgCode string
+	LiveDiscovery       string
 	Lookup              *schema.Lookup
 	CanonIssues         map[string]*schema.Issue
 
@@ -43,6 +44,7 @@ func NewScanner(conf *config.Config) *Scanner {
 	s.ScanUpload = conf.ScanUploadPath
 	s.PDFUpload = conf.PDFUploadPath
 	s.PDFBatchMARCOrgCode = conf.PDFBatchMARCOrgCode
+	s.LiveDiscovery = conf.LiveDiscovery
 
 	return s
 }
@@ -79,6 +81,8 @@ func (s *Scanner) Duplicate() *Scanner {
 	s2.Tempdir = s.Tempdir
 	s2.ScanUpload = s.ScanUpload
 	s2.PDFUpload = s.PDFUpload
+	s2.PDFBatchMARCOrgCode = s.PDFBatchMARCOrgCode
+	s2.LiveDiscovery = s.LiveDiscovery
 	s2.skipweb = s.skipweb
 	s2.skipsftp = s.skipsftp
 	s2.skipscan = s.skipscan
@@ -87,6 +91,14 @@ func (s *Scanner) Duplicate() *Scanner {
 	return s2
 }
 
+// liveDiscoverer returns the LiveDiscoverer for the configured discovery mode
+func (s *Scanner) liveDiscoverer() issuefinder.LiveDiscoverer {
+	if s.LiveDiscovery == config.LiveDiscoveryIncremental {
+		return issuefinder.NewIncrementalDiscoverer(s.Tempdir)
+	}
+	return &issuefinder.Crawler{CachePath: s.Tempdir}
+}
+
 // LookupIssues returns a list of schema Issues for the give search key
 func (s *Scanner) LookupIssues(key *schema.Key) []*schema.Issue {
 	return s.Lookup.Issues(key)
@@ -101,7 +113,7 @@ func (s *Scanner) Scan() error {
 	var err error
 
 	if !s.skipweb {
-		_, err = f.FindWebBatches(s.Webroot, s.Tempdir)
+		_, err = f.DiscoverWebBatches(s.Webroot, s.liveDiscoverer())
 		if err != nil {
 			return fmt.Errorf("unable to cache web batches: %w", err)
 		}
@@ -128,16 +140,22 @@ func (s *Scanner) Scan() error {
 		}
 	}
 
+	return s.useFinder(f, f.Issues)
+}
+
+// useFinder builds a lookup from f's issues and checks the given issues for
+// dupes (only if this is an "everything" scanner), then swaps in f
+func (s *Scanner) useFinder(f *issuefinder.Finder, dupeCheck schema.IssueList) error {
 	// Create a new lookup using the new finder's data
 	s.Lookup = schema.NewLookup()
-	err = s.Lookup.Populate(f.Issues)
+	var err = s.Lookup.Populate(f.Issues)
 	if err != nil {
 		return fmt.Errorf("cannot build issue lookup for Scanner: %w", err)
 	}
 
-	// If this is an "everything" scanner, we need to check all issues for dupes
+	// If this is an "everything" scanner, we need to check issues for dupes
 	if !s.skipweb && !s.skipdb && !s.skipsftp && !s.skipscan {
-		for _, i := range f.Issues {
+		for _, i := range dupeCheck {
 			i.CheckDupes(s.Lookup)
 		}
 	}
@@ -147,3 +165,50 @@ func (s *Scanner) Scan() error {
 
 	return nil
 }
+
+// RefreshTitles returns a new Scanner in which the given upload title
+// directories have been rescanned. Everything else is shared with s, which
+// isn't modified, so s remains safe to use. Directories in upload areas this
+// scanner doesn't search are ignored.
+func (s *Scanner) RefreshTitles(dirs []TitleDir) (*Scanner, error) {
+	var searchers = make(map[issuefinder.Namespace]*issuefinder.Searcher)
+	var refreshed []TitleDir
+	for _, td := range dirs {
+		if (td.Namespace == issuefinder.SFTPUpload && s.skipsftp) || (td.Namespace == issuefinder.ScanUpload && s.skipscan) {
+			continue
+		}
+
+		var srch = searchers[td.Namespace]
+		if srch == nil {
+			srch = s.Finder.Searchers[td.Namespace]
+		}
+		if srch == nil {
+			return nil, fmt.Errorf("scanner has no data for namespace %d", td.Namespace)
+		}
+
+		var err error
+		srch, err = srch.RefreshTitle(td.Path, s.PDFBatchMARCOrgCode)
+		if err != nil {
+			return nil, fmt.Errorf("unable to refresh %q: %w", td.Path, err)
+		}
+		searchers[td.Namespace] = srch
+		refreshed = append(refreshed, td)
+	}
+
+	var f = s.Finder
+	for _, srch := range searchers {
+		f = f.WithSearcher(srch)
+	}
+
+	var dupeCheck schema.IssueList
+	for _, td := range refreshed {
+		dupeCheck = append(dupeCheck, searchers[td.Namespace].IssuesIn(td.Path)...)
+	}
+
+	var s2 = s.Duplicate()
+	var err = s2.useFinder(f, dupeCheck)
+	if err != nil {
+		return nil, err
+	}
+	return s2, nil
+}
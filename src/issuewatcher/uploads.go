@@ -0,0 +1,212 @@
+package issuewatcher
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/uoregon-libraries/newspaper-curation-app/internal/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/issuefinder"
+)
+
+// uploadSettleTime is how long a title directory has to go without changes
+// before we report it. Uploads generate a flurry of events, and rescanning a
+// title after every one of them would be pointless.
+var uploadSettleTime = 5 * time.Second
+
+// TitleDir identifies a single title's directory in one of the upload areas
+type TitleDir struct {
+	Namespace issuefinder.Namespace
+	Path      string
+}
+
+// uploadRoot is a top-level upload directory being watched
+type uploadRoot struct {
+	path      string
+	namespace issuefinder.Namespace
+
+	// titleDepth is how far below the root a title directory lives: SFTP
+	// titles are directly under the root, while scanned titles are grouped by
+	// MARC org code
+	titleDepth int
+}
+
+// An UploadWatcher uses inotify to track which title directories in the SFTP
+// and scanned-issue upload areas have changed, so that consumers can refresh
+// just those titles instead of rescanning everything.
+//
+// Consumers poll Changes rather than receiving events directly, which lets
+// any number of them share a single watcher, each keeping track of what it
+// has already seen.
+type UploadWatcher struct {
+	sync.Mutex
+	fsw     *fsnotify.Watcher
+	roots   []uploadRoot
+	changed map[TitleDir]time.Time
+	lostAt  time.Time
+	settle  time.Duration
+}
+
+// NewUploadWatcher starts watching the given SFTP and scanned-issue upload
+// directories (and everything below them)
+func NewUploadWatcher(pdfUpload, scanUpload string) (*UploadWatcher, error) {
+	var fsw, err = fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	var uw = &UploadWatcher{
+		fsw: fsw,
+		roots: []uploadRoot{
+			{path: filepath.Clean(pdfUpload), namespace: issuefinder.SFTPUpload, titleDepth: 1},
+			{path: filepath.Clean(scanUpload), namespace: issuefinder.ScanUpload, titleDepth: 2},
+		},
+		changed: make(map[TitleDir]time.Time),
+		settle:  uploadSettleTime,
+	}
+
+	go uw.run()
+	for _, root := range uw.roots {
+		uw.addTree(root.path)
+	}
+
+	return uw, nil
+}
+
+// Close stops watching for changes
+func (uw *UploadWatcher) Close() error {
+	return uw.fsw.Close()
+}
+
+// addTree adds watches to dir and all directories below it. If a watch can't
+// be added, we can no longer trust what we see, so the loss is flagged for
+// consumers to fall back to a full scan.
+func (uw *UploadWatcher) addTree(dir string) {
+	var err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// A directory disappearing mid-walk isn't a problem; its removal
+			// generates events of its own
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		return uw.fsw.Add(path)
+	})
+
+	if err != nil {
+		logger.Warnf("Unable to watch all of %q for upload changes (relying on full scans until the next one succeeds): %s", dir, err)
+		uw.lost()
+	}
+}
+
+// run processes inotify events until the watcher is closed
+func (uw *UploadWatcher) run() {
+	for {
+		select {
+		case ev, ok := <-uw.fsw.Events:
+			if !ok {
+				return
+			}
+			uw.handleEvent(ev)
+
+		case err, ok := <-uw.fsw.Errors:
+			if !ok {
+				return
+			}
+			if errors.Is(err, fsnotify.ErrEventOverflow) {
+				logger.Warnf("Upload watcher lost events; a full scan will be needed")
+			} else {
+				logger.Errorf("Upload watcher error: %s", err)
+			}
+			uw.lost()
+		}
+	}
+}
+
+func (uw *UploadWatcher) handleEvent(ev fsnotify.Event) {
+	// New directories need to be watched, including anything already in them
+	// (e.g., a directory moved in from elsewhere)
+	if ev.Has(fsnotify.Create) {
+		var info, err = os.Stat(ev.Name)
+		if err == nil && info.IsDir() {
+			uw.addTree(ev.Name)
+		}
+	}
+
+	// A renamed directory keeps its watch, but events would then be reported
+	// under its old name, so we drop the watch. If it was moved somewhere we
+	// care about, its new location gets a Create event.
+	if ev.Has(fsnotify.Rename) {
+		_ = uw.fsw.Remove(ev.Name)
+	}
+
+	var td, ok = uw.titleDir(ev.Name)
+	if !ok {
+		return
+	}
+
+	uw.Lock()
+	uw.changed[td] = time.Now()
+	uw.Unlock()
+}
+
+// titleDir returns the title directory containing path, if any
+func (uw *UploadWatcher) titleDir(path string) (TitleDir, bool) {
+	for _, root := range uw.roots {
+		var rel, err = filepath.Rel(root.path, path)
+		if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+			continue
+		}
+
+		var parts = strings.Split(rel, string(filepath.Separator))
+		if len(parts) < root.titleDepth {
+			return TitleDir{}, false
+		}
+		var titlePath = filepath.Join(append([]string{root.path}, parts[:root.titleDepth]...)...)
+		return TitleDir{Namespace: root.namespace, Path: titlePath}, true
+	}
+
+	return TitleDir{}, false
+}
+
+// lost records that events were (or may have been) missed
+func (uw *UploadWatcher) lost() {
+	uw.Lock()
+	uw.lostAt = time.Now()
+	uw.Unlock()
+}
+
+// Changes returns the title directories whose most recent change came after
+// since and has had time to settle. lost is true if events were missed in
+// that same window, in which case callers should do a full scan instead of
+// trusting the list. until is the end of the window, and should be passed
+// in as since on the next call.
+func (uw *UploadWatcher) Changes(since time.Time) (dirs []TitleDir, lost bool, until time.Time) {
+	uw.Lock()
+	defer uw.Unlock()
+
+	until = time.Now().Add(-uw.settle)
+	if !until.After(since) {
+		return nil, false, since
+	}
+
+	for td, t := range uw.changed {
+		if t.After(since) && !t.After(until) {
+			dirs = append(dirs, td)
+		}
+	}
+	sort.Slice(dirs, func(i, j int) bool { return dirs[i].Path < dirs[j].Path })
+	lost = uw.lostAt.After(since) && !uw.lostAt.After(until)
+
+	return dirs, lost, until
+}
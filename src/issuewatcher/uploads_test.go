@@ -0,0 +1,118 @@
+package issuewatcher
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/issuefinder"
+)
+
+func TestTitleDir(t *testing.T) {
+	var uw = &UploadWatcher{roots: []uploadRoot{
+		{path: "/sftp", namespace: issuefinder.SFTPUpload, titleDepth: 1},
+		{path: "/scans", namespace: issuefinder.ScanUpload, titleDepth: 2},
+	}}
+
+	var tests = map[string]struct {
+		path   string
+		wantOK bool
+		want   TitleDir
+	}{
+		"sftp root":        {"/sftp", false, TitleDir{}},
+		"sftp title":       {"/sftp/foo", true, TitleDir{issuefinder.SFTPUpload, "/sftp/foo"}},
+		"sftp issue file":  {"/sftp/foo/2020-01-02/0001.pdf", true, TitleDir{issuefinder.SFTPUpload, "/sftp/foo"}},
+		"scan moc":         {"/scans/oru", false, TitleDir{}},
+		"scan title":       {"/scans/oru/sn12345678", true, TitleDir{issuefinder.ScanUpload, "/scans/oru/sn12345678"}},
+		"scan issue":       {"/scans/oru/sn12345678/2020-01-02", true, TitleDir{issuefinder.ScanUpload, "/scans/oru/sn12345678"}},
+		"outside of roots": {"/tmp/foo/bar", false, TitleDir{}},
+		"similar prefix":   {"/sftp2/foo", false, TitleDir{}},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got, ok = uw.titleDir(tc.path)
+			if ok != tc.wantOK || got != tc.want {
+				t.Errorf("titleDir(%q) = %#v, %v; want %#v, %v", tc.path, got, ok, tc.want, tc.wantOK)
+			}
+		})
+	}
+}
+
+// waitForChanges polls the watcher until it reports a change or a timeout
+// is hit, since inotify events arrive asynchronously. A single filesystem
+// operation can generate several events, so once a change is seen we wait a
+// moment and collect any stragglers; otherwise they'd show up in the next
+// step's window.
+func waitForChanges(t *testing.T, uw *UploadWatcher, since time.Time) ([]TitleDir, time.Time) {
+	var deadline = time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		var dirs, lost, until = uw.Changes(since)
+		if lost {
+			t.Fatalf("Watcher unexpectedly lost events")
+		}
+		if len(dirs) > 0 {
+			time.Sleep(100 * time.Millisecond)
+			var more []TitleDir
+			more, _, until = uw.Changes(until)
+			return mergeTitleDirs(dirs, more), until
+		}
+		since = until
+		time.Sleep(10 * time.Millisecond)
+	}
+	return nil, since
+}
+
+func TestUploadWatcher(t *testing.T) {
+	var root = t.TempDir()
+	var sftp, scans = filepath.Join(root, "sftp"), filepath.Join(root, "scans")
+	var existing = filepath.Join(scans, "oru", "sn12345678")
+	for _, dir := range []string{sftp, existing} {
+		var err = os.MkdirAll(dir, 0755)
+		if err != nil {
+			t.Fatalf("Unable to create %q: %s", dir, err)
+		}
+	}
+
+	var uw, err = NewUploadWatcher(sftp, scans)
+	if err != nil {
+		t.Fatalf("Unable to create upload watcher: %s", err)
+	}
+	defer uw.Close()
+	uw.settle = 0
+
+	// A brand new title and issue: the watcher has to notice the new
+	// directories and start watching them in order to see the PDF
+	var issueDir = filepath.Join(sftp, "foo", "2020-01-02")
+	err = os.MkdirAll(issueDir, 0755)
+	if err != nil {
+		t.Fatalf("Unable to create %q: %s", issueDir, err)
+	}
+	var dirs, since = waitForChanges(t, uw, time.Time{})
+	var want = TitleDir{issuefinder.SFTPUpload, filepath.Join(sftp, "foo")}
+	if len(dirs) != 1 || dirs[0] != want {
+		t.Fatalf("Expected changes to be %#v, got %#v", want, dirs)
+	}
+
+	// Drop a file in the new issue dir and make sure it's seen
+	err = os.WriteFile(filepath.Join(issueDir, "0001.pdf"), []byte("%PDF-"), 0644)
+	if err != nil {
+		t.Fatalf("Unable to write PDF: %s", err)
+	}
+	dirs, since = waitForChanges(t, uw, since)
+	if len(dirs) != 1 || dirs[0] != want {
+		t.Fatalf("Expected changes to be %#v, got %#v", want, dirs)
+	}
+
+	// Changes to pre-existing scanned titles are reported under the title
+	err = os.Mkdir(filepath.Join(existing, "2020-01-02"), 0755)
+	if err != nil {
+		t.Fatalf("Unable to create scanned issue dir: %s", err)
+	}
+	dirs, _ = waitForChanges(t, uw, since)
+	want = TitleDir{issuefinder.ScanUpload, existing}
+	if len(dirs) != 1 || dirs[0] != want {
+		t.Fatalf("Expected changes to be %#v, got %#v", want, dirs)
+	}
+}
@@ -13,16 +13,22 @@ import (
 
 	"github.com/uoregon-libraries/newspaper-curation-app/internal/logger"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/httpcache"
 )
 
 // A Watcher wraps the Scanner to provide a long-running issue watcher which
-// scans issue directories and the live site at regular intervals
+// scans issue directories and the live site at regular intervals. Between
+// full scans, changes to the upload directories are picked up via inotify and
+// only the affected titles are rescanned.
 type Watcher struct {
 	sync.RWMutex
-	Scanner         *Scanner
-	status          watcherStatus
-	lastFullRefresh time.Time
-	done            chan bool
+	Scanner      *Scanner
+	conf         *config.Config
+	uploads      *UploadWatcher
+	uploadsSince time.Time
+	requested    map[TitleDir]bool
+	status       watcherStatus
+	done         chan bool
 }
 
 type watcherStatus int
@@ -53,9 +59,10 @@ func New(conf *config.Config) *Watcher {
 	// We want our first load to reuse the existing cache if available, because
 	// an app restart usually happens very shortly after a crash / server reboot
 	return &Watcher{
-		Scanner:         NewScanner(conf),
-		lastFullRefresh: time.Now(),
-		done:            make(chan bool),
+		Scanner:   NewScanner(conf),
+		conf:      conf,
+		requested: make(map[TitleDir]bool),
+		done:      make(chan bool),
 	}
 }
 
@@ -63,7 +70,11 @@ func New(conf *config.Config) *Watcher {
 // often.  The refreshing happens on a new issuefinder.Finder which then
 // replaces the current finder data, preventing slow searches from holding up
 // read access.
-func (w *Watcher) Watch(interval time.Duration) {
+//
+// Everything is rescanned every pollInterval if the upload directories can't
+// be watched. When they can, changes are picked up as they happen, and the
+// full rescan only runs every rescanInterval as a safety net.
+func (w *Watcher) Watch(pollInterval, rescanInterval time.Duration) {
 	w.Lock()
 
 	// If a cache file is available, use it, but we'll still be refreshing data
@@ -81,11 +92,30 @@ func (w *Watcher) Watch(interval time.Duration) {
 	w.status |= running
 	w.Unlock()
 
+	// Failing to watch uploads isn't fatal: we just won't see new uploads until
+	// the next full scan, as was the case before we had inotify watching
+	var uploads, uerr = NewUploadWatcher(w.conf.PDFUploadPath, w.conf.ScanUploadPath)
+	if uerr != nil {
+		logger.Warnf("Unable to watch upload directories for changes; new uploads will only be seen on full scans: %s", uerr)
+	}
+	w.Lock()
+	w.uploads = uploads
+	w.Unlock()
+
+	var interval = pollInterval
+	if uploads != nil {
+		interval = rescanInterval
+	}
+
 	var lastRefresh time.Time
 	for {
-		if time.Since(lastRefresh) > interval {
+		var dirs, lost = w.uploadChanges()
+		dirs = mergeTitleDirs(dirs, w.requestedDirs())
+		if lost || time.Since(lastRefresh) > interval {
 			w.process()
 			lastRefresh = time.Now()
+		} else if len(dirs) > 0 {
+			w.refreshTitles(dirs)
 		}
 		time.Sleep(time.Second * 1)
 
@@ -94,12 +124,106 @@ func (w *Watcher) Watch(interval time.Duration) {
 		var stopped = (w.status&running == 0)
 		w.RUnlock()
 		if stopped {
+			if uploads != nil {
+				uploads.Close()
+			}
 			w.done <- true
 			return
 		}
 	}
 }
 
+// Uploads returns the watcher's inotify-based upload directory watcher, or
+// nil if watching isn't running (or couldn't be set up)
+func (w *Watcher) Uploads() *UploadWatcher {
+	w.RLock()
+	defer w.RUnlock()
+	return w.uploads
+}
+
+// uploadChanges returns the title directories which have changed since the
+// last time we checked
+func (w *Watcher) uploadChanges() (dirs []TitleDir, lost bool) {
+	var uploads = w.Uploads()
+	if uploads == nil {
+		return nil, false
+	}
+	dirs, lost, w.uploadsSince = uploads.Changes(w.uploadsSince)
+	return dirs, lost
+}
+
+// RequestRefresh asks the watcher to rescan the given title directories on
+// its next pass, without waiting for a full scan. This is for changes the
+// watcher has no other way to know about quickly, such as SFTPGo telling us
+// an upload finished when inotify isn't available.
+func (w *Watcher) RequestRefresh(dirs ...TitleDir) {
+	w.Lock()
+	defer w.Unlock()
+	for _, d := range dirs {
+		w.requested[d] = true
+	}
+}
+
+// requestedDirs returns and clears the directories passed to RequestRefresh
+func (w *Watcher) requestedDirs() []TitleDir {
+	w.Lock()
+	defer w.Unlock()
+	var dirs []TitleDir
+	for d := range w.requested {
+		dirs = append(dirs, d)
+	}
+	clear(w.requested)
+	return dirs
+}
+
+// mergeTitleDirs combines two lists of title directories, skipping any
+// directory already seen
+func mergeTitleDirs(a, b []TitleDir) []TitleDir {
+	var seen = make(map[TitleDir]bool)
+	var merged []TitleDir
+	for _, list := range [][]TitleDir{a, b} {
+		for _, d := range list {
+			if !seen[d] {
+				seen[d] = true
+				merged = append(merged, d)
+			}
+		}
+	}
+	return merged
+}
+
+// refreshTitles rescans just the given title directories and swaps in the
+// updated data. The scan cache isn't rewritten; that's left to the full
+// refresh, since serializing everything would cost far more than the
+// rescanning.
+func (w *Watcher) refreshTitles(dirs []TitleDir) {
+	defer func() {
+		if r := recover(); r != nil {
+			var buf = make([]byte, 100000)
+			buf = buf[:runtime.Stack(buf, false)]
+			logger.Errorf("issuewatcher: panic refreshing titles: %v\n%s", r, buf)
+		}
+	}()
+
+	w.RLock()
+	var scanner = w.Scanner
+	w.RUnlock()
+
+	var next, err = scanner.RefreshTitles(dirs)
+	if err != nil {
+		logger.Errorf("Unable to refresh changed upload titles: %s", err)
+		return
+	}
+
+	// Full refreshes happen in the same goroutine as this, so there's no risk
+	// of clobbering newer data here
+	w.Lock()
+	w.Scanner = next
+	w.Unlock()
+
+	logger.Debugf("Refreshed %d changed upload title(s)", len(dirs))
+}
+
 // process refreshes the watcher's data and serializes it out
 func (w *Watcher) process() {
 	// Never let a crash stop us. This could result in an absurd amount of
@@ -118,10 +242,30 @@ func (w *Watcher) process() {
 	if err != nil {
 		logger.Warnf("Unable to cache to %#v: %s", w.Scanner.CacheFile(), err)
 	}
+	w.pruneCache()
+}
+
+// cacheMaxUnused is how long a cached web file can go unread before it's
+// pruned.  Anything the scanner still needs is read on every scan, so this
+// only removes data for batches and titles which are no longer live.
+const cacheMaxUnused = 30 * 24 * time.Hour
+
+// pruneCache removes cached web files which are no longer used, and the least
+// recently used files if the cache is larger than the configured maximum
+func (w *Watcher) pruneCache() {
+	var opts = httpcache.PruneOptions{MaxSize: int64(w.conf.IssueCacheMaxSize), MaxUnused: cacheMaxUnused}
+	var removed, err = httpcache.Prune(w.Scanner.Tempdir, opts)
+	if err != nil {
+		logger.Warnf("Unable to prune cache in %#v: %s", w.Scanner.Tempdir, err)
+	}
+	if len(removed) > 0 {
+		logger.Debugf("Pruned %d file(s) from the web cache", len(removed))
+	}
 }
 
 // refresh runs the searchers and replaces the underlying issuefinder.Finder.
-// Every week, it forces a full refresh of web data as well.
+// Cached web data isn't thrown away: it expires on its own, and is revalidated
+// with the live site when it does.
 func (w *Watcher) refresh() {
 	logger.Debugf("Refreshing issue data")
 	w.Lock()
@@ -144,18 +288,6 @@ func (w *Watcher) refresh() {
 	w.status |= refreshing
 	w.Unlock()
 
-	// Every week, we force a full web refresh
-	var tempdir string
-	if time.Since(w.lastFullRefresh) > time.Hour*24*7 {
-		logger.Debugf("Deleting cache and reindexing all data from scratch")
-
-		// We don't want to delete tempdir when it's a routine cleaning!  TODO: make this way less hacky
-		tempdir = w.Scanner.Tempdir
-		w.cleanupTempDir()
-		w.Scanner.Tempdir = tempdir
-		w.lastFullRefresh = time.Now()
-	}
-
 	// This won't do anything if we already have a temp dir
 	w.makeTempDir()
 
@@ -183,20 +315,6 @@ func (w *Watcher) refresh() {
 	logger.Debugf("Issue data refreshed")
 }
 
-// cleanupTempDir removes the httpcache temp dir files and subdirectories.
-// This must have a lock to be used safely.
-func (w *Watcher) cleanupTempDir() {
-	var td = w.Scanner.Tempdir
-	if td == "" {
-		return
-	}
-	var err = os.RemoveAll(td)
-	if err != nil {
-		logger.Errorf("Unable to remove issuewatcher.Watcher's temp dir %#v: %s", td, err)
-	}
-	w.Scanner.Tempdir = ""
-}
-
 // makeTempDir creates the temporary directory for httpcache to use.  This does
 // nothing if a temporary directory already exists.
 func (w *Watcher) makeTempDir() {
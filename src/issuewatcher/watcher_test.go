@@ -0,0 +1,32 @@
+package issuewatcher
+
+import (
+	"testing"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/issuefinder"
+)
+
+func TestRequestRefresh(t *testing.T) {
+	var w = New(&config.Config{})
+	var foo = TitleDir{issuefinder.SFTPUpload, "/sftp/foo"}
+	var bar = TitleDir{issuefinder.SFTPUpload, "/sftp/bar"}
+
+	w.RequestRefresh(foo)
+	w.RequestRefresh(foo, bar)
+
+	var got = w.requestedDirs()
+	if len(got) != 2 {
+		t.Fatalf("requestedDirs() = %#v; want foo and bar exactly once", got)
+	}
+
+	got = w.requestedDirs()
+	if len(got) != 0 {
+		t.Fatalf("requestedDirs() after clearing = %#v; want nothing", got)
+	}
+
+	var merged = mergeTitleDirs([]TitleDir{foo}, []TitleDir{bar, foo})
+	if len(merged) != 2 || merged[0] != foo || merged[1] != bar {
+		t.Fatalf("mergeTitleDirs() = %#v; want foo then bar", merged)
+	}
+}
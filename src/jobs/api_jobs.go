@@ -2,6 +2,7 @@ package jobs
 
 import (
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/uoregon-libraries/newspaper-curation-app/internal/openoni"
@@ -10,35 +11,65 @@ import (
 	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
 )
 
-const (
-	serverTypeStaging = "staging"
-	serverTypeProd    = "production"
-)
-
-// getONIAgent attempts to set up a connection to the staging or prod ONI Agent
-// based on the job's "location" arg
+// getONIAgent attempts to set up a connection to the ONI Agent for the
+// environment named in the job's "location" arg. Jobs queued before ONI
+// environments were configurable name a role instead, which works as long as
+// the role has just one environment.
 func getONIAgent(j *Job, c *config.Config) (*openoni.RPC, error) {
-	var lookup = map[string]string{
-		serverTypeProd:    c.ProductionAgentConnection,
-		serverTypeStaging: c.StagingAgentConnection,
+	var name = j.db.Args[JobArgLocation]
+	if name == "" {
+		return nil, fmt.Errorf("getONIAgent: environment name (location arg) is required")
+	}
+
+	var envs, err = c.ResolveONIEnvironments([]string{name})
+	if err != nil {
+		return nil, fmt.Errorf("getONIAgent: config error: %w", err)
+	}
+	if len(envs) != 1 {
+		return nil, fmt.Errorf("getONIAgent: config error: %q refers to %d ONI environments", name, len(envs))
+	}
+	return openoni.ForEnvironment(envs[0])
+}
+
+// resolveLegacyEnvironment handles a load or purge queued before ONI
+// environments were configurable, whose "location" arg names a role rather
+// than an environment. The job and the wait job entwined with it are pointed
+// at the role's first environment, and a copy of the pair is queued right
+// after them for each of the role's other environments.
+func (j *BatchJob) resolveLegacyEnvironment(c *config.Config) error {
+	var name = j.db.Args[JobArgLocation]
+	if c.ONIEnvironment(name) != nil {
+		return nil
 	}
-	var server = j.db.Args[JobArgLocation]
-	if server == "" {
-		return nil, fmt.Errorf("getONIAgent: server type (location arg) is required")
+
+	// Unknown names are left for getONIAgent to report
+	var envs, err = c.ResolveONIEnvironments([]string{name})
+	if err != nil || len(envs) == 0 {
+		return nil
 	}
 
-	var st = j.db.Args[JobArgLocation]
-	var connection = lookup[st]
-	if connection == "" {
-		return nil, fmt.Errorf("getONIAgent: config error: server type (location arg) %q has empty connection string", st)
+	var overrides []map[string]string
+	for _, env := range envs {
+		overrides = append(overrides, makeLocArgs(env.Name))
+	}
+	err = j.db.ExpandEntwined(overrides)
+	if err != nil {
+		return fmt.Errorf("replacing role %q with its environments: %w", name, err)
 	}
-	return openoni.New(connection)
+	j.Logger.Infof("Job was queued for role %q: running it on %q, with copies queued for the role's other environments", name, envs[0].Name)
+	return nil
 }
 
 type batchJobFunc func(batchname string) (jobid int64, err error)
 
+// queueAgentJob calls fn with the batch's name: the "BatchName" arg if it's
+// set, otherwise the batch's full name
 func (j *BatchJob) queueAgentJob(name string, fn batchJobFunc) ProcessResponse {
-	var jobid, err = fn(j.DBBatch.FullName)
+	var batchname = j.db.Args[JobArgBatchName]
+	if batchname == "" {
+		batchname = j.DBBatch.FullName
+	}
+	var jobid, err = fn(batchname)
 	if err != nil {
 		j.Logger.Errorf("Error calling ONI Agent: %s", err)
 		return PRFailure
@@ -80,7 +111,14 @@ type ONILoadBatch struct {
 
 // Process sends the RPC request to an ONI Agent, requesting a batch load
 func (j *ONILoadBatch) Process(c *config.Config) ProcessResponse {
-	var agent, err = getONIAgent(j.BatchJob.Job, c)
+	var err = j.resolveLegacyEnvironment(c)
+	if err != nil {
+		j.Logger.Errorf("Error resolving ONI environment: %s", err)
+		return PRFailure
+	}
+
+	var agent *openoni.RPC
+	agent, err = getONIAgent(j.BatchJob.Job, c)
 	if err != nil {
 		j.Logger.Errorf("Error constructing ONI RPC: %s", err)
 		return PRFailure
@@ -115,7 +153,14 @@ type ONIPurgeBatch struct {
 
 // Process sends the RPC request to an ONI Agent, requesting a batch purge
 func (j *ONIPurgeBatch) Process(c *config.Config) ProcessResponse {
-	var agent, err = getONIAgent(j.BatchJob.Job, c)
+	var err = j.resolveLegacyEnvironment(c)
+	if err != nil {
+		j.Logger.Errorf("Error resolving ONI environment: %s", err)
+		return PRFailure
+	}
+
+	var agent *openoni.RPC
+	agent, err = getONIAgent(j.BatchJob.Job, c)
 	if err != nil {
 		j.Logger.Errorf("Error constructing ONI RPC: %s", err)
 		return PRFailure
@@ -156,10 +201,14 @@ func (j *ONIWaitForJob) Process(c *config.Config) ProcessResponse {
 		return PRFailure
 	}
 
-	var jobID = j.DBBatch.ONIAgentJobID
+	return j.checkAgentJob(agent, j.DBBatch.ONIAgentJobID)
+}
 
-	var js openoni.JobStatus
-	js, err = agent.GetJobStatus(int64(jobID))
+// checkAgentJob asks the agent for the given job's status and translates it
+// to a ProcessResponse: PRTryLater if the job isn't done yet, PRSuccess if it
+// completed successfully, and a failure otherwise
+func (j *Job) checkAgentJob(agent *openoni.RPC, jobID int64) ProcessResponse {
+	var js, err = agent.GetJobStatus(jobID)
 	if err != nil {
 		j.Logger.Errorf("Error calling ONI Agent: %s", err)
 		return PRFailure
@@ -186,3 +235,179 @@ func (j *ONIWaitForJob) Process(c *config.Config) ProcessResponse {
 	j.Logger.Errorf("Unknown value returned when requesting job status for job %d: %q", jobID, js)
 	return PRFatal
 }
+
+// getSync returns the sync record for the given object in the environment
+// named by the job's "location" arg
+func (j *Job) getSync(objectType string, objectID int64) (*models.ONISync, error) {
+	return models.FindONISync(objectType, objectID, j.db.Args[JobArgLocation])
+}
+
+// recordSync updates the sync record's status, retrying on database errors
+// since a stale status would mislead users about what's in ONI
+func (j *Job) recordSync(s *models.ONISync, status models.ONISyncStatus, message string) error {
+	return retry.Do(time.Minute*10, func() error {
+		var err = s.SetStatus(status, message)
+		if err != nil {
+			j.Logger.Warnf("Unable to update ONI sync record. Retrying: %s", err)
+		}
+		return err
+	})
+}
+
+// failSync records a failed sync and returns the given response. Errors
+// recording the failure are logged but otherwise ignored, since the job is
+// already failing.
+func (j *Job) failSync(s *models.ONISync, pr ProcessResponse, message string) ProcessResponse {
+	j.Logger.Errorf("%s", message)
+	var err = j.recordSync(s, models.ONISyncStatusFailed, message)
+	if err != nil {
+		j.Logger.Errorf("Unable to record ONI sync failure: %s", err)
+	}
+	return pr
+}
+
+// ONILoadTitle sends a title's MARC XML to an ONI Agent
+type ONILoadTitle struct {
+	*TitleJob
+}
+
+// Process sends the RPC request to an ONI Agent, requesting a title load, and
+// stores the agent's job id for the next job to watch
+func (j *ONILoadTitle) Process(c *config.Config) ProcessResponse {
+	var s, err = j.getSync(models.JobObjectTypeTitle, j.DBTitle.ID)
+	if err != nil {
+		j.Logger.Errorf("Unable to look up sync record: %s", err)
+		return PRFailure
+	}
+
+	var agent *openoni.RPC
+	agent, err = getONIAgent(j.TitleJob.Job, c)
+	if err != nil {
+		return j.failSync(s, PRFailure, fmt.Sprintf("Error constructing ONI RPC: %s", err))
+	}
+
+	if j.DBTitle.MARCXML == "" {
+		return j.failSync(s, PRFatal, "Title has no MARC record to send to ONI")
+	}
+
+	var jobid int64
+	jobid, err = agent.LoadTitle([]byte(j.DBTitle.MARCXML))
+	if err != nil {
+		return j.failSync(s, PRFailure, fmt.Sprintf("Error calling ONI Agent: %s", err))
+	}
+	j.Logger.Infof("Queued load title job in %s ONI Agent: job id %d", s.Environment, jobid)
+
+	// The wait job gets the agent's job id directly: the sync record is shared
+	// by every pipeline for this title and environment, so a requeued sync
+	// could replace the id before the wait is done with it
+	err = retry.Do(time.Minute*10, func() error {
+		var err = j.db.SetEntwinedArgs(map[string]string{JobArgONIJobID: strconv.FormatInt(jobid, 10)})
+		if err != nil {
+			j.Logger.Warnf("Unable to pass ONI Agent job id to the next job. Retrying: %s", err)
+		}
+		return err
+	})
+	if err != nil {
+		j.Logger.Criticalf("Unable to pass ONI Agent job id to the next job after successfully queueing it! Manual intervention required! Error: %s", err)
+		return PRFatal
+	}
+
+	s.ONIAgentJobID = jobid
+	err = j.recordSync(s, models.ONISyncStatusLoading, "")
+	if err != nil {
+		j.Logger.Criticalf("Unable to update sync record after successfully queueing an ONI job! Manual intervention required! Error: %s", err)
+		return PRFatal
+	}
+
+	return PRSuccess
+}
+
+// ONIWaitForTitle polls ONI until the title load queued by ONILoadTitle
+// completes, then marks the title as synced
+type ONIWaitForTitle struct {
+	*TitleJob
+}
+
+// Process connects to an ONI Agent and checks the status of the title load
+func (j *ONIWaitForTitle) Process(c *config.Config) ProcessResponse {
+	var s, err = j.getSync(models.JobObjectTypeTitle, j.DBTitle.ID)
+	if err != nil {
+		j.Logger.Errorf("Unable to look up sync record: %s", err)
+		return PRFailure
+	}
+
+	// As with batches, -1 means the agent had nothing to do
+	var id = agentJobID(j.db, s)
+	if id != -1 {
+		if id < 1 {
+			return j.failSync(s, PRFatal, fmt.Sprintf("Invalid ONI Agent job id (%d)", id))
+		}
+
+		var agent *openoni.RPC
+		agent, err = getONIAgent(j.TitleJob.Job, c)
+		if err != nil {
+			return j.failSync(s, PRFailure, fmt.Sprintf("Error constructing ONI RPC: %s", err))
+		}
+
+		var pr = j.checkAgentJob(agent, id)
+		switch pr {
+		case PRTryLater:
+			return pr
+		case PRFailure, PRFatal:
+			return j.failSync(s, pr, fmt.Sprintf("ONI Agent job %d didn't complete successfully", id))
+		}
+	}
+
+	err = j.recordSync(s, models.ONISyncStatusSynced, "")
+	if err != nil {
+		j.Logger.Errorf("Unable to mark title as synced: %s", err)
+		return PRFailure
+	}
+	return PRSuccess
+}
+
+// agentJobID returns the ONI Agent job id handed to a wait job. Jobs queued
+// before the id was handed off have to rely on the sync record instead.
+func agentJobID(dbJob *models.Job, s *models.ONISync) int64 {
+	var val, ok = dbJob.Args[JobArgONIJobID]
+	if !ok {
+		return s.ONIAgentJobID
+	}
+	var id, _ = strconv.ParseInt(val, 10, 64)
+	return id
+}
+
+// ONIEnsureAwardee makes sure ONI has a MOC's awardee record
+type ONIEnsureAwardee struct {
+	*MOCJob
+}
+
+// Process sends the RPC request to an ONI Agent to create or verify the MOC's
+// awardee
+func (j *ONIEnsureAwardee) Process(c *config.Config) ProcessResponse {
+	var s, err = j.getSync(models.JobObjectTypeMOC, j.DBMOC.ID)
+	if err != nil {
+		j.Logger.Errorf("Unable to look up sync record: %s", err)
+		return PRFailure
+	}
+
+	var agent *openoni.RPC
+	agent, err = getONIAgent(j.MOCJob.Job, c)
+	if err != nil {
+		return j.failSync(s, PRFailure, fmt.Sprintf("Error constructing ONI RPC: %s", err))
+	}
+
+	var msg string
+	msg, err = agent.EnsureAwardee(j.DBMOC)
+	if err != nil {
+		return j.failSync(s, PRFailure, fmt.Sprintf("ONI Agent couldn't verify awardee's existence: %s", err))
+	}
+	j.Logger.Infof("ONI Agent ensure-awardee response: %s", msg)
+
+	err = j.recordSync(s, models.ONISyncStatusSynced, msg)
+	if err != nil {
+		j.Logger.Errorf("Unable to mark MOC as synced: %s", err)
+		return PRFailure
+	}
+	return PRSuccess
+}
@@ -0,0 +1,31 @@
+package jobs
+
+import (
+	"github.com/uoregon-libraries/newspaper-curation-app/src/archive"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+)
+
+// ArchiveBatch moves or copies a batch from its current location to the
+// configured archive target
+type ArchiveBatch struct {
+	*BatchJob
+}
+
+// Process implements Processor. Targets skip what's already archived, so a
+// failure is always worth retrying.
+func (j *ArchiveBatch) Process(c *config.Config) ProcessResponse {
+	var target, err = archive.New(c.ArchiveTarget)
+	if err != nil {
+		j.Logger.Errorf("Unable to set up archive target: %s", err)
+		return PRFatal
+	}
+
+	var name = j.DBBatch.FullName
+	j.Logger.Infof("Archiving batch %q from %q to %q", name, j.DBBatch.Location, target.Location(name))
+	err = target.Archive(j.DBBatch.Location, name)
+	if err != nil {
+		j.Logger.Errorf("Unable to archive batch: %s", err)
+		return PRFailure
+	}
+	return PRSuccess
+}
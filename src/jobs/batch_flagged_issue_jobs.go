@@ -1,6 +1,7 @@
 package jobs
 
 import (
+	"strconv"
 	"time"
 
 	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
@@ -125,3 +126,28 @@ func (j *DeleteBatch) Process(*config.Config) ProcessResponse {
 	}
 	return PRSuccess
 }
+
+// UnbatchIssues deletes a batch which hasn't been approved and returns its
+// issues to the batching queue
+type UnbatchIssues struct {
+	*BatchJob
+}
+
+// Process reads the requesting user's id and reason from the job args and
+// runs batch.Unbatch
+func (j *UnbatchIssues) Process(*config.Config) ProcessResponse {
+	var arg = j.db.Args[JobArgID]
+	var userID, err = strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		j.Logger.Errorf("Error reading job arg (%q) as int64: %s; killing job", arg, err)
+		return PRFatal
+	}
+
+	j.Logger.Debugf("Unbatching batch %d (%s)", j.DBBatch.ID, j.DBBatch.FullName)
+	err = j.DBBatch.Unbatch(userID, j.db.Args[JobArgMessage])
+	if err != nil {
+		j.Logger.Errorf("Database error unbatching batch: %s", err)
+		return PRFailure
+	}
+	return PRSuccess
+}
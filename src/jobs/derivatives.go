@@ -10,6 +10,7 @@ import (
 	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/derivatives/alto"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/derivatives/jp2"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
 )
 
 var pdfFilenameRegex = regexp.MustCompile(`(?i:^[0-9]{4}.pdf)`)
@@ -25,6 +26,7 @@ type MakeDerivatives struct {
 	AltoDerivativeSources []string
 	JP2DerivativeSources  []string
 	findTIFFs             func() bool
+	jp2Source             string
 	AltoDPI               int
 	JP2DPI                int
 	JP2Quality            float64
@@ -51,15 +53,17 @@ func (md *MakeDerivatives) Process(c *config.Config) ProcessResponse {
 		// For scanned issues, we have to verify TIFFs and use the scan DPI for
 		// generating ALTO XML
 		md.findTIFFs = md._findTIFFs
+		md.jp2Source = "tiff"
 		md.AltoDPI = c.ScannedPDFDPI
 	} else {
 		// Born-digital issues don't check TIFFs and use the JP2 DPI for ALTO
 		md.findTIFFs = func() bool { return true }
+		md.jp2Source = "pdf"
 		md.AltoDPI = c.DPI
 	}
 
 	// Run our serial operations, failing on the first non-ok response
-	if RunWhileTrue(md.findPDFs, md.findTIFFs, md.validateSourceFiles, md.generateDerivatives) {
+	if RunWhileTrue(md.findPDFs, md.findTIFFs, md.validateSourceFiles, md.generateDerivatives, md.recordSettings) {
 		return PRSuccess
 	}
 	return PRFailure
@@ -171,6 +175,25 @@ func (md *MakeDerivatives) generateDerivatives() (ok bool) {
 	return ok
 }
 
+// recordSettings stores the settings the derivatives were generated with,
+// since the configuration may change before the issue's provenance is needed
+func (md *MakeDerivatives) recordSettings() (ok bool) {
+	var settings = &models.DerivativeSettings{
+		IssueID:    md.DBIssue.ID,
+		JP2Source:  md.jp2Source,
+		JP2DPI:     md.JP2DPI,
+		JP2Quality: md.JP2Quality,
+		ALTODPI:    md.AltoDPI,
+	}
+	var err = settings.Save()
+	if err != nil {
+		md.Logger.Errorf("Unable to record derivative settings: %s", err)
+		return false
+	}
+
+	return true
+}
+
 // createAltoXML produces ALTO XML from the given PDF file
 func (md *MakeDerivatives) createAltoXML(file string, pageno int) (ok bool) {
 	var outputFile = strings.Replace(file, filepath.Ext(file), ".xml", 1)
@@ -0,0 +1,265 @@
+package jobs
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/uoregon-libraries/gopkg/bagit"
+	"github.com/uoregon-libraries/gopkg/hasher"
+	"github.com/uoregon-libraries/newspaper-curation-app/internal/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+)
+
+// liveCopyExclusions lists the files which aren't copied to a batch's live
+// location: ONI serves JP2s and PDFs, so the TIFFs and archived originals
+// only live in the full batch
+var liveCopyExclusions = []string{"*.tif", "*.tiff", "*.TIF", "*.TIFF", "*.tar.bz", "*.tar"}
+
+// fixityResult holds the outcome of verifying a bag
+type fixityResult struct {
+	files         int
+	discrepancies []string
+}
+
+// verifyBag checks every file in the bag at root against its manifests.
+// Manifest entries whose filenames match any of the skip patterns aren't
+// expected to be on disk, which lets us verify partial copies like the live
+// batch. An error is returned only if the bag couldn't be verified at all;
+// bad files are reported as discrepancies.
+func verifyBag(root string, skip []string) (*fixityResult, error) {
+	var b = bagit.New(root, hasher.NewSHA256())
+	var err = b.ReadManifests()
+	if err != nil {
+		return nil, fmt.Errorf("reading bag manifests: %w", err)
+	}
+	if len(b.ManifestChecksums) == 0 {
+		return nil, fmt.Errorf("bag manifest lists no files")
+	}
+
+	// As with bagit's own validation, a bad tag manifest means we can't trust
+	// the rest of the bag, so there's no point hashing the payload
+	var r = &fixityResult{}
+	if len(b.ManifestTagSums) > 0 {
+		err = b.GenerateTagSums()
+		if err != nil {
+			return nil, fmt.Errorf("generating tag checksums: %w", err)
+		}
+		r.files = len(b.ManifestTagSums)
+		r.discrepancies = bagit.Compare("tag manifest", b.ManifestTagSums, b.ActualTagSums)
+		if len(r.discrepancies) > 0 {
+			sort.Strings(r.discrepancies)
+			return r, nil
+		}
+	}
+
+	err = b.GenerateChecksums()
+	if err != nil {
+		return nil, fmt.Errorf("generating payload checksums: %w", err)
+	}
+
+	var expected []*bagit.FileChecksum
+	for _, sum := range b.ManifestChecksums {
+		if !matchesAny(filepath.Base(sum.Path), skip) {
+			expected = append(expected, sum)
+		}
+	}
+	r.files += len(expected)
+	r.discrepancies = bagit.Compare("manifest", expected, b.ActualChecksums)
+	sort.Strings(r.discrepancies)
+	return r, nil
+}
+
+func matchesAny(name string, patterns []string) bool {
+	for _, p := range patterns {
+		var ok, _ = filepath.Match(p, name)
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// checkFixity verifies one copy of a batch, records the results, and raises
+// an alert if anything is wrong
+func checkFixity(batch *models.Batch, which, location string, skip []string) (*models.BatchFixityCheck, error) {
+	var check = &models.BatchFixityCheck{
+		BatchID:   batch.ID,
+		Copy:      which,
+		Location:  location,
+		StartedAt: time.Now(),
+	}
+
+	var r, err = verifyBag(location, skip)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		check.Status = models.FixityStatusFailed
+		check.Details = fmt.Sprintf("batch directory or bag manifest is missing: %s", err)
+	case err != nil:
+		check.Status = models.FixityStatusError
+		check.Details = err.Error()
+	case len(r.discrepancies) > 0:
+		check.Status = models.FixityStatusFailed
+		check.Files = r.files
+		check.Details = strings.Join(r.discrepancies, "\n")
+	default:
+		check.Status = models.FixityStatusOK
+		check.Files = r.files
+	}
+	check.FinishedAt = time.Now()
+
+	if check.Status == models.FixityStatusFailed {
+		var msg = fmt.Sprintf("Fixity check failed for batch %d (%s), %s copy at %q", batch.ID, batch.FullName, which, location)
+		logger.CriticalFixNeeded(msg, errors.New(strings.Replace(check.Details, "\n", "; ", -1)))
+	}
+
+	err = check.Save()
+	if err != nil {
+		return check, fmt.Errorf("saving fixity check for batch %d: %w", batch.ID, err)
+	}
+	return check, nil
+}
+
+// ValidateBagit verifies a batch's entire bag: tag files and payload. It runs
+// against the job's location if one is given, otherwise the batch's current
+// location.
+type ValidateBagit struct {
+	*BatchJob
+}
+
+// Process implements Processor, recording a fixity check and failing if the
+// bag doesn't match its manifests
+func (j *ValidateBagit) Process(*config.Config) ProcessResponse {
+	var location = j.db.Args[JobArgLocation]
+	if location == "" {
+		location = j.DBBatch.Location
+	}
+	var which = j.db.Args[JobArgFixityCopy]
+
+	j.Logger.Infof("Validating bag for batch %q at %q", j.DBBatch.FullName, location)
+	var check, err = checkFixity(j.DBBatch, which, location, nil)
+	if err != nil {
+		j.Logger.Errorf("Unable to record bag validation: %s", err)
+		return PRFailure
+	}
+
+	switch check.Status {
+	case models.FixityStatusOK:
+		j.Logger.Infof("Bag is valid: %d file(s) verified", check.Files)
+		return PRSuccess
+
+	// An error is usually a filesystem hiccup, which is worth retrying
+	case models.FixityStatusError:
+		j.Logger.Errorf("Unable to validate bag: %s", check.Details)
+		return PRFailure
+	}
+
+	// Bad files won't fix themselves, so there's no point retrying: someone
+	// has to figure out what happened before the batch can move on
+	j.Logger.Errorf("Bag is invalid:")
+	for _, p := range check.Problems() {
+		j.Logger.Errorf("- %s", p)
+	}
+	return PRFatal
+}
+
+// fixityCopy identifies one copy of a batch for the fixity auditor
+type fixityCopy struct {
+	batch    *models.Batch
+	which    string
+	location string
+	skip     []string
+	lastRun  time.Time
+}
+
+// auditableCopies returns every copy of a live batch the auditor should
+// verify. The live copy must always exist. The archive copy is only checked
+// when batches are archived to a filesystem: it's expected until the batch
+// is flagged as archived, and after that it may have been moved to long-term
+// storage, so it's only checked if it's still there. S3 objects are verified
+// when uploaded, and tape staging copies are transient.
+func auditableCopies(c *config.Config, batches []*models.Batch) []*fixityCopy {
+	var copies []*fixityCopy
+	for _, b := range batches {
+		if !b.StatusMeta.Live {
+			continue
+		}
+
+		copies = append(copies, &fixityCopy{
+			batch:    b,
+			which:    models.FixityCopyLive,
+			location: filepath.Join(c.BatchProductionPath, b.FullName),
+			skip:     liveCopyExclusions,
+		})
+
+		if c.ArchiveTarget == nil || c.ArchiveTarget.Type != config.ArchiveFilesystem {
+			continue
+		}
+		var archive = filepath.Join(c.ArchiveTarget.Path, b.FullName)
+		var _, err = os.Stat(archive)
+		if b.Status == models.BatchStatusLive || err == nil {
+			copies = append(copies, &fixityCopy{batch: b, which: models.FixityCopyArchive, location: archive})
+		}
+	}
+	return copies
+}
+
+// nextFixityCopy returns the copy which has gone longest without a check,
+// or nil if every copy has been checked within the interval. Copies which
+// have never been checked come first.
+func nextFixityCopy(copies []*fixityCopy, checks []*models.BatchFixityCheck, interval time.Duration, now time.Time) *fixityCopy {
+	var last = make(map[int64]map[string]time.Time)
+	for _, c := range checks {
+		if last[c.BatchID] == nil {
+			last[c.BatchID] = make(map[string]time.Time)
+		}
+		last[c.BatchID][c.Copy] = c.FinishedAt
+	}
+
+	var next *fixityCopy
+	for _, fc := range copies {
+		fc.lastRun = last[fc.batch.ID][fc.which]
+		if now.Sub(fc.lastRun) < interval {
+			continue
+		}
+		if next == nil || fc.lastRun.Before(next.lastRun) {
+			next = fc
+		}
+	}
+	return next
+}
+
+// AuditNextFixity verifies the batch copy most in need of a fixity check.
+// It returns false if nothing was due, so callers can wait before trying
+// again.
+func AuditNextFixity(c *config.Config) (bool, error) {
+	var batches, err = models.AllBatches()
+	if err != nil {
+		return false, fmt.Errorf("reading batches: %w", err)
+	}
+	var checks []*models.BatchFixityCheck
+	checks, err = models.LatestFixityChecks()
+	if err != nil {
+		return false, fmt.Errorf("reading fixity checks: %w", err)
+	}
+
+	var next = nextFixityCopy(auditableCopies(c, batches), checks, c.FixityAuditInterval, time.Now())
+	if next == nil {
+		return false, nil
+	}
+
+	logger.Infof("Auditing fixity of batch %q (%s copy)", next.batch.FullName, next.which)
+	var check *models.BatchFixityCheck
+	check, err = checkFixity(next.batch, next.which, next.location, next.skip)
+	if err != nil {
+		return true, err
+	}
+	logger.Infof("Fixity audit of batch %q (%s copy): %s, %d file(s) verified", next.batch.FullName, next.which, check.Status, check.Files)
+	return true, nil
+}
@@ -0,0 +1,147 @@
+package jobs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+)
+
+func TestVerifyBag(t *testing.T) {
+	var tests = map[string]struct {
+		change    func(dir string) error
+		skip      []string
+		wantFiles int
+		wantProbs []string
+	}{
+		"untouched": {
+			change:    func(string) error { return nil },
+			wantFiles: 7,
+		},
+		"corrupt file": {
+			change: func(dir string) error {
+				return os.WriteFile(filepath.Join(dir, "data", "bar.txt"), []byte("changed"), 0600)
+			},
+			wantFiles: 7,
+			wantProbs: []string{`corrupt file: "data/bar.txt"`},
+		},
+		"missing file": {
+			change:    func(dir string) error { return os.Remove(filepath.Join(dir, "data", "subdir", "other.txt")) },
+			wantFiles: 7,
+			wantProbs: []string{`missing file: "data/subdir/other.txt"`},
+		},
+		"extra file": {
+			change:    func(dir string) error { return os.WriteFile(filepath.Join(dir, "data", "new.txt"), []byte("hi"), 0600) },
+			wantFiles: 7,
+			wantProbs: []string{`extra file: "data/new.txt"`},
+		},
+		"skipped file missing": {
+			change:    func(dir string) error { return os.Remove(filepath.Join(dir, "data", "quux.txt")) },
+			skip:      []string{"quux.*"},
+			wantFiles: 6,
+		},
+		"bad tag file": {
+			change:    func(dir string) error { return os.WriteFile(filepath.Join(dir, "bagit.txt"), []byte("nope"), 0600) },
+			wantFiles: 2,
+			wantProbs: []string{`corrupt file: "bagit.txt"`},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var dir = getdir(t)
+			defer os.RemoveAll(dir)
+			if (&WriteBagitManifest{BatchJob: getBatchJob(dir)}).Process(&config.Config{}) != PRSuccess {
+				t.Fatalf("Unable to write bag")
+			}
+			var err = tc.change(dir)
+			if err != nil {
+				t.Fatalf("Unable to change bag: %s", err)
+			}
+
+			var r *fixityResult
+			r, err = verifyBag(dir, tc.skip)
+			if err != nil {
+				t.Fatalf("verifyBag() error: %s", err)
+			}
+			if r.files != tc.wantFiles {
+				t.Errorf("verifyBag() checked %d files; want %d", r.files, tc.wantFiles)
+			}
+			if len(r.discrepancies) != len(tc.wantProbs) {
+				t.Fatalf("verifyBag() discrepancies = %#v; want %#v", r.discrepancies, tc.wantProbs)
+			}
+			for i, want := range tc.wantProbs {
+				if !strings.HasPrefix(r.discrepancies[i], want) {
+					t.Errorf("discrepancy %d = %q; want it to start with %q", i, r.discrepancies[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyBagNotABag(t *testing.T) {
+	var _, err = verifyBag(t.TempDir(), nil)
+	if err == nil {
+		t.Fatalf("verifyBag() on an empty directory should fail")
+	}
+}
+
+func TestNextFixityCopy(t *testing.T) {
+	var now = time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	var b1, b2 = &models.Batch{ID: 1}, &models.Batch{ID: 2}
+	var copies = []*fixityCopy{
+		{batch: b1, which: models.FixityCopyLive},
+		{batch: b1, which: models.FixityCopyArchive},
+		{batch: b2, which: models.FixityCopyLive},
+	}
+	var check = func(b *models.Batch, which string, age time.Duration) *models.BatchFixityCheck {
+		return &models.BatchFixityCheck{BatchID: b.ID, Copy: which, FinishedAt: now.Add(-age)}
+	}
+
+	var tests = map[string]struct {
+		checks    []*models.BatchFixityCheck
+		wantBatch *models.Batch
+		wantWhich string
+	}{
+		"nothing checked": {nil, b1, models.FixityCopyLive},
+		"unchecked copy first": {
+			[]*models.BatchFixityCheck{check(b1, models.FixityCopyLive, 50*time.Hour), check(b2, models.FixityCopyLive, 60*time.Hour)},
+			b1, models.FixityCopyArchive,
+		},
+		"oldest check next": {
+			[]*models.BatchFixityCheck{
+				check(b1, models.FixityCopyLive, 50*time.Hour),
+				check(b1, models.FixityCopyArchive, 30*time.Hour),
+				check(b2, models.FixityCopyLive, 60*time.Hour),
+			},
+			b2, models.FixityCopyLive,
+		},
+		"everything recent": {
+			[]*models.BatchFixityCheck{
+				check(b1, models.FixityCopyLive, time.Hour),
+				check(b1, models.FixityCopyArchive, time.Hour),
+				check(b2, models.FixityCopyLive, 23*time.Hour),
+			},
+			nil, "",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got = nextFixityCopy(copies, tc.checks, 24*time.Hour, now)
+			if tc.wantBatch == nil {
+				if got != nil {
+					t.Fatalf("nextFixityCopy() = batch %d %s; want nil", got.batch.ID, got.which)
+				}
+				return
+			}
+			if got == nil || got.batch != tc.wantBatch || got.which != tc.wantWhich {
+				t.Fatalf("nextFixityCopy() = %#v; want batch %d %s", got, tc.wantBatch.ID, tc.wantWhich)
+			}
+		})
+	}
+}
@@ -55,10 +55,18 @@ func DBJobToProcessor(dbJob *models.Job) Processor {
 		return &WriteBagitManifest{BatchJob: NewBatchJob(dbJob)}
 	case models.JobTypeValidateTagManifest:
 		return &ValidateTagManifest{BatchJob: NewBatchJob(dbJob)}
+	case models.JobTypeValidateBagit:
+		return &ValidateBagit{BatchJob: NewBatchJob(dbJob)}
+	case models.JobTypeValidateBatch:
+		return &ValidateBatch{BatchJob: NewBatchJob(dbJob)}
+	case models.JobTypeArchiveBatch:
+		return &ArchiveBatch{BatchJob: NewBatchJob(dbJob)}
 	case models.JobTypeMarkBatchLive:
 		return &MarkBatchLive{BatchJob: NewBatchJob(dbJob)}
 	case models.JobTypeDeleteBatch:
 		return &DeleteBatch{BatchJob: NewBatchJob(dbJob)}
+	case models.JobTypeUnbatchIssues:
+		return &UnbatchIssues{BatchJob: NewBatchJob(dbJob)}
 	case models.JobTypeSyncRecursive:
 		return &SyncRecursive{Job: NewJob(dbJob)}
 	case models.JobTypeVerifyRecursive:
@@ -87,6 +95,12 @@ func DBJobToProcessor(dbJob *models.Job) Processor {
 		return &ONIPurgeBatch{BatchJob: NewBatchJob(dbJob)}
 	case models.JobTypeONIWaitForJob:
 		return &ONIWaitForJob{BatchJob: NewBatchJob(dbJob)}
+	case models.JobTypeONILoadTitle:
+		return &ONILoadTitle{TitleJob: NewTitleJob(dbJob)}
+	case models.JobTypeONIWaitForTitle:
+		return &ONIWaitForTitle{TitleJob: NewTitleJob(dbJob)}
+	case models.JobTypeONIEnsureAwardee:
+		return &ONIEnsureAwardee{MOCJob: NewMOCJob(dbJob)}
 	default:
 		logger.Errorf("Unknown job type %q for job id %d", dbJob.Type, dbJob.ID)
 	}
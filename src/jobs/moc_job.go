@@ -0,0 +1,46 @@
+package jobs
+
+import (
+	"fmt"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/internal/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+)
+
+// MOCJob wraps the Job type to add things needed in all jobs tied to
+// specific MARC org codes
+type MOCJob struct {
+	*Job
+	DBMOC *models.MOC
+}
+
+// NewMOCJob setups up a MOCJob from a database Job, centralizing the common
+// validations and data manipulation
+func NewMOCJob(dbJob *models.Job) *MOCJob {
+	var j, err = newMOCJob(dbJob)
+	if err != nil {
+		logger.Errorf("Unable to create MOC job %d: %s", dbJob.ID, err)
+	}
+	return j
+}
+
+// newMOCJob actually creates the job and returns it and possibly an error.
+// See newIssueJob for why this is split up this way.
+func newMOCJob(dbJob *models.Job) (j *MOCJob, err error) {
+	j = &MOCJob{Job: NewJob(dbJob)}
+
+	j.DBMOC, err = models.FindMOCByID(dbJob.ObjectID)
+	if err != nil {
+		return j, err
+	}
+	if j.DBMOC == nil {
+		return j, fmt.Errorf("MOC id %d does not exist", dbJob.ObjectID)
+	}
+
+	return j, nil
+}
+
+// Valid returns whether the database MOC was found successfully
+func (j *MOCJob) Valid() bool {
+	return j.DBMOC != nil
+}
@@ -1,12 +1,14 @@
 package jobs
 
 import (
+	"errors"
 	"fmt"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/uoregon-libraries/newspaper-curation-app/src/archive"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/schema"
@@ -24,6 +26,9 @@ const (
 	JobArgMessage      = "Message"
 	JobArgExclude      = "Exclude"
 	JobArgID           = "ID"
+	JobArgBatchName    = "BatchName"
+	JobArgFixityCopy   = "FixityCopy"
+	JobArgONIJobID     = "ONIJobID"
 )
 
 func makeWSArgs(ws schema.WorkflowStep) map[string]string {
@@ -38,6 +43,10 @@ func makeLocArgs(loc string) map[string]string {
 	return map[string]string{JobArgLocation: loc}
 }
 
+func makeFixityArgs(loc, which string) map[string]string {
+	return map[string]string{JobArgLocation: loc, JobArgFixityCopy: which}
+}
+
 func makeIDArgs(id int64) map[string]string {
 	return map[string]string{JobArgID: strconv.FormatInt(id, 10)}
 }
@@ -94,6 +103,70 @@ func getJobsForONIBatch(batch *models.Batch, jType models.JobType, env string) [
 	return []*models.Job{queue, wait}
 }
 
+// getJobsForONIEnvs returns jobs for loading or purging an ONI batch on each
+// of the given environments, logging a batch action after each. actionFormat
+// is used to build the action, and must contain a single %s for the
+// environment's name.
+func getJobsForONIEnvs(batch *models.Batch, jType models.JobType, envs []*config.ONIEnvironment, actionFormat string) []*models.Job {
+	var jobs []*models.Job
+	for _, env := range envs {
+		jobs = append(jobs, getJobsForONIBatch(batch, jType, env.Name)...)
+		jobs = append(jobs, batch.BuildJob(models.JobTypeBatchAction, makeActionArgs(fmt.Sprintf(actionFormat, env.Name))))
+	}
+	return jobs
+}
+
+// loadEnvs resolves the names of the environments a batch is being loaded
+// into. A load has to go somewhere, so an empty list is an error.
+func loadEnvs(names []string, c *config.Config) ([]*config.ONIEnvironment, error) {
+	var envs, err = c.ResolveONIEnvironments(names)
+	if err == nil && len(envs) == 0 {
+		err = fmt.Errorf("at least one ONI environment is required")
+	}
+	return envs, err
+}
+
+// LoadedONIEnvironments returns the names of the environments a batch is
+// loaded on according to its job history: those where its most recent
+// successful load wasn't followed by a purge. Purges of an older version of
+// the batch, which name that version rather than this one, don't count.
+//
+// Names are as recorded on the jobs, so very old jobs may report a role name
+// rather than an environment; see [config.Config.ResolveONIEnvironments].
+//
+// oniJobs must be the batch's successful ONI load and purge jobs, oldest
+// first, as returned by [models.FindBatchONIJobs].
+func LoadedONIEnvironments(batch *models.Batch, oniJobs []*models.Job) []string {
+	var loaded = make(map[string]bool)
+	var order []string
+	for _, j := range oniJobs {
+		var name = j.Args[JobArgBatchName]
+		if name != "" && name != batch.FullName {
+			continue
+		}
+
+		var env = j.Args[JobArgLocation]
+		if _, seen := loaded[env]; !seen {
+			order = append(order, env)
+		}
+		loaded[env] = models.JobType(j.Type) == models.JobTypeONILoadBatch
+	}
+
+	var list []string
+	for _, env := range order {
+		if loaded[env] {
+			list = append(list, env)
+		}
+	}
+
+	// A QC-ready batch got that way by being loaded to staging, even if its
+	// jobs were from before NCA kept pipelines around
+	if len(list) == 0 && batch.Status == models.BatchStatusQCReady {
+		list = append(list, string(config.ONIRoleStaging))
+	}
+	return list
+}
+
 // QueueSFTPIssueMove queues up an issue move into the workflow area followed
 // by a page-split and then a move to the page review area
 //
@@ -160,7 +233,7 @@ func QueueMoveIssueForDerivatives(issue *models.Issue, workflowPath string) erro
 	jobs = append(jobs, issue.BuildJob(models.JobTypeMakeDerivatives, nil))
 	jobs = append(jobs, issue.BuildJob(models.JobTypePrepIssuePageLabels, nil))
 	jobs = append(jobs, issue.BuildJob(models.JobTypeSetIssueWS, makeWSArgs(schema.WSReadyForMetadataEntry)))
-	jobs = append(jobs, issue.BuildJob(models.JobTypeIssueAction, makeActionArgs("Created issue derivatives")))
+	jobs = append(jobs, issue.BuildJob(models.JobTypeIssueAction, makeActionArgs(models.DerivativesCreatedMessage)))
 
 	return models.QueueIssueJobs(models.PNMoveIssueForDerivatives, issue, jobs...)
 }
@@ -188,16 +261,37 @@ func QueueFinalizeIssue(issue *models.Issue) error {
 
 // QueueMakeBatch sets up the jobs for generating a batch on disk: generating
 // the directories and hard-links, making the batch XML, putting the batch
-// where it can be loaded onto staging, loading it, and generating the bagit
-// manifest. Nothing can happen automatically after all this until the batch is
-// verified on staging.
-func QueueMakeBatch(batch *models.Batch, c *config.Config) error {
-	return models.QueueBatchJobs(models.PNMakeBatch, batch, getJobsForMakeBatch(batch, c)...)
+// where it can be loaded into ONI, loading it into the named environments,
+// and generating the bagit manifest. If no environments are named, the batch
+// is loaded into those with the staging role. Nothing can happen
+// automatically after all this until the batch is verified in ONI.
+func QueueMakeBatch(batch *models.Batch, c *config.Config, envs ...string) error {
+	if len(envs) == 0 {
+		envs = []string{string(config.ONIRoleStaging)}
+	}
+	var jobs, err = getJobsForMakeBatch(batch, envs, c)
+	if err != nil {
+		return err
+	}
+	return models.QueueBatchJobs(models.PNMakeBatch, batch, jobs...)
 }
 
 // getJobsForMakeBatch returns all jobs needed to generate a batch, copy its
-// essential files to the live location, and load it onto staging.
-func getJobsForMakeBatch(batch *models.Batch, c *config.Config) []*models.Job {
+// essential files to the live location, and load it into the named ONI
+// environments. If the batch is a corrected version of a live batch, the old
+// version is purged from those environments first, since ONI can't hold two
+// batches with the same issues.
+func getJobsForMakeBatch(batch *models.Batch, envNames []string, c *config.Config) ([]*models.Job, error) {
+	var envs, err = loadEnvs(envNames, c)
+	if err != nil {
+		return nil, err
+	}
+	var old *models.Batch
+	old, err = batch.Replaces()
+	if err != nil {
+		return nil, fmt.Errorf("looking up batch replaced by %s: %w", batch.FullName, err)
+	}
+
 	// Prepare the various directory vars we'll need
 	var batchname = batch.FullName
 	var wipDir = filepath.Join(c.BatchOutputPath, ".wip-"+batchname)
@@ -223,17 +317,62 @@ func getJobsForMakeBatch(batch *models.Batch, c *config.Config) []*models.Job {
 		batch.BuildJob(models.JobTypeValidateTagManifest, nil),
 	)
 
+	// Before anything goes to staging, we check the batch against NDNP rules
+	// so broken files are caught here instead of during QC
+	jobs = append(jobs, batch.BuildJob(models.JobTypeValidateBatch, nil))
+
 	// Finally, the last jobs copy the essential files to the final path so we
 	// can ingest them into staging
-	jobs = append(jobs, getJobsForCopyDir(outDir, liveDir, "*.tif", "*.tiff", "*.TIF", "*.TIFF", "*.tar.bz", "*.tar")...)
+	jobs = append(jobs, getJobsForCopyDir(outDir, liveDir, liveCopyExclusions...)...)
 	jobs = append(jobs, batch.BuildJob(models.JobTypeBatchAction, makeActionArgs("copied to live path")))
-	jobs = append(jobs, getJobsForONIBatch(batch, models.JobTypeONILoadBatch, serverTypeStaging)...)
-	jobs = append(jobs, batch.BuildJob(models.JobTypeBatchAction, makeActionArgs("ingested on staging")))
+	jobs = append(jobs, getJobsForPurgingReplaced(batch, old, envs)...)
+	jobs = append(jobs, getJobsForONIEnvs(batch, models.JobTypeONILoadBatch, envs, "ingested on %s")...)
 	jobs = append(jobs, batch.BuildJob(models.JobTypeSetBatchStatus, makeBSArgs(models.BatchStatusQCReady)))
 
+	return jobs, nil
+}
+
+// getJobsForPurgingReplaced returns jobs to purge old, the batch's previous
+// version, from the given environments. The jobs belong to the new batch, but
+// name the old one, so the whole process is logged on the batch being built.
+// Batches which aren't corrections (old is nil) get no jobs.
+func getJobsForPurgingReplaced(batch, old *models.Batch, envs []*config.ONIEnvironment) []*models.Job {
+	if old == nil {
+		return nil
+	}
+
+	var jobs []*models.Job
+	for _, env := range envs {
+		var purge = getJobsForONIBatch(batch, models.JobTypeONIPurgeBatch, env.Name)
+		purge[0].Args[JobArgBatchName] = old.FullName
+		jobs = append(jobs, purge...)
+		jobs = append(jobs, batch.BuildJob(models.JobTypeBatchAction, makeActionArgs(fmt.Sprintf("purged %s from %s", old.FullName, env.Name))))
+	}
 	return jobs
 }
 
+// getJobsForSuperseding returns jobs to retire old, the batch's previous
+// version, once the batch is live: the old version's live files are removed,
+// and it's flagged as superseded. Its archive copy is left alone. The live
+// files are only removed once the old version has been flagged as archived,
+// so an error is returned if it hasn't. Batches which aren't corrections (old
+// is nil) get no jobs.
+func getJobsForSuperseding(batch, old *models.Batch, c *config.Config) ([]*models.Job, error) {
+	if old == nil {
+		return nil, nil
+	}
+	if !old.Archived() {
+		return nil, fmt.Errorf("%s cannot replace %s: %s has not been archived", batch.FullName, old.FullName, old.FullName)
+	}
+
+	return []*models.Job{
+		models.NewJob(models.JobTypeKillDir, makeLocArgs(filepath.Join(c.BatchProductionPath, old.FullName))),
+		old.BuildJob(models.JobTypeSetBatchStatus, makeBSArgs(models.BatchStatusSuperseded)),
+		old.BuildJob(models.JobTypeBatchAction, makeActionArgs("superseded by "+batch.FullName)),
+		batch.BuildJob(models.JobTypeBatchAction, makeActionArgs("replaced "+old.FullName)),
+	}, nil
+}
+
 // QueueRemoveErroredIssue builds jobs necessary to take an issue permanently
 // out of NCA's workflow:
 //
@@ -319,7 +458,7 @@ func getJobsForRemoveErroredIssue(issue *models.Issue, erroredIssueRoot string)
 		issue.BuildJob(models.JobTypeIgnoreIssue, nil),
 		issue.BuildJob(models.JobTypeSetIssueLocation, makeLocArgs("")),
 		issue.BuildJob(models.JobTypeSetIssueWS, makeWSArgs(schema.WSUnfixableMetadataError)),
-		issue.BuildJob(models.JobTypeIssueAction, makeActionArgs("Errored issue removed from NCA")),
+		issue.BuildJob(models.JobTypeIssueAction, makeActionArgs(models.ErroredIssueRemovedMessage)),
 	)
 
 	return jobs
@@ -327,8 +466,13 @@ func getJobsForRemoveErroredIssue(issue *models.Issue, erroredIssueRoot string)
 
 // getJobsForFinalizingFlaggedIssues returns the common jobs needed when a batch has
 // issues that were flagged for removal, and the QCer is ready to finalize
-// the batch and handle the flagged issues.
-func getJobsForFinalizingFlaggedIssues(batch *models.Batch, flagged []*models.FlaggedIssue, c *config.Config) []*models.Job {
+// the batch and handle the flagged issues. The batch is purged from the named
+// ONI environments.
+func getJobsForFinalizingFlaggedIssues(batch *models.Batch, flagged []*models.FlaggedIssue, purge []string, c *config.Config) ([]*models.Job, error) {
+	var envs, err = c.ResolveONIEnvironments(purge)
+	if err != nil {
+		return nil, err
+	}
 	var jobs []*models.Job
 
 	// First: jobs to destroy the two batch dirs. The full-batch dir contains
@@ -341,9 +485,8 @@ func getJobsForFinalizingFlaggedIssues(batch *models.Batch, flagged []*models.Fl
 		batch.BuildJob(models.JobTypeSetBatchLocation, makeLocArgs("")),
 	)
 
-	// Next purge the batch from staging
-	jobs = append(jobs, getJobsForONIBatch(batch, models.JobTypeONIPurgeBatch, serverTypeStaging)...)
-	jobs = append(jobs, batch.BuildJob(models.JobTypeBatchAction, makeActionArgs("purged batch from staging")))
+	// Next purge the batch from ONI
+	jobs = append(jobs, getJobsForONIEnvs(batch, models.JobTypeONIPurgeBatch, envs, "purged batch from %s")...)
 
 	// Now we remove issues one at a time so we can easily resume / restart.
 	// Removing an issue means we first remove the METS XML file, and only when
@@ -365,45 +508,212 @@ func getJobsForFinalizingFlaggedIssues(batch *models.Batch, flagged []*models.Fl
 	jobs = append(jobs, batch.BuildJob(models.JobTypeEmptyBatchFlaggedIssuesList, nil))
 	jobs = append(jobs, batch.BuildJob(models.JobTypeBatchAction, makeActionArgs("removed flagged issues from batch")))
 
-	return jobs
+	return jobs, nil
 }
 
 // QueueBatchFinalizeIssueFlagging generates jobs for removing flagged issues
-// from a batch which failed QC, then rebuilding the batch
-func QueueBatchFinalizeIssueFlagging(batch *models.Batch, flagged []*models.FlaggedIssue, c *config.Config) error {
+// from a batch which failed QC, purging it from the environments named in
+// purge, then rebuilding the batch and loading it into those named in load
+func QueueBatchFinalizeIssueFlagging(batch *models.Batch, flagged []*models.FlaggedIssue, purge, load []string, c *config.Config) error {
 	// Grab the common jobs for handling flagged issues, then regenerate the batch
-	var jobs = getJobsForFinalizingFlaggedIssues(batch, flagged, c)
-	jobs = append(jobs, getJobsForMakeBatch(batch, c)...)
+	var jobs, err = getJobsForFinalizingFlaggedIssues(batch, flagged, purge, c)
+	if err != nil {
+		return err
+	}
+	var makeJobs []*models.Job
+	makeJobs, err = getJobsForMakeBatch(batch, load, c)
+	if err != nil {
+		return err
+	}
+	jobs = append(jobs, makeJobs...)
 
 	return models.QueueBatchJobs(models.PNFinalizeIssueFlagging, batch, jobs...)
 }
 
 // QueueBatchForDeletion is used when all issues in a batch need to be
-// rejected, rendering the batch unnecessary (and useless).
-func QueueBatchForDeletion(batch *models.Batch, flagged []*models.FlaggedIssue, c *config.Config) error {
+// rejected, rendering the batch unnecessary (and useless). The batch is
+// purged from the environments named in purge.
+func QueueBatchForDeletion(batch *models.Batch, flagged []*models.FlaggedIssue, purge []string, c *config.Config) error {
 	// Grab the common jobs for handling flagged issues, then destroy the batch
-	var jobs = getJobsForFinalizingFlaggedIssues(batch, flagged, c)
+	var jobs, err = getJobsForFinalizingFlaggedIssues(batch, flagged, purge, c)
+	if err != nil {
+		return err
+	}
 	jobs = append(jobs, batch.BuildJob(models.JobTypeDeleteBatch, nil))
 	jobs = append(jobs, batch.BuildJob(models.JobTypeBatchAction, makeActionArgs("deleted batch")))
 
 	return models.QueueBatchJobs(models.PNBatchDeletion, batch, jobs...)
 }
 
-// QueueBatchGoLive fires off all jobs needed to ingest a batch live and get it
-// ready for archiving.
-func QueueBatchGoLive(batch *models.Batch, c *config.Config) error {
-	var finalPath = filepath.Join(c.BatchArchivePath, batch.FullName)
+// QueueUnbatch takes apart a batch which hasn't been approved so its issues
+// can be batched again. Stuck jobs from earlier pipelines are canceled, the
+// batch is purged from the environments named in purge (normally those in
+// [LoadedONIEnvironments]), its directories are removed, and finally its
+// issues are returned to the batching queue. The batch is in the
+// "unbatching" status until then, so nothing else can be done with it.
+func QueueUnbatch(batch *models.Batch, userID int64, reason string, purge []string, c *config.Config) error {
+	var envs, err = c.ResolveONIEnvironments(purge)
+	if err != nil {
+		return err
+	}
+	return models.QueueUnbatchJobs(batch, func(summary *models.BatchJobSummary) []*models.Job {
+		return getJobsForUnbatch(batch, summary, userID, reason, envs, c)
+	})
+}
+
+// getJobsForUnbatch returns the jobs for taking apart a batch given a summary
+// of its existing jobs
+func getJobsForUnbatch(batch *models.Batch, summary *models.BatchJobSummary, userID int64, reason string, envs []*config.ONIEnvironment, c *config.Config) []*models.Job {
 	var jobs []*models.Job
+	for _, j := range summary.Stuck {
+		jobs = append(jobs, j.BuildJob(models.JobTypeCancelJob, nil))
+	}
+	jobs = append(jobs, getJobsForONIEnvs(batch, models.JobTypeONIPurgeBatch, envs, "purged batch from %s")...)
+
+	// The batch may have been stopped anywhere in its build, so we remove every
+	// directory it could have left behind
+	var wipDir = filepath.Join(c.BatchOutputPath, ".wip-"+batch.FullName)
+	var outDir = filepath.Join(c.BatchOutputPath, batch.FullName)
+	var liveDir = filepath.Join(c.BatchProductionPath, batch.FullName)
+	jobs = append(jobs,
+		models.NewJob(models.JobTypeKillDir, makeLocArgs(liveDir)),
+		models.NewJob(models.JobTypeKillDir, makeLocArgs(outDir)),
+		models.NewJob(models.JobTypeKillDir, makeLocArgs(wipDir)),
+		batch.BuildJob(models.JobTypeSetBatchLocation, makeLocArgs("")),
+	)
+
+	var args = makeIDArgs(userID)
+	args[JobArgMessage] = reason
+	jobs = append(jobs, batch.BuildJob(models.JobTypeUnbatchIssues, args))
+
+	return jobs
+}
+
+// QueueBatchGoLive fires off all jobs needed to ingest a batch into the named
+// ONI environments (usually those with the production role) and get it ready
+// for archiving.
+func QueueBatchGoLive(batch *models.Batch, envNames []string, c *config.Config) error {
+	var envs, err = loadEnvs(envNames, c)
+	if err != nil {
+		return err
+	}
+	var target archive.Target
+	target, err = archive.New(c.ArchiveTarget)
+	if err != nil {
+		return fmt.Errorf("setting up archive target: %w", err)
+	}
+
+	// A corrected batch retires the version it replaces once it's live, which
+	// we check up front so nothing is queued if that version isn't ready
+	var old *models.Batch
+	old, err = batch.Replaces()
+	if err != nil {
+		return fmt.Errorf("looking up batch replaced by %s: %w", batch.FullName, err)
+	}
+	var supersede []*models.Job
+	supersede, err = getJobsForSuperseding(batch, old, c)
+	if err != nil {
+		return err
+	}
+
+	var jobs []*models.Job
+
+	// Nothing should go live without a full check of the bag: the tag manifest
+	// check when the batch was built doesn't look at the payload, and the batch
+	// has sat on disk through QC since then
+	jobs = append(jobs,
+		batch.BuildJob(models.JobTypeValidateBagit, makeFixityArgs(batch.Location, models.FixityCopyOutput)),
+		batch.BuildJob(models.JobTypeBatchAction, makeActionArgs("validated bag before going live")),
+	)
 
-	// First we need jobs to push the batch to production ONI
-	jobs = append(jobs, getJobsForONIBatch(batch, models.JobTypeONILoadBatch, serverTypeProd)...)
-	jobs = append(jobs, batch.BuildJob(models.JobTypeBatchAction, makeActionArgs("ingested on production")))
+	// Next we need jobs to push the batch to ONI. A corrected batch has to
+	// replace its previous version, so the old version is purged first.
+	jobs = append(jobs, getJobsForPurgingReplaced(batch, old, envs)...)
+	jobs = append(jobs, getJobsForONIEnvs(batch, models.JobTypeONILoadBatch, envs, "ingested on %s")...)
 
-	// Then archive-move jobs
-	jobs = append(jobs, getJobsForMoveDir(batch.Location, finalPath)...)
-	jobs = append(jobs, batch.BuildJob(models.JobTypeBatchAction, makeActionArgs("moved batch to archive location")))
+	// Then the batch is archived. S3 uploads and tape staging copies are
+	// verified as they're written, but a filesystem archive isn't, so it gets
+	// a full validation to be sure the archive copy is exactly what we built.
+	jobs = append(jobs,
+		batch.BuildJob(models.JobTypeArchiveBatch, nil),
+		batch.BuildJob(models.JobTypeBatchAction, makeActionArgs("archived batch to "+target.Location(batch.FullName))),
+	)
+	if local, ok := target.(archive.LocalTarget); ok {
+		jobs = append(jobs,
+			batch.BuildJob(models.JobTypeValidateBagit, makeFixityArgs(local.Path(batch.FullName), models.FixityCopyArchive)),
+			batch.BuildJob(models.JobTypeBatchAction, makeActionArgs("validated archived bag")),
+		)
+	}
+	jobs = append(jobs, models.NewJob(models.JobTypeKillDir, makeLocArgs(batch.Location)))
 	jobs = append(jobs, batch.BuildJob(models.JobTypeSetBatchLocation, makeLocArgs("")))
 	jobs = append(jobs, batch.BuildJob(models.JobTypeMarkBatchLive, nil))
 
+	// Once a corrected batch is live, the old version is retired
+	jobs = append(jobs, supersede...)
+
 	return models.QueueBatchJobs(models.PNGoLiveProcess, batch, jobs...)
 }
+
+// QueueTitleSync pushes the title's MARC record to every ONI environment. The
+// title must have its MARC XML stored already.
+func QueueTitleSync(t *models.Title, c *config.Config) error {
+	if t.MARCXML == "" {
+		return fmt.Errorf("title %q has no MARC record", t.LCCN)
+	}
+
+	var errs []error
+	for _, jobs := range getJobsForTitleSync(t, c) {
+		var env = jobs[0].Args[JobArgLocation]
+		var err = models.QueueONISyncJobs(models.PNSyncTitle, models.JobObjectTypeTitle, t.ID, fmt.Sprintf("title %s on %s", t.LCCN, env), env, jobs...)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("queueing sync to %s: %w", env, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// getJobsForTitleSync returns the jobs to load a title in each ONI
+// environment, one list per environment so each can be its own pipeline
+func getJobsForTitleSync(t *models.Title, c *config.Config) [][]*models.Job {
+	var list [][]*models.Job
+	for _, env := range c.ONIEnvironments {
+		var load = t.BuildJob(models.JobTypeONILoadTitle, makeLocArgs(env.Name))
+		var wait = t.BuildJob(models.JobTypeONIWaitForTitle, makeLocArgs(env.Name))
+		models.EntwineJobs([]*models.Job{load, wait})
+		list = append(list, []*models.Job{load, wait})
+	}
+	return list
+}
+
+// QueueMOCSync creates or verifies the MOC's awardee record in every ONI
+// environment
+func QueueMOCSync(moc *models.MOC, c *config.Config) error {
+	var errs []error
+	for _, env := range c.ONIEnvironments {
+		var job = moc.BuildJob(models.JobTypeONIEnsureAwardee, makeLocArgs(env.Name))
+		var err = models.QueueONISyncJobs(models.PNSyncMOC, models.JobObjectTypeMOC, moc.ID, fmt.Sprintf("MOC %s on %s", moc.Code, env.Name), env.Name, job)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("queueing sync to %s: %w", env.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// QueueReconcileLoad loads a batch into a single ONI environment without
+// changing the batch's status, for when ONI is missing a batch NCA expects
+// it to have
+func QueueReconcileLoad(batch *models.Batch, env *config.ONIEnvironment) error {
+	var jobs = getJobsForONIBatch(batch, models.JobTypeONILoadBatch, env.Name)
+	jobs = append(jobs, batch.BuildJob(models.JobTypeBatchAction, makeActionArgs(fmt.Sprintf("reloaded on %s to reconcile ONI with NCA", env.Name))))
+	return models.QueueBatchONIJobs(models.PNReconcileBatch, batch, jobs...)
+}
+
+// QueueReconcilePurge purges a batch from a single ONI environment without
+// changing the batch's status. oniName is the name ONI has for the batch,
+// which may be a different version than NCA's.
+func QueueReconcilePurge(batch *models.Batch, env *config.ONIEnvironment, oniName string) error {
+	var jobs = getJobsForONIBatch(batch, models.JobTypeONIPurgeBatch, env.Name)
+	jobs[0].Args[JobArgBatchName] = oniName
+	jobs = append(jobs, batch.BuildJob(models.JobTypeBatchAction, makeActionArgs(fmt.Sprintf("purged %s from %s to reconcile ONI with NCA", oniName, env.Name))))
+	return models.QueueBatchONIJobs(models.PNReconcileBatch, batch, jobs...)
+}
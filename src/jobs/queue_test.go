@@ -0,0 +1,194 @@
+package jobs
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+)
+
+func TestLoadedONIEnvironments(t *testing.T) {
+	var load = func(env string) *models.Job {
+		return &models.Job{Type: string(models.JobTypeONILoadBatch), Args: map[string]string{JobArgLocation: env}}
+	}
+	var purge = func(env, batchName string) *models.Job {
+		var j = &models.Job{Type: string(models.JobTypeONIPurgeBatch), Args: map[string]string{JobArgLocation: env}}
+		if batchName != "" {
+			j.Args[JobArgBatchName] = batchName
+		}
+		return j
+	}
+
+	var tests = map[string]struct {
+		status string
+		jobs   []*models.Job
+		want   []string
+	}{
+		"never loaded":        {status: models.BatchStatusPending},
+		"old qc-ready batch":  {status: models.BatchStatusQCReady, want: []string{"staging"}},
+		"loaded on two":       {status: models.BatchStatusQCReady, jobs: []*models.Job{load("stage1"), load("preview")}, want: []string{"stage1", "preview"}},
+		"purged from one":     {status: models.BatchStatusPending, jobs: []*models.Job{load("stage1"), load("preview"), purge("stage1", "")}, want: []string{"preview"}},
+		"purged and reloaded": {status: models.BatchStatusPending, jobs: []*models.Job{load("stage1"), purge("stage1", ""), load("stage1")}, want: []string{"stage1"}},
+		"purged everywhere":   {status: models.BatchStatusPending, jobs: []*models.Job{load("stage1"), purge("stage1", "")}},
+		"old version purged":  {status: models.BatchStatusPending, jobs: []*models.Job{purge("stage1", "batch_old_ver01"), load("stage1")}, want: []string{"stage1"}},
+		"this version purged": {status: models.BatchStatusPending, jobs: []*models.Job{load("stage1"), purge("stage1", "batch_foo_ver02")}},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var b = &models.Batch{FullName: "batch_foo_ver02", Status: tc.status}
+			var got = LoadedONIEnvironments(b, tc.jobs)
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestTitleSyncPipelines(t *testing.T) {
+	var c = &config.Config{ONIEnvironments: []*config.ONIEnvironment{
+		{Name: "staging", Role: config.ONIRoleStaging},
+		{Name: "production", Role: config.ONIRoleProduction},
+		{Name: "mirror", Role: config.ONIRoleProduction},
+	}}
+	var title = &models.Title{ID: 1, LCCN: "sn12345678", MARCXML: "<record/>"}
+
+	var lists = getJobsForTitleSync(title, c)
+	if len(lists) != len(c.ONIEnvironments) {
+		t.Fatalf("got %d job lists, want one per environment (%d)", len(lists), len(c.ONIEnvironments))
+	}
+
+	// Each environment's jobs stand alone: if one environment's agent is down
+	// and its jobs fail, nothing ties the other environments' jobs to them
+	var entwineIDs = make(map[int64]string)
+	for i, jobs := range lists {
+		var env = c.ONIEnvironments[i].Name
+		if len(jobs) != 2 {
+			t.Fatalf("%s: got %d jobs, want 2", env, len(jobs))
+		}
+		var load, wait = jobs[0], jobs[1]
+		if models.JobType(load.Type) != models.JobTypeONILoadTitle || models.JobType(wait.Type) != models.JobTypeONIWaitForTitle {
+			t.Errorf("%s: got job types %s, %s", env, load.Type, wait.Type)
+		}
+		for _, j := range jobs {
+			if j.Args[JobArgLocation] != env {
+				t.Errorf("%s: job %s has location %q", env, j.Type, j.Args[JobArgLocation])
+			}
+		}
+		if load.EntwineID == 0 || load.EntwineID != wait.EntwineID {
+			t.Errorf("%s: load and wait jobs aren't entwined", env)
+		}
+		if other, ok := entwineIDs[load.EntwineID]; ok {
+			t.Errorf("%s: jobs are entwined with %s", env, other)
+		}
+		entwineIDs[load.EntwineID] = env
+	}
+}
+
+func TestTitleSyncRequeueDuringWait(t *testing.T) {
+	var c = &config.Config{ONIEnvironments: []*config.ONIEnvironment{{Name: "staging", Role: config.ONIRoleStaging}}}
+	var title = &models.Title{ID: 1, LCCN: "sn12345678", MARCXML: "<record/>"}
+
+	// The first sync's load job has handed its agent job id to the wait job,
+	// and the sync record has the same id
+	var first = getJobsForTitleSync(title, c)[0]
+	first[1].Args[JobArgONIJobID] = "42"
+	var sync = &models.ONISync{Environment: "staging", ONIAgentJobID: 42}
+
+	// The title is saved again, requeueing the sync, and the new load job runs
+	// before the old wait job is done
+	var second = getJobsForTitleSync(title, c)[0]
+	second[1].Args[JobArgONIJobID] = "43"
+	sync.ONIAgentJobID = 43
+
+	if got := agentJobID(first[1], sync); got != 42 {
+		t.Errorf("first wait job: got agent job %d, want 42", got)
+	}
+	if got := agentJobID(second[1], sync); got != 43 {
+		t.Errorf("second wait job: got agent job %d, want 43", got)
+	}
+
+	// Jobs queued before agent job ids were handed off still use the sync
+	// record's id
+	var legacy = title.BuildJob(models.JobTypeONIWaitForTitle, makeLocArgs("staging"))
+	if got := agentJobID(legacy, sync); got != 43 {
+		t.Errorf("legacy wait job: got agent job %d, want 43", got)
+	}
+}
+
+func TestPurgingReplaced(t *testing.T) {
+	var envs = []*config.ONIEnvironment{{Name: "stage1"}, {Name: "stage2"}}
+	var batch = &models.Batch{ID: 2, FullName: "batch_foo_ver02"}
+
+	if jobs := getJobsForPurgingReplaced(batch, nil, envs); len(jobs) != 0 {
+		t.Errorf("Expected no jobs for a batch that isn't a correction, got %d", len(jobs))
+	}
+
+	var old = &models.Batch{ID: 1, FullName: "batch_foo_ver01"}
+	var purged []string
+	for _, j := range getJobsForPurgingReplaced(batch, old, envs) {
+		if j.ObjectID != batch.ID {
+			t.Errorf("Job %s belongs to object %d, not the new batch", j.Type, j.ObjectID)
+		}
+		if models.JobType(j.Type) == models.JobTypeONIPurgeBatch {
+			if j.Args[JobArgBatchName] != old.FullName {
+				t.Errorf("Purge job names batch %q, not %q", j.Args[JobArgBatchName], old.FullName)
+			}
+			purged = append(purged, j.Args[JobArgLocation])
+		}
+	}
+	if !slices.Equal(purged, []string{"stage1", "stage2"}) {
+		t.Errorf("Expected the old version to be purged from every environment, got %v", purged)
+	}
+}
+
+func TestSuperseding(t *testing.T) {
+	var c = &config.Config{BatchProductionPath: "/mnt/production"}
+	var batch = &models.Batch{ID: 2, FullName: "batch_foo_ver02"}
+
+	var jobs, err = getJobsForSuperseding(batch, nil, c)
+	if err != nil || len(jobs) != 0 {
+		t.Errorf("Expected no jobs for a batch that isn't a correction, got %d (%v)", len(jobs), err)
+	}
+
+	var tests = map[string]struct {
+		status  string
+		wantErr bool
+	}{
+		"live":          {status: models.BatchStatusLive, wantErr: true},
+		"live archived": {status: models.BatchStatusLiveArchived},
+		"live done":     {status: models.BatchStatusLiveDone},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var old = &models.Batch{ID: 1, FullName: "batch_foo_ver01", Status: tc.status}
+			var jobs, err = getJobsForSuperseding(batch, old, c)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("Expected an error, got %d jobs", len(jobs))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			var killed, superseded bool
+			for _, j := range jobs {
+				switch models.JobType(j.Type) {
+				case models.JobTypeKillDir:
+					killed = j.Args[JobArgLocation] == "/mnt/production/batch_foo_ver01"
+				case models.JobTypeSetBatchStatus:
+					superseded = j.ObjectID == old.ID && j.Args[JobArgBatchStatus] == models.BatchStatusSuperseded
+				}
+			}
+			if !killed {
+				t.Errorf("Expected the old version's live files to be removed")
+			}
+			if !superseded {
+				t.Errorf("Expected the old version to be flagged as superseded")
+			}
+		})
+	}
+}
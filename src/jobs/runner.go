@@ -12,8 +12,13 @@ import (
 	"github.com/uoregon-libraries/newspaper-curation-app/internal/retry"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/storage"
 )
 
+// storageHoldDelay is how long a pipeline held for lack of space waits before
+// it's checked again
+const storageHoldDelay = 10 * time.Minute
+
 // runnerLogger implements logger.Loggable for logging runner-level information
 type runnerLogger struct {
 	ID      int32
@@ -136,6 +141,9 @@ func (r *Runner) ProcessNextPendingJob() bool {
 	if dbJob == nil {
 		return false
 	}
+	if r.holdForStorage(dbJob) {
+		return true
+	}
 
 	var j = DBJobToProcessor(dbJob)
 	if j == nil {
@@ -145,6 +153,54 @@ func (r *Runner) ProcessNextPendingJob() bool {
 	return true
 }
 
+// holdForStorage checks that a heavy pipeline has room to run before its
+// first job starts. If it doesn't, the job is put back to pending and the
+// hold is recorded. Problems estimating space are logged and the job is
+// allowed to run, since a bad estimate shouldn't stall the whole workflow.
+func (r *Runner) holdForStorage(dbj *models.Job) bool {
+	if dbj.Sequence != 1 {
+		return false
+	}
+	var p, err = dbj.Pipeline()
+	if err != nil || p == nil {
+		r.logger.Errorf("Unable to look up pipeline %d for job %d: %v", dbj.PipelineID, dbj.ID, err)
+		return false
+	}
+	if !storage.Heavy(p.Name) {
+		return false
+	}
+
+	var list []*storage.Shortfall
+	list, err = storage.Check(r.config, p)
+	if err != nil {
+		r.logger.Warnf("Unable to check space for pipeline %d; starting it anyway: %s", p.ID, err)
+		return false
+	}
+	if len(list) == 0 {
+		err = models.ReleaseStorageHold(p.ID)
+		if err != nil {
+			r.logger.Warnf("Unable to clear storage hold for pipeline %d: %s", p.ID, err)
+		}
+		return false
+	}
+
+	var s = list[0]
+	var h = &models.StorageHold{Location: s.Location(), NeededBytes: s.Needed, FreeBytes: s.Available()}
+	err = retry.Do(time.Minute*10, func() error {
+		return models.HoldJobForStorage(dbj, h, storageHoldDelay)
+	})
+	if err != nil {
+		r.logger.Criticalf("Unable to hold job %d for lack of space! Manual intervention required! Error: %s", dbj.ID, err)
+		return true
+	}
+
+	// Only the first hold is worth a warning; after that it's just noise
+	if h.HeldAt.Equal(h.CheckedAt) {
+		r.logger.Warnf("Holding pipeline %d (%s, %s) for lack of space: %s", p.ID, p.Name, p.Description, s)
+	}
+	return true
+}
+
 func (r *Runner) process(pr Processor) {
 	var dbj = pr.DBJob()
 
@@ -0,0 +1,49 @@
+package jobs
+
+import (
+	"fmt"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/internal/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+)
+
+// TitleJob wraps the Job type to add things needed in all jobs tied to
+// specific titles
+type TitleJob struct {
+	*Job
+	DBTitle *models.Title
+}
+
+// NewTitleJob setups up a TitleJob from a database Job, centralizing the
+// common validations and data manipulation
+func NewTitleJob(dbJob *models.Job) *TitleJob {
+	var j, err = newTitleJob(dbJob)
+	if err != nil {
+		logger.Errorf("Unable to create title job %d: %s", dbJob.ID, err)
+	}
+	return j
+}
+
+// newTitleJob actually creates the job and returns it and possibly an error.
+// See newIssueJob for why this is split up this way.
+func newTitleJob(dbJob *models.Job) (j *TitleJob, err error) {
+	j = &TitleJob{Job: NewJob(dbJob)}
+
+	var t *models.Title
+	t, err = models.FindTitleByID(dbJob.ObjectID)
+	if err != nil {
+		return j, err
+	}
+	// Title lookups return an empty title rather than nil when nothing is found
+	if t == nil || t.ID == 0 {
+		return j, fmt.Errorf("title id %d does not exist", dbJob.ObjectID)
+	}
+
+	j.DBTitle = t
+	return j, nil
+}
+
+// Valid returns whether the database title was found successfully
+func (j *TitleJob) Valid() bool {
+	return j.DBTitle != nil
+}
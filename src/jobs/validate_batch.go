@@ -0,0 +1,85 @@
+package jobs
+
+import (
+	"path"
+	"strings"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/batchvalidator"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+)
+
+// ValidateBatch checks a newly built batch against NDNP rules before it's
+// loaded onto staging, attaching the results to the batch
+type ValidateBatch struct {
+	*BatchJob
+}
+
+// Process implements Processor, recording a validation report and stopping
+// the batch's pipeline if it found any problems
+func (j *ValidateBatch) Process(c *config.Config) ProcessResponse {
+	var issues, err = j.DBBatch.Issues()
+	if err != nil {
+		j.Logger.Errorf("Unable to read issues for batch %q: %s", j.DBBatch.FullName, err)
+		return PRFailure
+	}
+
+	var v = &batchvalidator.Validator{
+		Root:          j.DBBatch.Location,
+		Name:          j.DBBatch.FullName,
+		MinDPI:        min(c.DPI, c.ScannedPDFDPI),
+		OPJDecompress: c.OPJDecompress,
+		XMLLint:       c.XMLLint,
+		SchemaPath:    c.BatchSchemaPath,
+	}
+	for _, i := range issues {
+		var de = i.DateEdition()
+		v.Issues = append(v.Issues, batchvalidator.Issue{
+			METSPath: path.Join("data", i.LCCN, "print", de, de+".xml"),
+			Pages:    i.PageCount,
+		})
+	}
+
+	j.Logger.Infof("Validating batch %q at %q", j.DBBatch.FullName, j.DBBatch.Location)
+	var report = &models.BatchValidationReport{BatchID: j.DBBatch.ID, StartedAt: time.Now()}
+	var r = v.Validate()
+	report.FinishedAt = time.Now()
+	report.Issues = r.Issues
+	report.Pages = r.Pages
+	report.Files = r.Files
+	report.SchemaChecked = r.SchemaChecked
+	report.Status = models.ValidationStatusPassed
+	if !r.OK() {
+		report.Status = models.ValidationStatusFailed
+		var lines = make([]string, len(r.Problems))
+		for i, p := range r.Problems {
+			lines[i] = p.String()
+		}
+		report.Details = strings.Join(lines, "\n")
+	}
+
+	err = report.Save()
+	if err != nil {
+		j.Logger.Errorf("Unable to save validation report: %s", err)
+		return PRFailure
+	}
+	err = j.DBBatch.Save(models.ActionTypeInternalProcess, models.SystemUser.ID, "pre-QC validation: "+r.Summary())
+	if err != nil {
+		j.Logger.Errorf("Unable to record validation action: %s", err)
+		return PRFailure
+	}
+
+	if r.OK() {
+		j.Logger.Infof("Batch is valid: %s", r.Summary())
+		return PRSuccess
+	}
+
+	// A broken batch won't fix itself, so we stop here rather than retrying.
+	// The report on the batch's page explains what needs fixing.
+	j.Logger.Errorf("Batch failed validation: %s", r.Summary())
+	for _, p := range r.Problems {
+		j.Logger.Errorf("- %s", p)
+	}
+	return PRFatal
+}
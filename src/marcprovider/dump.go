@@ -0,0 +1,255 @@
+package marcprovider
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/uoregon-libraries/gopkg/xmlnode"
+	"github.com/uoregon-libraries/newspaper-curation-app/internal/logger"
+)
+
+// span is the location of a single record's raw XML within a dump
+type span struct {
+	start, end int64
+}
+
+// dumpIndex maps LCCNs to records in a MARCXML collection file. Records are
+// returned as their raw bytes, wrapped in the dump's own root element, so
+// namespaces and prefixes declared on the root still apply.
+type dumpIndex struct {
+	modTime time.Time
+	size    int64
+	open    []byte // The root element's start tag, e.g., <collection xmlns="...">
+	close   []byte // The root element's end tag
+	records map[string]span
+}
+
+// matches is true if the index was built from the file as it is now
+func (idx *dumpIndex) matches(info os.FileInfo) bool {
+	return info.ModTime().Equal(idx.modTime) && info.Size() == idx.size
+}
+
+// dumpBuild is an index build in progress. done is closed once idx and err
+// are set.
+type dumpBuild struct {
+	done chan struct{}
+	idx  *dumpIndex
+	err  error
+}
+
+// dumpFetcher finds records in a local MARCXML collection. The dump is
+// indexed the first time it's used, and again whenever the file changes.
+type dumpFetcher struct {
+	sync.Mutex
+	path     string
+	index    *dumpIndex
+	building *dumpBuild
+}
+
+func (f *dumpFetcher) fetch(ctx context.Context, lccn string) ([]byte, string, error) {
+	var idx, err = f.currentIndex(ctx)
+	if err != nil {
+		return nil, f.path, err
+	}
+
+	var sp, ok = idx.records[lccn]
+	if !ok {
+		return nil, f.path, ErrNotFound
+	}
+
+	var data []byte
+	data, err = idx.read(f.path, sp)
+	return data, fmt.Sprintf("%s (record at byte %d)", f.path, sp.start), err
+}
+
+// currentIndex returns an index matching the dump as it is now, building one
+// if necessary. Builds run without the lock held, which only guards swapping
+// in the new index, so lookups against a current index never wait on a
+// build. Only one build runs at a time. A build isn't tied to the lookup
+// which started it: if that lookup times out, the build carries on and the
+// next lookup uses its index, so even a dump too big to index within a
+// provider's timeout becomes usable.
+func (f *dumpFetcher) currentIndex(ctx context.Context) (*dumpIndex, error) {
+	var info, err = os.Stat(f.path)
+	if err != nil {
+		return nil, err
+	}
+
+	f.Lock()
+	var idx, b = f.index, f.building
+	if idx != nil && idx.matches(info) {
+		f.Unlock()
+		return idx, nil
+	}
+	if b == nil {
+		b = &dumpBuild{done: make(chan struct{})}
+		f.building = b
+		go f.build(b)
+	}
+	f.Unlock()
+
+	select {
+	case <-b.done:
+		return b.idx, b.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// build indexes the dump, then swaps in the new index
+func (f *dumpFetcher) build(b *dumpBuild) {
+	var start = time.Now()
+	b.idx, b.err = indexDump(f.path)
+	if b.err != nil {
+		b.err = fmt.Errorf("indexing MARC dump: %w", b.err)
+	} else {
+		logger.Infof("Indexed %d MARC record(s) in %q in %s", len(b.idx.records), f.path, time.Since(start))
+	}
+
+	f.Lock()
+	if b.err == nil {
+		f.index = b.idx
+	}
+	f.building = nil
+	f.Unlock()
+	close(b.done)
+}
+
+// read pulls the record at sp from the dump file
+func (idx *dumpIndex) read(path string, sp span) ([]byte, error) {
+	var f, err = os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var rec = make([]byte, sp.end-sp.start)
+	_, err = f.ReadAt(rec, sp.start)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.Write(idx.open)
+	buf.Write(rec)
+	buf.Write(idx.close)
+	return buf.Bytes(), nil
+}
+
+// indexDump reads every record in the dump, noting where each one lives by
+// its LCCN (010 $a, stripped of spaces). Records without an LCCN are skipped.
+func indexDump(path string) (*dumpIndex, error) {
+	var f, err = os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var info os.FileInfo
+	info, err = f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	var idx = &dumpIndex{modTime: info.ModTime(), size: info.Size(), records: make(map[string]span)}
+
+	// The decoder's offsets are relative to the start of the file, which is
+	// all we need to read records directly later
+	var dec = xml.NewDecoder(bufio.NewReader(f))
+	var depth int
+	for {
+		var start = dec.InputOffset()
+		var tok, err = dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if depth == 0 {
+				if t.Name.Local != "collection" {
+					return nil, fmt.Errorf("root element must be <collection>, not <%s>", t.Name.Local)
+				}
+				err = idx.readRoot(f, start, dec.InputOffset())
+				if err != nil {
+					return nil, err
+				}
+				depth++
+				continue
+			}
+
+			if t.Name.Local != "record" {
+				err = dec.Skip()
+				if err != nil {
+					return nil, err
+				}
+				continue
+			}
+
+			var n xmlnode.Node
+			err = dec.DecodeElement(&n, &t)
+			if err != nil {
+				return nil, err
+			}
+			var lccn = nodeLCCN(n)
+			if lccn != "" {
+				idx.records[lccn] = span{start: start, end: dec.InputOffset()}
+			}
+
+		case xml.EndElement:
+			depth--
+		}
+	}
+
+	return idx, nil
+}
+
+// readRoot stores the raw start tag of the dump's root element and builds
+// the matching end tag
+func (idx *dumpIndex) readRoot(f *os.File, start, end int64) error {
+	var raw = make([]byte, end-start)
+	var _, err = f.ReadAt(raw, start)
+	if err != nil {
+		return err
+	}
+
+	idx.open = bytes.TrimSpace(raw)
+	var name = strings.TrimPrefix(string(idx.open), "<")
+	name = strings.FieldsFunc(name, func(r rune) bool { return r == '>' || r == '/' || r <= ' ' })[0]
+	idx.close = []byte("</" + name + ">")
+	return nil
+}
+
+// nodeLCCN returns the record's 010 $a without spaces
+func nodeLCCN(rec xmlnode.Node) string {
+	for _, field := range rec.Nodes {
+		if field.XMLName.Local != "datafield" || attr(field, "tag") != "010" {
+			continue
+		}
+		for _, sub := range field.Nodes {
+			if sub.XMLName.Local == "subfield" && attr(sub, "code") == "a" {
+				return strings.Replace(sub.Content, " ", "", -1)
+			}
+		}
+	}
+	return ""
+}
+
+func attr(n xmlnode.Node, name string) string {
+	for _, a := range n.Attrs {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,180 @@
+package marcprovider
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/uoregon-libraries/gopkg/xmlnode"
+)
+
+func fillTemplate(template, lccn string) string {
+	return strings.Replace(template, "{{lccn}}", lccn, -1)
+}
+
+// ctxReader stops reading once its context is done
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r ctxReader) Read(p []byte) (int, error) {
+	var err = r.ctx.Err()
+	if err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
+
+// readFile reads a whole file, giving up if the context is done first
+func readFile(ctx context.Context, path string) ([]byte, error) {
+	var err = ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	var f *os.File
+	f, err = os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return io.ReadAll(ctxReader{ctx: ctx, r: f})
+}
+
+// fileFetcher reads a local file from a path template
+type fileFetcher string
+
+func (f fileFetcher) fetch(ctx context.Context, lccn string) ([]byte, string, error) {
+	var path = fillTemplate(string(f), lccn)
+	var data, err = readFile(ctx, path)
+	return data, path, err
+}
+
+// dirFetcher reads "<lccn>.xml" or "<lccn>/marc.xml" from a directory
+type dirFetcher string
+
+func (f dirFetcher) fetch(ctx context.Context, lccn string) ([]byte, string, error) {
+	// LCCNs are used as filenames, so we mustn't allow anything which could
+	// point outside the directory
+	if lccn == "" || strings.ContainsAny(lccn, `/\`) || strings.HasPrefix(lccn, ".") {
+		return nil, "", ErrNotFound
+	}
+
+	for _, path := range []string{filepath.Join(string(f), lccn+".xml"), filepath.Join(string(f), lccn, "marc.xml")} {
+		var data, err = readFile(ctx, path)
+		if err != ErrNotFound {
+			return data, path, err
+		}
+	}
+	return nil, "", ErrNotFound
+}
+
+// get requests a URL, treating a 404 as ErrNotFound
+func get(ctx context.Context, u string) ([]byte, error) {
+	var req, err = http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp *http.Response
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("non-200 response for GET %s: %s", u, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// httpFetcher requests a URL from a URL template
+type httpFetcher string
+
+func (f httpFetcher) fetch(ctx context.Context, lccn string) ([]byte, string, error) {
+	var u = fillTemplate(string(f), url.PathEscape(lccn))
+	var data, err = get(ctx, u)
+	return data, u, err
+}
+
+// sruFetcher runs an SRU searchRetrieve query for an LCCN and pulls the first
+// MARCXML record out of the response
+type sruFetcher struct {
+	baseURL string
+	query   string
+}
+
+func (f *sruFetcher) fetch(ctx context.Context, lccn string) ([]byte, string, error) {
+	var q = url.Values{}
+	q.Set("version", "1.2")
+	q.Set("operation", "searchRetrieve")
+	q.Set("query", fillTemplate(f.query, lccn))
+	q.Set("recordSchema", "marcxml")
+	q.Set("recordPacking", "xml")
+	q.Set("maximumRecords", "1")
+
+	var sep = "?"
+	if strings.Contains(f.baseURL, "?") {
+		sep = "&"
+	}
+	var u = f.baseURL + sep + q.Encode()
+
+	var data, err = get(ctx, u)
+	if err != nil {
+		return nil, u, err
+	}
+	data, err = sruRecord(data)
+	return data, u, err
+}
+
+// sruRecord finds the first MARC <record> within a <recordData> element of an
+// SRU response and returns it as a standalone XML document
+func sruRecord(response []byte) ([]byte, error) {
+	var dec = xml.NewDecoder(bytes.NewReader(response))
+	var inData bool
+	for {
+		var tok, err = dec.Token()
+		if err == io.EOF {
+			return nil, ErrNotFound
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parsing SRU response: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if t.Name.Local == "recordData" {
+				inData = true
+				continue
+			}
+			if inData && t.Name.Local == "record" {
+				var n xmlnode.Node
+				err = dec.DecodeElement(&n, &t)
+				if err != nil {
+					return nil, fmt.Errorf("parsing SRU record: %w", err)
+				}
+				return xml.Marshal(n)
+			}
+		case xml.EndElement:
+			if t.Name.Local == "recordData" {
+				inData = false
+			}
+		}
+	}
+}
@@ -0,0 +1,95 @@
+// Package marcprovider looks up titles' MARC records in the sources
+// configured via MARC_PROVIDERS: local files and directories, HTTP URL
+// templates, SRU endpoints, and local MARCXML bulk dumps
+package marcprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+)
+
+// ErrNotFound is returned when a provider has no record for an LCCN
+var ErrNotFound = errors.New("no MARC record found")
+
+// Record is the raw MARC XML a provider returned for an LCCN
+type Record struct {
+	// Provider is the name of the provider which found the record
+	Provider string
+
+	// Source is where, exactly, the provider found the record: a URL, a file
+	// path, etc.
+	Source string
+
+	XML []byte
+}
+
+// fetcher is implemented by each type of provider. Fetchers must give up and
+// return once the context is done.
+type fetcher interface {
+	fetch(ctx context.Context, lccn string) (data []byte, source string, err error)
+}
+
+// Provider is a single configured source of MARC records
+type Provider struct {
+	Name    string
+	Type    config.MARCProviderType
+	Timeout time.Duration
+	f       fetcher
+}
+
+// New returns a Provider for the given configuration
+func New(conf *config.MARCProvider) *Provider {
+	var p = &Provider{Name: conf.Name, Type: conf.Type, Timeout: conf.Timeout}
+	switch conf.Type {
+	case config.MARCProviderFile:
+		p.f = fileFetcher(conf.Location)
+	case config.MARCProviderDir:
+		p.f = dirFetcher(conf.Location)
+	case config.MARCProviderHTTP:
+		p.f = httpFetcher(conf.Location)
+	case config.MARCProviderSRU:
+		p.f = &sruFetcher{baseURL: conf.Location, query: conf.Query}
+	case config.MARCProviderDump:
+		p.f = &dumpFetcher{path: conf.Location}
+	default:
+		// Config validation should make this impossible, but a provider which
+		// never finds anything is better than a crash
+		p.f = badFetcher(conf.Type)
+	}
+	return p
+}
+
+// FromConfig returns a Provider for each configured MARC provider, in order
+func FromConfig(c *config.Config) []*Provider {
+	var list []*Provider
+	for _, conf := range c.MARCProviders {
+		list = append(list, New(conf))
+	}
+	return list
+}
+
+// Fetch asks the provider for the given LCCN's record, giving up after the
+// provider's timeout
+func (p *Provider) Fetch(lccn string) (*Record, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), p.Timeout)
+	defer cancel()
+
+	var data, source, err = p.f.fetch(ctx, lccn)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("timed out after %s", p.Timeout)
+		}
+		return nil, err
+	}
+	return &Record{Provider: p.Name, Source: source, XML: data}, nil
+}
+
+type badFetcher config.MARCProviderType
+
+func (f badFetcher) fetch(context.Context, string) ([]byte, string, error) {
+	return nil, "", fmt.Errorf("unknown provider type %q", string(f))
+}
@@ -0,0 +1,168 @@
+package marcprovider
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/internal/marc"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+)
+
+// record returns a minimal MARCXML record using the given namespace prefix
+// (which may be blank)
+func record(prefix, lccn, title string) string {
+	var p = prefix
+	if p != "" {
+		p += ":"
+	}
+	return fmt.Sprintf(`<%[1]srecord>
+  <%[1]sdatafield tag="010" ind1=" " ind2=" "><%[1]ssubfield code="a">%[2]s</%[1]ssubfield></%[1]sdatafield>
+  <%[1]sdatafield tag="245" ind1="1" ind2="0"><%[1]ssubfield code="a">%[3]s</%[1]ssubfield></%[1]sdatafield>
+</%[1]srecord>`, p, lccn, title)
+}
+
+func write(t *testing.T, path, data string) {
+	var err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err == nil {
+		err = os.WriteFile(path, []byte(data), 0644)
+	}
+	if err != nil {
+		t.Fatalf("Unable to write %q: %s", path, err)
+	}
+}
+
+func TestProviders(t *testing.T) {
+	var dir = t.TempDir()
+	write(t, filepath.Join(dir, "records", "sn1.xml"), record("", "sn1", "Flat file"))
+	write(t, filepath.Join(dir, "records", "sn2", "marc.xml"), record("", "sn2", "Nested file"))
+	write(t, filepath.Join(dir, "dump.xml"), `<?xml version="1.0"?>
+<marc:collection xmlns:marc="http://www.loc.gov/MARC21/slim">
+`+record("marc", "sn 3", "Dumped")+`
+`+record("marc", "sn4", "Also dumped")+`
+</marc:collection>`)
+
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/lccn/sn5/marc.xml":
+			fmt.Fprint(w, record("", "sn5", "Web"))
+		case "/sru":
+			if r.URL.Query().Get("query") != `bath.lccn="sn6"` {
+				fmt.Fprint(w, `<searchRetrieveResponse><numberOfRecords>0</numberOfRecords></searchRetrieveResponse>`)
+				return
+			}
+			fmt.Fprint(w, `<zs:searchRetrieveResponse xmlns:zs="http://www.loc.gov/zing/srw/"><zs:records><zs:record>
+<zs:recordSchema>marcxml</zs:recordSchema><zs:recordData>
+<record xmlns="http://www.loc.gov/MARC21/slim">`+record("", "sn6", "SRU")[len("<record>"):]+`
+</zs:recordData></zs:record></zs:records></zs:searchRetrieveResponse>`)
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	var provider = func(typ config.MARCProviderType, loc string, timeout time.Duration) *Provider {
+		return New(&config.MARCProvider{Name: string(typ), Type: typ, Location: loc, Timeout: timeout, Query: config.DefaultSRUQuery})
+	}
+	var tests = map[string]struct {
+		p         *Provider
+		lccn      string
+		wantTitle string
+		wantErr   error
+	}{
+		"file":          {p: provider(config.MARCProviderFile, filepath.Join(dir, "records", "{{lccn}}.xml"), time.Second), lccn: "sn1", wantTitle: "Flat file"},
+		"dir":           {p: provider(config.MARCProviderDir, filepath.Join(dir, "records"), time.Second), lccn: "sn2", wantTitle: "Nested file"},
+		"dir missing":   {p: provider(config.MARCProviderDir, filepath.Join(dir, "records"), time.Second), lccn: "sn9", wantErr: ErrNotFound},
+		"dir traversal": {p: provider(config.MARCProviderDir, filepath.Join(dir, "records"), time.Second), lccn: "../dump", wantErr: ErrNotFound},
+		"dump":          {p: provider(config.MARCProviderDump, filepath.Join(dir, "dump.xml"), time.Second), lccn: "sn3", wantTitle: "Dumped"},
+		"dump second":   {p: provider(config.MARCProviderDump, filepath.Join(dir, "dump.xml"), time.Second), lccn: "sn4", wantTitle: "Also dumped"},
+		"dump missing":  {p: provider(config.MARCProviderDump, filepath.Join(dir, "dump.xml"), time.Second), lccn: "sn1", wantErr: ErrNotFound},
+		"http":          {p: provider(config.MARCProviderHTTP, srv.URL+"/lccn/{{lccn}}/marc.xml", time.Second), lccn: "sn5", wantTitle: "Web"},
+		"http missing":  {p: provider(config.MARCProviderHTTP, srv.URL+"/lccn/{{lccn}}/marc.xml", time.Second), lccn: "sn9", wantErr: ErrNotFound},
+		"sru":           {p: provider(config.MARCProviderSRU, srv.URL+"/sru", time.Second), lccn: "sn6", wantTitle: "SRU"},
+		"sru missing":   {p: provider(config.MARCProviderSRU, srv.URL+"/sru", time.Second), lccn: "sn9", wantErr: ErrNotFound},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var rec, err = tc.p.Fetch(tc.lccn)
+			if tc.wantErr != nil {
+				if err != tc.wantErr {
+					t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if rec.Provider != tc.p.Name {
+				t.Errorf("Expected provider %q, got %q", tc.p.Name, rec.Provider)
+			}
+
+			var m *marc.MARC
+			m, err = marc.ParseXML(bytes.NewReader(rec.XML))
+			if err != nil {
+				t.Fatalf("Unable to parse record from %q: %s\n%s", rec.Source, err, rec.XML)
+			}
+			if m.Title() != tc.wantTitle {
+				t.Errorf("Expected title %q, got %q", tc.wantTitle, m.Title())
+			}
+		})
+	}
+
+	var slow = provider(config.MARCProviderHTTP, srv.URL+"/slow?{{lccn}}", 50*time.Millisecond)
+	var _, err = slow.Fetch("sn1")
+	if err == nil || err == ErrNotFound {
+		t.Errorf("Expected a timeout from a slow provider, got %v", err)
+	}
+}
+
+func TestFetchersHonorContext(t *testing.T) {
+	var dir = t.TempDir()
+	write(t, filepath.Join(dir, "sn1.xml"), record("", "sn1", "Flat file"))
+	write(t, filepath.Join(dir, "dump.xml"), "<collection>"+record("", "sn1", "Dumped")+"</collection>")
+
+	var ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+
+	var tests = map[string]fetcher{
+		"file": fileFetcher(filepath.Join(dir, "{{lccn}}.xml")),
+		"dir":  dirFetcher(dir),
+	}
+	for name, f := range tests {
+		t.Run(name, func(t *testing.T) {
+			var _, _, err = f.fetch(ctx, "sn1")
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("got error %v, want %v", err, context.Canceled)
+			}
+		})
+	}
+
+	// A dump lookup which gives up doesn't stop the index build it started, and
+	// lookups running alongside it share that build
+	var f = &dumpFetcher{path: filepath.Join(dir, "dump.xml")}
+	f.fetch(ctx, "sn1")
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var data, _, err = f.fetch(context.Background(), "sn1")
+			if err != nil || !bytes.Contains(data, []byte("Dumped")) {
+				t.Errorf("got %q, %v; want the dumped record", data, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
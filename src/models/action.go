@@ -36,8 +36,19 @@ const (
 	ActionTypeUndoBatch            ActionType = "undo-batch"
 	ActionTypeAbortBatchRejection  ActionType = "abort-reject-batch"
 	ActionTypeFlagBatchQCReady     ActionType = "flag-batch-qc-ready"
+	ActionTypeAnnotation           ActionType = "metadata-annotation"
+	ActionTypeResolveAnnotation    ActionType = "resolve-annotation"
+	ActionTypeAutoAssign           ActionType = "auto-assign-issue"
+	ActionTypeCorrectBatch         ActionType = "correct-batch"
+	ActionTypeAbandonCorrection    ActionType = "abandon-correction"
+	ActionTypeRetentionDelete      ActionType = "retention-delete"
+	ActionTypeUnbatch              ActionType = "unbatch"
 )
 
+// ErroredIssueRemovedMessage is the message of the action recorded when an
+// errored issue has been moved out of NCA's workflow
+const ErroredIssueRemovedMessage = "Errored issue removed from NCA"
+
 // Describe gives a human-readable explanation of what happened when a given
 // action type was applied
 func (at ActionType) Describe() string {
@@ -76,6 +87,20 @@ func (at ActionType) Describe() string {
 		return "Removed the batch, moving all issues back to NCA"
 	case ActionTypeFlagBatchQCReady:
 		return "flagged the batch as being ready for QC"
+	case ActionTypeAnnotation:
+		return "annotated the issue"
+	case ActionTypeResolveAnnotation:
+		return "resolved a reviewer annotation"
+	case ActionTypeAutoAssign:
+		return "assigned the issue"
+	case ActionTypeCorrectBatch:
+		return "started a correction of the live batch"
+	case ActionTypeAbandonCorrection:
+		return "abandoned a correction of the live batch"
+	case ActionTypeRetentionDelete:
+		return "deleted files past their retention period"
+	case ActionTypeUnbatch:
+		return "unbatched the batch, returning its issues to the batching queue"
 	default:
 		return string(at)
 	}
@@ -132,6 +157,17 @@ func findActionsByObjectTypeAndID(oType string, oID int64) ([]*Action, error) {
 	return list, op.Err()
 }
 
+// findAction returns the action with the given id, or nil if none exists
+func findAction(id int64) (*Action, error) {
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	var a = &Action{}
+	if !op.Select("actions", &Action{}).Where("id = ?", id).First(a) {
+		return nil, op.Err()
+	}
+	return a, op.Err()
+}
+
 // FindActionsForIssue returns all actions for the given issue id sorted
 // oldest first
 func FindActionsForIssue(issueID int64) ([]*Action, error) {
@@ -160,10 +196,11 @@ func (a *Action) Save() error {
 
 // important returns true for actions that really need to be seen, based on
 // their type.  This allows us to exclude things we want to capture, but not
-// spam at the curators / reviewers.
+// spam at the curators / reviewers.  Annotations are excluded since they're
+// displayed separately, alongside the page or field they refer to.
 func (a *Action) important() bool {
 	switch ActionType(a.ActionType) {
-	case ActionTypeInternalProcess, ActionTypeClaim, ActionTypeUnclaim:
+	case ActionTypeInternalProcess, ActionTypeClaim, ActionTypeUnclaim, ActionTypeAutoAssign, ActionTypeAnnotation:
 		return false
 	}
 
@@ -0,0 +1,228 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Nerdmaster/magicsql"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/dbi"
+)
+
+// AnnotationField describes a single piece of issue metadata a reviewer can
+// attach an annotation to
+type AnnotationField struct {
+	Name  string // Machine-friendly name, matching the metadata form's field id
+	Label string // Human-friendly name for display
+}
+
+// AnnotationFields is the full list of issue metadata fields which can be
+// annotated, in the order they appear on the metadata entry form
+var AnnotationFields = []AnnotationField{
+	{"page_label", "Page label"},
+	{"date_as_labeled", "Issue date as labeled"},
+	{"date", "Issue date"},
+	{"volume_number", "Volume number"},
+	{"issue_number", "Issue number"},
+	{"edition_number", "Edition number"},
+	{"edition_label", "Edition label"},
+}
+
+// findAnnotationField returns the field definition for the given name, or nil
+// if the name isn't valid
+func findAnnotationField(name string) *AnnotationField {
+	for _, f := range AnnotationFields {
+		if f.Name == name {
+			return &f
+		}
+	}
+	return nil
+}
+
+// An Annotation is a structured reviewer comment attached to a specific page
+// and/or metadata field of an issue. The comment's text, author, and creation
+// date live on the Action tied to the annotation, so annotations show up in an
+// issue's history just like any other action.
+type Annotation struct {
+	ID               int64 `sql:",primary"`
+	ActionID         int64
+	IssueID          int64
+	PageNumber       int    // One-based page number, or zero if not tied to a page
+	FieldName        string // Metadata field name, or empty if not tied to a field
+	ResolvedAt       time.Time
+	ResolvedByUserID int64
+
+	action       *Action
+	resolvedUser *User
+}
+
+// NewAnnotation returns an unsaved annotation for the given issue. The
+// message is stored on the annotation's action when it's saved.
+func NewAnnotation(issueID int64, page int, field, message string) *Annotation {
+	var a = &Annotation{IssueID: issueID, PageNumber: page, FieldName: field}
+	a.action = NewIssueAction(issueID, ActionTypeAnnotation)
+	a.action.Message = message
+	return a
+}
+
+// Validate returns an error if the annotation has no message, isn't tied to
+// anything useful, or refers to a field that doesn't exist
+func (a *Annotation) Validate() error {
+	if a.Message() == "" {
+		return fmt.Errorf("annotation message cannot be blank")
+	}
+	if a.PageNumber < 0 {
+		return fmt.Errorf("invalid page number %d", a.PageNumber)
+	}
+	if a.FieldName != "" && findAnnotationField(a.FieldName) == nil {
+		return fmt.Errorf("invalid field %q", a.FieldName)
+	}
+	if a.PageNumber == 0 && a.FieldName == "" {
+		return fmt.Errorf("annotation must refer to a page or a metadata field")
+	}
+	return nil
+}
+
+func findAnnotations(where string, args ...any) ([]*Annotation, error) {
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	var list []*Annotation
+	op.Select("issue_annotations", &Annotation{}).Where(where, args...).Order("page_number, id").AllObjects(&list)
+	return list, op.Err()
+}
+
+// FindAnnotation returns the annotation with the given id, or nil if none exists
+func FindAnnotation(id int64) (*Annotation, error) {
+	var list, err = findAnnotations("id = ?", id)
+	if len(list) == 0 {
+		return nil, err
+	}
+	return list[0], err
+}
+
+// FindAnnotationsForIssue returns all annotations, resolved or not, tied to
+// the given issue, ordered by page number
+func FindAnnotationsForIssue(issueID int64) ([]*Annotation, error) {
+	return findAnnotations("issue_id = ?", issueID)
+}
+
+// Action returns the action holding this annotation's message and author
+func (a *Annotation) Action() *Action {
+	if a.action == nil {
+		// Like issues' actions, we ignore errors here; an annotation without its
+		// action is just an annotation with an empty message
+		a.action, _ = findAction(a.ActionID)
+		if a.action == nil {
+			a.action = newAction()
+		}
+	}
+	return a.action
+}
+
+// Message returns the reviewer's comment
+func (a *Annotation) Message() string {
+	return a.Action().Message
+}
+
+// Author returns the reviewer who wrote the annotation
+func (a *Annotation) Author() *User {
+	return a.Action().Author()
+}
+
+// CreatedAt returns when the annotation was written
+func (a *Annotation) CreatedAt() time.Time {
+	return a.Action().CreatedAt
+}
+
+// FieldLabel returns the human-friendly name of the annotated field, if any
+func (a *Annotation) FieldLabel() string {
+	var f = findAnnotationField(a.FieldName)
+	if f == nil {
+		return ""
+	}
+	return f.Label
+}
+
+// Location returns a human-friendly description of what was annotated, e.g.,
+// "Page 7, Page label"
+func (a *Annotation) Location() string {
+	switch {
+	case a.PageNumber > 0 && a.FieldName != "":
+		return fmt.Sprintf("Page %d, %s", a.PageNumber, a.FieldLabel())
+	case a.PageNumber > 0:
+		return fmt.Sprintf("Page %d", a.PageNumber)
+	default:
+		return a.FieldLabel()
+	}
+}
+
+// Resolved returns true if somebody has marked this annotation as resolved
+func (a *Annotation) Resolved() bool {
+	return !a.ResolvedAt.IsZero()
+}
+
+// ResolvedBy returns the user who resolved the annotation
+func (a *Annotation) ResolvedBy() *User {
+	if a.resolvedUser == nil {
+		a.resolvedUser = FindUserByID(a.ResolvedByUserID)
+	}
+	return a.resolvedUser
+}
+
+// Resolve flags the annotation as resolved and records an action on the
+// issue so the resolution is visible in the issue's history
+func (a *Annotation) Resolve(userID int64) error {
+	if a.Resolved() {
+		return fmt.Errorf("annotation %d is already resolved", a.ID)
+	}
+
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	op.BeginTransaction()
+	defer op.EndTransaction()
+
+	a.ResolvedAt = time.Now()
+	a.ResolvedByUserID = userID
+	a.resolvedUser = nil
+
+	var act = NewIssueAction(a.IssueID, ActionTypeResolveAnnotation)
+	act.UserID = userID
+	act.Message = fmt.Sprintf("%s: %s", a.Location(), a.Message())
+	_ = act.SaveOp(op)
+	return a.saveOp(op)
+}
+
+// createOp saves a new annotation along with the action holding its message
+func (a *Annotation) createOp(op *magicsql.Operation, userID int64) error {
+	var act = a.Action()
+	act.ObjectID = a.IssueID
+	act.UserID = userID
+	_ = act.SaveOp(op)
+
+	a.ActionID = act.ID
+	return a.saveOp(op)
+}
+
+func (a *Annotation) saveOp(op *magicsql.Operation) error {
+	op.Save("issue_annotations", a)
+	return op.Err()
+}
+
+// rejectionMessage returns the reviewer's notes with a summary of their
+// annotations appended, so the rejection action says what was wrong even
+// when the reviewer only left annotations
+func rejectionMessage(notes string, annotations []*Annotation) string {
+	if len(annotations) == 0 {
+		return notes
+	}
+
+	var lines = make([]string, len(annotations))
+	for i, a := range annotations {
+		lines[i] = "- " + a.Location() + ": " + a.Message()
+	}
+	var summary = fmt.Sprintf("%d annotation(s):\n%s", len(annotations), strings.Join(lines, "\n"))
+	if notes == "" {
+		return summary
+	}
+	return notes + "\n\n" + summary
+}
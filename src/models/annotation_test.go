@@ -0,0 +1,84 @@
+package models
+
+import (
+	"testing"
+)
+
+func TestAnnotationValidate(t *testing.T) {
+	var tests = map[string]struct {
+		page    int
+		field   string
+		message string
+		valid   bool
+	}{
+		"page only":        {page: 3, message: "Label is wrong", valid: true},
+		"field only":       {field: "volume_number", message: "Should be 12", valid: true},
+		"page and field":   {page: 1, field: "page_label", message: "Label is 'A1'", valid: true},
+		"no message":       {page: 1, field: "page_label"},
+		"no location":      {message: "Something is wrong"},
+		"bad field":        {field: "lccn", message: "Wrong title"},
+		"negative page":    {page: -1, message: "Wrong page"},
+		"negative w/field": {page: -2, field: "date", message: "Wrong date"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var err = NewAnnotation(1, tc.page, tc.field, tc.message).Validate()
+			if tc.valid && err != nil {
+				t.Errorf("Expected annotation to be valid, got %s", err)
+			}
+			if !tc.valid && err == nil {
+				t.Errorf("Expected annotation to be invalid")
+			}
+		})
+	}
+}
+
+func TestAnnotationLocation(t *testing.T) {
+	var tests = map[string]struct {
+		page     int
+		field    string
+		expected string
+	}{
+		"page only":      {page: 7, expected: "Page 7"},
+		"field only":     {field: "date_as_labeled", expected: "Issue date as labeled"},
+		"page and field": {page: 2, field: "page_label", expected: "Page 2, Page label"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got = NewAnnotation(1, tc.page, tc.field, "x").Location()
+			if got != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestRejectionMessage(t *testing.T) {
+	var annotations = []*Annotation{
+		NewAnnotation(1, 2, "page_label", "should be 3"),
+		NewAnnotation(1, 0, "date", "wrong year"),
+	}
+	var summary = "2 annotation(s):\n- Page 2, Page label: should be 3\n- Issue date: wrong year"
+
+	var tests = map[string]struct {
+		notes       string
+		annotations []*Annotation
+		expected    string
+	}{
+		"notes only":       {notes: "bad", expected: "bad"},
+		"annotations only": {annotations: annotations, expected: summary},
+		"both":             {notes: "bad", annotations: annotations, expected: "bad\n\n" + summary},
+		"neither":          {},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got = rejectionMessage(tc.notes, tc.annotations)
+			if got != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
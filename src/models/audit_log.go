@@ -36,31 +36,47 @@ const (
 	AuditActionSaveDraft
 	AuditActionSaveQueue
 	AuditActionUploadMARC
+	AuditActionResolveAnnotation
+	AuditActionPublisherUpload
+	AuditActionResyncTitle
+	AuditActionReconcileBatch
+	AuditActionSFTPSetStatus
+	AuditActionSFTPResetPassword
+	AuditActionSFTPAddKey
+	AuditActionSFTPRemoveKey
 
 	AuditActionOverflow
 )
 
 var dbAuditActions = map[AuditAction]string{
-	AuditActionQueue:            "queue",
-	AuditActionSaveTitle:        "save-title",
-	AuditActionValidateTitle:    "validate-title",
-	AuditActionCreateMoc:        "create-moc",
-	AuditActionUpdateMoc:        "update-moc",
-	AuditActionDeleteMoc:        "delete-moc",
-	AuditActionSaveUser:         "save-user",
-	AuditActionDeactivateUser:   "deactivate-user",
-	AuditActionClaim:            "claim",
-	AuditActionUnclaim:          "unclaim",
-	AuditActionApproveMetadata:  "approve-metadata",
-	AuditActionRejectMetadata:   "reject-metadata",
-	AuditActionReportError:      "report-error",
-	AuditActionUndoErrorIssue:   "undo-error-issue",
-	AuditActionRemoveErrorIssue: "remove-error-issue",
-	AuditActionQueueForReview:   "queue-for-review",
-	AuditActionAutosave:         "autosave",
-	AuditActionSaveDraft:        "savedraft",
-	AuditActionSaveQueue:        "savequeue",
-	AuditActionUploadMARC:       "upload-marc",
+	AuditActionQueue:             "queue",
+	AuditActionSaveTitle:         "save-title",
+	AuditActionValidateTitle:     "validate-title",
+	AuditActionCreateMoc:         "create-moc",
+	AuditActionUpdateMoc:         "update-moc",
+	AuditActionDeleteMoc:         "delete-moc",
+	AuditActionSaveUser:          "save-user",
+	AuditActionDeactivateUser:    "deactivate-user",
+	AuditActionClaim:             "claim",
+	AuditActionUnclaim:           "unclaim",
+	AuditActionApproveMetadata:   "approve-metadata",
+	AuditActionRejectMetadata:    "reject-metadata",
+	AuditActionReportError:       "report-error",
+	AuditActionUndoErrorIssue:    "undo-error-issue",
+	AuditActionRemoveErrorIssue:  "remove-error-issue",
+	AuditActionQueueForReview:    "queue-for-review",
+	AuditActionAutosave:          "autosave",
+	AuditActionSaveDraft:         "savedraft",
+	AuditActionSaveQueue:         "savequeue",
+	AuditActionUploadMARC:        "upload-marc",
+	AuditActionResolveAnnotation: "resolve-annotation",
+	AuditActionPublisherUpload:   "publisher-upload",
+	AuditActionResyncTitle:       "resync-title",
+	AuditActionReconcileBatch:    "reconcile-batch",
+	AuditActionSFTPSetStatus:     "sftp-set-status",
+	AuditActionSFTPResetPassword: "sftp-reset-password",
+	AuditActionSFTPAddKey:        "sftp-add-key",
+	AuditActionSFTPRemoveKey:     "sftp-remove-key",
 }
 
 // String returns the human-readable value for an action
@@ -69,26 +85,34 @@ func (a AuditAction) String() string {
 }
 
 var auditActionLookup = map[string]AuditAction{
-	"queue":              AuditActionQueue,
-	"save-title":         AuditActionSaveTitle,
-	"validate-title":     AuditActionValidateTitle,
-	"create-moc":         AuditActionCreateMoc,
-	"update-moc":         AuditActionUpdateMoc,
-	"delete-moc":         AuditActionDeleteMoc,
-	"save-user":          AuditActionSaveUser,
-	"deactivate-user":    AuditActionDeactivateUser,
-	"claim":              AuditActionClaim,
-	"unclaim":            AuditActionUnclaim,
-	"approve-metadata":   AuditActionApproveMetadata,
-	"reject-metadata":    AuditActionRejectMetadata,
-	"report-error":       AuditActionReportError,
-	"undo-error-issue":   AuditActionUndoErrorIssue,
-	"remove-error-issue": AuditActionRemoveErrorIssue,
-	"queue-for-review":   AuditActionQueueForReview,
-	"autosave":           AuditActionAutosave,
-	"savedraft":          AuditActionSaveDraft,
-	"savequeue":          AuditActionSaveQueue,
-	"upload-marc":        AuditActionUploadMARC,
+	"queue":               AuditActionQueue,
+	"save-title":          AuditActionSaveTitle,
+	"validate-title":      AuditActionValidateTitle,
+	"create-moc":          AuditActionCreateMoc,
+	"update-moc":          AuditActionUpdateMoc,
+	"delete-moc":          AuditActionDeleteMoc,
+	"save-user":           AuditActionSaveUser,
+	"deactivate-user":     AuditActionDeactivateUser,
+	"claim":               AuditActionClaim,
+	"unclaim":             AuditActionUnclaim,
+	"approve-metadata":    AuditActionApproveMetadata,
+	"reject-metadata":     AuditActionRejectMetadata,
+	"report-error":        AuditActionReportError,
+	"undo-error-issue":    AuditActionUndoErrorIssue,
+	"remove-error-issue":  AuditActionRemoveErrorIssue,
+	"queue-for-review":    AuditActionQueueForReview,
+	"autosave":            AuditActionAutosave,
+	"savedraft":           AuditActionSaveDraft,
+	"savequeue":           AuditActionSaveQueue,
+	"upload-marc":         AuditActionUploadMARC,
+	"resolve-annotation":  AuditActionResolveAnnotation,
+	"publisher-upload":    AuditActionPublisherUpload,
+	"resync-title":        AuditActionResyncTitle,
+	"reconcile-batch":     AuditActionReconcileBatch,
+	"sftp-set-status":     AuditActionSFTPSetStatus,
+	"sftp-reset-password": AuditActionSFTPResetPassword,
+	"sftp-add-key":        AuditActionSFTPAddKey,
+	"sftp-remove-key":     AuditActionSFTPRemoveKey,
 }
 
 // AuditActionFromString returns the action int for the given string, if the
@@ -20,6 +20,9 @@ const (
 	BatchStatusLive         = "live"          // Batch has gone live; batch and its issues need to be archived
 	BatchStatusLiveArchived = "live_archived" // Batch is archived; its issues can be cleaned up in a few weeks
 	BatchStatusLiveDone     = "live_done"     // Batch has gone live; batch and its issues have been archived and are no longer on the filesystem
+	BatchStatusCorrecting   = "correcting"    // New version of a live batch; its issues are being corrected before it's rebuilt
+	BatchStatusSuperseded   = "superseded"    // Batch was live, but a corrected version has replaced it
+	BatchStatusUnbatching   = "unbatching"    // Batch is being taken apart; its issues will return to the batching queue
 )
 
 // BatchStatus describes the metadata corresponding to a database status
@@ -83,6 +86,27 @@ var statusMap = map[string]BatchStatus{
 		NeedsAction: false,
 		Description: "Live in production and archived: no longer available in NCA workflow",
 	},
+	BatchStatusCorrecting: {
+		Status:      BatchStatusCorrecting,
+		Live:        false,
+		Staging:     false,
+		NeedsAction: true,
+		Description: "Correcting a live batch: awaiting issue fixes before the new version is built",
+	},
+	BatchStatusSuperseded: {
+		Status:      BatchStatusSuperseded,
+		Live:        false,
+		Staging:     false,
+		NeedsAction: false,
+		Description: "Replaced in production by a corrected version",
+	},
+	BatchStatusUnbatching: {
+		Status:      BatchStatusUnbatching,
+		Live:        false,
+		Staging:     false,
+		NeedsAction: false,
+		Description: "Being unbatched: its issues will return to the batching queue",
+	},
 }
 
 // Batch contains metadata for generating a batch XML.  Issues can be
@@ -102,6 +126,15 @@ type Batch struct {
 	Location      string
 	ONIAgentJobID int64
 
+	// ReplacesBatchID is the live batch this batch is a corrected version of,
+	// if any
+	ReplacesBatchID int64
+
+	// QCRound counts how many times the batch has been rebuilt after failing
+	// QC so checklist results and findings from earlier rounds aren't mixed
+	// with the current review
+	QCRound int
+
 	issues  []*Issue
 	actions []*Action
 }
@@ -161,6 +194,13 @@ func AllBatches() ([]*Batch, error) {
 	return findBatches("status <> ?", BatchStatusDeleted)
 }
 
+// AllBatchesIncludingDeleted returns every batch in the database. This is
+// only useful when comparing NCA's records to external systems, where a
+// deleted batch may still be lingering.
+func AllBatchesIncludingDeleted() ([]*Batch, error) {
+	return findBatches("1 = 1")
+}
+
 // FindLiveArchivedBatches returns all batches that are live and archived
 func FindLiveArchivedBatches() ([]*Batch, error) {
 	return findBatches("status = ?", BatchStatusLiveArchived)
@@ -220,7 +260,9 @@ func (b *Batch) BuildJob(t JobType, args map[string]string) *Job {
 	return j
 }
 
-// Issues pulls all issues from the database which have this batch's ID
+// Issues pulls all issues from the database which belong to this batch. For a
+// batch which has gone live, that's the issues it went live with, even if
+// they've since moved to a corrected version.
 func (b *Batch) Issues() ([]*Issue, error) {
 	if len(b.issues) > 0 {
 		return b.issues, nil
@@ -230,9 +272,11 @@ func (b *Batch) Issues() ([]*Issue, error) {
 		return b.issues, nil
 	}
 
+	// Once a batch has gone live, its issues can move on to a corrected
+	// version, so we look up the issues it went live with instead
 	var finder = Issues().BatchID(b.ID)
-	if bs(b.Status).Live {
-		finder = finder.AllowIgnored()
+	if bs(b.Status).Live || b.Status == BatchStatusSuperseded {
+		finder = Issues().WentLiveIn(b.ID).AllowIgnored()
 	}
 	var issues, err = finder.Fetch()
 	b.issues = issues
@@ -384,7 +428,8 @@ func (b *Batch) SetLive() error {
 	b.Status = BatchStatusLive
 	b.WentLiveAt = time.Now()
 	_ = b.SaveOpWithoutAction(op)
-	op.Exec(`UPDATE issues SET ignored=1, workflow_step = ? WHERE batch_id = ?`, schema.WSInProduction, b.ID)
+	op.Exec(`UPDATE issues SET ignored=1, workflow_step = ?, workflow_step_changed_at = ? WHERE batch_id = ?`, schema.WSInProduction, time.Now(), b.ID)
+	op.Exec(`INSERT IGNORE INTO batch_issues (batch_id, issue_id) SELECT ?, id FROM issues WHERE batch_id = ?`, b.ID, b.ID)
 
 	return op.Err()
 }
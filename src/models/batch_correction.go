@@ -0,0 +1,215 @@
+package models
+
+import (
+	"fmt"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/dbi"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/schema"
+)
+
+// Correctable returns true if the batch's status allows building a corrected
+// version: it must be live, and its issues' files must still be in NCA
+func (b *Batch) Correctable() bool {
+	return b.Status == BatchStatusLive || b.Status == BatchStatusLiveArchived
+}
+
+// Replaces returns the batch this batch is a corrected version of, or nil if
+// it isn't a correction
+func (b *Batch) Replaces() (*Batch, error) {
+	if b.ReplacesBatchID == 0 {
+		return nil, nil
+	}
+	return FindBatch(b.ReplacesBatchID)
+}
+
+// Archived returns true if the batch is live and has been flagged as archived
+func (b *Batch) Archived() bool {
+	return b.Status == BatchStatusLiveArchived || b.Status == BatchStatusLiveDone
+}
+
+// Correction returns the corrected version of this batch, whether it's still
+// being worked on or has already replaced this batch. Nil is returned if the
+// batch hasn't been corrected.
+func (b *Batch) Correction() (*Batch, error) {
+	var list, err = findBatches("replaces_batch_id = ? AND status <> ?", b.ID, BatchStatusDeleted)
+	if len(list) == 0 {
+		return nil, err
+	}
+	return list[len(list)-1], err
+}
+
+// StartCorrection creates the next version of a live batch and moves all the
+// live batch's issues to it. Issues listed in fixIDs go back to metadata
+// entry; the rest are ready for batching as-is. The new batch waits in the
+// "correcting" status until all its issues are ready to be rebuilt, while the
+// live batch stays live until the new version replaces it. The live batch
+// still knows which issues it went live with, so its page and provenance
+// don't change.
+func (b *Batch) StartCorrection(userID int64, reason string, fixIDs []int64) (*Batch, error) {
+	if !b.Correctable() {
+		return nil, fmt.Errorf("batch %s cannot be corrected: status is %q", b.FullName, b.Status)
+	}
+
+	var existing, err = b.Correction()
+	if err != nil {
+		return nil, fmt.Errorf("looking for existing correction of %s: %w", b.FullName, err)
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("batch %s is already corrected by %s", b.FullName, existing.FullName)
+	}
+
+	var issues []*Issue
+	issues, err = b.Issues()
+	if err != nil {
+		return nil, fmt.Errorf("reading issues for %s: %w", b.FullName, err)
+	}
+	var fix map[int64]bool
+	fix, err = correctionFixes(b, issues, fixIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	op.BeginTransaction()
+	defer op.EndTransaction()
+
+	var nb = b.nextVersion()
+	err = nb.SaveOpWithoutAction(op)
+	if err != nil {
+		return nil, err
+	}
+	_ = nb.SaveOp(op, ActionTypeCorrectBatch, userID, fmt.Sprintf("correcting %s: %s", b.FullName, reason))
+	_ = b.SaveOp(op, ActionTypeCorrectBatch, userID, fmt.Sprintf("correcting as %s: %s", nb.FullName, reason))
+
+	for _, i := range issues {
+		i.moveToCorrection(nb.ID, fix[i.ID])
+		if !fix[i.ID] {
+			_ = i.SaveOp(op, ActionTypeInternalProcess, SystemUser.ID, fmt.Sprintf("added to corrected batch %s", nb.FullName))
+			continue
+		}
+		_ = i.SaveOp(op, ActionTypeReturnCurate, userID, fmt.Sprintf("pulled from live batch %s for correction: %s", b.FullName, reason))
+	}
+
+	return nb, op.Err()
+}
+
+// correctionFixes verifies the issues chosen for correction are part of the
+// batch, returning a lookup of their ids
+func correctionFixes(b *Batch, issues []*Issue, fixIDs []int64) (map[int64]bool, error) {
+	if len(fixIDs) == 0 {
+		return nil, fmt.Errorf("batch %s cannot be corrected: no issues chosen for correction", b.FullName)
+	}
+
+	var inBatch = make(map[int64]bool)
+	for _, i := range issues {
+		inBatch[i.ID] = true
+	}
+	var fix = make(map[int64]bool)
+	for _, id := range fixIDs {
+		if !inBatch[id] {
+			return nil, fmt.Errorf("issue %d is not part of batch %s", id, b.FullName)
+		}
+		fix[id] = true
+	}
+	return fix, nil
+}
+
+// nextVersion returns an unsaved batch for correcting b. The new version
+// keeps the original's name and creation date so only the version number
+// differs in its full name.
+func (b *Batch) nextVersion() *Batch {
+	var nb = &Batch{
+		MARCOrgCode:     b.MARCOrgCode,
+		Name:            b.Name,
+		CreatedAt:       b.CreatedAt,
+		Version:         b.Version + 1,
+		Status:          BatchStatusCorrecting,
+		ReplacesBatchID: b.ID,
+	}
+	nb.GenerateFullName()
+	return nb
+}
+
+// moveToCorrection puts a live issue into a corrected version of its batch.
+// Issues being fixed go back to metadata entry; the rest are ready for
+// batching as-is.
+func (i *Issue) moveToCorrection(batchID int64, fix bool) {
+	i.BatchID = batchID
+	i.Ignored = false
+	i.unclaim()
+	if !fix {
+		i.WorkflowStep = schema.WSReadyForBatching
+		return
+	}
+
+	// A stale rejection would put the corrected issue on the old reviewer's
+	// desk instead of back in the review queue
+	i.RejectedByUserID = 0
+	i.WorkflowStep = schema.WSReadyForMetadataEntry
+}
+
+// AbandonCorrection gives up on a correction which hasn't been built yet: the
+// new version is deleted, and its issues go back to the live batch it was
+// meant to replace. Metadata changes already made to those issues are kept,
+// but the live batch isn't rebuilt, so they won't be in production. Issues
+// which are being processed must finish first.
+func (b *Batch) AbandonCorrection(userID int64, reason string) error {
+	if b.Status != BatchStatusCorrecting {
+		return fmt.Errorf("correction %s cannot be abandoned: status is %q", b.FullName, b.Status)
+	}
+
+	var old, err = b.Replaces()
+	if err != nil {
+		return fmt.Errorf("looking up batch replaced by %s: %w", b.FullName, err)
+	}
+	if old == nil {
+		return fmt.Errorf("correction %s cannot be abandoned: it doesn't replace a batch", b.FullName)
+	}
+
+	var issues []*Issue
+	issues, err = b.Issues()
+	if err != nil {
+		return fmt.Errorf("reading issues for %s: %w", b.FullName, err)
+	}
+	err = checkAbandonable(b, issues)
+	if err != nil {
+		return err
+	}
+
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	op.BeginTransaction()
+	defer op.EndTransaction()
+
+	b.Status = BatchStatusDeleted
+	_ = b.SaveOp(op, ActionTypeAbandonCorrection, userID, reason)
+	_ = old.SaveOp(op, ActionTypeAbandonCorrection, userID, fmt.Sprintf("abandoned correction %s: %s", b.FullName, reason))
+	for _, i := range issues {
+		i.returnToLive(old.ID)
+		_ = i.SaveOp(op, ActionTypeInternalProcess, userID, fmt.Sprintf("returned to live batch %s: correction %s abandoned", old.FullName, b.FullName))
+	}
+
+	return op.Err()
+}
+
+// checkAbandonable returns an error if any of a correction's issues are in a
+// step where jobs may be working on them
+func checkAbandonable(b *Batch, issues []*Issue) error {
+	for _, i := range issues {
+		switch i.WorkflowStep {
+		case schema.WSAwaitingProcessing, schema.WSReadyForMETSXML:
+			return fmt.Errorf("correction %s cannot be abandoned: issue %s is being processed", b.FullName, i.Key())
+		}
+	}
+	return nil
+}
+
+// returnToLive puts an issue from an abandoned correction back into the live
+// batch it came from
+func (i *Issue) returnToLive(batchID int64) {
+	i.BatchID = batchID
+	i.Ignored = true
+	i.WorkflowStep = schema.WSInProduction
+	i.unclaim()
+}
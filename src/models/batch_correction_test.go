@@ -0,0 +1,139 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/schema"
+)
+
+func TestCorrectionFixes(t *testing.T) {
+	var b = &Batch{FullName: "batch_foo_ver01"}
+	var issues = []*Issue{{ID: 1}, {ID: 2}, {ID: 3}}
+
+	var tests = map[string]struct {
+		ids     []int64
+		want    []int64
+		wantErr bool
+	}{
+		"nothing chosen":     {wantErr: true},
+		"one issue":          {ids: []int64{2}, want: []int64{2}},
+		"several issues":     {ids: []int64{1, 3}, want: []int64{1, 3}},
+		"issue not in batch": {ids: []int64{1, 4}, wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var fix, err = correctionFixes(b, issues, tc.ids)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("Expected an error, got %v", fix)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if len(fix) != len(tc.want) {
+				t.Errorf("Expected %d issues to fix, got %v", len(tc.want), fix)
+			}
+			for _, id := range tc.want {
+				if !fix[id] {
+					t.Errorf("Expected issue %d to be fixed", id)
+				}
+			}
+		})
+	}
+}
+
+func TestNextVersion(t *testing.T) {
+	var created = time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC)
+	var b = &Batch{ID: 7, MARCOrgCode: "oru", Name: "foo", CreatedAt: created, Version: 1, Status: BatchStatusLiveArchived}
+	b.GenerateFullName()
+
+	var nb = b.nextVersion()
+	if nb.FullName != "batch_oru_20260304foo_ver02" {
+		t.Errorf("Expected the new version's name to differ only by version, got %q", nb.FullName)
+	}
+	if nb.Status != BatchStatusCorrecting || nb.ReplacesBatchID != b.ID || nb.ID != 0 {
+		t.Errorf("Expected an unsaved correcting batch replacing %d, got %#v", b.ID, nb)
+	}
+}
+
+func TestMoveToCorrection(t *testing.T) {
+	var newIssue = func() *Issue {
+		return &Issue{
+			ID:                     1,
+			BatchID:                7,
+			Ignored:                true,
+			WorkflowStep:           schema.WSInProduction,
+			WorkflowOwnerID:        3,
+			WorkflowOwnerExpiresAt: time.Now().Add(time.Hour),
+			RejectedByUserID:       4,
+		}
+	}
+
+	var tests = map[string]struct {
+		fix          bool
+		wantStep     schema.WorkflowStep
+		wantRejecter int64
+	}{
+		"unchanged issue": {wantStep: schema.WSReadyForBatching, wantRejecter: 4},
+		"issue to fix":    {fix: true, wantStep: schema.WSReadyForMetadataEntry},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var i = newIssue()
+			i.moveToCorrection(8, tc.fix)
+			if i.BatchID != 8 {
+				t.Errorf("Expected the issue to move to batch 8, got %d", i.BatchID)
+			}
+			if i.Ignored {
+				t.Errorf("Expected the issue to be back in the workflow")
+			}
+			if i.WorkflowOwnerID != 0 || !i.WorkflowOwnerExpiresAt.IsZero() {
+				t.Errorf("Expected the issue to be unclaimed")
+			}
+			if i.WorkflowStep != tc.wantStep {
+				t.Errorf("Expected workflow step %q, got %q", tc.wantStep, i.WorkflowStep)
+			}
+			if i.RejectedByUserID != tc.wantRejecter {
+				t.Errorf("Expected rejecter %d, got %d", tc.wantRejecter, i.RejectedByUserID)
+			}
+		})
+	}
+
+	// Abandoning the correction puts things back the way they were
+	var i = newIssue()
+	i.moveToCorrection(8, true)
+	i.returnToLive(7)
+	if i.BatchID != 7 || !i.Ignored || i.WorkflowStep != schema.WSInProduction || i.WorkflowOwnerID != 0 {
+		t.Errorf("Expected the issue to be back in live batch 7, got %#v", i)
+	}
+}
+
+func TestCheckAbandonable(t *testing.T) {
+	var b = &Batch{FullName: "batch_foo_ver02"}
+	var tests = map[string]struct {
+		steps   []schema.WorkflowStep
+		wantErr bool
+	}{
+		"issues being fixed":    {steps: []schema.WorkflowStep{schema.WSReadyForMetadataEntry, schema.WSAwaitingMetadataReview, schema.WSReadyForBatching}},
+		"issue being processed": {steps: []schema.WorkflowStep{schema.WSReadyForBatching, schema.WSAwaitingProcessing}, wantErr: true},
+		"issue awaiting METS":   {steps: []schema.WorkflowStep{schema.WSReadyForMETSXML}, wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var issues []*Issue
+			for _, step := range tc.steps {
+				issues = append(issues, &Issue{LCCN: "sn12345678", WorkflowStep: step})
+			}
+			var err = checkAbandonable(b, issues)
+			if tc.wantErr != (err != nil) {
+				t.Errorf("Expected error: %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/dbi"
+)
+
+// Fixity checks are run against different copies of a batch's files
+const (
+	FixityCopyOutput  = "output"  // The full batch in BATCH_OUTPUT_PATH, before it goes live
+	FixityCopyArchive = "archive" // The full batch in a filesystem or tape staging archive target
+	FixityCopyLive    = "live"    // The copy ONI serves from BATCH_PRODUCTION_PATH, which has no TIFFs
+)
+
+// Possible outcomes of a fixity check
+const (
+	FixityStatusOK     = "ok"     // Every file matched the bag manifest
+	FixityStatusFailed = "failed" // Files were missing, extra, or didn't match the manifest
+	FixityStatusError  = "error"  // The check couldn't be completed, e.g., the manifest was unreadable
+)
+
+// BatchFixityCheck records the results of verifying one copy of a batch
+// against its bag manifest
+type BatchFixityCheck struct {
+	ID         int64 `sql:",primary"`
+	BatchID    int64
+	Copy       string
+	Location   string
+	Status     string
+	Files      int
+	Details    string // Newline-separated list of problems found
+	StartedAt  time.Time
+	FinishedAt time.Time
+}
+
+// Problems splits the check's details into a list for display
+func (c *BatchFixityCheck) Problems() []string {
+	if c.Details == "" {
+		return nil
+	}
+	return strings.Split(c.Details, "\n")
+}
+
+// OK returns true if the check passed
+func (c *BatchFixityCheck) OK() bool {
+	return c.Status == FixityStatusOK
+}
+
+// Save stores the check's results
+func (c *BatchFixityCheck) Save() error {
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	op.Save("batch_fixity_checks", c)
+	return op.Err()
+}
+
+// FixityChecks returns all fixity checks run against this batch, newest first
+func (b *Batch) FixityChecks() ([]*BatchFixityCheck, error) {
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	var list []*BatchFixityCheck
+	op.Select("batch_fixity_checks", &BatchFixityCheck{}).Where("batch_id = ?", b.ID).Order("id DESC").AllObjects(&list)
+	return list, op.Err()
+}
+
+// LatestFixityChecks returns the most recent check of each copy of every
+// batch which has been checked
+func LatestFixityChecks() ([]*BatchFixityCheck, error) {
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	var list []*BatchFixityCheck
+	op.Select("batch_fixity_checks", &BatchFixityCheck{}).
+		Where("id IN (SELECT MAX(id) FROM batch_fixity_checks GROUP BY batch_id, copy)").
+		AllObjects(&list)
+	return list, op.Err()
+}
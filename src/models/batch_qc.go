@@ -0,0 +1,280 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/dbi"
+)
+
+// QC checklist results a reviewer can record
+const (
+	QCResultPass = "pass"
+	QCResultFail = "fail"
+	QCResultNA   = "n/a"
+)
+
+// QCResults lists the results a reviewer may choose for a check, in the
+// order they're presented
+var QCResults = []string{QCResultPass, QCResultFail, QCResultNA}
+
+// ValidQCResult returns true if r is one of the known QC results
+func ValidQCResult(r string) bool {
+	return r == QCResultPass || r == QCResultFail || r == QCResultNA
+}
+
+// BatchQCResult is a reviewer's answer to one QC checklist item for a single
+// round of QC on a batch
+type BatchQCResult struct {
+	ID         int64 `sql:",primary"`
+	BatchID    int64
+	QCRound    int
+	CheckName  string
+	CheckLabel string // Label at the time of review, in case the checklist changes later
+	Result     string
+	Notes      string
+	UserID     int64
+	UpdatedAt  time.Time
+}
+
+// Author returns the user who recorded the result
+func (r *BatchQCResult) Author() *User {
+	return FindUserByID(r.UserID)
+}
+
+// Save stores the result, replacing any previous answer for the same check
+// in the same QC round
+func (r *BatchQCResult) Save() error {
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	r.UpdatedAt = time.Now()
+	if r.ID == 0 {
+		var existing = &BatchQCResult{}
+		var ok = op.Select("batch_qc_results", &BatchQCResult{}).
+			Where("batch_id = ? AND qc_round = ? AND check_name = ?", r.BatchID, r.QCRound, r.CheckName).
+			First(existing)
+		if ok {
+			r.ID = existing.ID
+		}
+	}
+	op.Save("batch_qc_results", r)
+	return op.Err()
+}
+
+// BatchQCFinding is a problem a reviewer found with a specific issue in a
+// batch during QC
+type BatchQCFinding struct {
+	ID        int64 `sql:",primary"`
+	BatchID   int64
+	QCRound   int
+	IssueID   int64
+	CheckName string
+	Note      string
+	UserID    int64
+	CreatedAt time.Time
+}
+
+// Author returns the user who recorded the finding
+func (f *BatchQCFinding) Author() *User {
+	return FindUserByID(f.UserID)
+}
+
+// Save stores the finding
+func (f *BatchQCFinding) Save() error {
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	if f.CreatedAt.IsZero() {
+		f.CreatedAt = time.Now()
+	}
+	op.Save("batch_qc_findings", f)
+	return op.Err()
+}
+
+// Delete removes the finding
+func (f *BatchQCFinding) Delete() error {
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	op.Exec("DELETE FROM batch_qc_findings WHERE id = ?", f.ID)
+	return op.Err()
+}
+
+// FindBatchQCFinding looks up a single finding by its id
+func FindBatchQCFinding(id int64) (*BatchQCFinding, error) {
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	var f = &BatchQCFinding{}
+	var ok = op.Select("batch_qc_findings", &BatchQCFinding{}).Where("id = ?", id).First(f)
+	if !ok {
+		return nil, op.Err()
+	}
+	return f, op.Err()
+}
+
+// QCResults returns the checklist results recorded for the batch's current
+// QC round
+func (b *Batch) QCResults() ([]*BatchQCResult, error) {
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	var list []*BatchQCResult
+	op.Select("batch_qc_results", &BatchQCResult{}).
+		Where("batch_id = ? AND qc_round = ?", b.ID, b.QCRound).
+		Order("id").AllObjects(&list)
+	return list, op.Err()
+}
+
+// QCFindings returns the per-issue findings recorded for the batch's current
+// QC round
+func (b *Batch) QCFindings() ([]*BatchQCFinding, error) {
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	var list []*BatchQCFinding
+	op.Select("batch_qc_findings", &BatchQCFinding{}).
+		Where("batch_id = ? AND qc_round = ?", b.ID, b.QCRound).
+		Order("id").AllObjects(&list)
+	return list, op.Err()
+}
+
+// QCResultsBetween returns all checklist results recorded in the given time
+// range, for reporting
+func QCResultsBetween(start, end time.Time) ([]*BatchQCResult, error) {
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	var list []*BatchQCResult
+	op.Select("batch_qc_results", &BatchQCResult{}).
+		Where("updated_at >= ? AND updated_at < ?", start, end).
+		Order("batch_id, qc_round, id").AllObjects(&list)
+	return list, op.Err()
+}
+
+// QCFindingsBetween returns all findings recorded in the given time range,
+// for reporting
+func QCFindingsBetween(start, end time.Time) ([]*BatchQCFinding, error) {
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	var list []*BatchQCFinding
+	op.Select("batch_qc_findings", &BatchQCFinding{}).
+		Where("created_at >= ? AND created_at < ?", start, end).
+		Order("batch_id, qc_round, id").AllObjects(&list)
+	return list, op.Err()
+}
+
+// QCChecklistItem is one check on a batch's QC checklist along with what the
+// reviewer has recorded for it in the current round
+type QCChecklistItem struct {
+	Name     string
+	Label    string
+	Help     string
+	Result   *BatchQCResult
+	Findings []*BatchQCFinding
+}
+
+// Answered returns true if the reviewer has recorded a result for the check
+func (i *QCChecklistItem) Answered() bool {
+	return i.Result != nil && ValidQCResult(i.Result.Result)
+}
+
+// Failed returns true if the check was marked as a failure or has any
+// per-issue findings
+func (i *QCChecklistItem) Failed() bool {
+	return len(i.Findings) > 0 || (i.Result != nil && i.Result.Result == QCResultFail)
+}
+
+// QCChecklist is the full list of checks for a batch's QC round
+type QCChecklist struct {
+	Items []*QCChecklistItem
+
+	// Orphans holds findings for checks which are no longer configured. They
+	// still count against approval since the problem they describe is real.
+	Orphans []*BatchQCFinding
+}
+
+// NewQCChecklist pairs the configured checks with the results and findings
+// recorded for them
+func NewQCChecklist(items []*QCChecklistItem, results []*BatchQCResult, findings []*BatchQCFinding) *QCChecklist {
+	var cl = &QCChecklist{Items: items}
+	var byName = make(map[string]*QCChecklistItem)
+	for _, item := range items {
+		item.Result = nil
+		item.Findings = nil
+		byName[item.Name] = item
+	}
+	for _, r := range results {
+		var item = byName[r.CheckName]
+		if item != nil {
+			item.Result = r
+		}
+	}
+	for _, f := range findings {
+		var item = byName[f.CheckName]
+		if item == nil {
+			cl.Orphans = append(cl.Orphans, f)
+			continue
+		}
+		item.Findings = append(item.Findings, f)
+	}
+
+	return cl
+}
+
+// Complete returns true if every check has a result
+func (cl *QCChecklist) Complete() bool {
+	for _, item := range cl.Items {
+		if !item.Answered() {
+			return false
+		}
+	}
+	return true
+}
+
+// Failed returns true if any check failed or any finding was recorded
+func (cl *QCChecklist) Failed() bool {
+	if len(cl.Orphans) > 0 {
+		return true
+	}
+	for _, item := range cl.Items {
+		if item.Failed() {
+			return true
+		}
+	}
+	return false
+}
+
+// HasFindings returns true if any findings were recorded, including those
+// for checks which are no longer configured
+func (cl *QCChecklist) HasFindings() bool {
+	if len(cl.Orphans) > 0 {
+		return true
+	}
+	for _, item := range cl.Items {
+		if len(item.Findings) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Passed returns true if the checklist is complete with no failures, meaning
+// the batch may be approved
+func (cl *QCChecklist) Passed() bool {
+	return cl.Complete() && !cl.Failed()
+}
+
+// Summary describes the checklist's results in a single line, suitable for
+// an action log entry
+func (cl *QCChecklist) Summary() string {
+	var s string
+	for i, item := range cl.Items {
+		if i > 0 {
+			s += ", "
+		}
+		var r = "unanswered"
+		if item.Answered() {
+			r = item.Result.Result
+		}
+		s += fmt.Sprintf("%s: %s", item.Label, r)
+		if len(item.Findings) > 0 {
+			s += fmt.Sprintf(" (%d finding(s))", len(item.Findings))
+		}
+	}
+	return s
+}
@@ -0,0 +1,154 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/dbi"
+)
+
+// BatchQCSample is one page picked for review during a batch's QC round,
+// along with whether a reviewer has checked it
+type BatchQCSample struct {
+	ID               int64 `sql:",primary"`
+	BatchID          int64
+	QCRound          int
+	IssueID          int64
+	Sequence         int
+	Reasons          string // Newline-separated list of why the page was picked
+	ReviewedByUserID int64
+	ReviewedAt       time.Time
+}
+
+// ReasonList splits the sample's reasons for display
+func (s *BatchQCSample) ReasonList() []string {
+	if s.Reasons == "" {
+		return nil
+	}
+	return strings.Split(s.Reasons, "\n")
+}
+
+// Reviewed returns true if a reviewer has checked the page
+func (s *BatchQCSample) Reviewed() bool {
+	return s.ReviewedByUserID != 0
+}
+
+// Reviewer returns the user who checked the page
+func (s *BatchQCSample) Reviewer() *User {
+	return FindUserByID(s.ReviewedByUserID)
+}
+
+// SetReviewed records or clears the review of the page
+func (s *BatchQCSample) SetReviewed(userID int64, reviewed bool) error {
+	s.ReviewedByUserID = 0
+	s.ReviewedAt = time.Time{}
+	if reviewed {
+		s.ReviewedByUserID = userID
+		s.ReviewedAt = time.Now()
+	}
+
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	op.Save("batch_qc_samples", s)
+	return op.Err()
+}
+
+// QCSample returns the pages sampled for the batch's current QC round, in
+// the order they were picked
+func (b *Batch) QCSample() ([]*BatchQCSample, error) {
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	var list []*BatchQCSample
+	op.Select("batch_qc_samples", &BatchQCSample{}).
+		Where("batch_id = ? AND qc_round = ?", b.ID, b.QCRound).
+		Order("id").AllObjects(&list)
+	return list, op.Err()
+}
+
+// SaveQCSample stores the sample for the batch's current QC round. A round
+// only gets one sample, so recorded coverage always refers to the same pages.
+func (b *Batch) SaveQCSample(list []*BatchQCSample) error {
+	var existing, err = b.QCSample()
+	if err != nil {
+		return err
+	}
+	if len(existing) > 0 {
+		return errors.New("batch already has a sample for this QC round")
+	}
+
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	op.BeginTransaction()
+	defer op.EndTransaction()
+	for _, s := range list {
+		s.BatchID = b.ID
+		s.QCRound = b.QCRound
+		op.Save("batch_qc_samples", s)
+	}
+	return op.Err()
+}
+
+// QCRiskSignals holds the data used to weight a batch's QC sample
+type QCRiskSignals struct {
+	// LiveTitles holds the LCCNs of the batch's titles which already have
+	// issues in production
+	LiveTitles map[string]bool
+
+	// LiveBornDigitalTitles holds the LCCNs of the batch's titles which
+	// already have publisher-provided (born-digital) issues in production
+	LiveBornDigitalTitles map[string]bool
+
+	// Rejections counts each of the batch's issues' metadata rejections
+	Rejections map[int64]int
+}
+
+// QCRiskSignals looks up what's known about the batch's issues and titles
+// that might make them riskier than usual. Only metadata rejections since the
+// given time are counted.
+func (b *Batch) QCRiskSignals(since time.Time) (*QCRiskSignals, error) {
+	var s = &QCRiskSignals{
+		LiveTitles:            make(map[string]bool),
+		LiveBornDigitalTitles: make(map[string]bool),
+		Rejections:            make(map[int64]int),
+	}
+
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	var rows = op.Query(`
+		SELECT DISTINCT i.lccn, i.is_from_scanner
+		FROM issues i
+		JOIN batches b ON b.id = i.batch_id
+		WHERE b.status IN (?, ?, ?, ?) AND b.id <> ?
+			AND i.lccn IN (SELECT lccn FROM issues WHERE batch_id = ?)`,
+		BatchStatusLive, BatchStatusLiveArchived, BatchStatusLiveDone, BatchStatusSuperseded, b.ID, b.ID,
+	)
+	for rows.Next() {
+		var lccn string
+		var scanned bool
+		rows.Scan(&lccn, &scanned)
+		s.LiveTitles[lccn] = true
+		if !scanned {
+			s.LiveBornDigitalTitles[lccn] = true
+		}
+	}
+	rows.Close()
+
+	rows = op.Query(`
+		SELECT object_id, COUNT(*)
+		FROM actions
+		WHERE object_type = ? AND action_type = ? AND created_at >= ?
+			AND object_id IN (SELECT id FROM issues WHERE batch_id = ?)
+		GROUP BY object_id`,
+		actionObjectTypeIssue, ActionTypeMetadataRejection, since, b.ID,
+	)
+	for rows.Next() {
+		var id int64
+		var n int
+		rows.Scan(&id, &n)
+		s.Rejections[id] = n
+	}
+	rows.Close()
+
+	return s, op.Err()
+}
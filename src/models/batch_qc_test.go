@@ -0,0 +1,95 @@
+package models
+
+import (
+	"testing"
+)
+
+func TestQCChecklist(t *testing.T) {
+	var tests = map[string]struct {
+		results  []*BatchQCResult
+		findings []*BatchQCFinding
+		complete bool
+		failed   bool
+	}{
+		"Nothing recorded": {
+			complete: false,
+			failed:   false,
+		},
+		"Partially answered": {
+			results:  []*BatchQCResult{{CheckName: "images", Result: QCResultPass}},
+			complete: false,
+			failed:   false,
+		},
+		"All passed": {
+			results: []*BatchQCResult{
+				{CheckName: "images", Result: QCResultPass},
+				{CheckName: "ocr", Result: QCResultNA},
+			},
+			complete: true,
+			failed:   false,
+		},
+		"Failed check": {
+			results: []*BatchQCResult{
+				{CheckName: "images", Result: QCResultPass},
+				{CheckName: "ocr", Result: QCResultFail},
+			},
+			complete: true,
+			failed:   true,
+		},
+		"Passed but with a finding": {
+			results: []*BatchQCResult{
+				{CheckName: "images", Result: QCResultPass},
+				{CheckName: "ocr", Result: QCResultPass},
+			},
+			findings: []*BatchQCFinding{{CheckName: "ocr", IssueID: 5}},
+			complete: true,
+			failed:   true,
+		},
+		"Bogus result doesn't count": {
+			results: []*BatchQCResult{
+				{CheckName: "images", Result: QCResultPass},
+				{CheckName: "ocr", Result: "maybe"},
+			},
+			complete: false,
+			failed:   false,
+		},
+		"Results for unconfigured checks are ignored": {
+			results: []*BatchQCResult{
+				{CheckName: "images", Result: QCResultPass},
+				{CheckName: "ocr", Result: QCResultPass},
+				{CheckName: "old", Result: QCResultFail},
+			},
+			complete: true,
+			failed:   false,
+		},
+		"Findings for unconfigured checks still fail": {
+			results: []*BatchQCResult{
+				{CheckName: "images", Result: QCResultPass},
+				{CheckName: "ocr", Result: QCResultPass},
+			},
+			findings: []*BatchQCFinding{{CheckName: "old", IssueID: 5}},
+			complete: true,
+			failed:   true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var items = []*QCChecklistItem{
+				{Name: "images", Label: "Images"},
+				{Name: "ocr", Label: "OCR"},
+			}
+			var cl = NewQCChecklist(items, tc.results, tc.findings)
+			if cl.Complete() != tc.complete {
+				t.Errorf("Complete() should be %v", tc.complete)
+			}
+			if cl.Failed() != tc.failed {
+				t.Errorf("Failed() should be %v", tc.failed)
+			}
+			var passed = tc.complete && !tc.failed
+			if cl.Passed() != passed {
+				t.Errorf("Passed() should be %v", passed)
+			}
+		})
+	}
+}
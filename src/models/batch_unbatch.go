@@ -0,0 +1,136 @@
+package models
+
+import (
+	"fmt"
+
+	"github.com/Nerdmaster/magicsql"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/dbi"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/schema"
+)
+
+// Unbatchable returns true if the batch's status allows it to be taken apart
+// so its issues can be batched again: it must not have been approved yet.
+// Corrections are never unbatchable, as their issues belong to a live batch.
+func (b *Batch) Unbatchable() bool {
+	if b.ReplacesBatchID != 0 {
+		return false
+	}
+	return b.Status == BatchStatusPending || b.Status == BatchStatusQCReady
+}
+
+// Pipelines returns all pipelines which have worked on this batch
+func (b *Batch) Pipelines() ([]*Pipeline, error) {
+	return findPipelines("object_type = ? AND object_id = ?", JobObjectTypeBatch, b.ID)
+}
+
+// BatchJobSummary describes the jobs which have run, or are waiting to run,
+// across all of a batch's pipelines
+type BatchJobSummary struct {
+	// Active lists jobs which are queued up to run or currently running
+	Active []*Job
+
+	// Stuck lists jobs which can't run: failed jobs and anything waiting on
+	// them, which must be canceled before the batch can be taken apart
+	Stuck []*Job
+}
+
+// JobSummary reads all the batch's jobs to describe its current state. We
+// have to look at the jobs because a batch whose build failed may have been
+// loaded into ONI without its status ever changing.
+func (b *Batch) JobSummary() (*BatchJobSummary, error) {
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	return b.jobSummaryOp(op)
+}
+
+func (b *Batch) jobSummaryOp(op *magicsql.Operation) (*BatchJobSummary, error) {
+	var jobs, err = findJobsOp(op, "pipeline_id IN (SELECT id FROM pipelines WHERE object_type = ? AND object_id = ?)", JobObjectTypeBatch, b.ID)
+	if err != nil {
+		return nil, fmt.Errorf("reading jobs for batch %s: %w", b.FullName, err)
+	}
+	return summarizeJobs(jobs), nil
+}
+
+// summarizeJobs sorts a batch's jobs into a summary
+func summarizeJobs(jobs []*Job) *BatchJobSummary {
+	var s = &BatchJobSummary{}
+	for _, j := range jobs {
+		switch JobStatus(j.Status) {
+		case JobStatusPending, JobStatusInProcess:
+			s.Active = append(s.Active, j)
+		case JobStatusFailed, JobStatusOnHold:
+			s.Stuck = append(s.Stuck, j)
+		}
+	}
+	return s
+}
+
+// QueueUnbatchJobs moves the batch to the "unbatching" status and queues the
+// jobs which take it apart. build is given a summary of the batch's jobs and
+// returns the jobs to queue. Everything happens in one transaction with the
+// batch's row locked, so nothing else can be queued for the batch between
+// checking its jobs and queueing the new ones. Nothing is queued if the batch
+// can't be unbatched or has jobs waiting or running.
+func QueueUnbatchJobs(batch *Batch, build func(*BatchJobSummary) []*Job) error {
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	op.BeginTransaction()
+	defer op.EndTransaction()
+
+	var err error
+	batch.Status, err = lockBatchStatusOp(op, batch.ID)
+	if err != nil {
+		return err
+	}
+	if !batch.Unbatchable() {
+		return fmt.Errorf("batch %s cannot be unbatched: status is %q", batch.FullName, batch.Status)
+	}
+
+	var summary *BatchJobSummary
+	summary, err = batch.jobSummaryOp(op)
+	if err != nil {
+		return err
+	}
+	if len(summary.Active) > 0 {
+		return fmt.Errorf("batch %s has %d job(s) still waiting or running", batch.FullName, len(summary.Active))
+	}
+
+	return queueBatchJobsOp(op, PNUnbatch, batch, BatchStatusUnbatching, build(summary)...)
+}
+
+// Unbatch deletes the batch and returns its issues to the batching queue.
+// The user's reason is recorded on the batch and each of its issues. This
+// doesn't touch the filesystem or staging: it's the last step of the unbatch
+// pipeline, run after the batch's files are gone.
+func (b *Batch) Unbatch(userID int64, reason string) error {
+	var issues, err = b.Issues()
+	if err != nil {
+		return fmt.Errorf("reading issues for %s: %w", b.FullName, err)
+	}
+
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	op.BeginTransaction()
+	defer op.EndTransaction()
+
+	b.takeApart(issues)
+	_ = b.SaveOp(op, ActionTypeUnbatch, userID, reason)
+	_ = b.deleteFlaggedIssues(op)
+
+	for _, i := range issues {
+		_ = i.SaveOp(op, ActionTypeUnbatch, userID, fmt.Sprintf("removed from batch %s: %s", b.FullName, reason))
+	}
+	return op.Err()
+}
+
+// takeApart deletes the batch and returns its issues to the batching queue,
+// without saving anything
+func (b *Batch) takeApart(issues []*Issue) {
+	b.Status = BatchStatusDeleted
+	b.Location = ""
+	for _, i := range issues {
+		i.BatchID = 0
+		i.WorkflowStep = schema.WSReadyForBatching
+		i.unclaim()
+	}
+}
@@ -0,0 +1,104 @@
+package models
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/schema"
+)
+
+func TestBatchUnbatchable(t *testing.T) {
+	var tests = map[string]struct {
+		batch *Batch
+		want  bool
+	}{
+		"pending":         {batch: &Batch{Status: BatchStatusPending}, want: true},
+		"qc ready":        {batch: &Batch{Status: BatchStatusQCReady}, want: true},
+		"flagging":        {batch: &Batch{Status: BatchStatusQCFlagIssues}},
+		"live":            {batch: &Batch{Status: BatchStatusLive}},
+		"deleted":         {batch: &Batch{Status: BatchStatusDeleted}},
+		"unbatching":      {batch: &Batch{Status: BatchStatusUnbatching}},
+		"correction":      {batch: &Batch{Status: BatchStatusQCReady, ReplacesBatchID: 5}},
+		"correcting":      {batch: &Batch{Status: BatchStatusCorrecting, ReplacesBatchID: 5}},
+		"pending rebuild": {batch: &Batch{Status: BatchStatusPending, ReplacesBatchID: 5}},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := tc.batch.Unbatchable(); got != tc.want {
+				t.Errorf("Expected Unbatchable() to be %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestSummarizeJobs(t *testing.T) {
+	var job = func(id int64, jt JobType, st JobStatus) *Job {
+		return &Job{ID: id, Type: string(jt), Status: string(st)}
+	}
+	var ids = func(jobs []*Job) []int64 {
+		var list []int64
+		for _, j := range jobs {
+			list = append(list, j.ID)
+		}
+		return list
+	}
+
+	var tests = map[string]struct {
+		jobs       []*Job
+		wantActive []int64
+		wantStuck  []int64
+	}{
+		"nothing run": {},
+		"build running": {
+			jobs:       []*Job{job(1, JobTypeCreateBatchStructure, JobStatusSuccessful), job(2, JobTypeMakeBatchXML, JobStatusInProcess), job(3, JobTypeONILoadBatch, JobStatusOnHold)},
+			wantActive: []int64{2},
+			wantStuck:  []int64{3},
+		},
+		"build failed after loading": {
+			jobs:      []*Job{job(1, JobTypeONILoadBatch, JobStatusSuccessful), job(2, JobTypeSetBatchStatus, JobStatusFailed), job(3, JobTypeBatchAction, JobStatusOnHold)},
+			wantStuck: []int64{2, 3},
+		},
+		"loaded and purged": {
+			jobs: []*Job{job(5, JobTypeONIPurgeBatch, JobStatusSuccessful), job(2, JobTypeONILoadBatch, JobStatusSuccessful), job(7, JobTypeONILoadBatch, JobStatusFailedDone)},
+		},
+		"queued": {
+			jobs:       []*Job{job(1, JobTypeONILoadBatch, JobStatusPending)},
+			wantActive: []int64{1},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var s = summarizeJobs(tc.jobs)
+			if got := ids(s.Active); !slices.Equal(got, tc.wantActive) {
+				t.Errorf("Active: got %v, want %v", got, tc.wantActive)
+			}
+			if got := ids(s.Stuck); !slices.Equal(got, tc.wantStuck) {
+				t.Errorf("Stuck: got %v, want %v", got, tc.wantStuck)
+			}
+		})
+	}
+}
+
+func TestTakeApart(t *testing.T) {
+	var b = &Batch{ID: 3, Status: BatchStatusUnbatching, Location: "/mnt/batches/batch_foo_ver01"}
+	var issues = []*Issue{
+		{ID: 1, BatchID: 3, WorkflowStep: schema.WSReadyForBatching},
+		{ID: 2, BatchID: 3, WorkflowStep: schema.WSReadyForBatching, WorkflowOwnerID: 4, WorkflowOwnerExpiresAt: time.Now().Add(time.Hour)},
+	}
+
+	b.takeApart(issues)
+	if b.Status != BatchStatusDeleted || b.Location != "" {
+		t.Errorf("Expected the batch to be deleted with no location, got status %q, location %q", b.Status, b.Location)
+	}
+	for _, i := range issues {
+		if i.BatchID != 0 || i.WorkflowStep != schema.WSReadyForBatching {
+			t.Errorf("Issue %d: expected it to be back in the batching queue, got batch %d, step %q", i.ID, i.BatchID, i.WorkflowStep)
+		}
+		if i.WorkflowOwnerID != 0 || !i.WorkflowOwnerExpiresAt.IsZero() {
+			t.Errorf("Issue %d: expected it to be unclaimed", i.ID)
+		}
+	}
+}
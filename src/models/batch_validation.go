@@ -0,0 +1,60 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/dbi"
+)
+
+// Possible outcomes of a batch validation
+const (
+	ValidationStatusPassed = "passed" // The batch met every rule checked
+	ValidationStatusFailed = "failed" // The batch broke one or more rules
+)
+
+// BatchValidationReport records the results of checking a built batch
+// against NDNP rules before it's loaded onto staging
+type BatchValidationReport struct {
+	ID            int64 `sql:",primary"`
+	BatchID       int64
+	Status        string
+	Issues        int
+	Pages         int
+	Files         int
+	SchemaChecked bool
+	Details       string // Newline-separated list of problems found
+	StartedAt     time.Time
+	FinishedAt    time.Time
+}
+
+// Problems splits the report's details into a list for display
+func (r *BatchValidationReport) Problems() []string {
+	if r.Details == "" {
+		return nil
+	}
+	return strings.Split(r.Details, "\n")
+}
+
+// OK returns true if the batch passed validation
+func (r *BatchValidationReport) OK() bool {
+	return r.Status == ValidationStatusPassed
+}
+
+// Save stores the report
+func (r *BatchValidationReport) Save() error {
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	op.Save("batch_validation_reports", r)
+	return op.Err()
+}
+
+// ValidationReports returns all validation reports for this batch, newest
+// first
+func (b *Batch) ValidationReports() ([]*BatchValidationReport, error) {
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	var list []*BatchValidationReport
+	op.Select("batch_validation_reports", &BatchValidationReport{}).Where("batch_id = ?", b.ID).Order("id DESC").AllObjects(&list)
+	return list, op.Err()
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/dbi"
+)
+
+// DerivativeSettings holds the settings used to generate an issue's JP2 and
+// ALTO XML derivatives. A record is stored every time an issue's derivatives
+// are generated, since the configuration may change before the issue's
+// provenance is needed.
+type DerivativeSettings struct {
+	ID         int64 `sql:",primary"`
+	IssueID    int64
+	JP2Source  string  `sql:"jp2_source"` // "pdf" or "tiff"
+	JP2DPI     int     `sql:"jp2_dpi"`
+	JP2Quality float64 `sql:"jp2_quality"`
+	ALTODPI    int     `sql:"alto_dpi"`
+	CreatedAt  time.Time
+}
+
+// Message describes the settings for the issue's action history
+func (d *DerivativeSettings) Message() string {
+	return fmt.Sprintf("Generated derivatives: JP2s from %s at %d DPI and quality %g, ALTO XML at %d DPI",
+		d.JP2Source, d.JP2DPI, d.JP2Quality, d.ALTODPI)
+}
+
+// Save stores the settings, and adds an action to the issue's history
+// describing them
+func (d *DerivativeSettings) Save() error {
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	op.BeginTransaction()
+	defer op.EndTransaction()
+
+	if d.CreatedAt.IsZero() {
+		d.CreatedAt = time.Now()
+	}
+	op.Save("derivative_settings", d)
+
+	var a = NewIssueAction(d.IssueID, ActionTypeInternalProcess)
+	a.UserID = SystemUser.ID
+	a.Message = d.Message()
+	_ = a.SaveOp(op)
+
+	return op.Err()
+}
+
+// FindDerivativeSettings returns the settings the issue's derivatives were
+// most recently generated with, or nil if none were recorded
+func FindDerivativeSettings(issueID int64) (*DerivativeSettings, error) {
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	var d = &DerivativeSettings{}
+	var ok = op.Select("derivative_settings", &DerivativeSettings{}).Where("issue_id = ?", issueID).Order("id DESC").First(d)
+	if !ok {
+		return nil, op.Err()
+	}
+	return d, op.Err()
+}
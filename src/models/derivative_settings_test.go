@@ -0,0 +1,22 @@
+package models
+
+import "testing"
+
+func TestDerivativeSettingsMessage(t *testing.T) {
+	var tests = map[string]struct {
+		settings *DerivativeSettings
+		want     string
+	}{
+		"scanned":      {settings: &DerivativeSettings{JP2Source: "tiff", JP2DPI: 150, JP2Quality: 75, ALTODPI: 400}, want: "Generated derivatives: JP2s from tiff at 150 DPI and quality 75, ALTO XML at 400 DPI"},
+		"born digital": {settings: &DerivativeSettings{JP2Source: "pdf", JP2DPI: 150, JP2Quality: 37.5, ALTODPI: 150}, want: "Generated derivatives: JP2s from pdf at 150 DPI and quality 37.5, ALTO XML at 150 DPI"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got = tc.settings.Message()
+			if got != tc.want {
+				t.Errorf("Expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
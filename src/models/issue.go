@@ -13,6 +13,9 @@ import (
 	"github.com/uoregon-libraries/newspaper-curation-app/src/schema"
 )
 
+// claimDuration is how long an issue stays on somebody's desk once claimed
+const claimDuration = time.Hour * 24 * 7
+
 // Workflow steps in-process issues may have
 var allowedWorkflowSteps = []schema.WorkflowStep{
 	schema.WSUnfixableMetadataError,
@@ -57,13 +60,14 @@ type Issue struct {
 
 	/* Workflow information to keep track of the issue and what it needs */
 
-	BatchID                int64               // Which batch (if any) is this issue a part of?
+	BatchID                int64               // Which batch (if any) is this issue currently a part of?
 	Location               string              // Where is this issue on disk?
 	BackupLocation         string              // Where is the original backup located?  (born-digital only)
 	HumanName              string              // What is the issue's "human" name (for consistent folder naming)?
 	IsFromScanner          bool                // Is the issue scanned in-house?  (Born-digital == false)
 	WorkflowStepString     string              `sql:"workflow_step"` // If set, tells us what "human workflow" step we're on
 	WorkflowStep           schema.WorkflowStep `sql:"-"`
+	WorkflowStepChangedAt  time.Time           // When did the issue enter its current workflow step?
 	WorkflowOwnerID        int64               // Whose "desk" is this currently on?
 	WorkflowOwnerExpiresAt time.Time           // When does the workflow owner lose ownership?
 	MetadataEntryUserID    int64               // Who entered metadata?
@@ -83,6 +87,13 @@ type Issue struct {
 
 	// lazy-loaded list of pipelines directly tied to this issue
 	pipelines []*Pipeline
+
+	// lazy-loaded list of reviewer annotations
+	annotations []*Annotation
+
+	// savedWorkflowStep is the workflow step as of the last load or save, so we
+	// know when to update WorkflowStepChangedAt
+	savedWorkflowStep schema.WorkflowStep
 }
 
 // FlaggedIssue is a record indicating an issue which was flagged for removal
@@ -331,7 +342,44 @@ func (i *Issue) claim(byUserID int64) {
 	}
 
 	i.WorkflowOwnerID = byUserID
-	i.WorkflowOwnerExpiresAt = time.Now().Add(time.Hour * 24 * 7)
+	i.WorkflowOwnerExpiresAt = time.Now().Add(claimDuration)
+}
+
+// Assign puts the issue on the given user's desk on the system's behalf, such
+// as when the workload balancer hands out work. Unlike Claim, the action is
+// attributed to the system rather than the new owner.
+//
+// The claim is written with a conditional update so that an issue somebody
+// claimed after it was loaded is left alone rather than silently taken from
+// them. The return value is false when that happens.
+func (i *Issue) Assign(userID int64, message string) (bool, error) {
+	if userID <= 0 {
+		return false, fmt.Errorf("invalid user id %d", userID)
+	}
+
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	op.BeginTransaction()
+	defer op.EndTransaction()
+
+	var now = time.Now()
+	var expires = now.Add(claimDuration)
+	var n = op.Exec(`UPDATE issues SET workflow_owner_id = ?, workflow_owner_expires_at = ?
+		WHERE id = ? AND (workflow_owner_id = 0 OR workflow_owner_expires_at < ?)`,
+		userID, expires, i.ID, now).RowsAffected()
+	if op.Err() != nil || n == 0 {
+		return false, op.Err()
+	}
+
+	i.WorkflowOwnerID = userID
+	i.WorkflowOwnerExpiresAt = expires
+	var a = NewIssueAction(i.ID, ActionTypeAutoAssign)
+	a.UserID = SystemUser.ID
+	a.Message = message
+	i.actions = append(i.actions, a)
+	_ = a.SaveOp(op)
+
+	return true, op.Err()
 }
 
 // Unclaim removes the workflow owner and resets the workflow expiration time
@@ -377,12 +425,55 @@ func (i *Issue) ApproveMetadata(reviewerID int64) error {
 }
 
 // RejectMetadata sends the issue back to the metadata entry user and saves the
-// reviewer's notes
-func (i *Issue) RejectMetadata(reviewerID int64, notes string) error {
+// reviewer's notes and any page- or field-specific annotations
+func (i *Issue) RejectMetadata(reviewerID int64, notes string, annotations ...*Annotation) error {
+	for _, a := range annotations {
+		var err = a.Validate()
+		if err != nil {
+			return fmt.Errorf("invalid annotation: %w", err)
+		}
+	}
+
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	op.BeginTransaction()
+	defer op.EndTransaction()
+
 	i.claim(i.MetadataEntryUserID)
 	i.RejectedByUserID = reviewerID
 	i.WorkflowStep = schema.WSReadyForMetadataEntry
-	return i.Save(ActionTypeMetadataRejection, reviewerID, notes)
+	_ = i.SaveOp(op, ActionTypeMetadataRejection, reviewerID, rejectionMessage(notes, annotations))
+	for _, a := range annotations {
+		a.IssueID = i.ID
+		_ = a.createOp(op, reviewerID)
+	}
+	i.annotations = nil
+
+	return op.Err()
+}
+
+// Annotations returns all reviewer annotations tied to this issue, resolved
+// or not, ordered by page number
+func (i *Issue) Annotations() []*Annotation {
+	if i.annotations == nil {
+		// As with actions, we deliberately ignore errors here
+		i.annotations, _ = FindAnnotationsForIssue(i.ID)
+	}
+
+	return i.annotations
+}
+
+// UnresolvedAnnotations returns the annotations which haven't yet been
+// resolved
+func (i *Issue) UnresolvedAnnotations() []*Annotation {
+	var list []*Annotation
+	for _, a := range i.Annotations() {
+		if !a.Resolved() {
+			list = append(list, a)
+		}
+	}
+
+	return list
 }
 
 // ReportError adds an error message to the issue and flags it as being in the
@@ -486,6 +577,10 @@ func (i *Issue) SaveOpWithoutAction(op *magicsql.Operation) error {
 // serialize prepares struct data to work with the database fields better
 func (i *Issue) serialize() {
 	i.PageLabelsCSV = strings.Join(i.PageLabels, "␟")
+	if i.WorkflowStep != i.savedWorkflowStep || i.WorkflowStepChangedAt.IsZero() {
+		i.WorkflowStepChangedAt = time.Now()
+		i.savedWorkflowStep = i.WorkflowStep
+	}
 	i.WorkflowStepString = string(i.WorkflowStep)
 	i.PageCount = len(i.PageLabels)
 }
@@ -495,6 +590,7 @@ func (i *Issue) serialize() {
 func (i *Issue) deserialize() {
 	i.PageLabels = strings.Split(i.PageLabelsCSV, "␟")
 	i.WorkflowStep = schema.WorkflowStep(i.WorkflowStepString)
+	i.savedWorkflowStep = i.WorkflowStep
 	i.setHumanName()
 }
 
@@ -559,6 +655,20 @@ func FindCompletedIssuesReadyForRemoval() ([]*Issue, error) {
 	return list, op.Err()
 }
 
+// FindArchivedIssuesWithFiles returns issues still on disk in NCA's workflow
+// location despite their batch having been archived, whether or not the batch
+// has been closed out
+func FindArchivedIssuesWithFiles() ([]*Issue, error) {
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+
+	var list []*Issue
+	var cond = "batch_id IN (SELECT id FROM batches WHERE status IN (?, ?)) AND location <> ''"
+	op.Select("issues", &Issue{}).Where(cond, BatchStatusLiveArchived, BatchStatusLiveDone).AllObjects(&list)
+	deserializeIssues(list)
+	return list, op.Err()
+}
+
 // FindIssuesLackingMetadataEntryDate is a one-off to help migrate legacy
 // issues. It's dumb and shouldn't live here. Blah.
 func FindIssuesLackingMetadataEntryDate() ([]*Issue, error) {
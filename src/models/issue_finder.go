@@ -61,6 +61,14 @@ func (f *IssueFinder) BatchID(batchID int64) *IssueFinder {
 	return f
 }
 
+// WentLiveIn filters issues to those the given batch held when it went live.
+// Unlike BatchID, this still finds issues which have since moved on to a
+// corrected version of the batch.
+func (f *IssueFinder) WentLiveIn(batchID int64) *IssueFinder {
+	f.conditions["id IN (SELECT issue_id FROM batch_issues WHERE batch_id = ?)"] = batchID
+	return f
+}
+
 // AllowIgnored removes the standard "ignored = false" clause. This is useful
 // for some very specific cases, like showing issues belonging to live batches.
 func (f *IssueFinder) AllowIgnored() *IssueFinder {
@@ -76,6 +84,14 @@ func (f *IssueFinder) OnDesk(userID int64) *IssueFinder {
 	return f
 }
 
+// Claimed filters issues to those currently on somebody's desk, with a claim
+// which has not yet expired
+func (f *IssueFinder) Claimed() *IssueFinder {
+	f.conditions["workflow_owner_id > 0"] = nil
+	f.conditions["workflow_owner_expires_at > ?"] = time.Now()
+	return f
+}
+
 // Available filters issues to just those which are "available". We
 // define "available" as any issue without an owner or where ownership expired
 // (e.g., an issue was sitting on somebody's desk for several days).
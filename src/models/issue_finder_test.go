@@ -17,7 +17,7 @@ func TestIssueFinder(t *testing.T) {
 		expectSQL string
 	}
 
-	var prefix = "SELECT id,marc_org_code,lccn,date,date_as_labeled,volume,issue,edition,edition_label,page_labels_csv,page_count,batch_id,location,backup_location,human_name,is_from_scanner,workflow_step,workflow_owner_id,workflow_owner_expires_at,metadata_entry_user_id,metadata_entered_at,reviewed_by_user_id,metadata_approved_at,rejected_by_user_id,ignored,draft_comment FROM issues"
+	var prefix = "SELECT id,marc_org_code,lccn,date,date_as_labeled,volume,issue,edition,edition_label,page_labels_csv,page_count,batch_id,location,backup_location,human_name,is_from_scanner,workflow_step,workflow_step_changed_at,workflow_owner_id,workflow_owner_expires_at,metadata_entry_user_id,metadata_entered_at,reviewed_by_user_id,metadata_approved_at,rejected_by_user_id,ignored,draft_comment FROM issues"
 	var tests = map[string]testCase{
 		"Base": {
 			fn:        func(f *IssueFinder) *IssueFinder { return f },
@@ -39,6 +39,10 @@ func TestIssueFinder(t *testing.T) {
 			fn:        func(f *IssueFinder) *IssueFinder { return f.BatchID(101) },
 			expectSQL: "%PREFIX% WHERE (batch_id = ?) AND (ignored = ?)",
 		},
+		"WentLiveIn": {
+			fn:        func(f *IssueFinder) *IssueFinder { return f.WentLiveIn(101) },
+			expectSQL: "%PREFIX% WHERE (id IN (SELECT issue_id FROM batch_issues WHERE batch_id = ?)) AND (ignored = ?)",
+		},
 		"AllowIgnored": {
 			fn:        func(f *IssueFinder) *IssueFinder { return f.AllowIgnored() },
 			expectSQL: "%PREFIX%",
@@ -3,6 +3,7 @@ package models
 import (
 	"encoding/json"
 	"fmt"
+	"maps"
 	"math/rand"
 	"strings"
 	"time"
@@ -16,6 +17,8 @@ const (
 	JobObjectTypeJob   = "job"
 	JobObjectTypeIssue = "issue"
 	JobObjectTypeBatch = "batch"
+	JobObjectTypeTitle = "title"
+	JobObjectTypeMOC   = "moc"
 )
 
 // JobType represents all possible jobs the system queues and processes
@@ -44,6 +47,7 @@ const (
 	JobTypeFinalizeBatchFlaggedIssue JobType = "finalize_batch_flagged_issue"
 
 	// Jobs that are directly tied to a batch
+	JobTypeArchiveBatch                JobType = "archive_batch"
 	JobTypeBatchAction                 JobType = "record_batch_action"
 	JobTypeCreateBatchStructure        JobType = "create_batch_structure"
 	JobTypeDeleteBatch                 JobType = "delete_batch"
@@ -53,9 +57,17 @@ const (
 	JobTypeSetBatchLocation            JobType = "set_batch_location"
 	JobTypeSetBatchStatus              JobType = "set_batch_status"
 	JobTypeValidateTagManifest         JobType = "validate_tagmanifest"
+	JobTypeValidateBagit               JobType = "validate_bagit"
+	JobTypeValidateBatch               JobType = "validate_batch"
 	JobTypeWriteBagitManifest          JobType = "write_bagit_manifest"
 	JobTypeONILoadBatch                JobType = "oni_load_batch"
 	JobTypeONIPurgeBatch               JobType = "oni_purge_batch"
+	JobTypeUnbatchIssues               JobType = "unbatch_issues"
+
+	// Jobs that push titles and awardees to ONI
+	JobTypeONILoadTitle     JobType = "oni_load_title"
+	JobTypeONIWaitForTitle  JobType = "oni_wait_for_title"
+	JobTypeONIEnsureAwardee JobType = "oni_ensure_awardee"
 
 	// Fairly general-purpose jobs, which use only the job args, not an object id
 	JobTypeCleanFiles      JobType = "clean_files"
@@ -91,8 +103,12 @@ var ValidJobTypes = []JobType{
 	JobTypeWriteActionLog,
 	JobTypeWriteBagitManifest,
 	JobTypeValidateTagManifest,
+	JobTypeValidateBagit,
+	JobTypeValidateBatch,
+	JobTypeArchiveBatch,
 	JobTypeMarkBatchLive,
 	JobTypeDeleteBatch,
+	JobTypeUnbatchIssues,
 	JobTypeSyncRecursive,
 	JobTypeVerifyRecursive,
 	JobTypeKillDir,
@@ -107,6 +123,9 @@ var ValidJobTypes = []JobType{
 	JobTypeONILoadBatch,
 	JobTypeONIPurgeBatch,
 	JobTypeONIWaitForJob,
+	JobTypeONILoadTitle,
+	JobTypeONIWaitForTitle,
+	JobTypeONIEnsureAwardee,
 }
 
 // JobStatus represents the different states in which a job can exist
@@ -266,6 +285,13 @@ func FindJobsByStatusAndType(status JobStatus, typ JobType) ([]*Job, error) {
 	return findJobs("status = ? AND job_type = ?", status, typ)
 }
 
+// FindBatchONIJobs returns the batch's successful ONI load and purge jobs,
+// oldest first, so callers can tell which environments it's loaded on
+func FindBatchONIJobs(batchID int64) ([]*Job, error) {
+	return findJobs("pipeline_id IN (SELECT id FROM pipelines WHERE object_type = ? AND object_id = ?) AND status = ? AND job_type IN (?, ?) ORDER BY id",
+		JobObjectTypeBatch, batchID, JobStatusSuccessful, JobTypeONILoadBatch, JobTypeONIPurgeBatch)
+}
+
 // Logs lazy-loads all logs for this job from the database
 func (j *Job) Logs() []*JobLog {
 	if j.logs == nil {
@@ -431,6 +457,104 @@ func (j *Job) QueueSiblingJobs(list []*Job) error {
 	return op.Err()
 }
 
+// ExpandEntwined runs the job's entwined group once per entry in overrides.
+// The group's existing jobs get the first entry's args, while copies of the
+// group with each later entry's args are inserted into the pipeline directly
+// after it, pushing back everything which would have run next. This is for a
+// group which turns out to need running against several targets, such as an
+// ONI job queued for what is now more than one environment.
+func (j *Job) ExpandEntwined(overrides []map[string]string) error {
+	if j.EntwineID == 0 {
+		return fmt.Errorf("expanding group: invalid job %d, no entwinement id", j.ID)
+	}
+	if len(overrides) == 0 {
+		return nil
+	}
+
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	op.BeginTransaction()
+	defer op.EndTransaction()
+
+	var group, err = findJobsOp(op, "pipeline_id = ? AND entwine_id = ? AND status <> ? ORDER BY sequence", j.PipelineID, j.EntwineID, JobStatusFailedDone)
+	if err != nil {
+		return fmt.Errorf("getting entwined jobs: %w", err)
+	}
+	if len(group) == 0 {
+		return fmt.Errorf("getting entwined jobs: none found for job %d", j.ID)
+	}
+	var first, last = group[0].Sequence, group[len(group)-1].Sequence
+	var width = last - first + 1
+
+	// Make room for the copies, then write them after the group
+	var shift = width * (len(overrides) - 1)
+	op.Exec("UPDATE jobs SET sequence = sequence + ? WHERE pipeline_id = ? AND sequence > ?", shift, j.PipelineID, last)
+	for n, args := range overrides {
+		var copies []*Job
+		for _, job := range group {
+			if n == 0 {
+				// The caller's copy of j has to see its new args, too
+				if job.ID == j.ID {
+					job = j
+				}
+				maps.Copy(job.Args, args)
+				_ = job.SaveOp(op)
+				continue
+			}
+
+			var clone = job.Clone()
+			clone.Args = maps.Clone(job.Args)
+			maps.Copy(clone.Args, args)
+			clone.Status = string(JobStatusOnHold)
+			clone.RetryCount = 0
+			clone.StartedAt = time.Time{}
+			clone.CompletedAt = time.Time{}
+			clone.RunAt = time.Now()
+			clone.Sequence = job.Sequence + width*n
+			copies = append(copies, clone)
+		}
+		if len(copies) > 0 {
+			EntwineJobs(copies)
+			for _, clone := range copies {
+				_ = clone.SaveOp(op)
+			}
+		}
+	}
+
+	return op.Err()
+}
+
+// SetEntwinedArgs adds args to j and to every job in its entwined group which
+// hasn't run yet, so a job can hand data to the jobs which follow it. Since
+// the args live on the jobs, a retried group carries them along, and jobs in
+// another pipeline for the same object can't overwrite them.
+func (j *Job) SetEntwinedArgs(args map[string]string) error {
+	if j.EntwineID == 0 {
+		return fmt.Errorf("setting group args: invalid job %d, no entwinement id", j.ID)
+	}
+
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	op.BeginTransaction()
+	defer op.EndTransaction()
+
+	var group, err = findJobsOp(op, "pipeline_id = ? AND entwine_id = ? AND status IN (?, ?)", j.PipelineID, j.EntwineID, JobStatusOnHold, JobStatusPending)
+	if err != nil {
+		return fmt.Errorf("getting entwined jobs: %w", err)
+	}
+	maps.Copy(j.Args, args)
+	_ = j.SaveOp(op)
+	for _, job := range group {
+		if job.ID == j.ID {
+			continue
+		}
+		maps.Copy(job.Args, args)
+		_ = job.SaveOp(op)
+	}
+
+	return op.Err()
+}
+
 // EntwineJobs "connects" the passed-in jobs so that on any failure, the list
 // as a whole is requeued instead of justthe job which failed. This should only
 // be used for jobs where the *group* is idempotent **or** resilience is so
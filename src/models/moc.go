@@ -64,3 +64,11 @@ func (moc *MOC) Delete() error {
 	op.Exec("DELETE FROM mocs WHERE id = ?", moc.ID)
 	return op.Err()
 }
+
+// BuildJob sets up a job for this MOC
+func (moc *MOC) BuildJob(t JobType, args map[string]string) *Job {
+	var j = NewJob(t, args)
+	j.ObjectID = moc.ID
+	j.ObjectType = JobObjectTypeMOC
+	return j
+}
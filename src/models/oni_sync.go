@@ -0,0 +1,109 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Nerdmaster/magicsql"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/dbi"
+)
+
+// ONISyncStatus describes where an object is in the process of being pushed
+// to an ONI environment
+type ONISyncStatus string
+
+// All valid ONI sync statuses
+const (
+	ONISyncStatusQueued  ONISyncStatus = "queued"  // Jobs exist but haven't yet talked to the agent
+	ONISyncStatusLoading ONISyncStatus = "loading" // The agent accepted the data and is loading it
+	ONISyncStatusSynced  ONISyncStatus = "synced"  // ONI has the latest data
+	ONISyncStatusFailed  ONISyncStatus = "failed"  // The last attempt failed; the job may still retry
+)
+
+// ONISync records the state of a single object (title or MOC) in a single ONI
+// environment, so we can tell users whether ONI has what NCA has.
+// ONIAgentJobID is the most recent agent job, kept for reference; the job
+// waiting on the agent carries its own copy in case the sync is requeued.
+type ONISync struct {
+	ID            int64 `sql:",primary"`
+	ObjectType    string
+	ObjectID      int64
+	Environment   string
+	Status        string
+	ONIAgentJobID int64
+	Message       string
+	UpdatedAt     time.Time
+}
+
+// FindONISyncs returns all sync records for the given object, one per
+// environment it has ever been queued for
+func FindONISyncs(objectType string, objectID int64) ([]*ONISync, error) {
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	var list []*ONISync
+	op.Select("oni_syncs", &ONISync{}).Where("object_type = ? AND object_id = ?", objectType, objectID).Order("environment").AllObjects(&list)
+	return list, op.Err()
+}
+
+// FindONISync returns the object's sync record for the given environment. If
+// none exists, an unsaved record is returned.
+func FindONISync(objectType string, objectID int64, env string) (*ONISync, error) {
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	return findONISyncOp(op, objectType, objectID, env)
+}
+
+func findONISyncOp(op *magicsql.Operation, objectType string, objectID int64, env string) (*ONISync, error) {
+	var s = &ONISync{}
+	var ok = op.Select("oni_syncs", &ONISync{}).Where("object_type = ? AND object_id = ? AND environment = ?", objectType, objectID, env).First(s)
+	if !ok {
+		s = &ONISync{ObjectType: objectType, ObjectID: objectID, Environment: env}
+	}
+	return s, op.Err()
+}
+
+// SetStatus updates the sync's status and message and saves it
+func (s *ONISync) SetStatus(status ONISyncStatus, message string) error {
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	return s.setStatusOp(op, status, message)
+}
+
+func (s *ONISync) setStatusOp(op *magicsql.Operation, status ONISyncStatus, message string) error {
+	s.Status = string(status)
+	s.Message = message
+	s.UpdatedAt = time.Now()
+	op.Save("oni_syncs", s)
+	return op.Err()
+}
+
+// QueueONISyncJobs marks the given object as queued for a single environment,
+// then creates a pipeline for the jobs and saves them. As with the other
+// pipeline queueing functions, this is all done in a single transaction.
+//
+// Each environment gets its own pipeline so that an agent which is down
+// doesn't hold up the others.
+func QueueONISyncJobs(name PipelineName, objectType string, objectID int64, desc string, env string, jobs ...*Job) error {
+	if len(jobs) == 0 {
+		return fmt.Errorf("QueueONISyncJobs called with an empty jobs list")
+	}
+
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	op.BeginTransaction()
+	defer op.EndTransaction()
+
+	var s, err = findONISyncOp(op, objectType, objectID, env)
+	if err != nil {
+		return fmt.Errorf("find sync record for %s %d in %s: %w", objectType, objectID, env, err)
+	}
+	err = s.setStatusOp(op, ONISyncStatusQueued, "")
+	if err != nil {
+		return fmt.Errorf("save sync record for %s %d in %s: %w", objectType, objectID, env, err)
+	}
+
+	var p = newPipeline(name, desc)
+	p.ObjectType = objectType
+	p.ObjectID = objectID
+	return p.queueSerialOp(op, jobs...)
+}
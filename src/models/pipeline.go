@@ -26,6 +26,10 @@ const (
 	PNFinalizeIssueFlagging   PipelineName = "FinalizeIssueFlagging"
 	PNBatchDeletion           PipelineName = "BatchDeletion"
 	PNGoLiveProcess           PipelineName = "GoLiveProcess"
+	PNSyncTitle               PipelineName = "SyncTitle"
+	PNSyncMOC                 PipelineName = "SyncMOC"
+	PNReconcileBatch          PipelineName = "ReconcileBatch"
+	PNUnbatch                 PipelineName = "Unbatch"
 )
 
 // A Pipeline is a connected series of independent jobs which all perform tasks
@@ -118,6 +122,8 @@ func QueueIssueJobs(name PipelineName, issue *Issue, jobs ...*Job) error {
 // The first job in the list is set to pending while the others will be set to
 // be on hold, and jobs will be given a sequence based on the order they're
 // passed in here.
+//
+// Batches being unbatched can't have anything else queued.
 func QueueBatchJobs(name PipelineName, batch *Batch, jobs ...*Job) error {
 	if len(jobs) == 0 {
 		return fmt.Errorf("QueueBatchJobs called with an empty jobs list")
@@ -128,7 +134,21 @@ func QueueBatchJobs(name PipelineName, batch *Batch, jobs ...*Job) error {
 	op.BeginTransaction()
 	defer op.EndTransaction()
 
-	batch.Status = BatchStatusPending
+	var status, err = lockBatchStatusOp(op, batch.ID)
+	if err != nil {
+		return err
+	}
+	if status == BatchStatusUnbatching {
+		return fmt.Errorf("batch %s is being unbatched", batch.FullName)
+	}
+
+	return queueBatchJobsOp(op, name, batch, BatchStatusPending, jobs...)
+}
+
+// queueBatchJobsOp sets the batch's status and queues the jobs on a new
+// pipeline within the given transaction
+func queueBatchJobsOp(op *magicsql.Operation, name PipelineName, batch *Batch, status string, jobs ...*Job) error {
+	batch.Status = status
 	var err = batch.SaveOpWithoutAction(op)
 	if err != nil {
 		return err
@@ -140,6 +160,41 @@ func QueueBatchJobs(name PipelineName, batch *Batch, jobs ...*Job) error {
 	return p.queueSerialOp(op, jobs...)
 }
 
+// lockBatchStatusOp reads a batch's status from the database, locking its row
+// until the transaction ends so nothing else can queue work for the batch in
+// the meantime
+func lockBatchStatusOp(op *magicsql.Operation, id int64) (string, error) {
+	var status string
+	var rows = op.Query("SELECT status FROM batches WHERE id = ? FOR UPDATE", id)
+	for rows.Next() {
+		rows.Scan(&status)
+	}
+	rows.Close()
+	if op.Err() == nil && status == "" {
+		return "", fmt.Errorf("batch %d not found", id)
+	}
+	return status, op.Err()
+}
+
+// QueueBatchONIJobs is like QueueBatchJobs, but leaves the batch's status
+// alone. This is for loading or purging a batch in ONI to make ONI match NCA,
+// where the batch's place in NCA's workflow isn't changing.
+func QueueBatchONIJobs(name PipelineName, batch *Batch, jobs ...*Job) error {
+	if len(jobs) == 0 {
+		return fmt.Errorf("QueueBatchONIJobs called with an empty jobs list")
+	}
+
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	op.BeginTransaction()
+	defer op.EndTransaction()
+
+	var p = newPipeline(name, fmt.Sprintf("batch %s", batch.FullName))
+	p.ObjectType = JobObjectTypeBatch
+	p.ObjectID = batch.ID
+	return p.queueSerialOp(op, jobs...)
+}
+
 // QueueJobs is a shortcut to create a pipeline, save it, queue up a bunch of
 // jobs on that pipeline, and save each of them. All this is done in a
 // transaction to ensure the state doesn't change if any of these DB operations
@@ -160,7 +215,8 @@ func QueueJobs(name PipelineName, description string, jobs ...*Job) error {
 	// job-set will start with the object in question, so this should prevent
 	// accidental calls that should have used an object-focused function
 	// (queueXJobs)
-	if jobs[0].ObjectType == JobObjectTypeBatch || jobs[0].ObjectType == JobObjectTypeIssue {
+	switch jobs[0].ObjectType {
+	case JobObjectTypeBatch, JobObjectTypeIssue, JobObjectTypeTitle, JobObjectTypeMOC:
 		return fmt.Errorf("QueueJobs called with object type %s", jobs[0].ObjectType)
 	}
 
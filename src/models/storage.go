@@ -0,0 +1,180 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/dbi"
+)
+
+// StorageSample records how much space was free on one of the filesystems
+// NCA writes to at a point in time
+type StorageSample struct {
+	ID         int64 `sql:",primary"`
+	Location   string
+	Path       string
+	FreeBytes  int64
+	TotalBytes int64
+	SampledAt  time.Time
+}
+
+// Save stores the sample
+func (s *StorageSample) Save() error {
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	op.Save("storage_samples", s)
+	return op.Err()
+}
+
+// FindStorageSamples returns a location's samples taken since the given time,
+// oldest first
+func FindStorageSamples(location string, since time.Time) ([]*StorageSample, error) {
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	var list []*StorageSample
+	op.Select("storage_samples", &StorageSample{}).
+		Where("location = ? AND sampled_at >= ?", location, since).
+		Order("sampled_at").AllObjects(&list)
+	return list, op.Err()
+}
+
+// PruneStorageSamples removes samples taken before the given time
+func PruneStorageSamples(before time.Time) error {
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	op.Exec("DELETE FROM storage_samples WHERE sampled_at < ?", before)
+	return op.Err()
+}
+
+// StorageHold records that a pipeline was kept from starting because it
+// would have used more space than a location had free. FreeBytes is what was
+// left for the pipeline after setting aside what running pipelines need.
+type StorageHold struct {
+	ID          int64 `sql:",primary"`
+	PipelineID  int64
+	Location    string
+	NeededBytes int64
+	FreeBytes   int64
+	HeldAt      time.Time
+	CheckedAt   time.Time
+
+	pipeline *Pipeline
+}
+
+// Pipeline returns the held pipeline
+func (h *StorageHold) Pipeline() (*Pipeline, error) {
+	if h.pipeline == nil {
+		var p, err = findPipeline(h.PipelineID)
+		if err != nil {
+			return nil, err
+		}
+		h.pipeline = p
+	}
+	return h.pipeline, nil
+}
+
+// FindStorageHolds returns holds on pipelines which still haven't started,
+// oldest first. Holds on pipelines which were canceled or otherwise moved on
+// without starting aren't included.
+func FindStorageHolds() ([]*StorageHold, error) {
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	var list []*StorageHold
+	op.Select("storage_holds", &StorageHold{}).
+		Where("pipeline_id IN (SELECT pipeline_id FROM jobs WHERE sequence = 1 AND status = ?)", JobStatusPending).
+		Order("held_at").AllObjects(&list)
+	return list, op.Err()
+}
+
+// HoldJobForStorage puts a pipeline's first job back to pending, to be tried
+// again after the delay, and records why. The pipeline is marked as not
+// started, since none of its work has been done.
+func HoldJobForStorage(j *Job, h *StorageHold, delay time.Duration) error {
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	op.BeginTransaction()
+	defer op.EndTransaction()
+
+	j.Status = string(JobStatusPending)
+	j.StartedAt = time.Time{}
+	j.RunAt = time.Now().Add(delay)
+	var err = j.SaveOp(op)
+	if err != nil {
+		return fmt.Errorf("saving job %d: %w", j.ID, err)
+	}
+
+	var p *Pipeline
+	p, err = findPipeline(j.PipelineID)
+	if err != nil {
+		return fmt.Errorf("looking up pipeline %d: %w", j.PipelineID, err)
+	}
+	p.StartedAt = time.Time{}
+	err = p.saveOp(op)
+	if err != nil {
+		return fmt.Errorf("saving pipeline %d: %w", p.ID, err)
+	}
+
+	// Keep the original hold time so it's clear how long a pipeline has waited
+	var existing = &StorageHold{}
+	if op.Select("storage_holds", &StorageHold{}).Where("pipeline_id = ?", p.ID).First(existing) {
+		h.ID = existing.ID
+		h.HeldAt = existing.HeldAt
+	}
+	h.PipelineID = p.ID
+	h.CheckedAt = time.Now()
+	if h.HeldAt.IsZero() {
+		h.HeldAt = h.CheckedAt
+	}
+	op.Save("storage_holds", h)
+	return op.Err()
+}
+
+// ReleaseStorageHold removes any hold on the pipeline
+func ReleaseStorageHold(pipelineID int64) error {
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	op.Exec("DELETE FROM storage_holds WHERE pipeline_id = ?", pipelineID)
+	return op.Err()
+}
+
+// FindUnstartedPipelines returns pipelines with one of the given names which
+// haven't started or finished, oldest first
+func FindUnstartedPipelines(names ...PipelineName) ([]*Pipeline, error) {
+	return findNamedPipelines(names, "started_at IS NULL AND completed_at IS NULL")
+}
+
+// FindRunningPipelines returns pipelines with one of the given names which
+// have started but not finished, oldest first. Pipelines with a job that
+// failed for good are skipped: they won't do anything more until somebody
+// steps in.
+func FindRunningPipelines(names ...PipelineName) ([]*Pipeline, error) {
+	return findNamedPipelines(names, "started_at IS NOT NULL AND completed_at IS NULL AND "+
+		"NOT EXISTS (SELECT 1 FROM jobs WHERE jobs.pipeline_id = pipelines.id AND jobs.status = ?)", JobStatusFailed)
+}
+
+// findNamedPipelines returns pipelines matching cond with one of the given
+// names, oldest first
+func findNamedPipelines(names []PipelineName, cond string, args ...any) ([]*Pipeline, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	var placeholders []string
+	for _, n := range names {
+		args = append(args, string(n))
+		placeholders = append(placeholders, "?")
+	}
+	var where = fmt.Sprintf("%s AND name IN (%s)", cond, strings.Join(placeholders, ","))
+
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	var list []*Pipeline
+	op.Select("pipelines", &Pipeline{}).Where(where, args...).Order("id").AllObjects(&list)
+	return list, op.Err()
+}
+
+// Pipeline returns the pipeline this job belongs to
+func (j *Job) Pipeline() (*Pipeline, error) {
+	return findPipeline(j.PipelineID)
+}
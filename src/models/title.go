@@ -24,6 +24,10 @@ type Title struct {
 	MARCTitle     string
 	MARCLocation  string
 	LangCode3     string
+
+	// MARCXML is the raw MARC record last retrieved or uploaded for this title,
+	// which is what we send to ONI when syncing
+	MARCXML string `sql:"marc_xml"`
 }
 
 // findTitle searches the database for a single title
@@ -40,6 +44,11 @@ func FindTitleByID(id int64) (*Title, error) {
 	return findTitle("id = ?", id)
 }
 
+// FindTitleBySFTPUser gets the title with the given SFTP username
+func FindTitleBySFTPUser(user string) (*Title, error) {
+	return findTitle("sftp_user = ?", user)
+}
+
 // FindTitleByLCCN gets a title with the given LCCN
 func FindTitleByLCCN(lccn string) (*Title, error) {
 	return findTitle("lccn = ?", lccn)
@@ -95,6 +104,19 @@ func (t *Title) SaveOp(op *magicsql.Operation) error {
 	return op.Err()
 }
 
+// BuildJob sets up a job for this title
+func (t *Title) BuildJob(jt JobType, args map[string]string) *Job {
+	var j = NewJob(jt, args)
+	j.ObjectID = t.ID
+	j.ObjectType = JobObjectTypeTitle
+	return j
+}
+
+// ONISyncs returns this title's sync records
+func (t *Title) ONISyncs() ([]*ONISync, error) {
+	return FindONISyncs(JobObjectTypeTitle, t.ID)
+}
+
 // CalculateEmbargoLiftDate returns the date an embargo will lift relative to
 // the given time (usually this would be an issue's publication date)
 func (t *Title) CalculateEmbargoLiftDate(dt time.Time) (time.Time, error) {
@@ -0,0 +1,108 @@
+package models
+
+import (
+	"time"
+
+	"github.com/Nerdmaster/magicsql"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/dbi"
+)
+
+// TitleMARCRecord is a single version of a title's MARC record. A new version
+// is stored every time a title's MARC XML changes, so we can see what changed
+// and where it came from.
+type TitleMARCRecord struct {
+	ID        int64 `sql:",primary"`
+	TitleID   int64
+	Version   int
+	MARCXML   string `sql:"marc_xml"`
+	Provider  string // The MARC provider which supplied the record, or "upload"
+	Source    string // Where the record came from: a URL, a file path, an uploaded file's name, etc.
+	UserID    int64
+	CreatedAt time.Time
+}
+
+// User returns the user who saved this version of the record
+func (r *TitleMARCRecord) User() *User {
+	return FindUserByID(r.UserID)
+}
+
+// MARCRecords returns all versions of this title's MARC record, newest first
+func (t *Title) MARCRecords() ([]*TitleMARCRecord, error) {
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	var list []*TitleMARCRecord
+	op.Select("title_marc_records", &TitleMARCRecord{}).Where("title_id = ?", t.ID).Order("version DESC").AllObjects(&list)
+	return list, op.Err()
+}
+
+// MARCRecord returns the given version of this title's MARC record, or nil if
+// there's no such version
+func (t *Title) MARCRecord(version int) (*TitleMARCRecord, error) {
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	var r = &TitleMARCRecord{}
+	var ok = op.Select("title_marc_records", &TitleMARCRecord{}).Where("title_id = ? AND version = ?", t.ID, version).First(r)
+	if !ok {
+		return nil, op.Err()
+	}
+	return r, op.Err()
+}
+
+// LatestMARCRecord returns the newest version of this title's MARC record, or
+// nil if it has none
+func (t *Title) LatestMARCRecord() (*TitleMARCRecord, error) {
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	var r = t.latestMARCRecordOp(op)
+	return r, op.Err()
+}
+
+func (t *Title) latestMARCRecordOp(op *magicsql.Operation) *TitleMARCRecord {
+	var r = &TitleMARCRecord{}
+	var ok = op.Select("title_marc_records", &TitleMARCRecord{}).Where("title_id = ?", t.ID).Order("version DESC").First(r)
+	if !ok {
+		return nil
+	}
+	return r
+}
+
+// SaveMARC sets the title's MARC XML and saves the title. If the XML differs
+// from the latest stored version, a new version is recorded with the given
+// provider, source, and user, and returned. If the XML hasn't changed, the
+// returned record is nil.
+func (t *Title) SaveMARC(xml, provider, source string, userID int64) (*TitleMARCRecord, error) {
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	op.BeginTransaction()
+	defer op.EndTransaction()
+
+	t.MARCXML = xml
+	var err = t.SaveOp(op)
+	if err != nil {
+		return nil, err
+	}
+
+	var version = 1
+	var latest = t.latestMARCRecordOp(op)
+	if latest != nil {
+		if latest.MARCXML == xml {
+			return nil, op.Err()
+		}
+		version = latest.Version + 1
+	}
+
+	var r = &TitleMARCRecord{
+		TitleID:   t.ID,
+		Version:   version,
+		MARCXML:   xml,
+		Provider:  provider,
+		Source:    source,
+		UserID:    userID,
+		CreatedAt: time.Now(),
+	}
+	op.Save("title_marc_records", r)
+	if op.Err() != nil {
+		return nil, op.Err()
+	}
+	return r, nil
+}
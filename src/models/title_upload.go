@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/dbi"
+)
+
+// TitleUpload records a single file a publisher sent us, as reported by
+// SFTPGo, so we can tell when we last received anything for a title
+type TitleUpload struct {
+	ID         int64 `sql:",primary"`
+	TitleID    int64
+	Path       string // The file's path as the publisher sees it
+	Size       int64
+	Protocol   string
+	ReceivedAt time.Time
+}
+
+// Save stores the upload record
+func (u *TitleUpload) Save() error {
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	op.Save("title_uploads", u)
+	return op.Err()
+}
+
+// RecentUploads returns up to limit of this title's most recent uploads,
+// newest first
+func (t *Title) RecentUploads(limit int) ([]*TitleUpload, error) {
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	var list []*TitleUpload
+	op.Select("title_uploads", &TitleUpload{}).Where("title_id = ?", t.ID).Order("received_at DESC, id DESC").Limit(uint64(limit)).AllObjects(&list)
+	return list, op.Err()
+}
+
+// LastUploadTimes returns the time of each title's most recent upload, keyed
+// by title id. Titles which have never had an upload reported aren't
+// included.
+func LastUploadTimes() (map[int64]time.Time, error) {
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+
+	var rows = op.Query("SELECT title_id, MAX(received_at) FROM title_uploads GROUP BY title_id")
+	defer rows.Close()
+
+	var times = make(map[int64]time.Time)
+	for rows.Next() {
+		var id int64
+		var t time.Time
+		rows.Scan(&id, &t)
+		times[id] = t
+	}
+
+	return times, op.Err()
+}
@@ -17,6 +17,7 @@ type User struct {
 	Guest       bool   `sql:"-"`
 	IP          string `sql:"-"`
 	Deactivated bool
+	AutoAssign  bool // Should the workload balancer hand this user issues?
 
 	// realRoles is the actual list of roles a user has based on RolesString
 	realRoles *privilege.RoleSet
@@ -143,6 +144,19 @@ func InactiveUsers() ([]*User, error) {
 	return users, op.Err()
 }
 
+// AutoAssignUsers returns all active users who have opted into automatic
+// issue assignment
+func AutoAssignUsers() ([]*User, error) {
+	var users []*User
+	var op = dbi.DB.Operation()
+	op.Select("users", &User{}).Where("deactivated = ? AND auto_assign = ?", false, true).AllObjects(&users)
+
+	for _, u := range users {
+		u.deserialize()
+	}
+	return users, op.Err()
+}
+
 // FindActiveUserWithLogin looks for a user whose login name is the given string.
 // Deactivated users need not apply.
 func FindActiveUserWithLogin(l string) *User {
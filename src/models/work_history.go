@@ -0,0 +1,48 @@
+package models
+
+import (
+	"fmt"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/dbi"
+)
+
+// WorkHistory maps user ids to the number of issues, per LCCN, each user has
+// worked on
+type WorkHistory map[int64]map[string]int
+
+// workHistory counts issues per user and LCCN using the given user-id column.
+// Ignored issues are included, as issues which have gone live still tell us
+// who is familiar with a title.
+func workHistory(column string) (WorkHistory, error) {
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+
+	var sql = fmt.Sprintf("SELECT %[1]s, lccn, COUNT(*) FROM issues WHERE %[1]s > 0 GROUP BY %[1]s, lccn", column)
+	var rows = op.Query(sql)
+	defer rows.Close()
+
+	var h = make(WorkHistory)
+	for rows.Next() {
+		var userID int64
+		var lccn string
+		var count int
+		rows.Scan(&userID, &lccn, &count)
+		if h[userID] == nil {
+			h[userID] = make(map[string]int)
+		}
+		h[userID][lccn] = count
+	}
+
+	return h, op.Err()
+}
+
+// CurationHistory returns the number of issues per title each user has
+// entered metadata for
+func CurationHistory() (WorkHistory, error) {
+	return workHistory("metadata_entry_user_id")
+}
+
+// ReviewHistory returns the number of issues per title each user has reviewed
+func ReviewHistory() (WorkHistory, error) {
+	return workHistory("reviewed_by_user_id")
+}
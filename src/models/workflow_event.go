@@ -0,0 +1,159 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/dbi"
+)
+
+// DerivativesCreatedMessage is the action message recorded when an issue's
+// derivatives are done and it's ready for metadata entry. Reports rely on
+// this to know when an issue first became available to curators.
+const DerivativesCreatedMessage = "Created issue derivatives"
+
+// WorkflowEvent is a trimmed-down issue action used for reporting: when a
+// person (or the system) moved an issue from one manual workflow step to
+// another
+type WorkflowEvent struct {
+	IssueID   int64
+	LCCN      string
+	PageCount int
+	Type      ActionType
+	UserID    int64
+	When      time.Time
+}
+
+// FindWorkflowEvents returns all curation and review events recorded before
+// the given time, ordered by issue and then time
+func FindWorkflowEvents(before time.Time) ([]*WorkflowEvent, error) {
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+
+	var rows = op.Query(`
+		SELECT a.object_id, i.lccn, i.page_count, a.action_type, a.user_id, a.created_at
+		FROM actions a
+		JOIN issues i ON i.id = a.object_id
+		WHERE a.object_type = ? AND a.created_at < ? AND (
+			a.action_type IN (?, ?, ?, ?, ?) OR (a.action_type = ? AND a.message = ?)
+		)
+		ORDER BY a.object_id, a.created_at, a.id`,
+		actionObjectTypeIssue, before,
+		ActionTypeMetadataEntry, ActionTypeMetadataApproval, ActionTypeMetadataRejection,
+		ActionTypeReturnCurate, ActionTypeReturnReview,
+		ActionTypeInternalProcess, DerivativesCreatedMessage,
+	)
+	defer rows.Close()
+
+	var list []*WorkflowEvent
+	for rows.Next() {
+		var e = &WorkflowEvent{}
+		var aType string
+		rows.Scan(&e.IssueID, &e.LCCN, &e.PageCount, &aType, &e.UserID, &e.When)
+		e.Type = ActionType(aType)
+		list = append(list, e)
+	}
+
+	return list, op.Err()
+}
+
+// workflowAuditActions maps the audit log actions which mirror curation and
+// review to the equivalent issue action
+var workflowAuditActions = map[string]ActionType{
+	dbAuditActions[AuditActionQueueForReview]:  ActionTypeMetadataEntry,
+	dbAuditActions[AuditActionApproveMetadata]: ActionTypeMetadataApproval,
+	dbAuditActions[AuditActionRejectMetadata]:  ActionTypeMetadataRejection,
+}
+
+// FindAuditWorkflowEvents returns curation and review events recorded in the
+// audit logs before the given time. These largely duplicate issue actions, but
+// they can fill gaps where an action was never written or was purged.
+//
+// Events are in no particular order, and any audit log which can't be tied to
+// an existing issue and user is skipped.
+func FindAuditWorkflowEvents(before time.Time) ([]*WorkflowEvent, error) {
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+
+	var users = make(map[string]int64)
+	var rows = op.Query("SELECT id, login FROM users")
+	for rows.Next() {
+		var id int64
+		var login string
+		rows.Scan(&id, &login)
+		users[login] = id
+	}
+	rows.Close()
+
+	var issues = make(map[int64]*WorkflowEvent)
+	rows = op.Query("SELECT id, lccn, page_count FROM issues")
+	for rows.Next() {
+		var e = &WorkflowEvent{}
+		rows.Scan(&e.IssueID, &e.LCCN, &e.PageCount)
+		issues[e.IssueID] = e
+	}
+	rows.Close()
+
+	var list []*WorkflowEvent
+	rows = op.Query("SELECT `when`, user, action, message FROM audit_logs WHERE action IN (?, ?, ?) AND `when` < ?",
+		dbAuditActions[AuditActionQueueForReview], dbAuditActions[AuditActionApproveMetadata],
+		dbAuditActions[AuditActionRejectMetadata], before)
+	defer rows.Close()
+	for rows.Next() {
+		var when time.Time
+		var login, action, message string
+		rows.Scan(&when, &login, &action, &message)
+
+		var id int64
+		var _, err = fmt.Sscanf(message, "issue id %d", &id)
+		var i = issues[id]
+		if err != nil || i == nil || users[login] == 0 {
+			continue
+		}
+		list = append(list, &WorkflowEvent{
+			IssueID:   i.IssueID,
+			LCCN:      i.LCCN,
+			PageCount: i.PageCount,
+			Type:      workflowAuditActions[action],
+			UserID:    users[login],
+			When:      when,
+		})
+	}
+
+	return list, op.Err()
+}
+
+// FindIssueWorkflowEvents returns the most recent metadata entry and approval
+// recorded on each issue itself, for issues whose history predates the action
+// log. Events are in no particular order.
+func FindIssueWorkflowEvents(before time.Time) ([]*WorkflowEvent, error) {
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+
+	var rows = op.Query(`
+		SELECT id, lccn, page_count, metadata_entry_user_id, metadata_entered_at, reviewed_by_user_id, metadata_approved_at
+		FROM issues
+		WHERE (metadata_entry_user_id > 0 AND metadata_entered_at < ?) OR (reviewed_by_user_id > 0 AND metadata_approved_at < ?)`,
+		before, before,
+	)
+	defer rows.Close()
+
+	var list []*WorkflowEvent
+	for rows.Next() {
+		var id, curator, reviewer int64
+		var lccn string
+		var pages int
+		var entered, approved sql.NullTime
+		rows.Scan(&id, &lccn, &pages, &curator, &entered, &reviewer, &approved)
+
+		if curator > 0 && entered.Valid && entered.Time.Before(before) {
+			list = append(list, &WorkflowEvent{IssueID: id, LCCN: lccn, PageCount: pages, Type: ActionTypeMetadataEntry, UserID: curator, When: entered.Time})
+		}
+		if reviewer > 0 && approved.Valid && approved.Time.Before(before) {
+			list = append(list, &WorkflowEvent{IssueID: id, LCCN: lccn, PageCount: pages, Type: ActionTypeMetadataApproval, UserID: reviewer, When: approved.Time})
+		}
+	}
+
+	return list, op.Err()
+}
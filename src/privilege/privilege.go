@@ -15,6 +15,10 @@ var (
 	ReviewIssueMetadata   = newPrivilege(RoleIssueReviewer, RoleIssueManager)
 	ReviewOwnMetadata     = newPrivilege(RoleIssueManager)
 	ReviewUnfixableIssues = newPrivilege(RoleIssueManager)
+	ViewWorkQueues        = newPrivilege(RoleIssueManager)
+
+	// Curation / review productivity reporting
+	ViewProductivityReports = newPrivilege(RoleIssueManager)
 
 	// User management
 	ListUsers   = newPrivilege(RoleUserManager)
@@ -24,6 +28,11 @@ var (
 	ViewUploadedIssues   = newPrivilege(RoleWorkflowManager)
 	ModifyUploadedIssues = newPrivilege(RoleWorkflowManager)
 
+	// Upload born-digital PDFs through the publisher portal. Publishers only
+	// see their own titles; workflow managers can upload on behalf of any
+	// publisher.
+	UploadPublisherIssues = newPrivilege(RolePublisher, RoleWorkflowManager)
+
 	// Search for issues across all locations (NCA workflow, uploads, and in
 	// production). No need to restrict this as it's just an informational
 	// display at the moment.
@@ -44,6 +53,19 @@ var (
 	// Flag batches as archived and ready to begin the deletion countdown
 	ArchiveBatches = newPrivilege(RoleBatchLoader)
 
+	// Compare NCA's batches to ONI's and requeue loads or purges to fix them
+	ReconcileBatches = newPrivilege(RoleBatchLoader)
+
+	// Pull a live batch's issues back for fixes and rebuild it as a new version
+	CorrectLiveBatches = newPrivilege(RoleBatchLoader)
+
+	// Take apart a batch which hasn't passed QC, sending its issues back to
+	// the batching queue
+	UnbatchBatches = newPrivilege(RoleBatchLoader)
+
+	// See free space, forecasts, and pipelines held for lack of space
+	ViewStorage = newPrivilege(RoleWorkflowManager, RoleBatchLoader)
+
 	// Site managers only
 	ListAuditLogs = newPrivilege(RoleSiteManager)
 
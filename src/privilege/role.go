@@ -65,6 +65,8 @@ var (
 	RoleBatchReviewer   = newRole("batch reviewer",
 		"Can view, reject, and approve batches which NCA has built but which are not yet in production.")
 	RoleBatchLoader = newRole("batch loader", "Can flag batches for archive as well as manually load and purge batches (NCA doesn't use this, but it may be useful to know).")
+	RolePublisher   = newRole("publisher", `Can upload born-digital PDFs through the publisher portal for
+		titles whose SFTP username matches the publisher's login`)
 )
 
 // roles is our internal map of string to Role object
@@ -138,6 +140,7 @@ func AssignableRoles() *RoleSet {
 		RoleBatchBuilder,
 		RoleBatchReviewer,
 		RoleBatchLoader,
+		RolePublisher,
 	)
 }
 
@@ -0,0 +1,87 @@
+package provenance
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// csvHeaders are the columns of the CSV export, one row per issue
+var csvHeaders = []string{
+	"Batch", "Issue", "LCCN", "Title", "Date", "Edition", "Volume", "Number", "Pages", "Source",
+	"Curated By", "Curated At", "Reviewed By", "Reviewed At",
+	"JP2 Source", "JP2 DPI", "JP2 Quality", "ALTO DPI", "History",
+}
+
+// WriteCSV writes one row per issue, suitable for a spreadsheet. Each issue's
+// action history is written to its last column, one action per line.
+func (p *Package) WriteCSV(w io.Writer) error {
+	var cw = csv.NewWriter(w)
+	_ = cw.Write(csvHeaders)
+	for _, i := range p.Issues {
+		var d = i.Derivatives.fields()
+		_ = cw.Write([]string{
+			p.Batch, i.Key, i.LCCN, i.Title, i.Date, strconv.Itoa(i.Edition), i.Volume, i.Number,
+			strconv.Itoa(i.Pages), i.Source,
+			i.CuratedBy, timestamp(i.CuratedAt), i.ReviewedBy, timestamp(i.ReviewedAt),
+			d.source, d.dpi, d.quality, d.altoDPI,
+			history(i.History),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSON writes the full package as indented JSON
+func (p *Package) WriteJSON(w io.Writer) error {
+	var enc = json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(p)
+}
+
+// derivativeFields holds derivative settings formatted for CSV and PREMIS
+type derivativeFields struct {
+	source, dpi, quality, altoDPI string
+}
+
+// fields formats the derivative settings for export, reporting each as
+// "unknown" if the settings were never recorded
+func (d *Derivatives) fields() derivativeFields {
+	if d == nil {
+		return derivativeFields{"unknown", "unknown", "unknown", "unknown"}
+	}
+	return derivativeFields{
+		source:  d.JP2Source,
+		dpi:     strconv.Itoa(d.JP2DPI),
+		quality: strconv.FormatFloat(d.JP2Quality, 'f', -1, 64),
+		altoDPI: strconv.Itoa(d.ALTODPI),
+	}
+}
+
+// timestamp formats an optional time for the CSV, leaving unset times blank
+func timestamp(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// history flattens events into one line each for the CSV
+func history(events []*Event) string {
+	var lines = make([]string, len(events))
+	for idx, e := range events {
+		var line = e.When.Format(time.RFC3339) + " "
+		if e.Agent != "" {
+			line += e.Agent + " "
+		}
+		line += e.Description
+		if e.Message != "" {
+			line += ": " + e.Message
+		}
+		lines[idx] = line
+	}
+	return strings.Join(lines, "\n")
+}
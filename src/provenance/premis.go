@@ -0,0 +1,202 @@
+package provenance
+
+import (
+	"encoding/xml"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+)
+
+// Identifier types used in the PREMIS export
+const (
+	premisBatchID  = "NCA batch name"
+	premisIssueID  = "NCA issue key"
+	premisActionID = "NCA action id"
+	premisAgentID  = "NCA user login"
+)
+
+// premisDoc is a PREMIS 3 document: the batch and its issues are
+// intellectual entities, each action is an event, and each person (or NCA
+// itself) who took an action is an agent
+type premisDoc struct {
+	XMLName        xml.Name        `xml:"premis"`
+	XMLNS          string          `xml:"xmlns,attr"`
+	XSI            string          `xml:"xmlns:xsi,attr"`
+	SchemaLocation string          `xml:"xsi:schemaLocation,attr"`
+	Version        string          `xml:"version,attr"`
+	Objects        []*premisObject `xml:"object"`
+	Events         []*premisEvent  `xml:"event"`
+	Agents         []*premisAgent  `xml:"agent"`
+}
+
+type premisObject struct {
+	Type          string                `xml:"xsi:type,attr"`
+	IDType        string                `xml:"objectIdentifier>objectIdentifierType"`
+	IDValue       string                `xml:"objectIdentifier>objectIdentifierValue"`
+	Properties    []*premisProperty     `xml:"significantProperties"`
+	Relationships []*premisRelationship `xml:"relationship"`
+}
+
+type premisProperty struct {
+	Type  string `xml:"significantPropertiesType"`
+	Value string `xml:"significantPropertiesValue"`
+}
+
+type premisRelationship struct {
+	Type       string `xml:"relationshipType"`
+	SubType    string `xml:"relationshipSubType"`
+	RelIDType  string `xml:"relatedObjectIdentifier>relatedObjectIdentifierType"`
+	RelIDValue string `xml:"relatedObjectIdentifier>relatedObjectIdentifierValue"`
+}
+
+type premisEvent struct {
+	IDType     string              `xml:"eventIdentifier>eventIdentifierType"`
+	IDValue    string              `xml:"eventIdentifier>eventIdentifierValue"`
+	Type       string              `xml:"eventType"`
+	DateTime   string              `xml:"eventDateTime"`
+	Detail     string              `xml:"eventDetailInformation>eventDetail"`
+	Agent      *premisLinkingAgent `xml:"linkingAgentIdentifier,omitempty"`
+	ObjIDType  string              `xml:"linkingObjectIdentifier>linkingObjectIdentifierType"`
+	ObjIDValue string              `xml:"linkingObjectIdentifier>linkingObjectIdentifierValue"`
+}
+
+type premisLinkingAgent struct {
+	IDType  string `xml:"linkingAgentIdentifierType"`
+	IDValue string `xml:"linkingAgentIdentifierValue"`
+	Role    string `xml:"linkingAgentRole"`
+}
+
+type premisAgent struct {
+	IDType  string `xml:"agentIdentifier>agentIdentifierType"`
+	IDValue string `xml:"agentIdentifier>agentIdentifierValue"`
+	Name    string `xml:"agentName"`
+	Type    string `xml:"agentType"`
+	Version string `xml:"agentVersion,omitempty"`
+}
+
+// WritePREMIS writes the package as a PREMIS 3 XML document
+func (p *Package) WritePREMIS(w io.Writer) error {
+	var doc = &premisDoc{
+		XMLNS:          "http://www.loc.gov/premis/v3",
+		XSI:            "http://www.w3.org/2001/XMLSchema-instance",
+		SchemaLocation: "http://www.loc.gov/premis/v3 https://www.loc.gov/standards/premis/premis.xsd",
+		Version:        "3.0",
+	}
+
+	var batch = &premisObject{
+		Type:    "intellectualEntity",
+		IDType:  premisBatchID,
+		IDValue: p.Batch,
+		Properties: props(
+			"MARC org code", p.MARCOrgCode,
+			"status", p.Status,
+			"created", p.CreatedAt.Format(time.RFC3339),
+			"went live", timestamp(p.WentLiveAt),
+		),
+	}
+	doc.Objects = append(doc.Objects, batch)
+	doc.Events = append(doc.Events, premisEvents(p.History, premisBatchID, p.Batch)...)
+
+	for _, i := range p.Issues {
+		batch.Relationships = append(batch.Relationships, &premisRelationship{
+			Type:       "structural",
+			SubType:    "includes",
+			RelIDType:  premisIssueID,
+			RelIDValue: i.Key,
+		})
+
+		var d = i.Derivatives.fields()
+		doc.Objects = append(doc.Objects, &premisObject{
+			Type:    "intellectualEntity",
+			IDType:  premisIssueID,
+			IDValue: i.Key,
+			Properties: props(
+				"title", i.Title,
+				"LCCN", i.LCCN,
+				"date", i.Date,
+				"edition", strconv.Itoa(i.Edition),
+				"volume", i.Volume,
+				"number", i.Number,
+				"pages", strconv.Itoa(i.Pages),
+				"source", i.Source,
+				"curated by", i.CuratedBy,
+				"curated at", timestamp(i.CuratedAt),
+				"reviewed by", i.ReviewedBy,
+				"reviewed at", timestamp(i.ReviewedAt),
+				"JP2 source", d.source,
+				"JP2 DPI", d.dpi,
+				"JP2 quality", d.quality,
+				"ALTO DPI", d.altoDPI,
+			),
+			Relationships: []*premisRelationship{{
+				Type:       "structural",
+				SubType:    "is included in",
+				RelIDType:  premisBatchID,
+				RelIDValue: p.Batch,
+			}},
+		})
+		doc.Events = append(doc.Events, premisEvents(i.History, premisIssueID, i.Key)...)
+	}
+
+	for _, login := range p.Agents() {
+		var a = &premisAgent{IDType: premisAgentID, IDValue: login, Name: login, Type: "person"}
+		if login == models.SystemUser.Login {
+			a.Name = "Newspaper Curation App"
+			a.Type = "software"
+			a.Version = p.NCAVersion
+		}
+		doc.Agents = append(doc.Agents, a)
+	}
+
+	var _, err = io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+	var enc = xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	err = enc.Encode(doc)
+	if err == nil {
+		_, err = io.WriteString(w, "\n")
+	}
+	return err
+}
+
+// props builds significant properties from type/value pairs, skipping any
+// with no value
+func props(pairs ...string) []*premisProperty {
+	var list []*premisProperty
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if pairs[i+1] != "" {
+			list = append(list, &premisProperty{Type: pairs[i], Value: pairs[i+1]})
+		}
+	}
+	return list
+}
+
+// premisEvents converts events on a single object to PREMIS events linked to
+// that object
+func premisEvents(events []*Event, objIDType, objIDValue string) []*premisEvent {
+	var list = make([]*premisEvent, len(events))
+	for idx, e := range events {
+		var detail = e.Description
+		if e.Message != "" {
+			detail += ": " + e.Message
+		}
+		var pe = &premisEvent{
+			IDType:     premisActionID,
+			IDValue:    strconv.FormatInt(e.ID, 10),
+			Type:       e.Type,
+			DateTime:   e.When.Format(time.RFC3339),
+			Detail:     detail,
+			ObjIDType:  objIDType,
+			ObjIDValue: objIDValue,
+		}
+		if e.Agent != "" {
+			pe.Agent = &premisLinkingAgent{IDType: premisAgentID, IDValue: e.Agent, Role: "implementer"}
+		}
+		list[idx] = pe
+	}
+	return list
+}
@@ -0,0 +1,227 @@
+// Package provenance assembles a batch's provenance package for handing the
+// batch off to the Library of Congress or a partner: every issue with its
+// source, who curated and reviewed it and when, the derivative settings used,
+// and the full action history of the issues and the batch itself.
+//
+// Derivative settings come from the action NCA records when it generates an
+// issue's derivatives. Issues processed before NCA recorded these have no
+// settings, and exports report them as unknown.
+package provenance
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/version"
+)
+
+// Issue sources
+const (
+	SourceBornDigital = "born-digital"
+	SourceScanned     = "scanned"
+)
+
+// Package is the full provenance record for a batch
+type Package struct {
+	Batch       string     `json:"batch"`
+	MARCOrgCode string     `json:"marc_org_code"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+	WentLiveAt  *time.Time `json:"went_live_at,omitempty"`
+	ExportedAt  time.Time  `json:"exported_at"`
+	NCAVersion  string     `json:"nca_version"`
+	Issues      []*Issue   `json:"issues"`
+	History     []*Event   `json:"history"`
+}
+
+// Issue is a single issue's provenance
+type Issue struct {
+	Key         string       `json:"key"`
+	LCCN        string       `json:"lccn"`
+	Title       string       `json:"title"`
+	Date        string       `json:"date"`
+	Edition     int          `json:"edition"`
+	Volume      string       `json:"volume,omitempty"`
+	Number      string       `json:"number,omitempty"`
+	Pages       int          `json:"pages"`
+	Source      string       `json:"source"`
+	CuratedBy   string       `json:"curated_by,omitempty"`
+	CuratedAt   *time.Time   `json:"curated_at,omitempty"`
+	ReviewedBy  string       `json:"reviewed_by,omitempty"`
+	ReviewedAt  *time.Time   `json:"reviewed_at,omitempty"`
+	Derivatives *Derivatives `json:"derivatives"`
+	History     []*Event     `json:"history"`
+}
+
+// Derivatives describes the settings used to generate an issue's JP2 and
+// ALTO XML derivatives. An issue's Derivatives are nil if the settings were
+// never recorded.
+type Derivatives struct {
+	JP2Source  string  `json:"jp2_source"`
+	JP2DPI     int     `json:"jp2_dpi"`
+	JP2Quality float64 `json:"jp2_quality"`
+	ALTODPI    int     `json:"alto_dpi"`
+}
+
+// Event is a single action taken on an issue or batch
+type Event struct {
+	ID          int64     `json:"id"`
+	When        time.Time `json:"when"`
+	Type        string    `json:"type"`
+	Description string    `json:"description"`
+	Agent       string    `json:"agent,omitempty"`
+	Message     string    `json:"message,omitempty"`
+}
+
+// loginFunc returns the login of the user with the given id, or an empty
+// string if there's no user
+type loginFunc func(id int64) string
+
+// New reads the batch, its issues, and all their actions to build the
+// batch's provenance package
+func New(b *models.Batch, now time.Time) (*Package, error) {
+	var issues, err = b.Issues()
+	if err != nil {
+		return nil, fmt.Errorf("reading issues for %s: %w", b.FullName, err)
+	}
+	var actions []*models.Action
+	actions, err = b.ActivityLog()
+	if err != nil {
+		return nil, fmt.Errorf("reading actions for %s: %w", b.FullName, err)
+	}
+
+	var login = userLogins()
+	var p = &Package{
+		Batch:       b.FullName,
+		MARCOrgCode: b.MARCOrgCode,
+		Status:      b.Status,
+		CreatedAt:   b.CreatedAt,
+		WentLiveAt:  optTime(b.WentLiveAt),
+		ExportedAt:  now,
+		NCAVersion:  version.Version,
+		History:     newEvents(actions, login),
+	}
+
+	sort.Slice(issues, func(i, j int) bool {
+		return issues[i].Key() < issues[j].Key()
+	})
+	for _, i := range issues {
+		var list []*models.Action
+		list, err = models.FindActionsForIssue(i.ID)
+		if err != nil {
+			return nil, fmt.Errorf("reading actions for issue %s: %w", i.Key(), err)
+		}
+		var settings *models.DerivativeSettings
+		settings, err = models.FindDerivativeSettings(i.ID)
+		if err != nil {
+			return nil, fmt.Errorf("reading derivative settings for issue %s: %w", i.Key(), err)
+		}
+		p.Issues = append(p.Issues, newIssue(i, list, settings, login))
+	}
+
+	return p, nil
+}
+
+// userLogins returns a loginFunc which caches users so a batch's worth of
+// actions doesn't look up the same few people over and over
+func userLogins() loginFunc {
+	var logins = make(map[int64]string)
+	return func(id int64) string {
+		if id == 0 {
+			return ""
+		}
+		var login, ok = logins[id]
+		if !ok {
+			login = models.FindUserByID(id).Login
+			logins[id] = login
+		}
+		return login
+	}
+}
+
+// newIssue builds an issue's provenance from its data, actions, and the
+// settings its derivatives were generated with, if they were recorded
+func newIssue(i *models.Issue, actions []*models.Action, settings *models.DerivativeSettings, login loginFunc) *Issue {
+	var pi = &Issue{
+		Key:         i.Key(),
+		LCCN:        i.LCCN,
+		Date:        i.Date,
+		Edition:     i.Edition,
+		Volume:      i.Volume,
+		Number:      i.Issue,
+		Pages:       i.PageCount,
+		Source:      SourceBornDigital,
+		CuratedBy:   login(i.MetadataEntryUserID),
+		CuratedAt:   optTime(i.MetadataEnteredAt),
+		ReviewedBy:  login(i.ReviewedByUserID),
+		ReviewedAt:  optTime(i.MetadataApprovedAt),
+		Derivatives: derivatives(settings),
+		History:     newEvents(actions, login),
+	}
+	if i.Title != nil {
+		pi.Title = i.Title.MARCTitle
+	}
+	if i.IsFromScanner {
+		pi.Source = SourceScanned
+	}
+	return pi
+}
+
+// derivatives converts recorded derivative settings for export, returning nil
+// if none were recorded
+func derivatives(d *models.DerivativeSettings) *Derivatives {
+	if d == nil {
+		return nil
+	}
+	return &Derivatives{JP2Source: d.JP2Source, JP2DPI: d.JP2DPI, JP2Quality: d.JP2Quality, ALTODPI: d.ALTODPI}
+}
+
+func newEvents(actions []*models.Action, login loginFunc) []*Event {
+	var list = make([]*Event, len(actions))
+	for idx, a := range actions {
+		list[idx] = &Event{
+			ID:          a.ID,
+			When:        a.CreatedAt,
+			Type:        a.ActionType,
+			Description: a.Type().Describe(),
+			Agent:       login(a.UserID),
+			Message:     a.Message,
+		}
+	}
+	return list
+}
+
+// optTime returns nil for a zero time so unset dates are left out of exports
+// rather than showing up as the year 1
+func optTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// Agents returns every agent which took part in the package's events, sorted
+// by login
+func (p *Package) Agents() []string {
+	var seen = make(map[string]bool)
+	var add = func(events []*Event) {
+		for _, e := range events {
+			if e.Agent != "" {
+				seen[e.Agent] = true
+			}
+		}
+	}
+	add(p.History)
+	for _, i := range p.Issues {
+		add(i.History)
+	}
+
+	var list []string
+	for agent := range seen {
+		list = append(list, agent)
+	}
+	sort.Strings(list)
+	return list
+}
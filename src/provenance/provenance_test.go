@@ -0,0 +1,190 @@
+package provenance
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+)
+
+var when = time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+func testLogin(id int64) string {
+	switch id {
+	case 0:
+		return ""
+	case models.SystemUser.ID:
+		return models.SystemUser.Login
+	}
+	return fmt.Sprintf("user%d", id)
+}
+
+func testPackage() *Package {
+	var born = &models.Issue{
+		LCCN: "sn12345678", Date: "2020-01-02", Edition: 1, PageCount: 8,
+		MetadataEntryUserID: 1, MetadataEnteredAt: when, ReviewedByUserID: 2, MetadataApprovedAt: when.Add(time.Hour),
+	}
+	var scanned = &models.Issue{LCCN: "sn12345678", Date: "2020-01-03", Edition: 1, PageCount: 4, IsFromScanner: true}
+	var actions = []*models.Action{
+		{ID: 10, CreatedAt: when, ActionType: string(models.ActionTypeMetadataEntry), UserID: 1},
+		{ID: 11, CreatedAt: when.Add(time.Hour), ActionType: string(models.ActionTypeMetadataApproval), UserID: 2, Message: "looks good"},
+	}
+	var settings = &models.DerivativeSettings{JP2Source: "tiff", JP2DPI: 150, JP2Quality: 75, ALTODPI: 400}
+	var scannedActions = []*models.Action{
+		{ID: 12, CreatedAt: when, ActionType: string(models.ActionTypeInternalProcess), UserID: models.SystemUser.ID, Message: settings.Message()},
+	}
+
+	return &Package{
+		Batch:      "batch_oru_test_ver01",
+		Status:     models.BatchStatusQCReady,
+		CreatedAt:  when,
+		ExportedAt: when,
+		Issues: []*Issue{
+			newIssue(born, actions, nil, testLogin),
+			newIssue(scanned, scannedActions, settings, testLogin),
+		},
+		History: []*Event{{ID: 20, When: when, Type: string(models.ActionTypeInternalProcess), Description: "made", Agent: models.SystemUser.Login}},
+	}
+}
+
+func TestNewIssue(t *testing.T) {
+	var p = testPackage()
+	var born, scanned = p.Issues[0], p.Issues[1]
+
+	if born.Source != SourceBornDigital || scanned.Source != SourceScanned {
+		t.Errorf("Expected sources %q and %q, got %q and %q", SourceBornDigital, SourceScanned, born.Source, scanned.Source)
+	}
+	if born.Derivatives != nil {
+		t.Errorf("Expected no derivative settings for the born-digital issue, got %#v", born.Derivatives)
+	}
+	if *scanned.Derivatives != (Derivatives{JP2Source: "tiff", JP2DPI: 150, JP2Quality: 75, ALTODPI: 400}) {
+		t.Errorf("Unexpected scanned derivative settings: %#v", scanned.Derivatives)
+	}
+	if born.CuratedBy != "user1" || born.ReviewedBy != "user2" || born.CuratedAt == nil || !born.ReviewedAt.Equal(when.Add(time.Hour)) {
+		t.Errorf("Unexpected curation data: %q at %v, %q at %v", born.CuratedBy, born.CuratedAt, born.ReviewedBy, born.ReviewedAt)
+	}
+	if scanned.CuratedBy != "" || scanned.CuratedAt != nil {
+		t.Errorf("Expected no curation data for scanned issue, got %q at %v", scanned.CuratedBy, scanned.CuratedAt)
+	}
+	if len(born.History) != 2 || born.History[1].Description != models.ActionTypeMetadataApproval.Describe() {
+		t.Errorf("Unexpected history: %#v", born.History)
+	}
+
+	var agents = strings.Join(p.Agents(), ",")
+	if agents != models.SystemUser.Login+",user1,user2" {
+		t.Errorf("Unexpected agents: %q", agents)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	var err = testPackage().WriteCSV(&buf)
+	if err != nil {
+		t.Fatalf("Unable to write CSV: %s", err)
+	}
+
+	var rows [][]string
+	rows, err = csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("Unable to read CSV: %s", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("Expected a header and 2 issues, got %d rows", len(rows))
+	}
+	if len(rows[1]) != len(csvHeaders) {
+		t.Fatalf("Expected %d columns, got %d", len(csvHeaders), len(rows[1]))
+	}
+
+	var got = make(map[string]string)
+	for i, h := range csvHeaders {
+		got[h] = rows[1][i]
+	}
+	var want = map[string]string{
+		"Batch":       "batch_oru_test_ver01",
+		"Issue":       "sn12345678/2020010201",
+		"Pages":       "8",
+		"Source":      SourceBornDigital,
+		"Curated By":  "user1",
+		"Curated At":  "2026-10-01T12:00:00Z",
+		"JP2 Source":  "unknown",
+		"JP2 Quality": "unknown",
+		"ALTO DPI":    "unknown",
+		"History": "2026-10-01T12:00:00Z user1 " + models.ActionTypeMetadataEntry.Describe() + "\n" +
+			"2026-10-01T13:00:00Z user2 " + models.ActionTypeMetadataApproval.Describe() + ": looks good",
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("Expected %q to be %q, got %q", k, v, got[k])
+		}
+	}
+	if rows[2][11] != "" {
+		t.Errorf("Expected no curation date for the scanned issue, got %q", rows[2][11])
+	}
+	if rows[2][14] != "tiff" || rows[2][17] != "400" {
+		t.Errorf("Expected the scanned issue's recorded settings, got %q", rows[2][14:18])
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	var err = testPackage().WriteJSON(&buf)
+	if err != nil {
+		t.Fatalf("Unable to write JSON: %s", err)
+	}
+	if strings.Contains(buf.String(), "0001-01-01") {
+		t.Errorf("Expected unset times to be left out, got %s", buf.String())
+	}
+
+	var p Package
+	err = json.Unmarshal(buf.Bytes(), &p)
+	if err != nil {
+		t.Fatalf("Unable to read JSON: %s", err)
+	}
+	if len(p.Issues) != 2 || p.Issues[0].History[1].Message != "looks good" || p.Issues[1].Derivatives.ALTODPI != 400 {
+		t.Errorf("JSON didn't round-trip: %s", buf.String())
+	}
+}
+
+func TestWritePREMIS(t *testing.T) {
+	var buf bytes.Buffer
+	var err = testPackage().WritePREMIS(&buf)
+	if err != nil {
+		t.Fatalf("Unable to write PREMIS: %s", err)
+	}
+
+	var doc struct {
+		Objects []struct {
+			Type string `xml:"type,attr"`
+			ID   string `xml:"objectIdentifier>objectIdentifierValue"`
+		} `xml:"object"`
+		Events []struct {
+			ID     string `xml:"eventIdentifier>eventIdentifierValue"`
+			Agent  string `xml:"linkingAgentIdentifier>linkingAgentIdentifierValue"`
+			Object string `xml:"linkingObjectIdentifier>linkingObjectIdentifierValue"`
+		} `xml:"event"`
+		Agents []struct {
+			Name string `xml:"agentName"`
+			Type string `xml:"agentType"`
+		} `xml:"agent"`
+	}
+	err = xml.Unmarshal(buf.Bytes(), &doc)
+	if err != nil {
+		t.Fatalf("Unable to read PREMIS: %s", err)
+	}
+
+	if len(doc.Objects) != 3 || doc.Objects[0].ID != "batch_oru_test_ver01" || doc.Objects[0].Type != "intellectualEntity" {
+		t.Errorf("Unexpected objects: %#v", doc.Objects)
+	}
+	if len(doc.Events) != 4 || doc.Events[0].ID != "20" || doc.Events[2].Agent != "user2" || doc.Events[2].Object != "sn12345678/2020010201" {
+		t.Errorf("Unexpected events: %#v", doc.Events)
+	}
+	if len(doc.Agents) != 3 || doc.Agents[0].Type != "software" || doc.Agents[1].Type != "person" {
+		t.Errorf("Unexpected agents: %#v", doc.Agents)
+	}
+}
@@ -0,0 +1,178 @@
+// Package qcsample picks a reproducible, risk-weighted random sample of a
+// batch's issues and pages so QC staff can focus on the pages most likely to
+// have problems when a batch is too big to review in full.
+package qcsample
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sort"
+)
+
+// Words below which a page's OCR is always considered sparse, and the
+// fraction of the batch's median words per page below which it's considered
+// sparse compared to its peers
+const (
+	lowWordFloor    = 20
+	lowDensityRatio = 0.25
+)
+
+// Page is a single page of an issue and the number of OCR words found on it.
+// Words is negative if the page's OCR couldn't be read.
+type Page struct {
+	Sequence int
+	Words    int
+}
+
+// Issue is a candidate for the sample. Reasons lists the issue's risk
+// signals, such as being from a new title, which make it more likely to be
+// picked.
+type Issue struct {
+	ID      int64
+	Pages   []Page
+	Reasons []string
+}
+
+// Pick is a single page chosen for review, along with why it was chosen
+type Pick struct {
+	IssueID  int64
+	Sequence int
+	Reasons  []string
+}
+
+// Seed returns the random seed for a batch's QC round, which makes the
+// sample reproducible: the same batch and round with the same candidates
+// always gets the same sample
+func Seed(batchID int64, round int) [2]uint64 {
+	return [2]uint64{uint64(batchID), uint64(round)}
+}
+
+// IssueCount returns how many issues to sample from a batch with n issues:
+// all of them for small batches, otherwise roughly the square root
+func IssueCount(n int) int {
+	if n <= 5 {
+		return n
+	}
+	return max(5, int(math.Ceil(math.Sqrt(float64(n)))))
+}
+
+// lowDensityThreshold returns the word count below which a page's OCR is
+// considered sparse, based on the median of all readable pages
+func lowDensityThreshold(issues []*Issue) float64 {
+	var counts []int
+	for _, i := range issues {
+		for _, p := range i.Pages {
+			if p.Words >= 0 {
+				counts = append(counts, p.Words)
+			}
+		}
+	}
+	if len(counts) == 0 {
+		return lowWordFloor
+	}
+	sort.Ints(counts)
+	var median = float64(counts[len(counts)/2])
+	return max(lowWordFloor, median*lowDensityRatio)
+}
+
+func isLow(p Page, threshold float64) bool {
+	return p.Words >= 0 && float64(p.Words) < threshold
+}
+
+// weighted is a candidate with its sampling weight
+type weighted struct {
+	weight float64
+	key    float64
+	index  int
+}
+
+// pickWeighted chooses up to n candidates without replacement, where each
+// candidate's chance of being picked is proportional to its weight. Each
+// candidate gets a key of -ln(u)/weight, and the n smallest keys win.
+func pickWeighted(r *rand.Rand, list []*weighted, n int) []*weighted {
+	for _, w := range list {
+		w.key = -math.Log(1-r.Float64()) / w.weight
+	}
+	var sorted = make([]*weighted, len(list))
+	copy(sorted, list)
+	sort.SliceStable(sorted, func(a, b int) bool { return sorted[a].key < sorted[b].key })
+	if n < len(sorted) {
+		sorted = sorted[:n]
+	}
+
+	// Keep the original order so results read naturally
+	sort.Slice(sorted, func(a, b int) bool { return sorted[a].index < sorted[b].index })
+	return sorted
+}
+
+// Sample picks issueCount issues and up to pagesPerIssue pages from each.
+// Issues with risk signals or sparse OCR are weighted more heavily, as are
+// sparse pages within an issue. Each issue's first page is always included,
+// since mastheads are where date and title problems are most obvious.
+func Sample(issues []*Issue, seed [2]uint64, issueCount, pagesPerIssue int) []Pick {
+	var sorted = make([]*Issue, len(issues))
+	copy(sorted, issues)
+	sort.Slice(sorted, func(a, b int) bool { return sorted[a].ID < sorted[b].ID })
+
+	var r = rand.New(rand.NewPCG(seed[0], seed[1]))
+	var threshold = lowDensityThreshold(sorted)
+
+	var candidates = make([]*weighted, len(sorted))
+	for idx, i := range sorted {
+		var w = &weighted{weight: 1 + 2*float64(len(i.Reasons)), index: idx}
+
+		var low int
+		for _, p := range i.Pages {
+			if isLow(p, threshold) {
+				low++
+			}
+		}
+		if low > 0 {
+			w.weight += 2
+		}
+		candidates[idx] = w
+	}
+
+	var picks []Pick
+	for _, c := range pickWeighted(r, candidates, issueCount) {
+		picks = append(picks, samplePages(r, sorted[c.index], pagesPerIssue, threshold)...)
+	}
+	return picks
+}
+
+// samplePages picks pages from a single issue: the first page, then others
+// weighted toward sparse OCR
+func samplePages(r *rand.Rand, issue *Issue, n int, threshold float64) []Pick {
+	if len(issue.Pages) == 0 || n < 1 {
+		return nil
+	}
+
+	var pages = make([]Page, len(issue.Pages))
+	copy(pages, issue.Pages)
+	sort.Slice(pages, func(a, b int) bool { return pages[a].Sequence < pages[b].Sequence })
+
+	var pageReasons = func(p Page, extra ...string) []string {
+		var list = append([]string{}, issue.Reasons...)
+		list = append(list, extra...)
+		if isLow(p, threshold) {
+			list = append(list, fmt.Sprintf("low OCR text density (%d words)", p.Words))
+		}
+		return list
+	}
+
+	var picks = []Pick{{IssueID: issue.ID, Sequence: pages[0].Sequence, Reasons: pageReasons(pages[0], "first page")}}
+
+	var rest = make([]*weighted, len(pages)-1)
+	for idx, p := range pages[1:] {
+		rest[idx] = &weighted{weight: 1, index: idx + 1}
+		if isLow(p, threshold) {
+			rest[idx].weight = 4
+		}
+	}
+	for _, w := range pickWeighted(r, rest, n-1) {
+		var p = pages[w.index]
+		picks = append(picks, Pick{IssueID: issue.ID, Sequence: p.Sequence, Reasons: pageReasons(p)})
+	}
+	return picks
+}
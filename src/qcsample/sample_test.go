@@ -0,0 +1,122 @@
+package qcsample
+
+import (
+	"reflect"
+	"testing"
+)
+
+func makeIssues(n, pages, words int) []*Issue {
+	var list []*Issue
+	for i := 1; i <= n; i++ {
+		var issue = &Issue{ID: int64(i)}
+		for p := 1; p <= pages; p++ {
+			issue.Pages = append(issue.Pages, Page{Sequence: p, Words: words})
+		}
+		list = append(list, issue)
+	}
+	return list
+}
+
+func TestIssueCount(t *testing.T) {
+	var tests = map[int]int{0: 0, 1: 1, 5: 5, 6: 5, 25: 5, 26: 6, 100: 10, 1000: 32}
+	for n, expected := range tests {
+		var got = IssueCount(n)
+		if got != expected {
+			t.Errorf("IssueCount(%d): expected %d, got %d", n, expected, got)
+		}
+	}
+}
+
+func TestSampleReproducible(t *testing.T) {
+	var issues = makeIssues(50, 8, 1000)
+	var a = Sample(issues, Seed(12, 0), 7, 3)
+	var b = Sample(issues, Seed(12, 0), 7, 3)
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("Same seed gave different samples:\n%v\n%v", a, b)
+	}
+
+	// Candidate order mustn't matter
+	var reversed = make([]*Issue, len(issues))
+	for i, issue := range issues {
+		reversed[len(issues)-1-i] = issue
+	}
+	var c = Sample(reversed, Seed(12, 0), 7, 3)
+	if !reflect.DeepEqual(a, c) {
+		t.Errorf("Candidate order changed the sample:\n%v\n%v", a, c)
+	}
+
+	var d = Sample(issues, Seed(12, 1), 7, 3)
+	if reflect.DeepEqual(a, d) {
+		t.Errorf("A new QC round should get a different sample")
+	}
+}
+
+func TestSampleShape(t *testing.T) {
+	var picks = Sample(makeIssues(50, 8, 1000), Seed(3, 0), 7, 3)
+	if len(picks) != 21 {
+		t.Fatalf("Expected 21 pages (7 issues x 3 pages), got %d", len(picks))
+	}
+
+	var perIssue = make(map[int64][]int)
+	for _, p := range picks {
+		perIssue[p.IssueID] = append(perIssue[p.IssueID], p.Sequence)
+	}
+	if len(perIssue) != 7 {
+		t.Errorf("Expected 7 distinct issues, got %d", len(perIssue))
+	}
+	for id, seqs := range perIssue {
+		if seqs[0] != 1 {
+			t.Errorf("Issue %d: first page should always be sampled first; got %v", id, seqs)
+		}
+		var seen = make(map[int]bool)
+		for _, s := range seqs {
+			if seen[s] {
+				t.Errorf("Issue %d: page %d sampled twice", id, s)
+			}
+			seen[s] = true
+		}
+	}
+
+	// Small issues and small batches are sampled in full
+	picks = Sample(makeIssues(2, 1, 1000), Seed(3, 0), 5, 3)
+	if len(picks) != 2 {
+		t.Errorf("Expected both single-page issues, got %v", picks)
+	}
+}
+
+func TestSampleWeighting(t *testing.T) {
+	var issues = makeIssues(40, 4, 1000)
+	issues[10].Reasons = []string{"new title"}
+	issues[20].Pages[2].Words = 3
+
+	// Over many seeds, risky issues should be picked far more often than an
+	// ordinary issue
+	var counts = make(map[int64]int)
+	for seed := uint64(0); seed < 500; seed++ {
+		for _, p := range Sample(issues, [2]uint64{seed, 0}, 5, 1) {
+			counts[p.IssueID]++
+		}
+	}
+	if counts[11] < counts[1]*2 {
+		t.Errorf("Issue with a risk signal was picked %d times vs. %d for an ordinary issue", counts[11], counts[1])
+	}
+	if counts[21] < counts[1]*2 {
+		t.Errorf("Issue with sparse OCR was picked %d times vs. %d for an ordinary issue", counts[21], counts[1])
+	}
+}
+
+func TestSampleReasons(t *testing.T) {
+	var issues = makeIssues(1, 3, 1000)
+	issues[0].Reasons = []string{"new title"}
+	issues[0].Pages[1].Words = 5
+
+	var picks = Sample(issues, Seed(1, 0), 1, 3)
+	var expected = []Pick{
+		{IssueID: 1, Sequence: 1, Reasons: []string{"new title", "first page"}},
+		{IssueID: 1, Sequence: 2, Reasons: []string{"new title", "low OCR text density (5 words)"}},
+		{IssueID: 1, Sequence: 3, Reasons: []string{"new title"}},
+	}
+	if !reflect.DeepEqual(picks, expected) {
+		t.Errorf("Expected %#v, got %#v", expected, picks)
+	}
+}
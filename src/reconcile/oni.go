@@ -0,0 +1,54 @@
+package reconcile
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/chronam"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/httpcache"
+)
+
+// FetchBatchNames reads every page of the batch list from the ONI site at
+// webroot and returns the batch names. Nothing is cached: the whole point is
+// to see what ONI serves right now.
+func FetchBatchNames(webroot string) ([]string, error) {
+	var apiURL, err = url.Parse(webroot)
+	if err != nil {
+		return nil, err
+	}
+	apiURL.Path = "batches.json"
+
+	var c = httpcache.NewClient("", 0)
+	c.HTTPClient = &http.Client{Timeout: time.Minute}
+
+	var names []string
+	var next = apiURL.String()
+	for next != "" {
+		var r io.ReadCloser
+		r, err = c.Get(next)
+		if err != nil {
+			return nil, err
+		}
+		var contents []byte
+		contents, err = io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			return nil, fmt.Errorf("reading %q: %w", next, err)
+		}
+
+		var list *chronam.BatchesListJSON
+		list, err = chronam.ParseBatchesListJSON(contents)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSON in %q: %w", next, err)
+		}
+		for _, b := range list.Batches {
+			names = append(names, b.Name)
+		}
+		next = list.Next
+	}
+
+	return names, nil
+}
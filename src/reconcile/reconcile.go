@@ -0,0 +1,240 @@
+// Package reconcile compares NCA's batch records with the batches each ONI
+// environment actually serves, so that failed or forgotten loads and purges
+// can be found and fixed
+package reconcile
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+)
+
+// Kind describes what's wrong with a batch in an ONI environment
+type Kind string
+
+// All discrepancy kinds
+const (
+	// KindMissing means NCA expects the batch to be in the environment, but ONI
+	// doesn't serve it
+	KindMissing Kind = "missing"
+
+	// KindUnexpected means ONI serves a batch NCA knows about, but NCA's
+	// status says it shouldn't be there
+	KindUnexpected Kind = "unexpected"
+
+	// KindUnknown means ONI serves a batch NCA has no record of
+	KindUnknown Kind = "unknown"
+
+	// KindVersionMismatch means ONI serves a different version of a batch NCA
+	// knows about, e.g., "_ver01" when NCA has "_ver02"
+	KindVersionMismatch Kind = "version mismatch"
+)
+
+// Describe returns a human-friendly explanation of the discrepancy kind
+func (k Kind) Describe() string {
+	switch k {
+	case KindMissing:
+		return "NCA expects this batch to be loaded, but ONI doesn't have it"
+	case KindUnexpected:
+		return "ONI has this batch, but NCA's status says it shouldn't be here"
+	case KindUnknown:
+		return "ONI has this batch, but NCA has no record of it"
+	case KindVersionMismatch:
+		return "ONI has a different version of this batch than NCA"
+	}
+	return string(k)
+}
+
+// Discrepancy is a single batch which doesn't match between NCA and an ONI
+// environment
+type Discrepancy struct {
+	Kind        Kind
+	Environment *config.ONIEnvironment
+
+	// Batch is NCA's record, or nil for unknown batches
+	Batch *models.Batch
+
+	// ONIName is the batch name as ONI has it. For missing batches this is the
+	// name ONI should have.
+	ONIName string
+}
+
+// Action returns what can be done to fix the discrepancy: "load" to requeue
+// a load, "purge" to requeue a purge, or an empty string if NCA can't fix it
+func (d *Discrepancy) Action() string {
+	switch d.Kind {
+	case KindMissing:
+		return "load"
+	case KindUnexpected, KindVersionMismatch:
+		return "purge"
+	}
+	return ""
+}
+
+// NeedsReview is true when NCA can't fix the discrepancy itself, so a person
+// has to look into it
+func (d *Discrepancy) NeedsReview() bool {
+	return d.Action() == ""
+}
+
+// ReviewAdvice explains what a person should do about a discrepancy which
+// needs review
+func (d *Discrepancy) ReviewAdvice() string {
+	if d.Kind == KindUnknown {
+		return fmt.Sprintf("Find out where %s came from. If it doesn't belong in %s, purge it with ONI's purge_batch command.", d.ONIName, d.Environment.Name)
+	}
+	return ""
+}
+
+// Report holds every discrepancy found in a single reconciliation run
+type Report struct {
+	Discrepancies []*Discrepancy
+	GeneratedAt   time.Time
+}
+
+// NeedsReview returns the discrepancies NCA can't fix itself
+func (r *Report) NeedsReview() []*Discrepancy {
+	var list []*Discrepancy
+	for _, d := range r.Discrepancies {
+		if d.NeedsReview() {
+			list = append(list, d)
+		}
+	}
+	return list
+}
+
+// expectation is whether a batch should be in a given environment
+type expectation int
+
+const (
+	expectAbsent expectation = iota
+	expectPresent
+	expectEither
+)
+
+// expect returns whether a batch with the given status should be present in
+// an environment with the given role.  Batches in flux, and live batches on
+// staging environments, can go either way: staging sites aren't purged when
+// a batch goes live.
+func expect(b *models.Batch, role config.ONIRole) expectation {
+	if b.Status == models.BatchStatusPending {
+		return expectEither
+	}
+
+	switch role {
+	case config.ONIRoleStaging:
+		if b.StatusMeta.Staging {
+			return expectPresent
+		}
+		if b.StatusMeta.Live {
+			return expectEither
+		}
+	case config.ONIRoleProduction:
+		if b.StatusMeta.Live {
+			return expectPresent
+		}
+	}
+
+	return expectAbsent
+}
+
+var versionRE = regexp.MustCompile(`_ver\d+$`)
+
+// baseName strips the version suffix from a batch name
+func baseName(name string) string {
+	return versionRE.ReplaceAllString(name, "")
+}
+
+// Compare returns the discrepancies between NCA's batches and the list of
+// batch names ONI serves in the given environment
+func Compare(env *config.ONIEnvironment, batches []*models.Batch, oniNames []string) []*Discrepancy {
+	var byName = make(map[string]*models.Batch)
+	var byBase = make(map[string]*models.Batch)
+	for _, b := range batches {
+		byName[b.FullName] = b
+
+		// If NCA has more than one version, we compare against the newest
+		var base = baseName(b.FullName)
+		if byBase[base] == nil || byBase[base].Version < b.Version {
+			byBase[base] = b
+		}
+	}
+
+	var list []*Discrepancy
+	var served = make(map[string]bool)
+	for _, name := range oniNames {
+		served[name] = true
+
+		var b = byName[name]
+		if b != nil {
+			if expect(b, env.Role) == expectAbsent {
+				list = append(list, &Discrepancy{Kind: KindUnexpected, Environment: env, Batch: b, ONIName: name})
+			}
+			continue
+		}
+
+		b = byBase[baseName(name)]
+		if b != nil {
+			list = append(list, &Discrepancy{Kind: KindVersionMismatch, Environment: env, Batch: b, ONIName: name})
+			continue
+		}
+
+		list = append(list, &Discrepancy{Kind: KindUnknown, Environment: env, ONIName: name})
+	}
+
+	for _, b := range batches {
+		if !served[b.FullName] && expect(b, env.Role) == expectPresent {
+			list = append(list, &Discrepancy{Kind: KindMissing, Environment: env, Batch: b, ONIName: b.FullName})
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Kind != list[j].Kind {
+			return list[i].Kind < list[j].Kind
+		}
+		return list[i].ONIName < list[j].ONIName
+	})
+	return list
+}
+
+// Run pulls all batches from NCA and every configured ONI environment, and
+// reports on any discrepancies
+func Run(c *config.Config) (*Report, error) {
+	var batches, err = models.AllBatchesIncludingDeleted()
+	if err != nil {
+		return nil, fmt.Errorf("reading NCA batches: %w", err)
+	}
+
+	var r = &Report{GeneratedAt: time.Now()}
+	for _, env := range c.ONIEnvironments {
+		var list []*Discrepancy
+		list, err = compareEnvironment(env, batches)
+		if err != nil {
+			return nil, err
+		}
+		r.Discrepancies = append(r.Discrepancies, list...)
+	}
+
+	return r, nil
+}
+
+// ForEnvironment returns the current discrepancies for a single environment
+func ForEnvironment(env *config.ONIEnvironment) ([]*Discrepancy, error) {
+	var batches, err = models.AllBatchesIncludingDeleted()
+	if err != nil {
+		return nil, fmt.Errorf("reading NCA batches: %w", err)
+	}
+	return compareEnvironment(env, batches)
+}
+
+func compareEnvironment(env *config.ONIEnvironment, batches []*models.Batch) ([]*Discrepancy, error) {
+	var names, err = FetchBatchNames(env.Webroot)
+	if err != nil {
+		return nil, fmt.Errorf("reading batches from ONI environment %q: %w", env.Name, err)
+	}
+	return Compare(env, batches, names), nil
+}
@@ -0,0 +1,129 @@
+package reconcile
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+)
+
+func batch(id int64, name string, version int, status string, staging, live bool) *models.Batch {
+	return &models.Batch{
+		ID:         id,
+		FullName:   fmt.Sprintf("%s_ver%02d", name, version),
+		Version:    version,
+		Status:     status,
+		StatusMeta: models.BatchStatus{Status: status, Staging: staging, Live: live},
+	}
+}
+
+func TestCompare(t *testing.T) {
+	var batches = []*models.Batch{
+		batch(1, "batch_oru_Aardvark", 1, models.BatchStatusQCReady, true, false),
+		batch(2, "batch_oru_Badger", 1, models.BatchStatusLive, false, true),
+		batch(3, "batch_oru_Cheetah", 2, models.BatchStatusLiveDone, false, true),
+		batch(4, "batch_oru_Dingo", 1, models.BatchStatusDeleted, false, false),
+		batch(5, "batch_oru_Emu", 1, models.BatchStatusPending, false, false),
+	}
+	var staging = &config.ONIEnvironment{Name: "staging", Role: config.ONIRoleStaging}
+	var prod = &config.ONIEnvironment{Name: "production", Role: config.ONIRoleProduction}
+
+	var tests = map[string]struct {
+		env  *config.ONIEnvironment
+		oni  []string
+		want []string
+	}{
+		"staging in sync": {
+			env:  staging,
+			oni:  []string{"batch_oru_Aardvark_ver01", "batch_oru_Badger_ver01"},
+			want: nil,
+		},
+		"staging missing QC batch, has deleted batch": {
+			env:  staging,
+			oni:  []string{"batch_oru_Dingo_ver01", "batch_oru_Emu_ver01"},
+			want: []string{"missing batch_oru_Aardvark_ver01 1", "unexpected batch_oru_Dingo_ver01 4"},
+		},
+		"production": {
+			env: prod,
+			oni: []string{"batch_oru_Aardvark_ver01", "batch_oru_Badger_ver01", "batch_oru_Cheetah_ver01", "batch_oru_Zebra_ver01"},
+			want: []string{
+				"missing batch_oru_Cheetah_ver02 3",
+				"unexpected batch_oru_Aardvark_ver01 1",
+				"unknown batch_oru_Zebra_ver01 0",
+				"version mismatch batch_oru_Cheetah_ver01 3",
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got []string
+			for _, d := range Compare(tc.env, batches, tc.oni) {
+				var id int64
+				if d.Batch != nil {
+					id = d.Batch.ID
+				}
+				got = append(got, fmt.Sprintf("%s %s %d", d.Kind, d.ONIName, id))
+			}
+			if strings.Join(got, "\n") != strings.Join(tc.want, "\n") {
+				t.Errorf("Expected:\n%s\nGot:\n%s", strings.Join(tc.want, "\n"), strings.Join(got, "\n"))
+			}
+		})
+	}
+}
+
+func TestFetchBatchNames(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/batches.json" {
+			http.NotFound(w, req)
+			return
+		}
+		if req.URL.Query().Get("page") == "2" {
+			fmt.Fprint(w, `{"batches": [{"name": "batch_oru_Badger_ver01"}]}`)
+			return
+		}
+		fmt.Fprintf(w, `{"batches": [{"name": "batch_oru_Aardvark_ver01"}], "next": "%s/batches.json?page=2"}`, srv.URL)
+	}))
+	defer srv.Close()
+
+	var names, err = FetchBatchNames(srv.URL + "/")
+	if err != nil {
+		t.Fatalf("FetchBatchNames: %s", err)
+	}
+	var want = "batch_oru_Aardvark_ver01 batch_oru_Badger_ver01"
+	if strings.Join(names, " ") != want {
+		t.Errorf("Expected %q, got %q", want, names)
+	}
+}
+
+func TestNeedsReview(t *testing.T) {
+	var prod = &config.ONIEnvironment{Name: "production", Role: config.ONIRoleProduction}
+	var batches = []*models.Batch{
+		batch(1, "batch_oru_Aardvark", 1, models.BatchStatusQCReady, true, false),
+		batch(2, "batch_oru_Badger", 1, models.BatchStatusLive, false, true),
+	}
+	var r = &Report{Discrepancies: Compare(prod, batches, []string{"batch_oru_Aardvark_ver01", "batch_oru_Zebra_ver01"})}
+
+	var got []string
+	for _, d := range r.NeedsReview() {
+		got = append(got, fmt.Sprintf("%s %s", d.Kind, d.ONIName))
+		if d.ReviewAdvice() == "" {
+			t.Errorf("%s needs review, but has no advice", d.ONIName)
+		}
+	}
+	var want = []string{"unknown batch_oru_Zebra_ver01"}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Expected:\n%s\nGot:\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+
+	for _, d := range r.Discrepancies {
+		if d.Kind != KindUnknown && d.NeedsReview() {
+			t.Errorf("%s (%s) shouldn't need review", d.ONIName, d.Kind)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package retention
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/internal/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+)
+
+// Delete removes an eligible candidate's directory and records the deletion
+// in its issue's action log. An issue's workflow directory is also cleared
+// from the issue so nothing tries to use it.
+func Delete(c *Candidate) error {
+	if c.Problem != "" {
+		return fmt.Errorf("refusing to delete %q: %s", c.Path, c.Problem)
+	}
+
+	var err = os.RemoveAll(c.Path)
+	if err != nil {
+		return fmt.Errorf("removing %q: %w", c.Path, err)
+	}
+
+	var msg = fmt.Sprintf("Deleted %q under retention rule %s (%d file(s), %s)", c.Path, c.Rule, c.Files, c.Size())
+	if c.Rule.Target == config.RetentionFinishedIssues {
+		c.Issue.Location = ""
+		err = c.Issue.Save(models.ActionTypeRetentionDelete, models.SystemUser.ID, msg)
+	} else {
+		var a = models.NewIssueAction(c.Issue.ID, models.ActionTypeRetentionDelete)
+		a.UserID = models.SystemUser.ID
+		a.Message = msg
+		err = a.Save()
+	}
+	if err != nil {
+		return fmt.Errorf("recording deletion of %q for issue %d: %w", c.Path, c.Issue.ID, err)
+	}
+	return nil
+}
+
+// Enforce finds everything past its retention period and deletes what's
+// eligible, logging each deletion and each directory a safety check kept. The
+// report is returned along with any deletion failures.
+func Enforce(c *config.Config) (*Report, error) {
+	var r, err = Find(c, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, cand := range r.Skipped {
+		logger.Warnf("Retention: keeping %s: %s", cand.Describe(), cand.Problem)
+	}
+
+	var errs []error
+	for _, cand := range r.Eligible {
+		err = Delete(cand)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		r.Deleted = append(r.Deleted, cand)
+		logger.Infof("Retention: deleted %s", cand.Describe())
+	}
+	return r, errors.Join(errs...)
+}
@@ -0,0 +1,234 @@
+// Package retention enforces the configured retention rules, deleting files
+// NCA no longer needs once they've been kept long enough. Every directory is
+// tied back to an issue in the database, and is only deleted if the issue and
+// its batch are in a state where the files can't still be needed.
+package retention
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/internal/datasize"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+)
+
+// Candidate is a directory which is past its retention period
+type Candidate struct {
+	Rule  *config.RetentionRule
+	Path  string
+	Issue *models.Issue
+
+	// Since is when the retention period started
+	Since time.Time
+
+	// Problem explains why a safety check refused to delete the directory
+	Problem string
+
+	Files int
+	Bytes int64
+}
+
+// Size returns a human-friendly total of the candidate's files
+func (c *Candidate) Size() string {
+	var d = datasize.Datasize(c.Bytes)
+	return d.String()
+}
+
+// Describe summarizes the candidate for logs and reports
+func (c *Candidate) Describe() string {
+	var s = fmt.Sprintf("%s (%s; %d file(s), %s", c.Path, c.Rule, c.Files, c.Size())
+	if c.Issue != nil {
+		s += fmt.Sprintf("; issue %d, %s", c.Issue.ID, c.Issue.Key())
+	}
+	return s + ")"
+}
+
+// Report lists everything past its retention period, split into what can be
+// deleted and what failed a safety check. Deleted is only filled in when the
+// report is enforced.
+type Report struct {
+	Eligible []*Candidate
+	Skipped  []*Candidate
+	Deleted  []*Candidate
+}
+
+// Bytes returns the total size of the eligible directories
+func (r *Report) Bytes() int64 {
+	var n int64
+	for _, c := range r.Eligible {
+		n += c.Bytes
+	}
+	return n
+}
+
+// Size returns a human-friendly total of the eligible directories' files
+func (r *Report) Size() string {
+	var d = datasize.Datasize(r.Bytes())
+	return d.String()
+}
+
+// finder looks up candidates for a single rule
+type finder struct {
+	conf    *config.Config
+	rule    *config.RetentionRule
+	now     time.Time
+	batches map[int64]*models.Batch
+	report  *Report
+}
+
+// Find scans every configured rule's target for directories past their
+// retention period as of now
+func Find(c *config.Config, now time.Time) (*Report, error) {
+	var r = &Report{}
+	var batches = make(map[int64]*models.Batch)
+	for _, rule := range c.RetentionRules {
+		var f = &finder{conf: c, rule: rule, now: now, batches: batches, report: r}
+		var err error
+		switch rule.Target {
+		case config.RetentionErroredIssues:
+			err = f.erroredIssues()
+		case config.RetentionOriginalBackups:
+			err = f.originalBackups()
+		case config.RetentionFinishedIssues:
+			err = f.finishedIssues()
+		default:
+			err = fmt.Errorf("unknown target %q", rule.Target)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("applying rule %s: %w", rule, err)
+		}
+	}
+
+	for _, list := range [][]*Candidate{r.Eligible, r.Skipped} {
+		sort.Slice(list, func(i, j int) bool { return list[i].Path < list[j].Path })
+	}
+	return r, nil
+}
+
+// due returns true if the retention period starting at since has passed
+func (f *finder) due(since time.Time) bool {
+	return !since.IsZero() && !since.Add(f.rule.Keep).After(f.now)
+}
+
+// add measures the candidate and files it as eligible or skipped
+func (f *finder) add(c *Candidate) {
+	c.Rule = f.rule
+	if c.Problem == "" {
+		var err error
+		c.Files, c.Bytes, err = measure(c.Path)
+		if err != nil {
+			c.Problem = fmt.Sprintf("unable to read directory: %s", err)
+		}
+	}
+
+	if c.Problem != "" {
+		f.report.Skipped = append(f.report.Skipped, c)
+		return
+	}
+	f.report.Eligible = append(f.report.Eligible, c)
+}
+
+// batch looks up and caches an issue's batch
+func (f *finder) batch(id int64) (*models.Batch, error) {
+	if id == 0 {
+		return nil, nil
+	}
+	var b, ok = f.batches[id]
+	if ok {
+		return b, nil
+	}
+
+	var err error
+	b, err = models.FindBatch(id)
+	if err != nil {
+		return nil, fmt.Errorf("looking up batch %d: %w", id, err)
+	}
+	f.batches[id] = b
+	return b, nil
+}
+
+// issueForDir finds the issue a directory belongs to. Errored issues and
+// backups are named for the issue's HumanName, which ends in its ID. The
+// whole name has to match so a stray directory that happens to end in a
+// number is never tied to an unrelated issue.
+func issueForDir(path string) (*models.Issue, error) {
+	var name = filepath.Base(path)
+	var id = issueIDFromDir(name)
+	if id == 0 {
+		return nil, nil
+	}
+	var i, err = models.FindIssue(id)
+	if err != nil || i == nil || i.HumanName != name {
+		return nil, err
+	}
+	return i, nil
+}
+
+// issueIDFromDir pulls the issue ID from the end of a directory name
+func issueIDFromDir(name string) int64 {
+	var idx = strings.LastIndex(name, "-")
+	if idx < 0 {
+		return 0
+	}
+	var id, _ = strconv.ParseInt(name[idx+1:], 10, 64)
+	return id
+}
+
+// subdirs returns the full paths of the directories in root. Hidden
+// directories are in-progress work, so they're never included.
+func subdirs(root string) ([]string, error) {
+	var entries, err = os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []string
+	for _, e := range entries {
+		if e.IsDir() && !strings.HasPrefix(e.Name(), ".") {
+			list = append(list, filepath.Join(root, e.Name()))
+		}
+	}
+	return list, nil
+}
+
+// modTime returns when a directory was last changed
+func modTime(path string) (time.Time, error) {
+	var info, err = os.Stat(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
+
+// measure counts the files under path and their total size
+func measure(path string) (files int, bytes int64, err error) {
+	err = filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		var info, infoErr = d.Info()
+		if infoErr != nil {
+			return infoErr
+		}
+		files++
+		bytes += info.Size()
+		return nil
+	})
+	return files, bytes, err
+}
+
+// within returns true if path is strictly inside root
+func within(root, path string) bool {
+	var rel, err = filepath.Rel(filepath.Clean(root), filepath.Clean(path))
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,232 @@
+package retention
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/schema"
+)
+
+func TestIssueIDFromDir(t *testing.T) {
+	var tests = map[string]int64{
+		"sn12345678-2020010201-123": 123,
+		"sn12345678-2020010201":     2020010201,
+		"no-id-here":                0,
+		"noseparator":               0,
+		"":                          0,
+	}
+	for name, want := range tests {
+		var got = issueIDFromDir(name)
+		if got != want {
+			t.Errorf("issueIDFromDir(%q): expected %d, got %d", name, want, got)
+		}
+	}
+}
+
+func TestWithin(t *testing.T) {
+	var tests = map[string]struct {
+		root, path string
+		want       bool
+	}{
+		"child":          {"/mnt/workflow", "/mnt/workflow/issue-1", true},
+		"grandchild":     {"/mnt/workflow", "/mnt/workflow/a/b", true},
+		"trailing slash": {"/mnt/workflow/", "/mnt/workflow/issue-1", true},
+		"root itself":    {"/mnt/workflow", "/mnt/workflow/", false},
+		"sibling":        {"/mnt/workflow", "/mnt/workflow2/issue-1", false},
+		"escape":         {"/mnt/workflow", "/mnt/workflow/../batches", false},
+		"parent":         {"/mnt/workflow", "/mnt", false},
+		"dotdot name":    {"/mnt/workflow", "/mnt/workflow/..issue", true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := within(tc.root, tc.path); got != tc.want {
+				t.Errorf("within(%q, %q): expected %v, got %v", tc.root, tc.path, tc.want, got)
+			}
+		})
+	}
+}
+
+func TestDue(t *testing.T) {
+	var now = time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	var f = &finder{rule: &config.RetentionRule{Keep: 30 * 24 * time.Hour}, now: now}
+	var tests = map[string]struct {
+		since time.Time
+		want  bool
+	}{
+		"never":         {time.Time{}, false},
+		"recent":        {now.Add(-29 * 24 * time.Hour), false},
+		"exactly":       {now.Add(-30 * 24 * time.Hour), true},
+		"long ago":      {now.Add(-365 * 24 * time.Hour), true},
+		"in the future": {now.Add(time.Hour), false},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := f.due(tc.since); got != tc.want {
+				t.Errorf("expected due to be %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestCheckErroredIssue(t *testing.T) {
+	var tests = map[string]struct {
+		issue       *models.Issue
+		errContains string
+	}{
+		"ok":        {issue: &models.Issue{WorkflowStep: schema.WSUnfixableMetadataError, Ignored: true}},
+		"no issue":  {issue: nil, errContains: "no issue"},
+		"not error": {issue: &models.Issue{WorkflowStep: schema.WSReadyForBatching, Ignored: true}, errContains: "workflow step"},
+		"active":    {issue: &models.Issue{WorkflowStep: schema.WSUnfixableMetadataError}, errContains: "still active"},
+		"located": {
+			issue:       &models.Issue{WorkflowStep: schema.WSUnfixableMetadataError, Ignored: true, Location: "/mnt/workflow/x"},
+			errContains: "still located",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			checkProblem(t, checkErroredIssue(tc.issue), tc.errContains)
+		})
+	}
+}
+
+func TestRemovedAt(t *testing.T) {
+	var first = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	var second = first.Add(48 * time.Hour)
+	var removed = func(at time.Time) *models.Action {
+		return &models.Action{CreatedAt: at, Message: models.ErroredIssueRemovedMessage}
+	}
+	var other = &models.Action{CreatedAt: second.Add(time.Hour), Message: "metadata approved"}
+
+	var tests = map[string]struct {
+		actions []*models.Action
+		want    time.Time
+	}{
+		"no actions":    {},
+		"never removed": {actions: []*models.Action{other}},
+		"removed":       {actions: []*models.Action{removed(first), other}, want: first},
+		"removed twice": {actions: []*models.Action{removed(first), removed(second), other}, want: second},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := removedAt(tc.actions); !got.Equal(tc.want) {
+				t.Errorf("expected %s, got %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestCheckBackup(t *testing.T) {
+	var dir = "/mnt/backup/sn12345678-2020010201-5"
+	var live = &models.Batch{FullName: "batch_x", Status: models.BatchStatusLiveArchived}
+	var done = &models.Batch{FullName: "batch_x", Status: models.BatchStatusLiveDone}
+	var notArchived = &models.Batch{FullName: "batch_x", Status: models.BatchStatusLive}
+	var prod = &models.Issue{WorkflowStep: schema.WSInProduction}
+
+	var tests = map[string]struct {
+		issue       *models.Issue
+		batch       *models.Batch
+		errContains string
+	}{
+		"archived":     {issue: prod, batch: live},
+		"done":         {issue: prod, batch: done},
+		"not archived": {issue: prod, batch: notArchived, errContains: "not archived"},
+		"not in prod":  {issue: &models.Issue{WorkflowStep: schema.WSReadyForBatching}, batch: live, errContains: "workflow step"},
+		"referenced":   {issue: &models.Issue{WorkflowStep: schema.WSInProduction, BackupLocation: dir}, batch: live, errContains: "still refers"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			checkProblem(t, checkBackup(dir, tc.issue, tc.batch), tc.errContains)
+		})
+	}
+}
+
+func TestCheckFinishedIssue(t *testing.T) {
+	var root = "/mnt/workflow"
+	var live = &models.Batch{FullName: "batch_x", Status: models.BatchStatusLiveArchived}
+	var tests = map[string]struct {
+		issue       *models.Issue
+		batch       *models.Batch
+		errContains string
+	}{
+		"ok": {issue: &models.Issue{WorkflowStep: schema.WSInProduction, Location: "/mnt/workflow/i-1"}, batch: live},
+		"not archived": {
+			issue:       &models.Issue{WorkflowStep: schema.WSInProduction, Location: "/mnt/workflow/i-1"},
+			batch:       &models.Batch{FullName: "batch_x", Status: models.BatchStatusQCReady},
+			errContains: "not archived",
+		},
+		"pulled for correction": {
+			issue:       &models.Issue{WorkflowStep: schema.WSAwaitingMetadataReview, Location: "/mnt/workflow/i-1"},
+			batch:       live,
+			errContains: "workflow step",
+		},
+		"outside workflow": {
+			issue:       &models.Issue{WorkflowStep: schema.WSInProduction, Location: "/mnt/batches/batch_x"},
+			batch:       live,
+			errContains: "isn't inside",
+		},
+		"workflow root": {
+			issue:       &models.Issue{WorkflowStep: schema.WSInProduction, Location: "/mnt/workflow"},
+			batch:       live,
+			errContains: "isn't inside",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			checkProblem(t, checkFinishedIssue(root, tc.issue, tc.batch), tc.errContains)
+		})
+	}
+}
+
+func checkProblem(t *testing.T, problem, errContains string) {
+	t.Helper()
+	if errContains == "" {
+		if problem != "" {
+			t.Fatalf("Expected no problem, got %q", problem)
+		}
+		return
+	}
+	if !strings.Contains(problem, errContains) {
+		t.Fatalf("Expected problem containing %q, got %q", errContains, problem)
+	}
+}
+
+func TestSubdirsAndMeasure(t *testing.T) {
+	var root = t.TempDir()
+	var write = func(rel string, size int) {
+		var full = filepath.Join(root, rel)
+		var err = os.MkdirAll(filepath.Dir(full), 0755)
+		if err == nil {
+			err = os.WriteFile(full, make([]byte, size), 0644)
+		}
+		if err != nil {
+			t.Fatalf("Unable to write %q: %s", full, err)
+		}
+	}
+	write("issue-1/0001.pdf", 100)
+	write("issue-1/sub/0002.pdf", 50)
+	write(".wip-issue-2/0001.pdf", 10)
+	write("stray.txt", 1)
+
+	var dirs, err = subdirs(root)
+	if err != nil {
+		t.Fatalf("Unable to read %q: %s", root, err)
+	}
+	if len(dirs) != 1 || filepath.Base(dirs[0]) != "issue-1" {
+		t.Fatalf("Expected only issue-1, got %v", dirs)
+	}
+
+	var files int
+	var bytes int64
+	files, bytes, err = measure(dirs[0])
+	if err != nil {
+		t.Fatalf("Unable to measure %q: %s", dirs[0], err)
+	}
+	if files != 2 || bytes != 150 {
+		t.Errorf("Expected 2 files and 150 bytes, got %d files and %d bytes", files, bytes)
+	}
+}
@@ -0,0 +1,186 @@
+package retention
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/schema"
+)
+
+// erroredIssues finds directories under ERRORED_ISSUES_PATH's month folders
+// whose issue was removed from NCA longer ago than the rule allows
+func (f *finder) erroredIssues() error {
+	var months, err = subdirs(f.conf.ErroredIssuesPath)
+	if err != nil {
+		return err
+	}
+
+	for _, month := range months {
+		var dirs []string
+		dirs, err = subdirs(month)
+		if err != nil {
+			return err
+		}
+		for _, dir := range dirs {
+			var c = &Candidate{Path: dir}
+			c.Issue, err = issueForDir(dir)
+			if err != nil {
+				return fmt.Errorf("looking up issue for %q: %w", dir, err)
+			}
+			c.Since, err = erroredSince(c.Issue, dir)
+			if err != nil {
+				return err
+			}
+			if !f.due(c.Since) {
+				continue
+			}
+			c.Problem = checkErroredIssue(c.Issue)
+			f.add(c)
+		}
+	}
+	return nil
+}
+
+// erroredSince returns when an errored issue was removed from NCA, according
+// to its actions. The directory's modification time is only used when the
+// issue or its action can't be found, since it changes any time something in
+// the directory is added or removed.
+func erroredSince(i *models.Issue, dir string) (time.Time, error) {
+	if i != nil {
+		var actions, err = models.FindActionsForIssue(i.ID)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("reading actions for issue %d: %w", i.ID, err)
+		}
+		var t = removedAt(actions)
+		if !t.IsZero() {
+			return t, nil
+		}
+	}
+	return modTime(dir)
+}
+
+// removedAt returns the time of the most recent "removed from NCA" action in
+// the list, or a zero time if there isn't one
+func removedAt(actions []*models.Action) time.Time {
+	var t time.Time
+	for _, a := range actions {
+		if a.Message == models.ErroredIssueRemovedMessage && a.CreatedAt.After(t) {
+			t = a.CreatedAt
+		}
+	}
+	return t
+}
+
+// checkErroredIssue returns why an errored issue's directory can't be
+// deleted, if there's any reason at all
+func checkErroredIssue(i *models.Issue) string {
+	switch {
+	case i == nil:
+		return "no issue in the database matches the directory name"
+	case i.WorkflowStep != schema.WSUnfixableMetadataError:
+		return fmt.Sprintf("issue's workflow step is %q, not %q", i.WorkflowStep, schema.WSUnfixableMetadataError)
+	case !i.Ignored:
+		return "issue is still active in NCA"
+	case i.Location != "":
+		return fmt.Sprintf("issue is still located at %q", i.Location)
+	}
+	return ""
+}
+
+// originalBackups finds born-digital upload backups whose issue's batch was
+// archived longer ago than the rule allows. Backups for issues still in the
+// workflow aren't due. Backups which can't be tied to an issue are reported
+// once they're older than the rule allows so somebody can look into them.
+func (f *finder) originalBackups() error {
+	var dirs, err = subdirs(f.conf.PDFBackupPath)
+	if err != nil {
+		return err
+	}
+
+	for _, dir := range dirs {
+		var c = &Candidate{Path: dir}
+		c.Issue, err = issueForDir(dir)
+		if err != nil {
+			return fmt.Errorf("looking up issue for %q: %w", dir, err)
+		}
+
+		if c.Issue == nil {
+			c.Since, err = modTime(dir)
+			if err != nil {
+				return err
+			}
+			if f.due(c.Since) {
+				c.Problem = "no issue in the database matches the directory name"
+				f.add(c)
+			}
+			continue
+		}
+
+		var b *models.Batch
+		b, err = f.batch(c.Issue.BatchID)
+		if err != nil {
+			return err
+		}
+		if b == nil || !f.due(b.ArchivedAt) {
+			continue
+		}
+		c.Since = b.ArchivedAt
+		c.Problem = checkBackup(dir, c.Issue, b)
+		f.add(c)
+	}
+	return nil
+}
+
+// checkBackup returns why an issue's original backup can't be deleted, if
+// there's any reason at all
+func checkBackup(dir string, i *models.Issue, b *models.Batch) string {
+	switch {
+	case !b.Archived():
+		return fmt.Sprintf("batch %s is %q, not archived", b.FullName, b.Status)
+	case i.WorkflowStep != schema.WSInProduction:
+		return fmt.Sprintf("issue's workflow step is %q, not %q", i.WorkflowStep, schema.WSInProduction)
+	case i.BackupLocation == dir:
+		return "issue still refers to this backup, so its originals may not have been archived with the batch"
+	}
+	return ""
+}
+
+// finishedIssues finds issue directories in the workflow location whose
+// batch was archived longer ago than the rule allows
+func (f *finder) finishedIssues() error {
+	var issues, err = models.FindArchivedIssuesWithFiles()
+	if err != nil {
+		return fmt.Errorf("looking up issues in archived batches: %w", err)
+	}
+
+	for _, i := range issues {
+		var b *models.Batch
+		b, err = f.batch(i.BatchID)
+		if err != nil {
+			return err
+		}
+		if b == nil || !f.due(b.ArchivedAt) {
+			continue
+		}
+
+		var c = &Candidate{Path: i.Location, Issue: i, Since: b.ArchivedAt}
+		c.Problem = checkFinishedIssue(f.conf.WorkflowPath, i, b)
+		f.add(c)
+	}
+	return nil
+}
+
+// checkFinishedIssue returns why an issue's workflow directory can't be
+// deleted, if there's any reason at all
+func checkFinishedIssue(workflowPath string, i *models.Issue, b *models.Batch) string {
+	switch {
+	case !b.Archived():
+		return fmt.Sprintf("batch %s is %q, not archived", b.FullName, b.Status)
+	case i.WorkflowStep != schema.WSInProduction:
+		return fmt.Sprintf("issue's workflow step is %q, not %q", i.WorkflowStep, schema.WSInProduction)
+	case !within(workflowPath, i.Location):
+		return fmt.Sprintf("issue's location isn't inside the workflow path %q", workflowPath)
+	}
+	return ""
+}
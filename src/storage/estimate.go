@@ -0,0 +1,234 @@
+package storage
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/internal/datasize"
+	"github.com/uoregon-libraries/newspaper-curation-app/internal/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+)
+
+// Rough per-page sizes of what the heavy pipelines write. These err on the
+// large side: a held pipeline costs a few minutes, but a full disk can leave
+// an issue or batch half-copied.
+const (
+	// derivativeBytesPerPage covers a page's JP2 and ALTO XML
+	derivativeBytesPerPage = 6 * datasize.MB
+
+	// liveBytesPerPage covers the JP2, PDF, and ALTO XML copied to the live
+	// location when a batch is built
+	liveBytesPerPage = 10 * datasize.MB
+
+	// batchOutputBytesPerPage covers the batch's own XML and bag manifests:
+	// the page files themselves are hard links to the issues' files
+	batchOutputBytesPerPage = 64 * datasize.KB
+)
+
+// HeavyPipelines are the pipelines which write enough data to be worth
+// checking before they start
+var HeavyPipelines = []models.PipelineName{
+	models.PNSFTPIssueMove,
+	models.PNMoveIssueForDerivatives,
+	models.PNMakeBatch,
+}
+
+// Heavy returns true if the named pipeline is checked for space before it
+// starts
+func Heavy(name string) bool {
+	return slices.Contains(HeavyPipelines, models.PipelineName(name))
+}
+
+// Demand is the estimated number of bytes a pipeline will write to each
+// location, keyed by location name
+type Demand map[string]int64
+
+// Total returns the bytes needed across all locations
+func (d Demand) Total() int64 {
+	var n int64
+	for _, v := range d {
+		n += v
+	}
+	return n
+}
+
+// Estimate returns how much a heavy pipeline is expected to write. Pipelines
+// which aren't heavy need nothing.
+func Estimate(p *models.Pipeline) (Demand, error) {
+	switch models.PipelineName(p.Name) {
+	case models.PNSFTPIssueMove:
+		var i, err = pipelineIssue(p)
+		if err != nil {
+			return nil, err
+		}
+		var bytes int64
+		_, bytes, err = countPages(i.Location)
+		if err != nil {
+			return nil, fmt.Errorf("reading issue %d's files: %w", i.ID, err)
+		}
+		return estimateIssueMove(bytes), nil
+
+	case models.PNMoveIssueForDerivatives:
+		var i, err = pipelineIssue(p)
+		if err != nil {
+			return nil, err
+		}
+		var pages int
+		var bytes int64
+		pages, bytes, err = countPages(i.Location)
+		if err != nil {
+			return nil, fmt.Errorf("reading issue %d's files: %w", i.ID, err)
+		}
+		return estimateDerivatives(bytes, max(pages, i.PageCount)), nil
+
+	case models.PNMakeBatch:
+		var b, err = models.FindBatch(p.ObjectID)
+		if err != nil {
+			return nil, fmt.Errorf("looking up batch %d: %w", p.ObjectID, err)
+		}
+		if b == nil {
+			return nil, fmt.Errorf("batch %d doesn't exist", p.ObjectID)
+		}
+		var issues []*models.Issue
+		issues, err = b.Issues()
+		if err != nil {
+			return nil, fmt.Errorf("looking up batch %d's issues: %w", b.ID, err)
+		}
+		var pages int
+		for _, i := range issues {
+			pages += i.PageCount
+		}
+		return estimateBatch(pages), nil
+	}
+
+	return Demand{}, nil
+}
+
+// estimateIssueMove returns the demand of moving an upload into NCA: the
+// upload is copied into the workflow, split into pages next to the original,
+// backed up, and then the split pages are copied to the page review area
+func estimateIssueMove(uploadBytes int64) Demand {
+	return Demand{
+		LocWorkflow:   uploadBytes * 2,
+		LocBackup:     uploadBytes,
+		LocPageReview: uploadBytes,
+	}
+}
+
+// estimateDerivatives returns the demand of moving an issue into the workflow
+// and generating its derivatives
+func estimateDerivatives(issueBytes int64, pages int) Demand {
+	return Demand{LocWorkflow: issueBytes + int64(pages)*derivativeBytesPerPage}
+}
+
+// estimateBatch returns the demand of building a batch and copying its files
+// to the live location
+func estimateBatch(pages int) Demand {
+	return Demand{
+		LocBatchOutput:     int64(pages) * batchOutputBytesPerPage,
+		LocBatchProduction: int64(pages) * liveBytesPerPage,
+	}
+}
+
+// pipelineIssue returns the issue an issue-focused pipeline works on
+func pipelineIssue(p *models.Pipeline) (*models.Issue, error) {
+	if p.ObjectType != models.JobObjectTypeIssue {
+		return nil, fmt.Errorf("pipeline %d (%s) isn't tied to an issue", p.ID, p.Name)
+	}
+	var i, err = models.FindIssue(p.ObjectID)
+	if err != nil {
+		return nil, fmt.Errorf("looking up issue %d: %w", p.ObjectID, err)
+	}
+	if i == nil {
+		return nil, fmt.Errorf("issue %d doesn't exist", p.ObjectID)
+	}
+	return i, nil
+}
+
+// countPages walks an issue directory, returning how many pages it has and
+// the size of all its files. Scanned issues have a PDF and a TIFF for each
+// page, so pages is the larger of the two counts rather than their sum.
+func countPages(dir string) (pages int, bytes int64, err error) {
+	var pdfs, tiffs int
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		var info, infoErr = d.Info()
+		if infoErr != nil {
+			return infoErr
+		}
+		bytes += info.Size()
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".pdf":
+			pdfs++
+		case ".tif", ".tiff":
+			tiffs++
+		}
+		return nil
+	})
+	return max(pdfs, tiffs), bytes, err
+}
+
+// Check estimates what a pipeline will write and returns every filesystem
+// which doesn't have room for it while leaving the configured reserve free.
+// Space the heavy pipelines already running are expected to write is set
+// aside first, so several pipelines starting close together can't each count
+// on the same free space.
+func Check(c *config.Config, p *models.Pipeline) ([]*Shortfall, error) {
+	var d, err = Estimate(p)
+	if err != nil {
+		return nil, fmt.Errorf("estimating space for pipeline %d (%s): %w", p.ID, p.Name, err)
+	}
+	if d.Total() == 0 {
+		return nil, nil
+	}
+
+	var committed Demand
+	committed, err = runningDemand(p.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var usage []*Usage
+	usage, err = MeasureAll(c)
+	if err != nil {
+		return nil, err
+	}
+	return shortfalls(usage, d, committed, int64(c.StorageReserve)), nil
+}
+
+// runningDemand adds up the estimated demand of every heavy pipeline which has
+// started but not finished, other than the given pipeline. There's no telling
+// how much a running pipeline has already written, so its whole estimate is
+// counted until it finishes, erring on the large side like the estimates
+// themselves. A pipeline which can't be estimated is logged and skipped.
+func runningDemand(exceptID int64) (Demand, error) {
+	var pipelines, err = models.FindRunningPipelines(HeavyPipelines...)
+	if err != nil {
+		return nil, fmt.Errorf("looking up running pipelines: %w", err)
+	}
+
+	var total = Demand{}
+	for _, p := range pipelines {
+		if p.ID == exceptID {
+			continue
+		}
+		var d, estErr = Estimate(p)
+		if estErr != nil {
+			logger.Warnf("Unable to estimate space for running pipeline %d (%s): %s", p.ID, p.Name, estErr)
+			continue
+		}
+		for name, n := range d {
+			total[name] += n
+		}
+	}
+	return total, nil
+}
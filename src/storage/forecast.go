@@ -0,0 +1,202 @@
+package storage
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/internal/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+)
+
+// TrendWindow is how far back we look to see how quickly space is being used
+const TrendWindow = 7 * 24 * time.Hour
+
+// warnDays is how close a forecast can get to running out of space before it
+// raises an alert
+const warnDays = 14
+
+// minTrendSpan is the shortest stretch of samples we'll draw a trend from, so
+// a couple of samples minutes apart can't predict anything wild
+const minTrendSpan = time.Hour
+
+// Forecast is the outlook for one filesystem. The filesystem is named for the
+// first location on it, and Shared lists any others.
+type Forecast struct {
+	*Usage
+	Shared  []string
+	Reserve int64
+
+	// Pending is the estimated space needed by heavy pipelines which haven't
+	// started yet, including held pipelines
+	Pending int64
+
+	// BytesPerDay is how quickly used space has grown over the trend window;
+	// negative means space is being freed. HasTrend is false when there
+	// weren't enough samples to tell.
+	BytesPerDay float64
+	HasTrend    bool
+}
+
+// Available returns how much space is left once waiting pipelines have run
+// and the reserve is set aside. This is negative if there isn't enough.
+func (f *Forecast) Available() int64 {
+	return f.Free - f.Pending - f.Reserve
+}
+
+// DaysLeft returns the estimated number of days until available space runs
+// out at the current rate, or -1 if space isn't being used up
+func (f *Forecast) DaysLeft() float64 {
+	if !f.HasTrend || f.BytesPerDay <= 0 {
+		return -1
+	}
+	return math.Max(0, float64(f.Available())/f.BytesPerDay)
+}
+
+// Outlook describes how long available space should last
+func (f *Forecast) Outlook() string {
+	var days = f.DaysLeft()
+	switch {
+	case !f.HasTrend:
+		return "not enough data yet"
+	case days < 0:
+		return "not running out"
+	case days == 0:
+		return "out of space"
+	}
+	return fmt.Sprintf("about %.0f day(s)", math.Ceil(days))
+}
+
+// Alert returns a warning if the filesystem is, or will soon be, too full
+func (f *Forecast) Alert() string {
+	var days = f.DaysLeft()
+	switch {
+	case f.Free < f.Reserve:
+		return "Free space is below the reserve: heavy pipelines will be held until space is freed"
+	case f.Available() < 0:
+		return "Waiting pipelines need more space than is free: some will be held until space is freed"
+	case days >= 0 && days < warnDays:
+		return fmt.Sprintf("At the current rate, free space will drop below the reserve in about %.0f day(s)", math.Ceil(days))
+	}
+	return ""
+}
+
+// PendingSize returns a human-friendly amount of space waiting pipelines need
+func (f *Forecast) PendingSize() string {
+	return Size(f.Pending)
+}
+
+// ReserveSize returns a human-friendly size of the reserve
+func (f *Forecast) ReserveSize() string {
+	return Size(f.Reserve)
+}
+
+// AvailableSize returns a human-friendly amount of available space
+func (f *Forecast) AvailableSize() string {
+	return Size(f.Available())
+}
+
+// Trend returns a human-friendly rate of change in used space
+func (f *Forecast) Trend() string {
+	if !f.HasTrend {
+		return "unknown"
+	}
+	if f.BytesPerDay >= 0 {
+		return "+" + Size(int64(f.BytesPerDay)) + "/day"
+	}
+	return Size(int64(f.BytesPerDay)) + "/day"
+}
+
+// Forecasts measures every monitored filesystem and forecasts how long its
+// space will last, based on recent samples and the pipelines waiting to run
+func Forecasts(c *config.Config, now time.Time) ([]*Forecast, error) {
+	var usage, err = MeasureAll(c)
+	if err != nil {
+		return nil, err
+	}
+
+	var pipelines []*models.Pipeline
+	pipelines, err = models.FindUnstartedPipelines(HeavyPipelines...)
+	if err != nil {
+		return nil, fmt.Errorf("looking up waiting pipelines: %w", err)
+	}
+	var demands []Demand
+	for _, p := range pipelines {
+		var d, estErr = Estimate(p)
+		if estErr != nil {
+			logger.Warnf("Unable to estimate space for pipeline %d (%s): %s", p.ID, p.Name, estErr)
+			continue
+		}
+		demands = append(demands, d)
+	}
+
+	var list = forecasts(usage, demands, int64(c.StorageReserve))
+	for _, f := range list {
+		var samples []*models.StorageSample
+		samples, err = models.FindStorageSamples(f.Name, now.Add(-TrendWindow))
+		if err != nil {
+			return nil, fmt.Errorf("looking up samples for %s: %w", f.Name, err)
+		}
+		f.BytesPerDay, f.HasTrend = trend(samples)
+	}
+	return list, nil
+}
+
+// forecasts groups locations by filesystem and adds up the pending demand on
+// each one
+func forecasts(usage []*Usage, demands []Demand, reserve int64) []*Forecast {
+	var list []*Forecast
+	var byDevice = make(map[uint64]*Forecast)
+	var byName = make(map[string]*Forecast)
+	for _, u := range usage {
+		var f = byDevice[u.Device]
+		if f == nil {
+			f = &Forecast{Usage: u, Reserve: reserve}
+			byDevice[u.Device] = f
+			list = append(list, f)
+		} else {
+			f.Shared = append(f.Shared, u.Name)
+		}
+		byName[u.Name] = f
+	}
+
+	for _, d := range demands {
+		for name, n := range d {
+			var f = byName[name]
+			if f != nil {
+				f.Pending += n
+			}
+		}
+	}
+	return list
+}
+
+// trend fits a line to the samples' used space over time, returning how many
+// bytes per day are being used up. Samples must cover at least minTrendSpan.
+func trend(samples []*models.StorageSample) (bytesPerDay float64, ok bool) {
+	if len(samples) < 2 {
+		return 0, false
+	}
+	var first = samples[0].SampledAt
+	if samples[len(samples)-1].SampledAt.Sub(first) < minTrendSpan {
+		return 0, false
+	}
+
+	// Plain least-squares regression, with time in days since the first sample
+	var n = float64(len(samples))
+	var sumX, sumY, sumXY, sumXX float64
+	for _, s := range samples {
+		var x = s.SampledAt.Sub(first).Hours() / 24
+		var y = float64(s.TotalBytes - s.FreeBytes)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+	var denom = n*sumXX - sumX*sumX
+	if denom == 0 {
+		return 0, false
+	}
+	return (n*sumXY - sumX*sumY) / denom, true
+}
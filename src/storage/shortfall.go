@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Shortfall describes a filesystem which doesn't have room for a pipeline.
+// Locations lists every location on that filesystem the pipeline writes to,
+// since their needs all come out of the same free space. Free is what's
+// actually free on the filesystem, and Committed is the part of that which
+// running pipelines are expected to use.
+type Shortfall struct {
+	Locations []string
+	Needed    int64
+	Free      int64
+	Committed int64
+	Reserve   int64
+}
+
+// Location returns the shortfall's locations as a single string for storage
+// and display
+func (s *Shortfall) Location() string {
+	return strings.Join(s.Locations, ", ")
+}
+
+// Available returns the free space left for the pipeline once running
+// pipelines' needs are set aside
+func (s *Shortfall) Available() int64 {
+	return s.Free - s.Committed
+}
+
+// String explains the shortfall for logs and job holds
+func (s *Shortfall) String() string {
+	if s.Committed == 0 {
+		return fmt.Sprintf("%s: needs %s, but only %s is free (reserve: %s)",
+			s.Location(), Size(s.Needed), Size(s.Free), Size(s.Reserve))
+	}
+	return fmt.Sprintf("%s: needs %s, but only %s is free after setting aside %s for running jobs (reserve: %s)",
+		s.Location(), Size(s.Needed), Size(s.Available()), Size(s.Committed), Size(s.Reserve))
+}
+
+// device collects the usage and demand for a single filesystem
+type device struct {
+	names     []string
+	free      int64
+	committed int64
+	needed    int64
+}
+
+// shortfalls groups the demand by filesystem and returns each filesystem
+// where it would leave less than reserve bytes free once the committed demand
+// of running pipelines is also taken out. Demand for locations which weren't
+// measured is ignored.
+func shortfalls(usage []*Usage, d, committed Demand, reserve int64) []*Shortfall {
+	var devices = make(map[uint64]*device)
+	var order []uint64
+	for _, u := range usage {
+		var dev = devices[u.Device]
+		if dev == nil {
+			dev = &device{free: u.Free}
+			devices[u.Device] = dev
+			order = append(order, u.Device)
+		}
+		dev.committed += max(committed[u.Name], 0)
+
+		var n, ok = d[u.Name]
+		if !ok || n <= 0 {
+			continue
+		}
+		dev.names = append(dev.names, u.Name)
+		dev.needed += n
+	}
+
+	var list []*Shortfall
+	for _, id := range order {
+		var dev = devices[id]
+		if dev.needed == 0 || dev.free-dev.committed-dev.needed >= reserve {
+			continue
+		}
+		sort.Strings(dev.names)
+		list = append(list, &Shortfall{Locations: dev.names, Needed: dev.needed, Free: dev.free, Committed: dev.committed, Reserve: reserve})
+	}
+	return list
+}
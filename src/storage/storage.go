@@ -0,0 +1,168 @@
+// Package storage watches free space on the filesystems NCA writes to. It
+// estimates how much space the heavy pipelines will need so they can be held
+// rather than fill a disk partway through, and forecasts when space will run
+// out based on how free space has changed over time.
+package storage
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/internal/datasize"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+)
+
+// Names of the locations we monitor, matching their settings so admins know
+// exactly which path is meant
+const (
+	LocWorkflow        = "WORKFLOW_PATH"
+	LocPageReview      = "PDF_PAGE_REVIEW_PATH"
+	LocBackup          = "ORIGINAL_PDF_BACKUP_PATH"
+	LocBatchOutput     = "BATCH_OUTPUT_PATH"
+	LocBatchProduction = "BATCH_PRODUCTION_PATH"
+	LocErroredIssues   = "ERRORED_ISSUES_PATH"
+)
+
+// Location is a configured path NCA writes to
+type Location struct {
+	Name string
+	Path string
+}
+
+// Locations returns every monitored location
+func Locations(c *config.Config) []*Location {
+	return []*Location{
+		{LocWorkflow, c.WorkflowPath},
+		{LocPageReview, c.PDFPageReviewPath},
+		{LocBackup, c.PDFBackupPath},
+		{LocBatchOutput, c.BatchOutputPath},
+		{LocBatchProduction, c.BatchProductionPath},
+		{LocErroredIssues, c.ErroredIssuesPath},
+	}
+}
+
+// Usage is a location's space at the time it was measured. Device identifies
+// the filesystem, since several locations often share one disk.
+type Usage struct {
+	*Location
+	Device uint64
+	Free   int64
+	Total  int64
+}
+
+// Measure reads the free and total space of the filesystem a location is on
+func Measure(l *Location) (*Usage, error) {
+	var info, err = os.Stat(l.Path)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s (%q): %w", l.Name, l.Path, err)
+	}
+	var st, ok = info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil, fmt.Errorf("reading %s (%q): no device information", l.Name, l.Path)
+	}
+
+	var fs syscall.Statfs_t
+	err = syscall.Statfs(l.Path, &fs)
+	if err != nil {
+		return nil, fmt.Errorf("reading filesystem for %s (%q): %w", l.Name, l.Path, err)
+	}
+
+	return &Usage{
+		Location: l,
+		Device:   uint64(st.Dev),
+		Free:     int64(fs.Bavail) * int64(fs.Bsize),
+		Total:    int64(fs.Blocks) * int64(fs.Bsize),
+	}, nil
+}
+
+// MeasureAll measures every monitored location
+func MeasureAll(c *config.Config) ([]*Usage, error) {
+	var list []*Usage
+	for _, l := range Locations(c) {
+		var u, err = Measure(l)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, u)
+	}
+	return list, nil
+}
+
+// RecordSamples measures every monitored location and stores the results so
+// forecasts can see how free space changes over time
+func RecordSamples(c *config.Config, now time.Time) ([]*Usage, error) {
+	var list, err = MeasureAll(c)
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range list {
+		err = u.Sample(now).Save()
+		if err != nil {
+			return nil, fmt.Errorf("saving sample for %s: %w", u.Name, err)
+		}
+	}
+	return list, nil
+}
+
+// Used returns how many bytes are in use on the location's filesystem
+func (u *Usage) Used() int64 {
+	return u.Total - u.Free
+}
+
+// PercentUsed returns how full the location's filesystem is, from 0 to 100
+func (u *Usage) PercentUsed() int {
+	if u.Total <= 0 {
+		return 0
+	}
+	return int(u.Used() * 100 / u.Total)
+}
+
+// FreeSize returns a human-friendly amount of free space
+func (u *Usage) FreeSize() string {
+	return Size(u.Free)
+}
+
+// TotalSize returns a human-friendly size of the location's filesystem
+func (u *Usage) TotalSize() string {
+	return Size(u.Total)
+}
+
+// Sample returns a database record of this usage
+func (u *Usage) Sample(at time.Time) *models.StorageSample {
+	return &models.StorageSample{
+		Location:   u.Name,
+		Path:       u.Path,
+		FreeBytes:  u.Free,
+		TotalBytes: u.Total,
+		SampledAt:  at,
+	}
+}
+
+// Size formats a byte count for display, rounded to a tenth of the largest
+// unit that keeps the number above one, since free space is rarely a whole
+// number of anything
+func Size(n int64) string {
+	var units = []struct {
+		size   int64
+		suffix string
+	}{
+		{datasize.TB, "TB"},
+		{datasize.GB, "GB"},
+		{datasize.MB, "MB"},
+		{datasize.KB, "KB"},
+	}
+
+	var abs = n
+	if abs < 0 {
+		abs = -abs
+	}
+	for _, u := range units {
+		if abs >= u.size {
+			return fmt.Sprintf("%.1f %s", float64(n)/float64(u.size), u.suffix)
+		}
+	}
+	return fmt.Sprintf("%d B", n)
+}
@@ -0,0 +1,232 @@
+package storage
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/internal/datasize"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+)
+
+func usage(name string, device uint64, free int64) *Usage {
+	return &Usage{Location: &Location{Name: name}, Device: device, Free: free, Total: 1000 * datasize.GB}
+}
+
+func TestShortfalls(t *testing.T) {
+	// Workflow and page review share a disk; batch production has its own
+	var list = []*Usage{
+		usage(LocWorkflow, 1, 100*datasize.GB),
+		usage(LocPageReview, 1, 100*datasize.GB),
+		usage(LocBatchProduction, 2, 10*datasize.GB),
+	}
+
+	var tests = map[string]struct {
+		demand    Demand
+		committed Demand
+		reserve   int64
+		want      []string
+	}{
+		"plenty":          {demand: Demand{LocWorkflow: 10 * datasize.GB}, reserve: 50 * datasize.GB},
+		"exactly reserve": {demand: Demand{LocWorkflow: 50 * datasize.GB}, reserve: 50 * datasize.GB},
+		"into reserve": {
+			demand:  Demand{LocWorkflow: 60 * datasize.GB},
+			reserve: 50 * datasize.GB,
+			want:    []string{LocWorkflow},
+		},
+		"shared disk adds up": {
+			demand:  Demand{LocWorkflow: 30 * datasize.GB, LocPageReview: 30 * datasize.GB},
+			reserve: 50 * datasize.GB,
+			want:    []string{LocPageReview + ", " + LocWorkflow},
+		},
+		"no reserve": {demand: Demand{LocBatchProduction: 10 * datasize.GB}},
+		"full disk": {
+			demand: Demand{LocBatchProduction: 11 * datasize.GB},
+			want:   []string{LocBatchProduction},
+		},
+		"unmeasured location": {demand: Demand{LocBackup: 500 * datasize.GB}},
+		"running pipeline": {
+			demand:    Demand{LocWorkflow: 30 * datasize.GB},
+			committed: Demand{LocWorkflow: 30 * datasize.GB},
+			reserve:   50 * datasize.GB,
+			want:      []string{LocWorkflow},
+		},
+		"running pipeline on another location on the same disk": {
+			demand:    Demand{LocWorkflow: 30 * datasize.GB},
+			committed: Demand{LocPageReview: 30 * datasize.GB},
+			reserve:   50 * datasize.GB,
+			want:      []string{LocWorkflow},
+		},
+		"running pipeline on another disk": {
+			demand:    Demand{LocWorkflow: 30 * datasize.GB},
+			committed: Demand{LocBatchProduction: 30 * datasize.GB},
+			reserve:   50 * datasize.GB,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got []string
+			for _, s := range shortfalls(list, tc.demand, tc.committed, tc.reserve) {
+				got = append(got, s.Location())
+			}
+			if strings.Join(got, "|") != strings.Join(tc.want, "|") {
+				t.Errorf("Expected shortfalls %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestForecasts(t *testing.T) {
+	var list = []*Usage{
+		usage(LocWorkflow, 1, 100*datasize.GB),
+		usage(LocPageReview, 1, 100*datasize.GB),
+		usage(LocBatchProduction, 2, 10*datasize.GB),
+	}
+	var demands = []Demand{
+		estimateIssueMove(1 * datasize.GB),
+		estimateBatch(100),
+	}
+
+	var got = forecasts(list, demands, 5*datasize.GB)
+	if len(got) != 2 {
+		t.Fatalf("Expected 2 filesystems, got %d", len(got))
+	}
+
+	var wf, prod = got[0], got[1]
+	if wf.Name != LocWorkflow || len(wf.Shared) != 1 || wf.Shared[0] != LocPageReview {
+		t.Errorf("Expected workflow to share its disk with page review, got %q sharing %q", wf.Name, wf.Shared)
+	}
+	if wf.Pending != 3*datasize.GB {
+		t.Errorf("Expected 3 GB pending on the workflow disk, got %s", wf.PendingSize())
+	}
+	if prod.Pending != 100*liveBytesPerPage {
+		t.Errorf("Expected %d bytes pending on the production disk, got %d", 100*liveBytesPerPage, prod.Pending)
+	}
+	if wf.Available() != 92*datasize.GB {
+		t.Errorf("Expected 92 GB available on the workflow disk, got %s", wf.AvailableSize())
+	}
+}
+
+func TestForecastAlert(t *testing.T) {
+	var tests = map[string]struct {
+		f           *Forecast
+		wantDays    float64
+		errContains string
+	}{
+		"fine":     {f: &Forecast{Usage: usage("x", 1, 100), Reserve: 10}, wantDays: -1},
+		"freeing":  {f: &Forecast{Usage: usage("x", 1, 100), Reserve: 10, BytesPerDay: -5, HasTrend: true}, wantDays: -1},
+		"no trend": {f: &Forecast{Usage: usage("x", 1, 100), Reserve: 10, BytesPerDay: 50}, wantDays: -1},
+		"slow":     {f: &Forecast{Usage: usage("x", 1, 100), Reserve: 10, BytesPerDay: 3, HasTrend: true}, wantDays: 30},
+		"soon": {
+			f:           &Forecast{Usage: usage("x", 1, 100), Reserve: 10, BytesPerDay: 9, HasTrend: true},
+			wantDays:    10,
+			errContains: "about 10 day(s)",
+		},
+		"pending": {
+			f:           &Forecast{Usage: usage("x", 1, 100), Reserve: 10, Pending: 95},
+			wantDays:    -1,
+			errContains: "Waiting pipelines",
+		},
+		"below reserve": {
+			f:           &Forecast{Usage: usage("x", 1, 5), Reserve: 10, BytesPerDay: 1, HasTrend: true},
+			wantDays:    0,
+			errContains: "below the reserve",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := tc.f.DaysLeft(); math.Abs(got-tc.wantDays) > 0.001 {
+				t.Errorf("Expected %v days left, got %v", tc.wantDays, got)
+			}
+			var alert = tc.f.Alert()
+			if tc.errContains == "" && alert != "" {
+				t.Errorf("Expected no alert, got %q", alert)
+			}
+			if !strings.Contains(alert, tc.errContains) {
+				t.Errorf("Expected alert containing %q, got %q", tc.errContains, alert)
+			}
+		})
+	}
+}
+
+func TestTrend(t *testing.T) {
+	var start = time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)
+	var sample = func(hours int, used int64) *models.StorageSample {
+		return &models.StorageSample{SampledAt: start.Add(time.Duration(hours) * time.Hour), TotalBytes: 1000, FreeBytes: 1000 - used}
+	}
+
+	var tests = map[string]struct {
+		samples []*models.StorageSample
+		want    float64
+		wantOK  bool
+	}{
+		"empty":     {},
+		"one":       {samples: []*models.StorageSample{sample(0, 10)}},
+		"too close": {samples: []*models.StorageSample{sample(0, 10), sample(0, 20)}},
+		"growing":   {samples: []*models.StorageSample{sample(0, 100), sample(24, 110), sample(48, 120)}, want: 10, wantOK: true},
+		"shrinking": {samples: []*models.StorageSample{sample(0, 100), sample(48, 80)}, want: -10, wantOK: true},
+		"noisy":     {samples: []*models.StorageSample{sample(0, 100), sample(24, 130), sample(48, 120)}, want: 10, wantOK: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got, ok = trend(tc.samples)
+			if ok != tc.wantOK || math.Abs(got-tc.want) > 0.001 {
+				t.Errorf("Expected (%v, %v), got (%v, %v)", tc.want, tc.wantOK, got, ok)
+			}
+		})
+	}
+}
+
+func TestCountPages(t *testing.T) {
+	var dir = t.TempDir()
+	var files = map[string]int{
+		"0001.pdf":  100,
+		"0001.tif":  200,
+		"0002.PDF":  100,
+		"0002.tiff": 200,
+		"0003.pdf":  100,
+		"notes.xml": 5,
+	}
+	for name, n := range files {
+		var err = os.WriteFile(filepath.Join(dir, name), make([]byte, n), 0644)
+		if err != nil {
+			t.Fatalf("Unable to write %q: %s", name, err)
+		}
+	}
+
+	var pages, bytes, err = countPages(dir)
+	if err != nil {
+		t.Fatalf("Unable to count pages: %s", err)
+	}
+	if pages != 3 || bytes != 705 {
+		t.Errorf("Expected 3 pages and 705 bytes, got %d pages and %d bytes", pages, bytes)
+	}
+
+	_, _, err = countPages(filepath.Join(dir, "missing"))
+	if err == nil {
+		t.Errorf("Expected an error counting a missing directory")
+	}
+}
+
+func TestSize(t *testing.T) {
+	var tests = map[int64]string{
+		0:                        "0 B",
+		512:                      "512 B",
+		1536:                     "1.5 KB",
+		10 * datasize.GB:         "10.0 GB",
+		-3 * datasize.MB / 2:     "-1.5 MB",
+		2*datasize.TB + 1<<39:    "2.5 TB",
+		int64(datasize.GB) * 999: "999.0 GB",
+	}
+	for n, want := range tests {
+		if got := Size(n); got != want {
+			t.Errorf("Size(%d): expected %q, got %q", n, want, got)
+		}
+	}
+}
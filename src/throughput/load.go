@@ -0,0 +1,50 @@
+package throughput
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+)
+
+// Load reads workflow events from the database and computes a report for the
+// given time range, filling in users' logins and titles' names. Issue actions
+// are the primary source; audit logs and the curation timestamps stored on
+// issues fill in whatever the actions are missing.
+func Load(start, end time.Time) (*Report, error) {
+	var events, err = models.FindWorkflowEvents(end)
+	if err != nil {
+		return nil, fmt.Errorf("loading workflow events: %w", err)
+	}
+	var audits []*models.WorkflowEvent
+	audits, err = models.FindAuditWorkflowEvents(end)
+	if err != nil {
+		return nil, fmt.Errorf("loading audit log events: %w", err)
+	}
+	var stamps []*models.WorkflowEvent
+	stamps, err = models.FindIssueWorkflowEvents(end)
+	if err != nil {
+		return nil, fmt.Errorf("loading issue timestamps: %w", err)
+	}
+
+	var r = Compute(Merge(events, audits, stamps), start, end)
+	for _, u := range r.Users {
+		u.Login = models.FindUserByID(u.UserID).Login
+	}
+
+	var titles models.TitleList
+	titles, err = models.Titles()
+	if err != nil {
+		return nil, fmt.Errorf("loading titles: %w", err)
+	}
+	for _, t := range r.Titles {
+		t.Name = t.LCCN
+		var title = titles.FindByLCCN(t.LCCN)
+		if title != nil {
+			t.Name = title.Name
+		}
+	}
+
+	r.sort()
+	return r, nil
+}
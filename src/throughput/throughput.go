@@ -0,0 +1,362 @@
+// Package throughput computes curator and reviewer productivity from the
+// workflow actions recorded on issues: how many issues and pages were curated
+// and reviewed, how often metadata was rejected, and how long issues waited
+// in each manual workflow step. Audit logs and the curation timestamps on the
+// issues themselves fill in work the actions don't cover.
+//
+// Page counts come from the issues' current data rather than what the issue
+// looked like at the time of an action, so an issue which had pages removed
+// after review will be slightly under-counted in older periods.
+package throughput
+
+import (
+	"sort"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+)
+
+// Stats holds counts and wait times for a single grouping: a month, a user, a
+// title, or the report as a whole
+type Stats struct {
+	IssuesCurated   int // Metadata entries
+	PagesCurated    int
+	IssuesApproved  int // Metadata reviews which approved the issue
+	IssuesRejected  int // Metadata reviews which rejected the issue
+	PagesReviewed   int // Pages in approved or rejected issues
+	CurationsReject int // Metadata entries which were later rejected
+
+	entryWaits  []time.Duration
+	reviewWaits []time.Duration
+}
+
+// IssuesReviewed returns the total number of reviews, approved or rejected
+func (s *Stats) IssuesReviewed() int {
+	return s.IssuesApproved + s.IssuesRejected
+}
+
+// RejectionRate returns the percent of reviews which rejected the issue
+func (s *Stats) RejectionRate() float64 {
+	return percent(s.IssuesRejected, s.IssuesReviewed())
+}
+
+// CurationRejectionRate returns the percent of metadata entries which were
+// later rejected by a reviewer
+func (s *Stats) CurationRejectionRate() float64 {
+	return percent(s.CurationsReject, s.IssuesCurated)
+}
+
+// AverageEntryWait returns the mean time issues spent waiting for metadata
+// entry, from becoming available to being queued for review
+func (s *Stats) AverageEntryWait() time.Duration {
+	return average(s.entryWaits)
+}
+
+// MedianEntryWait returns the median time issues spent waiting for metadata
+// entry
+func (s *Stats) MedianEntryWait() time.Duration {
+	return median(s.entryWaits)
+}
+
+// AverageReviewWait returns the mean time issues spent awaiting review
+func (s *Stats) AverageReviewWait() time.Duration {
+	return average(s.reviewWaits)
+}
+
+// MedianReviewWait returns the median time issues spent awaiting review
+func (s *Stats) MedianReviewWait() time.Duration {
+	return median(s.reviewWaits)
+}
+
+// Period is the stats for a single month
+type Period struct {
+	Stats
+	Month time.Time // First day of the month
+}
+
+// Label returns a human-friendly month name, e.g., "2025-04"
+func (p *Period) Label() string {
+	return p.Month.Format("2006-01")
+}
+
+// UserStats is the stats for a single person
+type UserStats struct {
+	Stats
+	UserID int64
+	Login  string
+}
+
+// TitleStats is the stats for a single title
+type TitleStats struct {
+	Stats
+	LCCN string
+	Name string
+}
+
+// Report holds all stats for a given time range
+type Report struct {
+	Start  time.Time
+	End    time.Time
+	Totals Stats
+	Months []*Period
+	Users  []*UserStats
+	Titles []*TitleStats
+
+	months map[string]*Period
+	users  map[int64]*UserStats
+	titles map[string]*TitleStats
+}
+
+// newReport returns an empty report for the given time range
+func newReport(start, end time.Time) *Report {
+	return &Report{
+		Start:  start,
+		End:    end,
+		months: make(map[string]*Period),
+		users:  make(map[int64]*UserStats),
+		titles: make(map[string]*TitleStats),
+	}
+}
+
+// inRange returns true if t falls within the report's time range
+func (r *Report) inRange(t time.Time) bool {
+	return !t.Before(r.Start) && t.Before(r.End)
+}
+
+func (r *Report) month(t time.Time) *Stats {
+	var m = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	var key = m.Format("2006-01")
+	if r.months[key] == nil {
+		r.months[key] = &Period{Month: m}
+		r.Months = append(r.Months, r.months[key])
+	}
+	return &r.months[key].Stats
+}
+
+func (r *Report) user(id int64) *Stats {
+	if r.users[id] == nil {
+		r.users[id] = &UserStats{UserID: id}
+		r.Users = append(r.Users, r.users[id])
+	}
+	return &r.users[id].Stats
+}
+
+func (r *Report) title(lccn string) *Stats {
+	if r.titles[lccn] == nil {
+		r.titles[lccn] = &TitleStats{LCCN: lccn}
+		r.Titles = append(r.Titles, r.titles[lccn])
+	}
+	return &r.titles[lccn].Stats
+}
+
+// apply runs fn against every grouping the event belongs to
+func (r *Report) apply(e *models.WorkflowEvent, userID int64, fn func(s *Stats)) {
+	fn(&r.Totals)
+	fn(r.month(e.When))
+	fn(r.user(userID))
+	fn(r.title(e.LCCN))
+}
+
+// issueState tracks a single issue's progress through the events stream
+type issueState struct {
+	entryStart  time.Time
+	reviewStart time.Time
+	curatorID   int64
+}
+
+// Compute builds a report from the given events. Events must be ordered by
+// issue and then time, as returned by [models.FindWorkflowEvents]. Events
+// prior to the report's start are still needed to compute wait times for
+// work completed in the report's time range.
+func Compute(events []*models.WorkflowEvent, start, end time.Time) *Report {
+	var r = newReport(start, end)
+	var states = make(map[int64]*issueState)
+
+	for _, e := range events {
+		var st = states[e.IssueID]
+		if st == nil {
+			st = &issueState{}
+			states[e.IssueID] = st
+		}
+		var counted = r.inRange(e.When)
+
+		switch e.Type {
+		case models.ActionTypeMetadataEntry:
+			if counted {
+				r.apply(e, e.UserID, func(s *Stats) {
+					s.IssuesCurated++
+					s.PagesCurated += e.PageCount
+					if !st.entryStart.IsZero() {
+						s.entryWaits = append(s.entryWaits, e.When.Sub(st.entryStart))
+					}
+				})
+			}
+			st.curatorID = e.UserID
+			st.reviewStart = e.When
+
+		case models.ActionTypeMetadataApproval, models.ActionTypeMetadataRejection:
+			var rejected = e.Type == models.ActionTypeMetadataRejection
+			if counted {
+				r.apply(e, e.UserID, func(s *Stats) {
+					if rejected {
+						s.IssuesRejected++
+					} else {
+						s.IssuesApproved++
+					}
+					s.PagesReviewed += e.PageCount
+					if !st.reviewStart.IsZero() {
+						s.reviewWaits = append(s.reviewWaits, e.When.Sub(st.reviewStart))
+					}
+				})
+				if rejected && st.curatorID != 0 {
+					r.apply(e, st.curatorID, func(s *Stats) { s.CurationsReject++ })
+				}
+			}
+			if rejected {
+				st.entryStart = e.When
+			}
+
+		case models.ActionTypeInternalProcess, models.ActionTypeReturnCurate:
+			st.entryStart = e.When
+
+		case models.ActionTypeReturnReview:
+			st.reviewStart = e.When
+		}
+	}
+
+	r.sort()
+	return r
+}
+
+// mergeWindow is how close in time two events of the same type on the same
+// issue must be for Merge to consider them the same event
+const mergeWindow = time.Minute * 5
+
+// Merge combines events from multiple sources into a single list ordered by
+// issue and then time, as [Compute] requires. The first source is considered
+// authoritative: an event from a later source is dropped if an event of the
+// same type on the same issue was already seen within a few minutes of it.
+func Merge(sources ...[]*models.WorkflowEvent) []*models.WorkflowEvent {
+	type key struct {
+		issueID int64
+		aType   models.ActionType
+	}
+	var seen = make(map[key][]time.Time)
+	var list []*models.WorkflowEvent
+
+	for idx, src := range sources {
+		var added []*models.WorkflowEvent
+		for _, e := range src {
+			var k = key{e.IssueID, e.Type}
+			if idx > 0 && hasNear(seen[k], e.When) {
+				continue
+			}
+			added = append(added, e)
+		}
+		for _, e := range added {
+			var k = key{e.IssueID, e.Type}
+			seen[k] = append(seen[k], e.When)
+		}
+		list = append(list, added...)
+	}
+
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].IssueID != list[j].IssueID {
+			return list[i].IssueID < list[j].IssueID
+		}
+		return list[i].When.Before(list[j].When)
+	})
+	return list
+}
+
+// hasNear returns true if any of the times is within mergeWindow of t
+func hasNear(times []time.Time, t time.Time) bool {
+	for _, other := range times {
+		var d = t.Sub(other)
+		if d < mergeWindow && d > -mergeWindow {
+			return true
+		}
+	}
+	return false
+}
+
+// sort puts months in chronological order, users by login, and titles by
+// name, falling back to ids when names aren't known yet
+func (r *Report) sort() {
+	sort.Slice(r.Months, func(i, j int) bool { return r.Months[i].Month.Before(r.Months[j].Month) })
+	sort.Slice(r.Users, func(i, j int) bool {
+		if r.Users[i].Login != r.Users[j].Login {
+			return r.Users[i].Login < r.Users[j].Login
+		}
+		return r.Users[i].UserID < r.Users[j].UserID
+	})
+	sort.Slice(r.Titles, func(i, j int) bool {
+		if r.Titles[i].Name != r.Titles[j].Name {
+			return r.Titles[i].Name < r.Titles[j].Name
+		}
+		return r.Titles[i].LCCN < r.Titles[j].LCCN
+	})
+}
+
+// ChartRow is a single month's bar in the monthly pages chart
+type ChartRow struct {
+	Label         string
+	PagesCurated  int
+	PagesReviewed int
+	CuratedWidth  float64 // Percent of the widest bar
+	ReviewedWidth float64
+}
+
+// Chart returns the monthly curated/reviewed page counts scaled for display as
+// a bar chart
+func (r *Report) Chart() []ChartRow {
+	var maxPages int
+	for _, m := range r.Months {
+		maxPages = max(maxPages, m.PagesCurated, m.PagesReviewed)
+	}
+
+	var rows = make([]ChartRow, len(r.Months))
+	for i, m := range r.Months {
+		rows[i] = ChartRow{
+			Label:         m.Label(),
+			PagesCurated:  m.PagesCurated,
+			PagesReviewed: m.PagesReviewed,
+			CuratedWidth:  percent(m.PagesCurated, maxPages),
+			ReviewedWidth: percent(m.PagesReviewed, maxPages),
+		}
+	}
+	return rows
+}
+
+func percent(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) * 100 / float64(total)
+}
+
+func average(list []time.Duration) time.Duration {
+	if len(list) == 0 {
+		return 0
+	}
+	var sum time.Duration
+	for _, d := range list {
+		sum += d
+	}
+	return sum / time.Duration(len(list))
+}
+
+func median(list []time.Duration) time.Duration {
+	if len(list) == 0 {
+		return 0
+	}
+	var sorted = make([]time.Duration, len(list))
+	copy(sorted, list)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var mid = len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
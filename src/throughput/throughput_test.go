@@ -0,0 +1,144 @@
+package throughput
+
+import (
+	"testing"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+)
+
+var day = time.Hour * 24
+var t0 = time.Date(2025, 3, 29, 12, 0, 0, 0, time.UTC)
+
+func ev(issueID int64, aType models.ActionType, userID int64, offset time.Duration) *models.WorkflowEvent {
+	return &models.WorkflowEvent{IssueID: issueID, LCCN: "sn12345678", PageCount: 10, Type: aType, UserID: userID, When: t0.Add(offset)}
+}
+
+// events returns a single issue's history: it's curated by user 1, rejected by
+// user 2, curated again, then approved
+func events() []*models.WorkflowEvent {
+	return []*models.WorkflowEvent{
+		ev(1, models.ActionTypeInternalProcess, -1, 0),
+		ev(1, models.ActionTypeMetadataEntry, 1, 2*day),
+		ev(1, models.ActionTypeMetadataRejection, 2, 3*day),
+		ev(1, models.ActionTypeMetadataEntry, 1, 4*day),
+		ev(1, models.ActionTypeMetadataApproval, 2, 5*day),
+	}
+}
+
+func TestCompute(t *testing.T) {
+	var r = Compute(events(), t0, t0.Add(30*day))
+	var s = r.Totals
+
+	if s.IssuesCurated != 2 || s.PagesCurated != 20 {
+		t.Errorf("Expected 2 issues / 20 pages curated, got %d / %d", s.IssuesCurated, s.PagesCurated)
+	}
+	if s.IssuesApproved != 1 || s.IssuesRejected != 1 || s.PagesReviewed != 20 {
+		t.Errorf("Expected 1 approval, 1 rejection, 20 pages reviewed; got %d, %d, %d", s.IssuesApproved, s.IssuesRejected, s.PagesReviewed)
+	}
+	if s.CurationsReject != 1 {
+		t.Errorf("Expected 1 rejected curation, got %d", s.CurationsReject)
+	}
+	if s.AverageEntryWait() != 36*time.Hour {
+		t.Errorf("Expected average entry wait of 36h, got %s", s.AverageEntryWait())
+	}
+	if s.MedianReviewWait() != day {
+		t.Errorf("Expected median review wait of 24h, got %s", s.MedianReviewWait())
+	}
+
+	if len(r.Users) != 2 {
+		t.Fatalf("Expected 2 users, got %d", len(r.Users))
+	}
+	var curator, reviewer = r.users[1], r.users[2]
+	if curator.IssuesCurated != 2 || curator.IssuesReviewed() != 0 || curator.CurationRejectionRate() != 50 {
+		t.Errorf("Unexpected curator stats: %#v", curator.Stats)
+	}
+	if reviewer.IssuesCurated != 0 || reviewer.IssuesReviewed() != 2 || reviewer.RejectionRate() != 50 {
+		t.Errorf("Unexpected reviewer stats: %#v", reviewer.Stats)
+	}
+
+	// The events straddle March and April
+	if len(r.Months) != 2 || r.Months[0].Label() != "2025-03" || r.Months[1].Label() != "2025-04" {
+		t.Fatalf("Unexpected months: %#v", r.Months)
+	}
+	if r.Months[0].IssuesCurated != 1 || r.Months[1].IssuesCurated != 1 {
+		t.Errorf("Expected one curation per month, got %d in March and %d in April",
+			r.Months[0].IssuesCurated, r.Months[1].IssuesCurated)
+	}
+	if r.Months[0].IssuesReviewed() != 0 || r.Months[1].IssuesReviewed() != 2 {
+		t.Errorf("Expected all reviews in April, got %d in March and %d in April",
+			r.Months[0].IssuesReviewed(), r.Months[1].IssuesReviewed())
+	}
+}
+
+func TestComputeRange(t *testing.T) {
+	// Starting the report just after the rejection means only the second round
+	// of curation and the approval are counted, but the wait times still use
+	// the events that happened before the report's start
+	var r = Compute(events(), t0.Add(3*day+time.Hour), t0.Add(30*day))
+	var s = r.Totals
+
+	if s.IssuesCurated != 1 || s.IssuesApproved != 1 || s.IssuesRejected != 0 || s.CurationsReject != 0 {
+		t.Errorf("Unexpected totals: %#v", s)
+	}
+	if s.AverageEntryWait() != day {
+		t.Errorf("Expected entry wait of 24h, got %s", s.AverageEntryWait())
+	}
+	if s.AverageReviewWait() != day {
+		t.Errorf("Expected review wait of 24h, got %s", s.AverageReviewWait())
+	}
+}
+
+func TestChart(t *testing.T) {
+	var r = Compute(events(), t0, t0.Add(30*day))
+	var rows = r.Chart()
+	if len(rows) != 2 {
+		t.Fatalf("Expected 2 chart rows, got %d", len(rows))
+	}
+	if rows[1].ReviewedWidth != 100 || rows[1].CuratedWidth != 50 {
+		t.Errorf("Expected April reviews to be the widest bar, got %#v", rows[1])
+	}
+	if rows[0].CuratedWidth != 50 || rows[0].ReviewedWidth != 0 {
+		t.Errorf("Expected March to have half-width curation and no reviews, got %#v", rows[0])
+	}
+}
+
+func TestMerge(t *testing.T) {
+	// The audit logs duplicate every action a few seconds later, and add a
+	// review the actions are missing; the issue's stored timestamps duplicate
+	// the final curation and approval, and add a curation for an issue with no
+	// actions at all
+	var audits []*models.WorkflowEvent
+	for _, e := range events()[1:] {
+		var dupe = *e
+		dupe.When = dupe.When.Add(time.Second * 3)
+		audits = append(audits, &dupe)
+	}
+	audits = append(audits, ev(1, models.ActionTypeMetadataApproval, 3, 6*day))
+	var stamps = []*models.WorkflowEvent{
+		ev(2, models.ActionTypeMetadataEntry, 1, day),
+		ev(1, models.ActionTypeMetadataApproval, 3, 6*day),
+		ev(1, models.ActionTypeMetadataEntry, 1, 4*day+time.Minute),
+	}
+
+	var list = Merge(events(), audits, stamps)
+	if len(list) != 7 {
+		t.Fatalf("Expected 7 events, got %d", len(list))
+	}
+	if list[5].UserID != 3 || !list[5].When.Equal(t0.Add(6*day)) {
+		t.Errorf("Expected the audit-only approval to be kept, got %#v", list[5])
+	}
+	if list[6].IssueID != 2 {
+		t.Errorf("Expected the issue-only curation last, got %#v", list[6])
+	}
+	for i := 1; i < len(list); i++ {
+		if list[i].IssueID == list[i-1].IssueID && list[i].When.Before(list[i-1].When) {
+			t.Errorf("Events out of order at %d: %#v", i, list)
+		}
+	}
+
+	var s = Compute(list, t0, t0.Add(30*day)).Totals
+	if s.IssuesCurated != 3 || s.IssuesApproved != 2 {
+		t.Errorf("Expected 3 curations and 2 approvals, got %d and %d", s.IssuesCurated, s.IssuesApproved)
+	}
+}
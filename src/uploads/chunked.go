@@ -0,0 +1,200 @@
+package uploads
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/uoregon-libraries/gopkg/fileutil"
+)
+
+// rename moves a staged file into place; tests replace it to simulate a
+// staging area on another device
+var rename = os.Rename
+
+// ErrFileExists is returned when a chunked upload would overwrite a file
+// that's already been delivered
+var ErrFileExists = errors.New("a file with this name has already been uploaded")
+
+// ErrNotPDF is returned when a completed upload doesn't look like a PDF
+var ErrNotPDF = errors.New("file is not a PDF")
+
+// OffsetError is returned when a chunk doesn't start where the staged data
+// ends. Clients should resume from Expected.
+type OffsetError struct {
+	Expected int64
+	Got      int64
+}
+
+func (e *OffsetError) Error() string {
+	return fmt.Sprintf("chunk starts at byte %d, but upload is at byte %d", e.Got, e.Expected)
+}
+
+// ChunkedFile is a single file being uploaded in pieces. Chunks are appended
+// to a staging file, which is only moved to its final location once the
+// expected number of bytes has arrived, so partial uploads never show up in
+// the upload scans. Because the staged data persists, an interrupted upload
+// can be resumed by asking for its Offset and sending the rest.
+type ChunkedFile struct {
+	PartPath  string
+	FinalPath string
+	Size      int64
+}
+
+// NewChunkedFile returns a ChunkedFile which stages data in stagingDir and
+// delivers the finished file to finalPath
+func NewChunkedFile(stagingDir, finalPath string, size int64) *ChunkedFile {
+	var name = filepath.Base(finalPath) + ".part"
+	return &ChunkedFile{PartPath: filepath.Join(stagingDir, name), FinalPath: finalPath, Size: size}
+}
+
+// Offset returns the number of bytes received so far
+func (f *ChunkedFile) Offset() (int64, error) {
+	var info, err = os.Stat(f.PartPath)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// WriteChunk appends the data in r to the staged file. offset must match the
+// staged file's current size, otherwise an *OffsetError is returned and
+// nothing is written. A chunk which would make the file larger than its
+// expected size is rejected as well. The new offset is returned.
+func (f *ChunkedFile) WriteChunk(offset int64, r io.Reader) (int64, error) {
+	if fileutil.Exists(f.FinalPath) {
+		return 0, ErrFileExists
+	}
+
+	var current, err = f.Offset()
+	if err != nil {
+		return 0, err
+	}
+	if current != offset {
+		return current, &OffsetError{Expected: current, Got: offset}
+	}
+
+	err = os.MkdirAll(filepath.Dir(f.PartPath), 0755)
+	if err != nil {
+		return current, err
+	}
+
+	var fh *os.File
+	fh, err = os.OpenFile(f.PartPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return current, err
+	}
+
+	// Read at most one byte more than we need so we can tell if the client sent
+	// too much data
+	var n int64
+	n, err = io.Copy(fh, io.LimitReader(r, f.Size-current+1))
+	var closeErr = fh.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil && current+n > f.Size {
+		err = fmt.Errorf("chunk would exceed the file's size of %d bytes", f.Size)
+	}
+
+	// On any failure we put the staged file back the way it was so a retry of
+	// the same chunk can succeed
+	if err != nil {
+		var truncErr = os.Truncate(f.PartPath, current)
+		if truncErr != nil {
+			return current, fmt.Errorf("%w (and unable to roll back staged data: %s)", err, truncErr)
+		}
+		return current, err
+	}
+
+	return current + n, nil
+}
+
+// Complete returns true if all bytes have been received
+func (f *ChunkedFile) Complete() bool {
+	var offset, err = f.Offset()
+	return err == nil && offset == f.Size
+}
+
+// Finish verifies the staged data is a complete PDF and moves it to its final
+// location. If the data isn't a PDF, the staged file is removed so the
+// upload can be started over.
+func (f *ChunkedFile) Finish() error {
+	if !f.Complete() {
+		return fmt.Errorf("upload is incomplete")
+	}
+	if fileutil.Exists(f.FinalPath) {
+		return ErrFileExists
+	}
+
+	var isPDF, err = hasPDFHeader(f.PartPath)
+	if err != nil {
+		return err
+	}
+	if !isPDF {
+		f.Abort()
+		return ErrNotPDF
+	}
+
+	err = os.MkdirAll(filepath.Dir(f.FinalPath), 0755)
+	if err != nil {
+		return err
+	}
+
+	// Renaming is atomic, but only works within a single filesystem. When the
+	// staging area lives elsewhere, we copy to a hidden file beside the final
+	// path, which upload scans ignore, and rename that into place so a partial
+	// PDF is never visible.
+	err = rename(f.PartPath, f.FinalPath)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+
+	var tmp = filepath.Join(filepath.Dir(f.FinalPath), "."+filepath.Base(f.FinalPath)+".tmp")
+	err = fileutil.CopyFile(f.PartPath, tmp)
+	if err == nil {
+		err = os.Rename(tmp, f.FinalPath)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Remove(f.PartPath)
+}
+
+// Abort removes any staged data
+func (f *ChunkedFile) Abort() error {
+	var err = os.Remove(f.PartPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func hasPDFHeader(path string) (bool, error) {
+	var fh, err = os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer fh.Close()
+
+	var header = make([]byte, 5)
+	_, err = io.ReadFull(fh, header)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(header, []byte("%PDF-")), nil
+}
@@ -0,0 +1,165 @@
+package uploads
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func newTestChunkedFile(t *testing.T, data string) *ChunkedFile {
+	var dir = t.TempDir()
+	return NewChunkedFile(filepath.Join(dir, "staging"), filepath.Join(dir, "sftp", "title", "2020-01-02", "0001.pdf"), int64(len(data)))
+}
+
+func TestChunkedFileResume(t *testing.T) {
+	var data = "%PDF-1.4 fake pdf data"
+	var f = newTestChunkedFile(t, data)
+
+	var offset, err = f.WriteChunk(0, strings.NewReader(data[:10]))
+	if err != nil {
+		t.Fatalf("First chunk: %s", err)
+	}
+	if offset != 10 {
+		t.Fatalf("Offset after first chunk: got %d, expected 10", offset)
+	}
+
+	// A retried or out-of-order chunk must be rejected, telling us where to
+	// resume from
+	_, err = f.WriteChunk(4, strings.NewReader(data[4:]))
+	var offErr *OffsetError
+	if !errors.As(err, &offErr) || offErr.Expected != 10 {
+		t.Fatalf("Out-of-order chunk: expected OffsetError at 10, got %v", err)
+	}
+
+	// A "new" ChunkedFile with the same paths picks up the staged data
+	f = NewChunkedFile(filepath.Dir(f.PartPath), f.FinalPath, f.Size)
+	offset, err = f.Offset()
+	if err != nil || offset != 10 {
+		t.Fatalf("Resumed offset: got %d (err %v), expected 10", offset, err)
+	}
+	if f.Complete() {
+		t.Fatalf("Partial upload shouldn't be complete")
+	}
+
+	offset, err = f.WriteChunk(offset, strings.NewReader(data[10:]))
+	if err != nil || offset != f.Size {
+		t.Fatalf("Final chunk: got offset %d (err %v), expected %d", offset, err, f.Size)
+	}
+
+	err = f.Finish()
+	if err != nil {
+		t.Fatalf("Finish: %s", err)
+	}
+	var got, _ = os.ReadFile(f.FinalPath)
+	if string(got) != data {
+		t.Errorf("Final file: got %q, expected %q", got, data)
+	}
+	if _, err = os.Stat(f.PartPath); !os.IsNotExist(err) {
+		t.Errorf("Staged file should be gone after Finish")
+	}
+
+	_, err = f.WriteChunk(0, strings.NewReader(data))
+	if !errors.Is(err, ErrFileExists) {
+		t.Errorf("Writing a delivered file: expected ErrFileExists, got %v", err)
+	}
+}
+
+func TestChunkedFileTooLarge(t *testing.T) {
+	var data = "%PDF-1.4"
+	var f = newTestChunkedFile(t, data)
+
+	var _, err = f.WriteChunk(0, strings.NewReader(data[:4]))
+	if err != nil {
+		t.Fatalf("First chunk: %s", err)
+	}
+
+	var offset int64
+	offset, err = f.WriteChunk(4, strings.NewReader(data[4:]+"extra"))
+	if err == nil {
+		t.Fatalf("Oversized chunk should fail")
+	}
+	if offset != 4 {
+		t.Errorf("Oversized chunk offset: got %d, expected 4", offset)
+	}
+
+	// The bad chunk must have been rolled back so a correct retry works
+	offset, err = f.WriteChunk(4, strings.NewReader(data[4:]))
+	if err != nil || offset != f.Size {
+		t.Errorf("Retried chunk: got offset %d (err %v), expected %d", offset, err, f.Size)
+	}
+}
+
+func TestChunkedFileNotPDF(t *testing.T) {
+	var data = "not a pdf"
+	var f = newTestChunkedFile(t, data)
+
+	var _, err = f.WriteChunk(0, strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("WriteChunk: %s", err)
+	}
+
+	err = f.Finish()
+	if !errors.Is(err, ErrNotPDF) {
+		t.Fatalf("Finish: expected ErrNotPDF, got %v", err)
+	}
+	if _, err = os.Stat(f.FinalPath); !os.IsNotExist(err) {
+		t.Errorf("Invalid PDF should not be delivered")
+	}
+	var offset, _ = f.Offset()
+	if offset != 0 {
+		t.Errorf("Invalid PDF should be removed from staging so it can be restarted")
+	}
+}
+
+func TestChunkedFileFinishRenameErrors(t *testing.T) {
+	defer func() { rename = os.Rename }()
+
+	var tests = map[string]struct {
+		err       error
+		delivered bool
+	}{
+		"cross-device": {err: syscall.EXDEV, delivered: true},
+		"other error":  {err: syscall.EACCES},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			rename = func(src, dst string) error { return &os.LinkError{Op: "rename", Old: src, New: dst, Err: tc.err} }
+
+			var data = "%PDF-1.4 fake pdf data"
+			var f = newTestChunkedFile(t, data)
+			var _, err = f.WriteChunk(0, strings.NewReader(data))
+			if err != nil {
+				t.Fatalf("WriteChunk: %s", err)
+			}
+
+			err = f.Finish()
+			if tc.delivered != (err == nil) {
+				t.Fatalf("Finish: expected delivery %v, got error %v", tc.delivered, err)
+			}
+
+			var got, _ = os.ReadFile(f.FinalPath)
+			if tc.delivered && string(got) != data {
+				t.Errorf("Delivered file: got %q, expected %q", got, data)
+			}
+			if !tc.delivered && got != nil {
+				t.Errorf("Nothing should be delivered after a failed rename")
+			}
+
+			var entries, _ = os.ReadDir(filepath.Dir(f.FinalPath))
+			for _, e := range entries {
+				if strings.HasPrefix(e.Name(), ".") {
+					t.Errorf("Temporary file %q left behind", e.Name())
+				}
+			}
+
+			var offset, _ = f.Offset()
+			if tc.delivered == (offset != 0) {
+				t.Errorf("Staged data: got offset %d after delivery %v", offset, tc.delivered)
+			}
+		})
+	}
+}
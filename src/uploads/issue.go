@@ -54,6 +54,15 @@ func (i *Issue) ValidateFast() {
 			i.WarnTooNew()
 		}
 	}
+	i.ValidateContents()
+}
+
+// ValidateContents runs the fast validations which don't depend on how
+// recently the issue was modified: title problems and duplicates. This is
+// useful for giving feedback while an issue is still being uploaded, when the
+// age checks would always fail. Unlike ValidateFast, this doesn't track
+// whether it has already run, so it should only be called once per Issue.
+func (i *Issue) ValidateContents() {
 	if i.Title.Errors.Major().Len() > 0 {
 		i.ErrBadTitle()
 	}
@@ -0,0 +1,138 @@
+// Package workload hands issues awaiting metadata entry or review to the
+// curators and reviewers who have opted into automatic assignment. Issues are
+// balanced by each person's current load and familiarity with the issue's
+// title, and the oldest issues are handed out first.
+package workload
+
+import (
+	"sort"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/privilege"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/schema"
+)
+
+// affinityCap limits how far title familiarity can outweigh load: somebody
+// who has curated hundreds of issues of a title will end up with at most this
+// many more issues on their desk than somebody who's never seen it
+const affinityCap = 5
+
+// Worker is a person who can be handed issues
+type Worker struct {
+	User     *models.User
+	Load     int            // How many issues are currently on the user's desk
+	Curated  map[string]int // How many issues the user has curated, per LCCN
+	Reviewed map[string]int // How many issues the user has reviewed, per LCCN
+}
+
+// canTake returns true if the worker is allowed to handle the issue in its
+// current workflow step. As with manual claims, a reviewer may not review
+// their own metadata without the ReviewOwnMetadata privilege.
+func (w *Worker) canTake(i *models.Issue) bool {
+	switch i.WorkflowStep {
+	case schema.WSReadyForMetadataEntry:
+		return w.User.PermittedTo(privilege.EnterIssueMetadata)
+	case schema.WSAwaitingMetadataReview:
+		if !w.User.PermittedTo(privilege.ReviewIssueMetadata) {
+			return false
+		}
+		return i.MetadataEntryUserID != w.User.ID || w.User.PermittedTo(privilege.ReviewOwnMetadata)
+	}
+
+	return false
+}
+
+// affinity returns how familiar the worker is with the issue's title for the
+// kind of work the issue needs
+func (w *Worker) affinity(i *models.Issue) int {
+	var n = w.Curated[i.LCCN]
+	if i.WorkflowStep == schema.WSAwaitingMetadataReview {
+		n = w.Reviewed[i.LCCN]
+	}
+	return min(n, affinityCap)
+}
+
+// score rates how good a fit the worker is for the issue: the higher the
+// better
+func (w *Worker) score(i *models.Issue) int {
+	return w.affinity(i) - w.Load
+}
+
+// An Assignment pairs an issue with the worker chosen to handle it
+type Assignment struct {
+	Issue        *models.Issue
+	Worker       *Worker
+	PriorOwnerID int64 // If non-zero, the user whose claim on the issue expired
+}
+
+// Balancer decides who gets which issues
+type Balancer struct {
+	MaxLoad int
+	Workers []*Worker
+}
+
+// Assign chooses a worker for as many of the given issues as it can without
+// putting more than MaxLoad issues on anybody's desk. Workers' loads are
+// updated as issues are assigned, but nothing is written to the database.
+//
+// Issues are assigned in the order they entered their current workflow step
+// (falling back to database id) so that issues which have been waiting the
+// longest get first pick of the available capacity. An issue
+// whose claim expired is never handed back to the person who let it expire.
+func (b *Balancer) Assign(issues []*models.Issue) []*Assignment {
+	var sorted = make([]*models.Issue, len(issues))
+	copy(sorted, issues)
+	sort.Slice(sorted, func(x, y int) bool {
+		var tx, ty = sorted[x].WorkflowStepChangedAt, sorted[y].WorkflowStepChangedAt
+		if !tx.Equal(ty) {
+			return tx.Before(ty)
+		}
+		return sorted[x].ID < sorted[y].ID
+	})
+
+	var list []*Assignment
+	for _, i := range sorted {
+		var w = b.bestWorker(i)
+		if w == nil {
+			continue
+		}
+
+		w.Load++
+		list = append(list, &Assignment{Issue: i, Worker: w, PriorOwnerID: i.WorkflowOwnerID})
+	}
+
+	return list
+}
+
+// bestWorker returns the highest-scoring worker who can take the issue, or
+// nil if nobody can. Ties go to the lighter load, then the lower user id, so
+// results are deterministic.
+func (b *Balancer) bestWorker(i *models.Issue) *Worker {
+	var best *Worker
+	for _, w := range b.Workers {
+		if w.Load >= b.MaxLoad || !w.canTake(i) {
+			continue
+		}
+		if i.WorkflowOwnerID != 0 && i.WorkflowOwnerID == w.User.ID {
+			continue
+		}
+
+		if best == nil || better(w, best, i) {
+			best = w
+		}
+	}
+
+	return best
+}
+
+// better returns true if a is a better choice than b for the issue
+func better(a, b *Worker, i *models.Issue) bool {
+	var sa, sb = a.score(i), b.score(i)
+	if sa != sb {
+		return sa > sb
+	}
+	if a.Load != b.Load {
+		return a.Load < b.Load
+	}
+	return a.User.ID < b.User.ID
+}
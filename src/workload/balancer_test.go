@@ -0,0 +1,136 @@
+package workload
+
+import (
+	"testing"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/privilege"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/schema"
+)
+
+func worker(id int64, login string, roles ...*privilege.Role) *Worker {
+	var u = models.NewUser(login)
+	u.ID = id
+	for _, r := range roles {
+		u.Grant(r)
+	}
+	return &Worker{User: u, Curated: map[string]int{}, Reviewed: map[string]int{}}
+}
+
+func issue(id int64, lccn string, ws schema.WorkflowStep) *models.Issue {
+	return &models.Issue{ID: id, LCCN: lccn, WorkflowStep: ws}
+}
+
+func assigned(list []*Assignment) map[int64]string {
+	var m = make(map[int64]string)
+	for _, a := range list {
+		m[a.Issue.ID] = a.Worker.User.Login
+	}
+	return m
+}
+
+func checkAssignments(t *testing.T, got, expected map[int64]string) {
+	t.Helper()
+	if len(got) != len(expected) {
+		t.Errorf("Expected %d assignments, got %d: %#v", len(expected), len(got), got)
+	}
+	for id, login := range expected {
+		if got[id] != login {
+			t.Errorf("Expected issue %d to go to %q, got %q", id, login, got[id])
+		}
+	}
+}
+
+func TestAssignBalancesLoad(t *testing.T) {
+	var alice = worker(1, "alice", privilege.RoleIssueCurator)
+	var bob = worker(2, "bob", privilege.RoleIssueCurator)
+	alice.Load = 2
+	var b = &Balancer{MaxLoad: 3, Workers: []*Worker{alice, bob}}
+
+	// Issues are deliberately out of order to verify the oldest are handled first
+	var issues []*models.Issue
+	for _, id := range []int64{5, 3, 1, 4, 2} {
+		issues = append(issues, issue(id, "sn1", schema.WSReadyForMetadataEntry))
+	}
+
+	// Bob gets issues until his load matches Alice's, then the tie goes to the
+	// lower user id, and the last issue can't be assigned at all
+	checkAssignments(t, assigned(b.Assign(issues)), map[int64]string{1: "bob", 2: "bob", 3: "alice", 4: "bob"})
+	if alice.Load != 3 || bob.Load != 3 {
+		t.Errorf("Expected both workers to be at max load, got alice: %d, bob: %d", alice.Load, bob.Load)
+	}
+}
+
+func TestAssignLongestWaitingFirst(t *testing.T) {
+	var alice = worker(1, "alice", privilege.RoleIssueCurator)
+	var b = &Balancer{MaxLoad: 2, Workers: []*Worker{alice}}
+
+	// Issue 1 has the lowest id but was only just returned to metadata entry,
+	// so the two issues which have been waiting longer go first
+	var now = time.Now()
+	var issues = []*models.Issue{
+		issue(1, "sn1", schema.WSReadyForMetadataEntry),
+		issue(2, "sn1", schema.WSReadyForMetadataEntry),
+		issue(3, "sn1", schema.WSReadyForMetadataEntry),
+	}
+	issues[0].WorkflowStepChangedAt = now
+	issues[1].WorkflowStepChangedAt = now.Add(-time.Hour)
+	issues[2].WorkflowStepChangedAt = now.Add(-time.Hour * 2)
+
+	checkAssignments(t, assigned(b.Assign(issues)), map[int64]string{2: "alice", 3: "alice"})
+}
+
+func TestAssignTitleAffinity(t *testing.T) {
+	var alice = worker(1, "alice", privilege.RoleIssueCurator)
+	var bob = worker(2, "bob", privilege.RoleIssueCurator)
+	alice.Curated["sn1"] = 100
+	bob.Curated["sn2"] = 2
+	var b = &Balancer{MaxLoad: 20, Workers: []*Worker{alice, bob}}
+
+	var issues []*models.Issue
+	for id := int64(1); id <= 8; id++ {
+		issues = append(issues, issue(id, "sn1", schema.WSReadyForMetadataEntry))
+	}
+	issues = append(issues, issue(9, "sn2", schema.WSReadyForMetadataEntry))
+
+	// Alice's affinity is capped, so once she has five more sn1 issues than Bob,
+	// the two alternate. Bob's affinity for sn2 then beats Alice's lighter load.
+	var expected = map[int64]string{1: "alice", 2: "alice", 3: "alice", 4: "alice", 5: "alice", 6: "bob", 7: "alice", 8: "bob", 9: "bob"}
+	checkAssignments(t, assigned(b.Assign(issues)), expected)
+}
+
+func TestAssignRespectsPrivileges(t *testing.T) {
+	var curator = worker(1, "curator", privilege.RoleIssueCurator)
+	var reviewer = worker(2, "reviewer", privilege.RoleIssueReviewer)
+	var manager = worker(3, "manager", privilege.RoleIssueManager)
+	var b = &Balancer{MaxLoad: 10, Workers: []*Worker{curator, reviewer, manager}}
+
+	var ownReview = issue(1, "sn1", schema.WSAwaitingMetadataReview)
+	ownReview.MetadataEntryUserID = reviewer.User.ID
+	var managerReview = issue(2, "sn1", schema.WSAwaitingMetadataReview)
+	managerReview.MetadataEntryUserID = manager.User.ID
+	var entry = issue(3, "sn1", schema.WSReadyForMetadataEntry)
+	var unfixable = issue(4, "sn1", schema.WSUnfixableMetadataError)
+
+	// The reviewer can't review their own issue, but the manager can, and
+	// nobody is automatically given unfixable issues
+	var got = assigned(b.Assign([]*models.Issue{ownReview, managerReview, entry, unfixable}))
+	checkAssignments(t, got, map[int64]string{1: "manager", 2: "reviewer", 3: "curator"})
+}
+
+func TestAssignExpiredClaim(t *testing.T) {
+	var alice = worker(1, "alice", privilege.RoleIssueCurator)
+	var bob = worker(2, "bob", privilege.RoleIssueCurator)
+	bob.Load = 5
+	var b = &Balancer{MaxLoad: 10, Workers: []*Worker{alice, bob}}
+
+	var i = issue(1, "sn1", schema.WSReadyForMetadataEntry)
+	i.WorkflowOwnerID = alice.User.ID
+
+	var list = b.Assign([]*models.Issue{i})
+	checkAssignments(t, assigned(list), map[int64]string{1: "bob"})
+	if len(list) == 1 && list[0].PriorOwnerID != alice.User.ID {
+		t.Errorf("Expected prior owner to be alice, got %d", list[0].PriorOwnerID)
+	}
+}
@@ -0,0 +1,161 @@
+package workload
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/schema"
+)
+
+// NewBalancer loads everybody who has opted into automatic assignment along
+// with their current load and work history
+func NewBalancer(maxLoad int) (*Balancer, error) {
+	var users, err = models.AutoAssignUsers()
+	if err != nil {
+		return nil, fmt.Errorf("loading users: %w", err)
+	}
+
+	var curated, reviewed models.WorkHistory
+	curated, err = models.CurationHistory()
+	if err != nil {
+		return nil, fmt.Errorf("loading curation history: %w", err)
+	}
+	reviewed, err = models.ReviewHistory()
+	if err != nil {
+		return nil, fmt.Errorf("loading review history: %w", err)
+	}
+
+	var b = &Balancer{MaxLoad: maxLoad}
+	for _, u := range users {
+		var load, err = models.Issues().OnDesk(u.ID).Count()
+		if err != nil {
+			return nil, fmt.Errorf("counting issues on %s's desk: %w", u.Login, err)
+		}
+		b.Workers = append(b.Workers, &Worker{User: u, Load: int(load), Curated: curated[u.ID], Reviewed: reviewed[u.ID]})
+	}
+
+	return b, nil
+}
+
+// Run assigns all unclaimed issues awaiting metadata entry or review, as well
+// as those whose claims have expired, to the people best suited to handle
+// them. Issues somebody claimed while the balancer was working are skipped.
+// The assignments which were successfully saved are returned.
+func Run(maxLoad int) ([]*Assignment, error) {
+	var b, err = NewBalancer(maxLoad)
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []*models.Issue
+	for _, ws := range []schema.WorkflowStep{schema.WSReadyForMetadataEntry, schema.WSAwaitingMetadataReview} {
+		var list, err = models.Issues().Available().InWorkflowStep(ws).Fetch()
+		if err != nil {
+			return nil, fmt.Errorf("loading issues in workflow step %q: %w", ws, err)
+		}
+		issues = append(issues, list...)
+	}
+
+	var saved []*Assignment
+	for _, a := range b.Assign(issues) {
+		var msg = "Assigned to " + a.Worker.User.Login
+		if a.PriorOwnerID != 0 {
+			msg += fmt.Sprintf(" (claim by %s expired)", models.FindUserByID(a.PriorOwnerID).Login)
+		}
+
+		var ok bool
+		ok, err = a.Issue.Assign(a.Worker.User.ID, msg)
+		if err != nil {
+			return saved, fmt.Errorf("assigning issue %d to %s: %w", a.Issue.ID, a.Worker.User.Login, err)
+		}
+		if ok {
+			saved = append(saved, a)
+		}
+	}
+
+	return saved, nil
+}
+
+// Queue is a single person's list of claimed issues
+type Queue struct {
+	User   *models.User
+	Issues []*models.Issue
+}
+
+// count returns how many issues in the queue are in the given workflow step
+func (q *Queue) count(ws schema.WorkflowStep) int {
+	var n int
+	for _, i := range q.Issues {
+		if i.WorkflowStep == ws {
+			n++
+		}
+	}
+	return n
+}
+
+// Curating returns the number of issues awaiting this user's metadata entry
+func (q *Queue) Curating() int {
+	return q.count(schema.WSReadyForMetadataEntry)
+}
+
+// Reviewing returns the number of issues awaiting this user's review
+func (q *Queue) Reviewing() int {
+	return q.count(schema.WSAwaitingMetadataReview)
+}
+
+// Unfixable returns the number of issues with reported errors this user
+// is handling
+func (q *Queue) Unfixable() int {
+	return q.count(schema.WSUnfixableMetadataError)
+}
+
+// NextExpiration returns the soonest time one of the queue's claims will
+// expire, or a zero time if the queue is empty
+func (q *Queue) NextExpiration() time.Time {
+	var t time.Time
+	for _, i := range q.Issues {
+		if t.IsZero() || i.WorkflowOwnerExpiresAt.Before(t) {
+			t = i.WorkflowOwnerExpiresAt
+		}
+	}
+	return t
+}
+
+// Queues returns every person's claimed issues, including an empty queue for
+// anybody in the auto-assignment pool with nothing on their desk. Queues are
+// sorted by login.
+func Queues() ([]*Queue, error) {
+	var issues, err = models.Issues().Claimed().OrderBy("workflow_owner_expires_at").Fetch()
+	if err != nil {
+		return nil, fmt.Errorf("loading claimed issues: %w", err)
+	}
+
+	var users []*models.User
+	users, err = models.AutoAssignUsers()
+	if err != nil {
+		return nil, fmt.Errorf("loading users: %w", err)
+	}
+
+	var queues = make(map[int64]*Queue)
+	for _, u := range users {
+		queues[u.ID] = &Queue{User: u}
+	}
+	for _, i := range issues {
+		var q = queues[i.WorkflowOwnerID]
+		if q == nil {
+			q = &Queue{User: models.FindUserByID(i.WorkflowOwnerID)}
+			queues[i.WorkflowOwnerID] = q
+		}
+		q.Issues = append(q.Issues, i)
+	}
+
+	var list = make([]*Queue, 0, len(queues))
+	for _, q := range queues {
+		list = append(list, q)
+	}
+	sort.Slice(list, func(x, y int) bool { return list[x].User.Login < list[y].User.Login })
+
+	return list, nil
+}
@@ -0,0 +1,38 @@
+// Handles the reviewer's annotation rows on the reject form
+document.addEventListener('DOMContentLoaded', function() {
+  const rows = document.getElementById('annotation-rows');
+  if (rows == null) {
+    return;
+  }
+
+  // addRow clones the first annotation row, clears its values, and returns
+  // the new row
+  const addRow = function() {
+    const row = rows.querySelector('.annotation-row').cloneNode(true);
+    row.querySelectorAll('input, textarea').forEach(function(el) { el.value = ''; });
+    row.querySelectorAll('select').forEach(function(el) { el.selectedIndex = 0; });
+    rows.appendChild(row);
+    return row;
+  };
+
+  // emptyRow returns the first row with no page or note, creating a new row
+  // if all are in use
+  const emptyRow = function() {
+    for (const row of rows.querySelectorAll('.annotation-row')) {
+      if (row.querySelector('.annotation-page').value == '' && row.querySelector('textarea').value == '') {
+        return row;
+      }
+    }
+    return addRow();
+  };
+
+  document.getElementById('add-annotation').addEventListener('click', function() {
+    addRow().querySelector('textarea').focus();
+  });
+
+  document.getElementById('annotate-current-page').addEventListener('click', function() {
+    const row = emptyRow();
+    row.querySelector('.annotation-page').value = document.getElementById('osd-image-number').textContent;
+    row.querySelector('textarea').focus();
+  });
+});
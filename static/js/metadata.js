@@ -11,6 +11,34 @@ function goToNextUnlabeledPage() {
   osd.goToPage(x % totalPages);
 }
 
+// showPageAnnotations fills in the page annotation box with any unresolved
+// reviewer annotations for the given zero-based page, hiding it if there are
+// none
+function showPageAnnotations(page) {
+  const box = document.getElementById('page-annotations');
+  if (box == null) {
+    return;
+  }
+
+  box.replaceChildren();
+  const notes = pageAnnotations[page];
+  if (notes == null || notes.length == 0) {
+    box.hidden = true;
+    return;
+  }
+
+  const heading = document.createElement('strong');
+  heading.textContent = 'Reviewer notes for this page:';
+  const list = document.createElement('ul');
+  for (const note of notes) {
+    const li = document.createElement('li');
+    li.textContent = note;
+    list.appendChild(li);
+  }
+  box.append(heading, list);
+  box.hidden = false;
+}
+
 document.addEventListener('DOMContentLoaded', function() {
   const pageLabelForm = document.getElementById('page-label-form');
   const metadataForm = document.getElementById('metadata-form');
@@ -68,6 +96,16 @@ document.addEventListener('DOMContentLoaded', function() {
     if (pageLabelText != null) {
       pageLabelText.textContent = pageLabels[data.page];
     }
+
+    showPageAnnotations(data.page);
+  });
+
+  // Let reviewer annotations jump straight to the page they refer to
+  document.querySelectorAll('.annotation-goto-page').forEach(function(btn) {
+    btn.addEventListener('click', function() {
+      osd.goToPage(parseInt(btn.dataset.page) - 1);
+      document.getElementById('osd-body').scrollIntoView();
+    });
   });
 
   osd.goToPage(0);
@@ -0,0 +1,238 @@
+// Chunked, resumable PDF uploads for the publisher portal. Each file is sent
+// in pieces; before sending, we ask the server how much it already has so an
+// interrupted upload picks up where it left off.
+window.addEventListener('load', function () {
+  const form = document.getElementById('publisher-upload-form');
+  if (!form) {
+    return;
+  }
+
+  const uploadURL = form.dataset.uploadUrl;
+  const issueURL = form.dataset.issueUrl;
+  const chunkSize = parseInt(form.dataset.chunkSize, 10);
+  const lccnField = document.getElementById('upload-lccn');
+  const dateField = document.getElementById('upload-date');
+  const fileInput = document.getElementById('upload-files');
+  const dropzone = document.getElementById('upload-dropzone');
+  const progress = document.getElementById('upload-progress');
+  const issueStatus = document.getElementById('upload-issue-status');
+  const maxRetries = 5;
+
+  let queue = [];
+  let busy = false;
+
+  lccnField.addEventListener('change', refreshIssue);
+  dateField.addEventListener('change', refreshIssue);
+  fileInput.addEventListener('change', function () {
+    addFiles(fileInput.files);
+    fileInput.value = '';
+  });
+
+  dropzone.addEventListener('dragover', function (e) {
+    e.preventDefault();
+    dropzone.classList.add('dragging');
+  });
+  dropzone.addEventListener('dragleave', function () {
+    dropzone.classList.remove('dragging');
+  });
+  dropzone.addEventListener('drop', function (e) {
+    e.preventDefault();
+    dropzone.classList.remove('dragging');
+    addFiles(e.dataTransfer.files);
+  });
+
+  function params(extra) {
+    const p = new URLSearchParams({lccn: lccnField.value, date: dateField.value});
+    for (const key in extra) {
+      p.set(key, extra[key]);
+    }
+    return p.toString();
+  }
+
+  function addFiles(files) {
+    if (!dateField.value) {
+      alert('Please choose the issue date before adding files');
+      return;
+    }
+
+    for (const file of files) {
+      queue.push({file: file, lccn: lccnField.value, date: dateField.value, row: addRow(file)});
+    }
+    next();
+  }
+
+  function addRow(file) {
+    const row = document.createElement('div');
+    row.className = 'upload-row mb-2';
+    const label = document.createElement('div');
+    label.textContent = `${file.name} (${fileSizeHuman(file.size)})`;
+    const bar = document.createElement('progress');
+    bar.max = file.size;
+    bar.value = 0;
+    const msg = document.createElement('div');
+    msg.className = 'upload-message';
+    msg.textContent = 'Waiting...';
+    row.append(label, bar, msg);
+    progress.appendChild(row);
+    return {bar: bar, msg: msg, el: row};
+  }
+
+  async function next() {
+    if (busy || queue.length === 0) {
+      return;
+    }
+    busy = true;
+    const item = queue.shift();
+    try {
+      await upload(item);
+    } catch (err) {
+      setMessage(item, `Upload paused: ${err.message}. Add the file again to resume.`, 'text-danger');
+    }
+    busy = false;
+    next();
+  }
+
+  function setMessage(item, text, cls) {
+    item.row.msg.textContent = text;
+    item.row.msg.className = 'upload-message ' + (cls || '');
+  }
+
+  async function request(method, url, body) {
+    let lastErr;
+    for (let attempt = 0; attempt < maxRetries; attempt++) {
+      try {
+        const resp = await fetch(url, {method: method, body: body, credentials: 'same-origin'});
+        return await resp.json();
+      } catch (err) {
+        lastErr = err;
+        await new Promise((resolve) => setTimeout(resolve, 1000 * Math.pow(2, attempt)));
+      }
+    }
+    throw lastErr;
+  }
+
+  async function upload(item) {
+    const file = item.file;
+    const base = {lccn: item.lccn, date: item.date, name: file.name, size: file.size};
+    const query = (extra) => new URLSearchParams(Object.assign({}, base, extra)).toString();
+
+    let status = await request('GET', `${uploadURL}?${query({})}`);
+    if (status.code !== 200) {
+      setMessage(item, status.message, 'text-danger');
+      return;
+    }
+    if (status.complete) {
+      item.row.bar.value = file.size;
+      setMessage(item, 'Already uploaded', 'text-success');
+      return;
+    }
+
+    let offset = status.offset;
+    while (offset < file.size) {
+      item.row.bar.value = offset;
+      setMessage(item, `Uploading... ${Math.floor(offset * 100 / file.size)}%`);
+
+      const chunk = file.slice(offset, Math.min(offset + chunkSize, file.size));
+      const resp = await request('POST', `${uploadURL}?${query({offset: offset})}`, chunk);
+
+      // A conflict with an offset means the server has a different amount of
+      // data than we thought; we simply resume from where it says to
+      if (resp.code === 409 && !resp.complete) {
+        offset = resp.offset;
+        continue;
+      }
+      if (resp.code === 409 && resp.complete) {
+        item.row.bar.value = file.size;
+        setMessage(item, 'Already uploaded', 'text-success');
+        return;
+      }
+      if (resp.code !== 200) {
+        setMessage(item, resp.message, 'text-danger');
+        return;
+      }
+
+      offset = resp.offset;
+      if (resp.complete) {
+        item.row.bar.value = file.size;
+        setMessage(item, 'Uploaded', 'text-success');
+        if (resp.issue && item.lccn === lccnField.value && item.date === dateField.value) {
+          renderIssue(resp.issue);
+        }
+        return;
+      }
+    }
+  }
+
+  async function refreshIssue() {
+    if (!dateField.value) {
+      return;
+    }
+    try {
+      const resp = await request('GET', `${issueURL}?${params({})}`);
+      if (resp.code !== 200) {
+        issueStatus.textContent = resp.message;
+        return;
+      }
+      renderIssue(resp.issue);
+    } catch (err) {
+      issueStatus.textContent = 'Unable to load issue status';
+    }
+  }
+
+  function renderIssue(issue) {
+    issueStatus.replaceChildren();
+
+    if (issue.files.length === 0) {
+      const p = document.createElement('p');
+      p.textContent = 'No files have been uploaded for this issue yet.';
+      issueStatus.appendChild(p);
+      return;
+    }
+
+    appendList('alert alert-danger', 'Problems with this issue:', issue.errors);
+    appendList('alert alert-warning', 'Warnings:', issue.warnings);
+
+    const list = document.createElement('ul');
+    for (const f of issue.files) {
+      const li = document.createElement('li');
+      li.textContent = `${f.name} (${fileSizeHuman(f.size)})`;
+      if (f.errors) {
+        const errs = document.createElement('span');
+        errs.className = 'text-danger';
+        errs.textContent = ': ' + f.errors.join('; ');
+        li.appendChild(errs);
+      }
+      list.appendChild(li);
+    }
+    issueStatus.appendChild(list);
+  }
+
+  function appendList(cls, heading, items) {
+    if (items.length === 0) {
+      return;
+    }
+    const div = document.createElement('div');
+    div.className = cls;
+    div.setAttribute('role', 'alert');
+    const p = document.createElement('p');
+    p.textContent = heading;
+    const ul = document.createElement('ul');
+    for (const item of items) {
+      const li = document.createElement('li');
+      li.textContent = item;
+      ul.appendChild(li);
+    }
+    div.append(p, ul);
+    issueStatus.appendChild(div);
+  }
+
+  function fileSizeHuman(number) {
+    if (number < 1024) {
+      return number + ' bytes';
+    }
+    if (number < 1048576) {
+      return (number/1024).toFixed(1) + 'KB';
+    }
+    return (number/1048576).toFixed(1) + 'MB';
+  }
+});